@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+const adminUsage = "usage: server bootstrap-admin <email>"
+
+// runCommand runs a command given on the command line instead of serving,
+// such as `go run . bootstrap-admin admin@example.com`.
+func runCommand(db *sql.DB, args []string) error {
+	switch args[0] {
+	case "bootstrap-admin":
+		if len(args) != 2 {
+			return errors.New(adminUsage)
+		}
+		return bootstrapAdmin(db, args[1])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], adminUsage)
+	}
+}
+
+// bootstrapAdmin makes an existing user the site's first admin. Once there is
+// an admin, further roles are granted through PUT /admin/users/{id}/role.
+func bootstrapAdmin(db *sql.DB, email string) error {
+	// bootstrapping touches neither sessions nor access tokens
+	admins := service.NewAdminService(store.NewAdminStore(db), nil, nil)
+	userID, err := admins.BootstrapAdmin(email)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return fmt.Errorf("no user with email %s; register the account first", email)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("user %d (%s) is now an admin\n", userID, email)
+	return nil
+}
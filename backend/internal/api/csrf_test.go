@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://social.example, http://localhost:3000/")
+	router := NewRouter(setupRouterTestDB(t))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/csrf", nil))
+	var issued struct {
+		Token string `json:"csrf_token"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &issued)
+	cookies := rr.Result().Cookies()
+	if issued.Token == "" || len(cookies) != 1 || cookies[0].Name != "csrf_token" || cookies[0].Value != issued.Token {
+		t.Fatalf("GET /csrf: got %s with cookies %v", rr.Body.String(), cookies)
+	}
+
+	tests := []struct {
+		name     string
+		cookie   string
+		header   string
+		origin   string
+		bearer   string
+		wantCode string
+	}{
+		{"matching token", issued.Token, issued.Token, "", "", ""},
+		{"matching token from an allowed origin", issued.Token, issued.Token, "https://social.example", "", ""},
+		{"no token", "", "", "", "", "csrf_token_invalid"},
+		{"header only", "", issued.Token, "", "", "csrf_token_invalid"},
+		{"mismatched token", issued.Token, "forged", "", "", "csrf_token_invalid"},
+		{"foreign origin", issued.Token, issued.Token, "https://evil.example", "", "csrf_origin_denied"},
+		{"bearer token", "", "", "", "snpat_anything", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/logout", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			var body utils.Response
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+			if tt.wantCode == "" && rr.Code != http.StatusOK {
+				t.Errorf("got %d %+v, want the request through", rr.Code, body)
+			}
+			if tt.wantCode != "" && (rr.Code != http.StatusForbidden || body.Code != tt.wantCode) {
+				t.Errorf("got %d %+v, want 403 %s", rr.Code, body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAllowedOriginsAreShared(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://social.example")
+	router := NewRouter(setupRouterTestDB(t))
+
+	for origin, allowed := range map[string]bool{"https://social.example": true, "https://evil.example": false} {
+		req := httptest.NewRequest("OPTIONS", "/posts", nil)
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); (got == origin) != allowed || (!allowed && got != "") {
+			t.Errorf("CORS for %s: Access-Control-Allow-Origin %q", origin, got)
+		}
+
+		req = httptest.NewRequest("GET", "/ws", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Origin", origin)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if denied := rr.Code == http.StatusForbidden; denied == allowed {
+			t.Errorf("websocket upgrade from %s: got %d", origin, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// AccessTokenHandler lets users manage personal access tokens for scripts and other clients.
+type AccessTokenHandler struct {
+	AccessTokenService service.AccessTokenService
+}
+
+func NewAccessTokenHandler(ats service.AccessTokenService) *AccessTokenHandler {
+	return &AccessTokenHandler{AccessTokenService: ats}
+}
+
+// sessionIdentity returns the caller when they are logged in with a session.
+// Tokens are managed from a logged-in browser only, so a read-only token
+// cannot be used to mint a write token.
+func sessionIdentity(w http.ResponseWriter, r *http.Request) (auth.Identity, bool) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return identity, false
+	}
+	if identity.SessionID == "" {
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Access tokens can only be managed from a logged-in session"})
+		return identity, false
+	}
+	return identity, true
+}
+
+type createdAccessToken struct {
+	Token       string              `json:"token"`
+	AccessToken *models.AccessToken `json:"access_token"`
+}
+
+// CreateToken handles POST /tokens with {name, scope, expires_in_days}.
+// The token is only included in this response.
+func (h *AccessTokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	identity, ok := sessionIdentity(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name          string `json:"name"`
+		Scope         string `json:"scope"`
+		ExpiresInDays int    `json:"expires_in_days"` // 0 for a token that never expires
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "expires_in_days cannot be negative"})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, accessToken, err := h.AccessTokenService.CreateToken(identity.UserID, req.Name, req.Scope, ttl)
+	if errors.Is(err, service.ErrInvalidAccessTokenName) || errors.Is(err, service.ErrInvalidAccessScope) {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("error creating access token:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to create access token"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, createdAccessToken{Token: token, AccessToken: accessToken})
+}
+
+// ListTokens handles GET /tokens.
+func (h *AccessTokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	identity, ok := sessionIdentity(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.AccessTokenService.ListTokens(identity.UserID)
+	if err != nil {
+		fmt.Println("error listing access tokens:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list access tokens"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, tokens)
+}
+
+// RevokeToken handles DELETE /tokens/{id}.
+func (h *AccessTokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	identity, ok := sessionIdentity(w, r)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid token ID"})
+		return
+	}
+
+	err = h.AccessTokenService.RevokeToken(identity.UserID, tokenID)
+	if errors.Is(err, service.ErrAccessTokenNotFound) {
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Access token not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error revoking access token:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to revoke access token"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access token revoked"})
+}
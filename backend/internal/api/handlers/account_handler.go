@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// AccountHandler lets users delete their own account.
+type AccountHandler struct {
+	AccountDeletionService service.AccountDeletionService
+}
+
+func NewAccountHandler(ads service.AccountDeletionService) *AccountHandler {
+	return &AccountHandler{AccountDeletionService: ads}
+}
+
+type scheduledDeletion struct {
+	Message     string    `json:"message"`
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// DeleteAccount handles DELETE /me. The account is signed out everywhere and
+// purged after the grace period unless the user logs in again before then.
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+	if identity.SessionID == "" {
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Accounts can only be deleted from a logged-in session"})
+		return
+	}
+
+	deleteAfter, err := h.AccountDeletionService.ScheduleDeletion(identity.UserID)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Account not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error scheduling account deletion:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to delete account"})
+		return
+	}
+
+	auth.ClearSessionCookie(w)
+	http.SetCookie(w, &http.Cookie{
+		Name:     "logged_in",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: false,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+	})
+	utils.RespondJSON(w, http.StatusAccepted, scheduledDeletion{
+		Message:     "Account scheduled for deletion; log in again before then to cancel",
+		DeleteAfter: deleteAfter,
+	})
+}
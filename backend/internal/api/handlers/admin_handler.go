@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// AdminHandler serves the /admin routes. Moderators can find, suspend and
+// sign out users; admins can also change roles and see platform counts.
+type AdminHandler struct {
+	AdminService service.AdminService
+}
+
+func NewAdminHandler(as service.AdminService) *AdminHandler {
+	return &AdminHandler{AdminService: as}
+}
+
+type adminUserList struct {
+	Users []*models.AdminUser `json:"users"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+}
+
+// ListUsers handles GET /admin/users?q=&page=&limit=. q matches email, nickname or name.
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	limit := 20
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	users, err := h.AdminService.ListUsers(query, limit, (page-1)*limit)
+	if err != nil {
+		fmt.Println("error listing users:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list users"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, adminUserList{Users: users, Page: page, Limit: limit})
+}
+
+// Suspend handles POST /admin/users/{id}/suspend. The user is signed out
+// everywhere and cannot log in until unsuspended.
+func (h *AdminHandler) Suspend(w http.ResponseWriter, r *http.Request) {
+	h.setSuspended(w, r, true)
+}
+
+// Unsuspend handles POST /admin/users/{id}/unsuspend.
+func (h *AdminHandler) Unsuspend(w http.ResponseWriter, r *http.Request) {
+	h.setSuspended(w, r, false)
+}
+
+func (h *AdminHandler) setSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	actorID, userID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.AdminService.SetSuspended(actorID, userID, suspended); err != nil {
+		respondAdminError(w, "error changing suspension:", err)
+		return
+	}
+	if suspended {
+		utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "User suspended"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "User unsuspended"})
+}
+
+// ForceLogout handles POST /admin/users/{id}/logout, ending all of the user's sessions.
+func (h *AdminHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.AdminService.ForceLogout(actorID, userID)
+	if err != nil {
+		respondAdminError(w, "error forcing logout:", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: fmt.Sprintf("Revoked %d sessions", revoked)})
+}
+
+// SetRole handles PUT /admin/users/{id}/role with {role}.
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+
+	if err := h.AdminService.SetRole(actorID, userID, req.Role); err != nil {
+		respondAdminError(w, "error changing role:", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Role updated"})
+}
+
+// Stats handles GET /admin/stats.
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.AdminService.Stats()
+	if err != nil {
+		fmt.Println("error counting platform stats:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to load platform stats"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, stats)
+}
+
+// userTarget returns the caller and the user named by the {id} path value.
+func userTarget(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	actorID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid user ID"})
+		return 0, 0, false
+	}
+	return actorID, userID, true
+}
+
+func respondAdminError(w http.ResponseWriter, logPrefix string, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "User not found"})
+	case errors.Is(err, service.ErrInvalidRole):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole), errors.Is(err, service.ErrOwnRole):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: err.Error(), Code: "insufficient_role"})
+	default:
+		fmt.Println(logPrefix, err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+	}
+}
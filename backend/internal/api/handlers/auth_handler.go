@@ -1,15 +1,17 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
 
+	authctx "github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
@@ -31,7 +33,6 @@ func (auth *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Check Content-Type header to determine how to parse the request
 	contentType := r.Header.Get("Content-Type")
-	fmt.Println("Content-Type:", contentType)
 
 	if strings.Contains(contentType, "application/json") {
 		// Parse JSON body
@@ -65,24 +66,114 @@ func (auth *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	fmt.Println("Login credentials:", creds)
-
-	authUser, sessionID, _ := auth.AuthService.AuthenticateUser(creds.Email, creds.Password)
+	client := models.SessionClient{UserAgent: r.UserAgent(), IPAddress: utils.ClientIP(r)}
+	authUser, sessionID, err := auth.AuthService.AuthenticateUser(creds.Email, creds.Password, client)
+	if errors.Is(err, service.ErrTwoFactorRequired) {
+		// the password was right; the client must now send a code to /login/2fa
+		utils.RespondJSON(w, http.StatusOK, twoFactorChallengeResponse{
+			Message: "Two-factor authentication required",
+			Code:    "two_factor_required",
+			Token:   sessionID,
+		})
+		return
+	}
+	if respondThrottled(w, err) {
+		return
+	}
+	if errors.Is(err, service.ErrAccountSuspended) {
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "This account has been suspended", Code: "account_suspended"})
+		return
+	}
 	if authUser == nil {
 		if sessionID == service.EXPIRED_SESSION {
 			utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to create session"})
-		} else if sessionID == service.INVALID_PASSWORD {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Invalid password"})
-		} else if sessionID == service.INVALID_EMAIL {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found"})
+			return
 		}
+		// the same answer whether or not the email exists
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: service.INVALID_CREDENTIALS})
 		return
 	}
 
-	fmt.Println("Authenticated user:", authUser)
+	session, err := auth.AuthService.GetSession(sessionID)
+	if err != nil {
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to create session"})
+		return
+	}
+
+	setLoginCookies(w, session)
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Logged in successfully"})
+}
+
+// twoFactorChallengeResponse is returned by Login instead of a session when the user has 2FA enabled.
+type twoFactorChallengeResponse struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+	Token   string `json:"two_factor_token"`
+}
+
+// LoginTwoFactor handles POST /login/2fa, the second login step. It takes the
+// token returned by Login and a code from the authenticator app or a recovery code.
+func (auth *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"two_factor_token"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	if req.Token == "" || req.Code == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Token and code are required"})
+		return
+	}
 
+	client := models.SessionClient{UserAgent: r.UserAgent(), IPAddress: utils.ClientIP(r)}
+	session, err := auth.AuthService.CompleteTwoFactorLogin(req.Token, req.Code, client)
+	if respondThrottled(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Invalid code", Code: "invalid_two_factor_code"})
+		return
+	case errors.Is(err, service.ErrInvalidTwoFactorChallenge):
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: err.Error(), Code: "two_factor_expired"})
+		return
+	case errors.Is(err, service.ErrAccountSuspended):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "This account has been suspended", Code: "account_suspended"})
+		return
+	case errors.Is(err, service.ErrTwoFactorUnavailable):
+		utils.RespondJSON(w, http.StatusServiceUnavailable, utils.Response{Message: err.Error(), Code: "two_factor_unavailable"})
+		return
+	case err != nil:
+		fmt.Println("error completing two-factor login:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to create session"})
+		return
+	}
+
+	setLoginCookies(w, session)
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Logged in successfully"})
+}
+
+// respondThrottled answers 429 with Retry-After when err is a
+// *service.LoginThrottledError, and reports whether it did.
+func respondThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	utils.RespondJSON(w, http.StatusTooManyRequests, utils.Response{
+		Message: "Too many failed login attempts, please try again later",
+		Code:    "too_many_attempts",
+	})
+	return true
+}
+
+// setLoginCookies issues the cookies of a new session.
+func setLoginCookies(w http.ResponseWriter, session *models.Session) {
 	// only used for UI checks to avoid flashing protected routes
-	fmt.Println("Setting login confirmation cookie:")
 	http.SetCookie(w, &http.Cookie{
 		Name:     "logged_in",
 		Value:    "true",
@@ -92,20 +183,8 @@ func (auth *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Secure:   true,
 	})
 
-	// used to actually authenticate users
-	sessionCookie := &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false, // set true in production with HTTPS
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		SameSite: http.SameSiteLaxMode,
-	}
-
-	fmt.Println("Setting cookie:", sessionCookie)
-	http.SetCookie(w, sessionCookie)
-	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Logged in successfully"})
+	// used to actually authenticate users; the cookie expires with the session
+	authctx.SetSessionCookie(w, session.ID, session.ExpiresAt)
 }
 
 // Signup handles user registration
@@ -178,9 +257,14 @@ func (auth *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create user through service
-	createdUser, err := auth.AuthService.CreateUser(user)
+	_, err = auth.AuthService.CreateUser(user)
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
+		var policyErr *utils.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			respondPasswordPolicy(w, policyErr)
+		} else if respondHandleError(w, err) {
+			return
+		} else if strings.Contains(err.Error(), "already exists") {
 			utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: "Email or nickname already taken"})
 		} else {
 			utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to create user"})
@@ -188,26 +272,17 @@ func (auth *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Println("User created successfully:", createdUser.ID)
 	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Registration successful"})
 }
 
 // LogoutHandler deletes session and clears cookie
 func (auth *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("session_id")
+	cookie, err := r.Cookie(authctx.SessionCookieName)
 	if err == nil {
 		_, _ = auth.AuthService.DeleteSession(cookie.Value)
 
 		// Clear cookies
-		http.SetCookie(w, &http.Cookie{
-			Name:     "session_id",
-			Value:    "",
-			Path:     "/",
-			MaxAge:   -1,
-			HttpOnly: true,
-			Secure:   false, // set true in production
-			SameSite: http.SameSiteLaxMode,
-		})
+		authctx.ClearSessionCookie(w)
 
 		http.SetCookie(w, &http.Cookie{
 			Name:     "logged_in",
@@ -224,27 +299,6 @@ func (auth *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Logged out successfully"})
 }
 
-// AuthMiddleware verifies session cookie, loads user ID into context
-func (auth *AuthHandler) AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("session_id")
-		if err != nil {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Authentication required"})
-			return
-		}
-
-		userID, err := auth.AuthService.GetUserIDBySession(cookie.Value)
-		if err != nil {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Invalid or expired session"})
-			return
-		}
-
-		// Add userID to request context for downstream handlers
-		ctx := context.WithValue(r.Context(), utils.User_id, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
 // ValidateAccountStepOne validates Account Crediential for Step One
 func (auth *AuthHandler) ValidateAccountStepOne(w http.ResponseWriter, r *http.Request) {
 	var serverresponse utils.Response
@@ -303,13 +357,11 @@ func (auth *AuthHandler) ValidateAccountStepOne(w http.ResponseWriter, r *http.R
 		utils.RespondJSON(w, statusCode, serverresponse)
 		return
 	}
-	// validate password
-	passwordManager := utils.NewPasswordManager(utils.PasswordConfig{})
-	_, err = passwordManager.HashPassword(AccountCrediential.Password)
-	if err != nil {
-		serverresponse.Message = err.Error()
-		statusCode = http.StatusBadRequest
-		utils.RespondJSON(w, statusCode, serverresponse)
+	// validate password against the same policy applied at registration
+	passwordManager := utils.NewPasswordManager(utils.PasswordConfigFromEnv())
+	var policyErr *utils.PasswordPolicyError
+	if errors.As(passwordManager.ValidatePasswordStrength(AccountCrediential.Password), &policyErr) {
+		respondPasswordPolicy(w, policyErr)
 		return
 	}
 
@@ -321,10 +373,9 @@ func (auth *AuthHandler) EditProfile(w http.ResponseWriter, r *http.Request) {
 	var serverResponse utils.Response
 
 	// get  LOGGED IN USER
-	LoggedInUser, ok := r.Context().Value(utils.User_id).(int64)
+	LoggedInUser, ok := authctx.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
-		fmt.Println("User not found in context")
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
 		return
 	}
@@ -400,11 +451,75 @@ func (auth *AuthHandler) EditProfile(w http.ResponseWriter, r *http.Request) {
 		Avatar:          &userAvatar,
 	}
 	err = auth.AuthService.EditUserProfile(user, LoggedInUser)
+	if respondHandleError(w, err) {
+		return
+	}
 	if err != nil {
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: err.Error()})
 		return
 	}
 
-	fmt.Println("User profile successfully updated:", user)
 	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Profile updated successfully"})
 }
+
+// respondHandleError answers a rejected nickname and reports whether it did.
+func respondHandleError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrHandleTaken):
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: "Nickname already taken", Code: "handle_taken"})
+	case errors.Is(err, service.ErrInvalidHandle), errors.Is(err, service.ErrReservedHandle):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error(), Code: "invalid_handle"})
+	default:
+		return false
+	}
+	return true
+}
+
+// respondPasswordPolicy reports every password rule that failed.
+func respondPasswordPolicy(w http.ResponseWriter, policyErr *utils.PasswordPolicyError) {
+	utils.RespondJSON(w, http.StatusBadRequest, utils.Response{
+		Message: policyErr.Error(),
+		Code:    "password_policy",
+		Errors:  policyErr.Violations,
+	})
+}
+
+// ChangePassword handles PUT /password. Every other session of the user is
+// signed out; the session making the change stays logged in.
+func (auth *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	identity, ok := authctx.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Current and new password are required"})
+		return
+	}
+
+	err := auth.AuthService.ChangePassword(identity.UserID, identity.SessionID, req.CurrentPassword, req.NewPassword)
+	var policyErr *utils.PasswordPolicyError
+	switch {
+	case errors.Is(err, service.ErrIncorrectPassword):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Current password is incorrect", Code: "incorrect_password"})
+		return
+	case errors.As(err, &policyErr):
+		respondPasswordPolicy(w, policyErr)
+		return
+	case err != nil:
+		fmt.Println("error changing password:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to change password"})
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Password changed, other sessions have been signed out"})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// BlockHandler serves blocking and the list of blocked users.
+type BlockHandler struct {
+	BlockService service.BlockService
+}
+
+func NewBlockHandler(bs service.BlockService) *BlockHandler {
+	return &BlockHandler{BlockService: bs}
+}
+
+// Block handles POST /users/{id}/block. Any follow between the two users,
+// in either direction, is removed.
+func (h *BlockHandler) Block(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.BlockService.Block(userID, targetID)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "User not found"})
+	case errors.Is(err, service.ErrBlockSelf):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error blocking user:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to block user"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "User blocked"})
+	}
+}
+
+// Unblock handles DELETE /users/{id}/block.
+func (h *BlockHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.BlockService.Unblock(userID, targetID); err != nil {
+		fmt.Println("error unblocking user:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to unblock user"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "User unblocked"})
+}
+
+// ListBlocked handles GET /users/blocked.
+func (h *BlockHandler) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	users, err := h.BlockService.ListBlocked(userID)
+	if err != nil {
+		fmt.Println("error listing blocked users:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list blocked users"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, struct {
+		Users []*models.BlockedUser `json:"users"`
+	}{users})
+}
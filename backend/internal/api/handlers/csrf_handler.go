@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// GetCSRFToken handles GET /csrf. It returns the token to send in the
+// X-CSRF-Token header, for frontends that cannot read the API's cookies.
+func GetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	token, err := middleware.EnsureCSRFToken(w, r)
+	if err != nil {
+		fmt.Println("error issuing CSRF token:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to issue CSRF token"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"csrf_token": token})
+}
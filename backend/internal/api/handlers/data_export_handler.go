@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// DataExportHandler lets users request and download an archive of their data.
+type DataExportHandler struct {
+	DataExportService service.DataExportService
+}
+
+func NewDataExportHandler(des service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{DataExportService: des}
+}
+
+type dataExportResponse struct {
+	*models.DataExport
+	StatusURL   string `json:"status_url"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func newDataExportResponse(export *models.DataExport) dataExportResponse {
+	statusURL := fmt.Sprintf("/me/exports/%d", export.ID)
+	response := dataExportResponse{DataExport: export, StatusURL: statusURL}
+	if export.Status == models.DataExportReady {
+		response.DownloadURL = statusURL + "/download"
+	}
+	return response
+}
+
+// RequestExport handles POST /me/exports. The archive is built in the
+// background; poll the status URL until it is ready.
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	export, err := h.DataExportService.RequestExport(userID)
+	if err != nil {
+		fmt.Println("error requesting data export:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to request data export"})
+		return
+	}
+	response := newDataExportResponse(export)
+	w.Header().Set("Location", response.StatusURL)
+	utils.RespondJSON(w, http.StatusAccepted, response)
+}
+
+// GetExport handles GET /me/exports/{id}.
+func (h *DataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, exportID, ok := exportTarget(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.DataExportService.GetExport(userID, exportID)
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Export not found"})
+	case err != nil:
+		fmt.Println("error getting data export:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to get data export"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, newDataExportResponse(export))
+	}
+}
+
+// DownloadExport handles GET /me/exports/{id}/download, serving the zip to
+// its owner until the export expires.
+func (h *DataExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	userID, exportID, ok := exportTarget(w, r)
+	if !ok {
+		return
+	}
+
+	file, export, err := h.DataExportService.OpenExport(userID, exportID)
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Export not found"})
+		return
+	case errors.Is(err, service.ErrExportNotReady):
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: err.Error(), Code: "export_not_ready"})
+		return
+	case errors.Is(err, service.ErrExportExpired):
+		utils.RespondJSON(w, http.StatusGone, utils.Response{Message: err.Error(), Code: "export_expired"})
+		return
+	case err != nil:
+		fmt.Println("error opening data export:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to download data export"})
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-export-%d.zip"`, export.ID))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, "", *export.CompletedAt, file)
+}
+
+func exportTarget(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return 0, 0, false
+	}
+	exportID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid export ID"})
+		return 0, 0, false
+	}
+	return userID, exportID, true
+}
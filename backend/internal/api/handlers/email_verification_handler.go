@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// EmailVerificationHandler confirms email addresses from emailed links.
+type EmailVerificationHandler struct {
+	EmailVerificationService service.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(evs service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{EmailVerificationService: evs}
+}
+
+// VerifyEmail handles POST /email/verify. The token is the proof, so no
+// session is needed and the link works from any browser.
+func (h *EmailVerificationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	if req.Token == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Token is required"})
+		return
+	}
+
+	err := h.EmailVerificationService.VerifyEmail(req.Token)
+	if errors.Is(err, service.ErrInvalidVerificationToken) {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Verification link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error verifying email:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to verify email"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Email verified"})
+}
+
+// ResendVerification handles POST /email/verify/resend for the logged in user.
+func (h *EmailVerificationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	err := h.EmailVerificationService.ResendVerification(userID)
+	if errors.Is(err, service.ErrEmailAlreadyVerified) {
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: "Email is already verified"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error resending verification email:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to send verification email"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Verification email sent"})
+}
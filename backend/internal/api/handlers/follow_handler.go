@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
@@ -29,7 +31,7 @@ func (follow *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
 	status := http.StatusOK
 
 	// Get current user id from session
-	followerId, ok := r.Context().Value(utils.User_id).(int64)
+	followerId, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -62,6 +64,10 @@ func (follow *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
 
 	if isFolloweeAccountPublic {
 		requestid, err := follow.FollowService.CreateFollowForPublicAccount(followerId, int64(followee.FolloweeId))
+		if errors.Is(err, service.ErrBlocked) {
+			utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: err.Error(), Code: "blocked"})
+			return
+		}
 		if err != nil {
 			status = http.StatusInternalServerError
 			serverResponse.Message = "Failed to create follow connection"
@@ -72,7 +78,7 @@ func (follow *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
 		// Send notification for public account follow
 		if follow.Notifier != nil {
 			followerName, avatar, err := follow.FollowService.GetUserInfo(followerId)
-			if err == nil {
+			if err == nil && follow.FollowService.ShouldNotify(int64(followee.FolloweeId), followerId, followerName+" started following you") {
 				// Store notification in database
 				err = follow.FollowService.AddtoNotification(int64(followee.FolloweeId), followerName+" started following you")
 				if err != nil {
@@ -105,6 +111,10 @@ func (follow *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
 
 	// Handle private account response
 	followID, err := follow.FollowService.CreateFollowForPrivateAccount(followerId, int64(followee.FolloweeId))
+	if errors.Is(err, service.ErrBlocked) {
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: err.Error(), Code: "blocked"})
+		return
+	}
 	if err != nil {
 		status = http.StatusInternalServerError
 		serverResponse.Message = "Failed to create follow connection"
@@ -115,7 +125,7 @@ func (follow *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
 	// Send notification for follow request
 	if follow.Notifier != nil {
 		followerName, avatar, err := follow.FollowService.GetUserInfo(followerId)
-		if err == nil {
+		if err == nil && follow.FollowService.ShouldNotify(int64(followee.FolloweeId), followerId, followerName+" sent you a follow request") {
 			// Store notification in database
 			err = follow.FollowService.AddtoNotification(int64(followee.FolloweeId), followerName+" sent you a follow request")
 			if err != nil {
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
@@ -32,7 +33,7 @@ func (fr *FollowRequestHandler) FollowRequestRespond(w http.ResponseWriter, r *h
 	status := http.StatusOK
 
 	// Get current user id from session (to verify they can respond to this request)
-	_, ok := r.Context().Value(utils.User_id).(int64)
+	_, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -196,7 +197,7 @@ func (fr *FollowRequestHandler) CancelFollowRequestRespond(w http.ResponseWriter
 	status := http.StatusOK
 
 	// Get current user id from session (to verify they can respond to this request)
-	_, ok := r.Context().Value(utils.User_id).(int64)
+	_, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -356,7 +357,7 @@ func (fr *FollowRequestHandler) CancelFollowRequest(w http.ResponseWriter, r *ht
 	status := http.StatusOK
 
 	// Get current user id from session (to verify they can cancel this request)
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -404,7 +405,7 @@ func (fr *FollowRequestHandler) CancelFollowRequest(w http.ResponseWriter, r *ht
 	// Send real-time notification to the recipient about cancellation
 	if fr.Notifier != nil && followeeID != 0 {
 		followerName, _, err := fr.FollowRequestService.RetrieveUserName(followerID)
-		if err == nil {
+		if err == nil && fr.FollowRequestService.ShouldNotify(followeeID, followerID, followerName+" cancelled their follow request") {
 			// Send real-time notification if recipient is online
 			if fr.Notifier.IsOnline(followeeID) {
 				fr.Notifier.SendNotification(followeeID, map[string]interface{}{
@@ -429,7 +430,7 @@ func (fr *FollowRequestHandler) GetRequestIDByUsers(w http.ResponseWriter, r *ht
 	status := http.StatusOK
 
 	// Get current user id from session (follower)
-	followerID, ok := r.Context().Value(utils.User_id).(int64)
+	followerID, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -470,7 +471,7 @@ func (fr *FollowRequestHandler) GetPendingFollowRequest(w http.ResponseWriter, r
 	status := http.StatusOK
 
 	// Get current user id from session (to verify they can cancel this request)
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -479,10 +480,9 @@ func (fr *FollowRequestHandler) GetPendingFollowRequest(w http.ResponseWriter, r
 	users, err := fr.FollowRequestService.GetPendingFollowRequest(userID)
 	if err != nil {
 		serverResponse.Message = "Error getting users"
-		fmt.Println("ther error", err)
+		fmt.Println("error getting pending follow requests:", err)
 		utils.RespondJSON(w, http.StatusInternalServerError, serverResponse)
 		return
 	}
-	fmt.Println("pending users", err)
 	utils.RespondJSON(w, status, users)
 }
@@ -2,18 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 )
 
-
-type contextKey string
-const userIDKey contextKey = "userID"
-
 type GroupHandler struct {
 	groupService            service.GroupService
 	groupRequestService     service.GroupRequestService
@@ -31,7 +29,7 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	creatorID, ok := r.Context().Value(userIDKey).(int64)
+	creatorID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
 		return
@@ -62,15 +60,15 @@ func (h *GroupHandler) SendJoinRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value(userIDKey).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
 		return
 	}
 
-		request, err := h.groupRequestService.SendJoinRequest(int64(groupID), int64(userID))
+	request, err := h.groupRequestService.SendJoinRequest(int64(groupID), userID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to send join request: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to send join request: %v", err), joinRequestErrorStatus(err))
 		return
 	}
 
@@ -96,15 +94,15 @@ func (h *GroupHandler) ApproveJoinRequest(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	approverID, ok := r.Context().Value(userIDKey).(int)
+	approverID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "Approver ID not found in context", http.StatusUnauthorized)
 		return
 	}
 
-	err = h.groupRequestService.ApproveJoinRequest(int64(requestID), int64(approverID))
+	err = h.groupRequestService.ApproveJoinRequest(int64(requestID), approverID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to approve join request: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to approve join request: %v", err), joinRequestErrorStatus(err))
 		return
 	}
 
@@ -123,15 +121,15 @@ func (h *GroupHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	rejecterID, ok := r.Context().Value(userIDKey).(int)
+	rejecterID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "Rejecter ID not found in context", http.StatusUnauthorized)
 		return
 	}
 
-	err = h.groupRequestService.RejectJoinRequest(int64(requestID), int64(rejecterID))
+	err = h.groupRequestService.RejectJoinRequest(int64(requestID), rejecterID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to reject join request: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to reject join request: %v", err), joinRequestErrorStatus(err))
 		return
 	}
 
@@ -142,6 +140,20 @@ func (h *GroupHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// joinRequestErrorStatus maps errors from the group join workflow to HTTP status codes.
+func joinRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotGroupAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrDuplicateJoinRequest),
+		errors.Is(err, service.ErrAlreadyGroupMember),
+		errors.Is(err, service.ErrRequestNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (h *GroupHandler) SendGroupChatMessage(w http.ResponseWriter, r *http.Request) {
 	groupIDStr := r.PathValue("groupID")
 	groupID, err := strconv.Atoi(groupIDStr)
@@ -150,7 +162,7 @@ func (h *GroupHandler) SendGroupChatMessage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	senderID, ok := r.Context().Value(userIDKey).(int)
+	senderID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "Sender ID not found in context", http.StatusUnauthorized)
 		return
@@ -164,7 +176,7 @@ func (h *GroupHandler) SendGroupChatMessage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	message, err := h.groupChatMessageService.SendGroupChatMessage(int64(groupID), int64(senderID), requestBody.Content)
+	message, err := h.groupChatMessageService.SendGroupChatMessage(int64(groupID), senderID, requestBody.Content)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
@@ -185,7 +197,7 @@ func (h *GroupHandler) GetGroupChatMessages(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	userID, ok := r.Context().Value(userIDKey).(int)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
 		return
@@ -203,7 +215,7 @@ func (h *GroupHandler) GetGroupChatMessages(w http.ResponseWriter, r *http.Reque
 		offset = 0 // Default offset
 	}
 
-	messages, err := h.groupChatMessageService.GetGroupChatMessages(int64(groupID), int64(userID), limit, offset)
+	messages, err := h.groupChatMessageService.GetGroupChatMessages(int64(groupID), userID, limit, offset)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get messages: %v", err), http.StatusInternalServerError)
 		return
@@ -2,14 +2,15 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
 )
 
 // MockGroupService is a mock implementation of the GroupService for testing.
@@ -107,8 +108,8 @@ func TestCreateGroup(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		// Add user ID to context (as int64 for CreateGroup)
-		ctx := context.WithValue(req.Context(), userIDKey, int64(1))
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -206,8 +207,8 @@ func TestSendJoinRequest(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int64 for SendJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, int64(101))
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -246,8 +247,8 @@ func TestSendJoinRequest(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "invalid")
 
-		// Add user ID to context (as int64 for SendJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, int64(101))
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -306,8 +307,8 @@ func TestSendJoinRequest(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int64 for SendJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, int64(101))
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -341,8 +342,8 @@ func TestApproveJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "1")
 
-		// Add user ID to context (as int for ApproveJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -379,8 +380,8 @@ func TestApproveJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "invalid")
 
-		// Add user ID to context (as int for ApproveJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -437,8 +438,8 @@ func TestApproveJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "1")
 
-		// Add user ID to context (as int for ApproveJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -449,6 +450,41 @@ func TestApproveJoinRequest(t *testing.T) {
 				status, http.StatusInternalServerError)
 		}
 	})
+
+	// Test case 5: Workflow errors map to client errors
+	workflowErrors := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"Approver lacks authority", service.ErrNotGroupAdmin, http.StatusForbidden},
+		{"Request already resolved", service.ErrRequestNotPending, http.StatusConflict},
+	}
+	for _, tc := range workflowErrors {
+		t.Run(tc.name, func(t *testing.T) {
+			mockGroupRequestService := &MockGroupRequestService{
+				ApproveJoinRequestFunc: func(requestID int64, approverID int64) error {
+					return tc.err
+				},
+			}
+			h := NewGroupHandler(&MockGroupService{}, mockGroupRequestService, &MockGroupChatMessageService{})
+
+			req, err := http.NewRequest("PUT", "/groups/1/join-request/1/approve", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("groupID", "1")
+			req.SetPathValue("requestID", "1")
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 101}))
+
+			rr := httptest.NewRecorder()
+			h.ApproveJoinRequest(rr, req)
+
+			if status := rr.Code; status != tc.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.want)
+			}
+		})
+	}
 }
 
 func TestRejectJoinRequest(t *testing.T) {
@@ -472,8 +508,8 @@ func TestRejectJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "1")
 
-		// Add user ID to context (as int for RejectJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -510,8 +546,8 @@ func TestRejectJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "invalid")
 
-		// Add user ID to context (as int for RejectJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -568,8 +604,8 @@ func TestRejectJoinRequest(t *testing.T) {
 		req.SetPathValue("groupID", "1")
 		req.SetPathValue("requestID", "1")
 
-		// Add user ID to context (as int for RejectJoinRequest)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -607,8 +643,8 @@ func TestSendGroupChatMessage(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for SendGroupChatMessage)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -645,8 +681,8 @@ func TestSendGroupChatMessage(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "invalid")
 
-		// Add user ID to context (as int for SendGroupChatMessage)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -673,8 +709,8 @@ func TestSendGroupChatMessage(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for SendGroupChatMessage)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -735,8 +771,8 @@ func TestSendGroupChatMessage(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for SendGroupChatMessage)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -776,8 +812,8 @@ func TestGetGroupChatMessages(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for GetGroupChatMessages)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -816,8 +852,8 @@ func TestGetGroupChatMessages(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "invalid")
 
-		// Add user ID to context (as int for GetGroupChatMessages)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -876,8 +912,8 @@ func TestGetGroupChatMessages(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for GetGroupChatMessages)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -918,8 +954,8 @@ func TestGetGroupChatMessages(t *testing.T) {
 		// Set path variables
 		req.SetPathValue("groupID", "1")
 
-		// Add user ID to context (as int for GetGroupChatMessages)
-		ctx := context.WithValue(req.Context(), userIDKey, 101)
+		// Add user identity to context
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 101})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
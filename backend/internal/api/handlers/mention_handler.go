@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// MentionHandler suggests users to @mention.
+type MentionHandler struct {
+	MentionService service.MentionService
+}
+
+func NewMentionHandler(ms service.MentionService) *MentionHandler {
+	return &MentionHandler{MentionService: ms}
+}
+
+// Autocomplete handles GET /users/mentions?q=&limit=, the users whose handle
+// starts with q, with or without its @, people the caller follows first.
+func (h *MentionHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 8
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 20 {
+		limit = l
+	}
+
+	users, err := h.MentionService.Autocomplete(userID, query.Get("q"), limit)
+	if err != nil {
+		fmt.Println("error suggesting mentions:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]any{"users": users})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// MuteHandler serves the user and keyword mute lists. Mutes only change what
+// the muting user sees; nothing tells the muted user.
+type MuteHandler struct {
+	MuteService service.MuteService
+}
+
+func NewMuteHandler(ms service.MuteService) *MuteHandler {
+	return &MuteHandler{MuteService: ms}
+}
+
+// MuteUser handles POST /users/{id}/mute with an optional {expires_in_hours}.
+// Without it, or with 0, the mute lasts until lifted.
+func (h *MuteHandler) MuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+			return
+		}
+	}
+
+	expiresAt, err := h.MuteService.MuteUser(userID, targetID, time.Duration(req.ExpiresInHours)*time.Hour)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "User not found"})
+	case errors.Is(err, service.ErrMuteSelf), errors.Is(err, service.ErrInvalidMuteDuration):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error muting user:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to mute user"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, struct {
+			Message   string     `json:"message"`
+			ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		}{"User muted", expiresAt})
+	}
+}
+
+// UnmuteUser handles DELETE /users/{id}/mute.
+func (h *MuteHandler) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := userTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.MuteService.UnmuteUser(userID, targetID); err != nil {
+		fmt.Println("error unmuting user:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to unmute user"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "User unmuted"})
+}
+
+// ListMutedUsers handles GET /users/muted. Expired mutes are left out.
+func (h *MuteHandler) ListMutedUsers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	users, err := h.MuteService.ListMutedUsers(userID)
+	if err != nil {
+		fmt.Println("error listing muted users:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list muted users"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, struct {
+		Users []*models.MutedUser `json:"users"`
+	}{users})
+}
+
+// MuteKeyword handles POST /muted-keywords with {keyword}.
+func (h *MuteHandler) MuteKeyword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	var req struct {
+		Keyword string `json:"keyword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+
+	keyword, err := h.MuteService.MuteKeyword(userID, req.Keyword)
+	switch {
+	case errors.Is(err, service.ErrInvalidKeyword):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case errors.Is(err, service.ErrKeywordExists):
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error muting keyword:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to mute keyword"})
+	default:
+		utils.RespondJSON(w, http.StatusCreated, keyword)
+	}
+}
+
+// UnmuteKeyword handles DELETE /muted-keywords/{id}.
+func (h *MuteHandler) UnmuteKeyword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+	keywordID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid keyword ID"})
+		return
+	}
+
+	err = h.MuteService.UnmuteKeyword(userID, keywordID)
+	switch {
+	case errors.Is(err, service.ErrKeywordNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error unmuting keyword:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to unmute keyword"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Keyword unmuted"})
+	}
+}
+
+// ListMutedKeywords handles GET /muted-keywords.
+func (h *MuteHandler) ListMutedKeywords(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	keywords, err := h.MuteService.ListMutedKeywords(userID)
+	if err != nil {
+		fmt.Println("error listing muted keywords:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list muted keywords"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, struct {
+		Keywords []*models.MutedKeyword `json:"keywords"`
+	}{keywords})
+}
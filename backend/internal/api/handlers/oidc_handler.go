@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// oidcStateCookie binds a provider callback to the browser that started the login.
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler serves "Sign in with <provider>" through OpenID Connect.
+type OIDCHandler struct {
+	OIDCService service.OIDCService
+	AuthService service.AuthServiceInterface
+	// AfterLoginURL is where the browser lands after the callback. Failures add
+	// an oidc_error query parameter; logins needing a second factor add a
+	// two_factor_token fragment for POST /login/2fa.
+	AfterLoginURL string
+}
+
+func NewOIDCHandler(oidcService service.OIDCService, authService service.AuthServiceInterface, afterLoginURL string) *OIDCHandler {
+	return &OIDCHandler{OIDCService: oidcService, AuthService: authService, AfterLoginURL: afterLoginURL}
+}
+
+// ListProviders handles GET /auth/oidc/providers.
+func (h *OIDCHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string][]string{"providers": h.OIDCService.Providers()})
+}
+
+// Login handles GET /auth/oidc/{provider}/login by redirecting to the provider.
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.OIDCService.BeginLogin(r.Context(), r.PathValue("provider"))
+	if errors.Is(err, service.ErrUnknownOIDCProvider) {
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Unknown sign-in provider"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error starting OIDC login:", err)
+		utils.RespondJSON(w, http.StatusBadGateway, utils.Response{Message: "Failed to reach the sign-in provider"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc/",
+		HttpOnly: true,
+		// Lax so the cookie comes back on the provider's top-level redirect
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback handles GET /auth/oidc/{provider}/callback, where the provider
+// sends the browser back with a code. It signs the user in and redirects to
+// AfterLoginURL.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc/", MaxAge: -1})
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		h.redirectError(w, r, "provider_denied")
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	state := query.Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		h.redirectError(w, r, "invalid_state")
+		return
+	}
+
+	userID, err := h.OIDCService.CompleteLogin(r.Context(), r.PathValue("provider"), state, query.Get("code"))
+	switch {
+	case errors.Is(err, service.ErrUnknownOIDCProvider):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Unknown sign-in provider"})
+		return
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		h.redirectError(w, r, "invalid_state")
+		return
+	case errors.Is(err, service.ErrOIDCAccountExists):
+		h.redirectError(w, r, "account_exists")
+		return
+	case errors.Is(err, service.ErrOIDCEmailRequired):
+		h.redirectError(w, r, "email_required")
+		return
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		fmt.Println("error completing OIDC login:", err)
+		h.redirectError(w, r, "login_failed")
+		return
+	case err != nil:
+		fmt.Println("error completing OIDC login:", err)
+		h.redirectError(w, r, "server_error")
+		return
+	}
+
+	client := models.SessionClient{UserAgent: r.UserAgent(), IPAddress: utils.ClientIP(r)}
+	session, pendingToken, err := h.AuthService.LoginUser(userID, client)
+	if errors.Is(err, service.ErrTwoFactorRequired) {
+		// a fragment keeps the token out of server logs and Referer headers
+		target := h.afterLogin(nil)
+		target.Fragment = "two_factor_token=" + pendingToken
+		http.Redirect(w, r, target.String(), http.StatusFound)
+		return
+	}
+	if errors.Is(err, service.ErrAccountSuspended) {
+		h.redirectError(w, r, "account_suspended")
+		return
+	}
+	if err != nil {
+		fmt.Println("error creating session:", err)
+		h.redirectError(w, r, "server_error")
+		return
+	}
+
+	setLoginCookies(w, session)
+	http.Redirect(w, r, h.afterLogin(nil).String(), http.StatusFound)
+}
+
+func (h *OIDCHandler) redirectError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, h.afterLogin(url.Values{"oidc_error": {code}}).String(), http.StatusFound)
+}
+
+// afterLogin returns AfterLoginURL with params added to its query.
+func (h *OIDCHandler) afterLogin(params url.Values) *url.URL {
+	target, err := url.Parse(h.AfterLoginURL)
+	if err != nil {
+		target = &url.URL{Path: "/"}
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	return target
+}
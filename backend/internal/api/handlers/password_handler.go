@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// PasswordHandler handles account recovery.
+type PasswordHandler struct {
+	PasswordResetService service.PasswordResetService
+}
+
+func NewPasswordHandler(prs service.PasswordResetService) *PasswordHandler {
+	return &PasswordHandler{PasswordResetService: prs}
+}
+
+// ForgotPassword handles POST /password/forgot. The response is the same
+// whether or not the email belongs to an account.
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Email is required"})
+		return
+	}
+
+	if err := h.PasswordResetService.RequestReset(email); err != nil {
+		// Delivery failures are logged rather than reported, so they cannot be used to probe accounts
+		fmt.Println("error requesting password reset:", err)
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "If an account exists for that email, a reset link has been sent"})
+}
+
+// ResetPassword handles POST /password/reset. A successful reset signs the
+// user out everywhere, including this browser.
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid JSON request body"})
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Token and password are required"})
+		return
+	}
+
+	err := h.PasswordResetService.ResetPassword(req.Token, req.Password)
+	var policyErr *utils.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		respondPasswordPolicy(w, policyErr)
+		return
+	case errors.Is(err, service.ErrInvalidResetToken):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Reset link is invalid or has expired"})
+		return
+	case err != nil:
+		fmt.Println("error resetting password:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to reset password"})
+		return
+	}
+
+	auth.ClearSessionCookie(w)
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Password has been reset, please log in again"})
+}
@@ -3,13 +3,16 @@ package handlers
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
@@ -36,16 +39,15 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	post.Privacy = r.FormValue("privacy")
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
 	post.UserID = userID
-	fmt.Println("able to get user from context: ", r.Context())
 
-	// Handle optional image upload using the helper
-	imageData, imageMimeType, status, err := handleImageUpload(r)
+	// Handle optional image uploads using the helper
+	images, status, err := handleImageUploads(r)
 	if err != nil {
 		utils.RespondJSON(w, status, utils.Response{Message: err.Error()})
 		return
@@ -75,12 +77,15 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	// Create post with viewers if it's private
 	var id int64
 	if post.Privacy == "private" && len(viewerIDs) > 0 {
-		id, err = h.PostService.CreatePostWithViewers(&post, imageData, imageMimeType, viewerIDs)
+		id, err = h.PostService.CreatePostWithViewers(&post, images, viewerIDs)
 	} else {
-		id, err = h.PostService.CreatePost(&post, imageData, imageMimeType)
+		id, err = h.PostService.CreatePost(&post, images)
 	}
 
 	if err != nil {
+		if galleryError(w, err) {
+			return
+		}
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: err.Error()})
 		return
 	}
@@ -110,22 +115,29 @@ func (h *PostHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	comment.PostID = postID
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
 	comment.UserID = userID
 
-	// Handle optional image upload using the helper
-	imageData, imageMimeType, status, err := handleImageUpload(r)
+	// Handle optional image uploads using the helper
+	images, status, err := handleImageUploads(r)
 	if err != nil {
 		utils.RespondJSON(w, status, utils.Response{Message: err.Error()})
 		return
 	}
 
-	id, err := h.PostService.CreateComment(&comment, imageData, imageMimeType)
+	id, err := h.PostService.CreateComment(&comment, images)
 	if err != nil {
+		if galleryError(w, err) {
+			return
+		}
+		if err == sql.ErrNoRows {
+			utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Post not found"})
+			return
+		}
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: err.Error()})
 		return
 	}
@@ -144,7 +156,13 @@ func (h *PostHandler) GetPostByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.PostService.GetPostByID(postID)
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
+		return
+	}
+
+	post, err := h.PostService.GetPostByID(postID, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Post not found"})
@@ -157,44 +175,50 @@ func (h *PostHandler) GetPostByID(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, post)
 }
 
+// GetPosts handles GET /posts, the home feed, newest first. Pages are
+// fetched by cursor: before= the next_cursor of a page for older posts, or
+// after= its prev_cursor for newer ones, with limit up to 50.
+//
+// Deprecated: page= selects the old offset pagination, which drifts as
+// posts arrive. It is kept for older clients only.
 func (h *PostHandler) GetPosts(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
 
-	// Check for pagination parameters
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
-
-	// If no pagination parameters, return all posts (backward compatibility)
-	if pageStr == "" && limitStr == "" {
-		posts, err := h.PostService.GetPosts(userID)
-		if err != nil {
-			utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
-			return
-		}
-		utils.RespondJSON(w, http.StatusOK, posts)
+	query := r.URL.Query()
+	limit := 15
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+	if query.Has("page") {
+		h.getPostsByPage(w, userID, query.Get("page"), limit)
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 15
-
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	page, err := h.PostService.GetFeed(userID, query.Get("before"), query.Get("after"), limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidCursor), errors.Is(err, service.ErrCursorConflict):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error getting feed:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, page)
 	}
+}
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
-			limit = l
-		}
-	}
+// getPostsByPage serves the deprecated offset pagination of GET /posts.
+func (h *PostHandler) getPostsByPage(w http.ResponseWriter, userID int64, pageStr string, limit int) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", `</posts>; rel="successor-version"`)
 
+	page := 1
+	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+		page = p
+	}
 	offset := (page - 1) * limit
 
 	// Get paginated posts and total count
@@ -227,6 +251,29 @@ func (h *PostHandler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, response)
 }
 
+// CountNewPosts handles GET /posts/new?since=, where since is the
+// prev_cursor of the newest page shown, so the feed can offer a refresh.
+func (h *PostHandler) CountNewPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
+		return
+	}
+
+	count, err := h.PostService.CountNewPosts(userID, r.URL.Query().Get("since"))
+	switch {
+	case errors.Is(err, service.ErrInvalidCursor):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error counting new posts:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, struct {
+			Count int `json:"count"`
+		}{count})
+	}
+}
+
 func (h *PostHandler) GetCommentsByPostID(w http.ResponseWriter, r *http.Request) {
 	postIDStr := r.PathValue("postId")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
@@ -235,7 +282,7 @@ func (h *PostHandler) GetCommentsByPostID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
@@ -243,6 +290,10 @@ func (h *PostHandler) GetCommentsByPostID(w http.ResponseWriter, r *http.Request
 
 	comments, err := h.PostService.GetCommentsByPostID(postID, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Post not found"})
+			return
+		}
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
 		return
 	}
@@ -266,7 +317,7 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
@@ -279,16 +330,19 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle optional image upload using the helper
-	imageData, imageMimeType, status, err := handleImageUpload(r)
+	// Handle image changes using the helper
+	edit, status, err := attachmentEdit(r)
 	if err != nil {
 		utils.RespondJSON(w, status, utils.Response{Message: err.Error()})
 		return
 	}
 
 	// Update the post
-	updatedPost, err := h.PostService.UpdatePost(postID, userID, content, imageData, imageMimeType)
+	updatedPost, err := h.PostService.UpdatePost(postID, userID, content, edit)
 	if err != nil {
+		if galleryError(w, err) {
+			return
+		}
 		if err.Error() == "unauthorized" {
 			utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "You can only edit your own posts"})
 		} else if err.Error() == "post not found" {
@@ -310,7 +364,7 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
@@ -331,42 +385,125 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusNoContent, utils.Response{Message: "Post deleted successfully"})
 }
 
-// handleImageUpload processes an optional image from a multipart form.
-// It returns the image data, its MIME type, an appropriate HTTP status code for errors, and any error encountered.
-func handleImageUpload(r *http.Request) (imageData []byte, imageMimeType string, status int, err error) {
-	file, handler, err := r.FormFile("image")
-	if err != nil {
-		if err == http.ErrMissingFile {
-			return nil, "", 0, nil // No image provided, not an error
+// handleImageUploads reads the optional images of a multipart form: the
+// files under "images", after a single "image" as older clients send it.
+// The "alt_text" values describe them in the same order.
+// It returns the images, an appropriate HTTP status code for errors, and any error encountered.
+func handleImageUploads(r *http.Request) ([]models.ImageUpload, int, error) {
+	if r.MultipartForm == nil {
+		return nil, 0, nil
+	}
+	files := append(r.MultipartForm.File["image"], r.MultipartForm.File["images"]...)
+	if len(files) > service.MaxAttachments {
+		return nil, http.StatusBadRequest, service.ErrTooManyAttachments
+	}
+	altTexts := r.MultipartForm.Value["alt_text"]
+
+	images := make([]models.ImageUpload, 0, len(files))
+	for i, handler := range files {
+		imageData, status, err := readImage(handler)
+		if err != nil {
+			return nil, status, err
+		}
+		image := models.ImageUpload{Data: imageData}
+		if i < len(altTexts) {
+			image.AltText = altTexts[i]
 		}
-		// Other errors during FormFile processing
-		return nil, "", http.StatusInternalServerError, fmt.Errorf("error retrieving the file: %w", err)
+		images = append(images, image)
 	}
-	defer file.Close()
+	return images, 0, nil
+}
 
+// readImage reads one uploaded image, checking its size and signature.
+func readImage(handler *multipart.FileHeader) (imageData []byte, status int, err error) {
 	// 20 MB limit
 	const maxImageSize = 20 << 20
 	if handler.Size > maxImageSize {
-		return nil, "", http.StatusBadRequest, fmt.Errorf("image size exceeds 20MB limit")
+		return nil, http.StatusBadRequest, fmt.Errorf("image size exceeds 20MB limit")
 	}
 
+	file, err := handler.Open()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error retrieving the file: %w", err)
+	}
+	defer file.Close()
+
 	// Use a TeeReader to read from the file for both signature check and full read
 	var buf bytes.Buffer
 	teeReader := io.TeeReader(file, &buf)
 
 	// Perform image signature check
 	if _, err := utils.DetectImageFormat(teeReader); err != nil {
-		return nil, "", http.StatusBadRequest, err
+		return nil, http.StatusBadRequest, err
 	}
 
 	// Read the entire image data from the buffer and the remaining file content
 	imageData, err = io.ReadAll(io.MultiReader(&buf, file))
 	if err != nil {
-		return nil, "", http.StatusInternalServerError, fmt.Errorf("failed to read image data: %w", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read image data: %w", err)
+	}
+	return imageData, 0, nil
+}
+
+// attachmentEdit reads how an edit changes a gallery. "keep" lists the ids of
+// the images to retain, comma-separated and in their new order; sent empty,
+// it removes them all. Without it the gallery is kept, unless an older client
+// sends a single "image" to replace it. "alt_text_<id>" replaces the alt text
+// of a kept image, and new images are read as by handleImageUploads.
+func attachmentEdit(r *http.Request) (models.AttachmentEdit, int, error) {
+	var edit models.AttachmentEdit
+	images, status, err := handleImageUploads(r)
+	if err != nil {
+		return edit, status, err
+	}
+	edit.Add = images
+
+	if keep, ok := r.MultipartForm.Value["keep"]; ok {
+		edit.Keep = []int64{}
+		for _, idStr := range strings.Split(strings.Join(keep, ","), ",") {
+			idStr = strings.TrimSpace(idStr)
+			if idStr == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				return edit, http.StatusBadRequest, fmt.Errorf("invalid attachment ID: %s", idStr)
+			}
+			edit.Keep = append(edit.Keep, id)
+		}
+	} else if len(r.MultipartForm.File["image"]) > 0 {
+		edit.Keep = []int64{}
 	}
-	imageMimeType = handler.Header.Get("Content-Type")
 
-	return imageData, imageMimeType, 0, nil
+	for key, values := range r.MultipartForm.Value {
+		idStr, found := strings.CutPrefix(key, "alt_text_")
+		if !found || len(values) == 0 {
+			continue
+		}
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return edit, http.StatusBadRequest, fmt.Errorf("invalid attachment ID: %s", idStr)
+		}
+		if edit.AltText == nil {
+			edit.AltText = map[int64]string{}
+		}
+		edit.AltText[id] = values[0]
+	}
+	return edit, 0, nil
+}
+
+// galleryError reports the errors of invalid images and galleries, returning
+// false for any other error.
+func galleryError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrTooManyAttachments), errors.Is(err, service.ErrAltTextTooLong):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case errors.Is(err, service.ErrUnknownAttachment):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error(), Code: "unknown_attachment"})
+	default:
+		return false
+	}
+	return true
 }
 
 func (h *PostHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
@@ -378,7 +515,7 @@ func (h *PostHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
@@ -409,7 +546,7 @@ func (h *PostHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
@@ -422,16 +559,19 @@ func (h *PostHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle optional image upload using the helper
-	imageData, imageMimeType, status, err := handleImageUpload(r)
+	// Handle image changes using the helper
+	edit, status, err := attachmentEdit(r)
 	if err != nil {
 		utils.RespondJSON(w, status, utils.Response{Message: err.Error()})
 		return
 	}
 
 	// Update the comment
-	updatedComment, err := h.PostService.UpdateComment(commentID, userID, content, imageData, imageMimeType)
+	updatedComment, err := h.PostService.UpdateComment(commentID, userID, content, edit)
 	if err != nil {
+		if galleryError(w, err) {
+			return
+		}
 		if err.Error() == "unauthorized" {
 			utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "You can only edit your own comments"})
 		} else if err.Error() == "comment not found" {
@@ -453,7 +593,7 @@ func (h *PostHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value(utils.User_id).(int64)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
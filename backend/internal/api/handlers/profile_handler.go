@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
@@ -11,6 +16,8 @@ import (
 
 type ProfileHandler struct {
 	ProfileService *service.ProfileService
+	// Handles resolves GET /users/by-handle/{handle}
+	Handles service.HandleService
 }
 
 func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
@@ -21,10 +28,8 @@ func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
 
 func (ps *ProfileHandler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	var serverResponse utils.Response
-	status := http.StatusOK
-	IsMyProfile := false
 	// get  LOGGED IN USER
-	LoggedInUser, ok := r.Context().Value(utils.User_id).(int64)
+	LoggedInUser, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
@@ -39,10 +44,45 @@ func (ps *ProfileHandler) ProfileHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if userId == LoggedInUser {
-		IsMyProfile = true
+	ps.writeProfile(w, userId, LoggedInUser)
+}
+
+// ProfileByHandle handles GET /users/by-handle/{handle}; a leading "@" is
+// optional. An old handle of a renamed user redirects to their current one.
+func (ps *ProfileHandler) ProfileByHandle(w http.ResponseWriter, r *http.Request) {
+	LoggedInUser, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	handle := r.PathValue("handle")
+	userId, current, err := ps.Handles.Resolve(handle)
+	if errors.Is(err, service.ErrHandleNotFound) {
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "User not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error resolving handle:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Error fetching profile details"})
+		return
+	}
+	if !strings.EqualFold(strings.TrimPrefix(handle, "@"), current) {
+		// not permanent: the old handle is freed for others once it expires
+		http.Redirect(w, r, "/users/by-handle/"+url.PathEscape(current), http.StatusFound)
+		return
 	}
+
+	ps.writeProfile(w, userId, LoggedInUser)
+}
+
+// writeProfile responds with a user's profile as LoggedInUser may see it.
+func (ps *ProfileHandler) writeProfile(w http.ResponseWriter, userId, LoggedInUser int64) {
+	var serverResponse utils.Response
+	status := http.StatusOK
+	IsMyProfile := userId == LoggedInUser
 	var profileDetails models.ProfileDetails
+	var err error
 
 	if IsMyProfile {
 		profileDetails, err = ps.ProfileService.GetUserOwnProfile(LoggedInUser)
@@ -67,14 +107,14 @@ func (ps *ProfileHandler) ProfileHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	posts, err := ps.ProfileService.GetUserPosts(userId)
+	posts, err := ps.ProfileService.GetUserPosts(userId, LoggedInUser)
 	if err != nil {
 		serverResponse.Message = "Error fetching posts"
 		utils.RespondJSON(w, http.StatusInternalServerError, serverResponse)
 		return
 	}
 
-	photos, err := ps.ProfileService.GetUserPhotos(userId)
+	photos, err := ps.ProfileService.GetUserPhotos(userId, LoggedInUser)
 	if err != nil {
 		serverResponse.Message = "Error fetching photos"
 		utils.RespondJSON(w, http.StatusInternalServerError, serverResponse)
@@ -92,32 +132,72 @@ func (ps *ProfileHandler) ProfileHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// GetFollowers handles GET /profile/{userid}/followers?cursor=&limit=.
+// With count_only=true it returns just the number of followers.
 func (f *ProfileHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
-	userIdstr := r.PathValue("userid")
-	userId, err := strconv.ParseInt(userIdstr, 10, 64)
-	if err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid User Id"})
+	f.followList(w, r, true)
+}
+
+// GetFollowees handles GET /profile/{userid}/followees, like GetFollowers.
+func (f *ProfileHandler) GetFollowees(w http.ResponseWriter, r *http.Request) {
+	f.followList(w, r, false)
+}
+
+func (f *ProfileHandler) followList(w http.ResponseWriter, r *http.Request, followers bool) {
+	viewerID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
 		return
 	}
-	followers, err := f.ProfileService.GetFollowersList(userId)
+	userId, err := strconv.ParseInt(r.PathValue("userid"), 10, 64)
 	if err != nil {
-		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: err.Error()})
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid User Id"})
 		return
 	}
-	utils.RespondJSON(w, http.StatusOK, followers)
-}
 
-func (f *ProfileHandler) GetFollowees(w http.ResponseWriter, r *http.Request) {
-	userIdstr := r.PathValue("userid")
-	userId, err := strconv.ParseInt(userIdstr, 10, 64)
-	if err != nil {
-		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid User Id"})
+	if r.URL.Query().Get("count_only") == "true" {
+		followerCount, followeeCount, err := f.ProfileService.CountFollowers(userId)
+		if err != nil {
+			respondFollowListError(w, err)
+			return
+		}
+		if followers {
+			utils.RespondJSON(w, http.StatusOK, models.FollowCount{Count: followerCount})
+		} else {
+			utils.RespondJSON(w, http.StatusOK, models.FollowCount{Count: followeeCount})
+		}
 		return
 	}
-	followers, err := f.ProfileService.GetFolloweesList(userId)
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	cursor := r.URL.Query().Get("cursor")
+
+	var list *models.FollowListResponse
+	if followers {
+		list, err = f.ProfileService.GetFollowersList(userId, viewerID, cursor, limit)
+	} else {
+		list, err = f.ProfileService.GetFolloweesList(userId, viewerID, cursor, limit)
+	}
 	if err != nil {
-		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: err.Error()})
+		respondFollowListError(w, err)
 		return
 	}
-	utils.RespondJSON(w, http.StatusOK, followers)
+	utils.RespondJSON(w, http.StatusOK, list)
+}
+
+func respondFollowListError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "User not found"})
+	case errors.Is(err, service.ErrPrivateProfile):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: err.Error(), Code: "private_profile"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	default:
+		fmt.Println("error listing follows:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to fetch follow list"})
+	}
 }
@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
@@ -28,23 +29,12 @@ func (h *ReactionHandler) ReactToPost(w http.ResponseWriter, r *http.Request) {
 		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid post ID"})
 		return
 	}
-	fmt.Println("got post if from url: ", postID)
-	userIDValue := r.Context().Value(utils.User_id)
-	if userIDValue == nil {
-		fmt.Println("unable to get user from context: ", r.Context())
+	uid, ok := auth.UserID(r.Context())
+	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
-	userID, ok := userIDValue.(int)
-	if !ok {
-		if userID64, ok := userIDValue.(int64); ok {
-			userID = int(userID64)
-		} else {
-			fmt.Println("unable to cast user ID from context, type:", fmt.Sprintf("%T", userIDValue))
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
-			return
-		}
-	}
+	userID := int(uid)
 
 	var reaction models.Reaction
 	if err := json.NewDecoder(r.Body).Decode(&reaction); err != nil {
@@ -56,6 +46,10 @@ func (h *ReactionHandler) ReactToPost(w http.ResponseWriter, r *http.Request) {
 	reaction.PostID = &postID
 
 	if err := h.service.ReactToPost(&reaction); err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Post not found"})
+			return
+		}
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to react to post"})
 		return
 	}
@@ -70,20 +64,12 @@ func (h *ReactionHandler) UnreactToPost(w http.ResponseWriter, r *http.Request)
 		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid post ID"})
 		return
 	}
-	userIDValue := r.Context().Value(utils.User_id)
-	if userIDValue == nil {
+	uid, ok := auth.UserID(r.Context())
+	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
-	userID, ok := userIDValue.(int)
-	if !ok {
-		if userID64, ok := userIDValue.(int64); ok {
-			userID = int(userID64)
-		} else {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
-			return
-		}
-	}
+	userID := int(uid)
 
 	if err := h.service.UnreactToPost(userID, postID); err != nil {
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to unreact to post"})
@@ -100,20 +86,12 @@ func (h *ReactionHandler) ReactToComment(w http.ResponseWriter, r *http.Request)
 		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid comment ID"})
 		return
 	}
-	userIDValue := r.Context().Value(utils.User_id)
-	if userIDValue == nil {
+	uid, ok := auth.UserID(r.Context())
+	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
-	userID, ok := userIDValue.(int)
-	if !ok {
-		if userID64, ok := userIDValue.(int64); ok {
-			userID = int(userID64)
-		} else {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
-			return
-		}
-	}
+	userID := int(uid)
 
 	var reaction models.Reaction
 	if err := json.NewDecoder(r.Body).Decode(&reaction); err != nil {
@@ -125,6 +103,10 @@ func (h *ReactionHandler) ReactToComment(w http.ResponseWriter, r *http.Request)
 	reaction.CommentID = &commentID
 
 	if err := h.service.ReactToComment(&reaction); err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Comment not found"})
+			return
+		}
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to react to comment"})
 		return
 	}
@@ -139,20 +121,12 @@ func (h *ReactionHandler) UnreactToComment(w http.ResponseWriter, r *http.Reques
 		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid comment ID"})
 		return
 	}
-	userIDValue := r.Context().Value(utils.User_id)
-	if userIDValue == nil {
+	uid, ok := auth.UserID(r.Context())
+	if !ok {
 		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
 		return
 	}
-	userID, ok := userIDValue.(int)
-	if !ok {
-		if userID64, ok := userIDValue.(int64); ok {
-			userID = int(userID64)
-		} else {
-			utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
-			return
-		}
-	}
+	userID := int(uid)
 
 	if err := h.service.UnreactToComment(userID, commentID); err != nil {
 		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to unreact to comment"})
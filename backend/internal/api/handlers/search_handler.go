@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// SearchHandler serves full-text search.
+type SearchHandler struct {
+	SearchService service.SearchService
+}
+
+func NewSearchHandler(ss service.SearchService) *SearchHandler {
+	return &SearchHandler{SearchService: ss}
+}
+
+// SearchPosts handles GET /search/posts?q=&cursor=&limit=, a page of the
+// posts and comments holding every word of q, best matches first, with the
+// matching words highlighted. Pass the next_cursor of a page as cursor to get
+// the one after it.
+func (h *SearchHandler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 20
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	page, err := h.SearchService.SearchPosts(userID, query.Get("q"), query.Get("cursor"), limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidSearch), errors.Is(err, service.ErrInvalidCursor):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case errors.Is(err, service.ErrSearchUnavailable):
+		utils.RespondJSON(w, http.StatusServiceUnavailable, utils.Response{Message: err.Error(), Code: "search_unavailable"})
+	case err != nil:
+		fmt.Println("error searching posts:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, page)
+	}
+}
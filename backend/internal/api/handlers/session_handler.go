@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// SessionHandler lets users see where they are logged in and sign out other devices.
+type SessionHandler struct {
+	SessionService service.SessionService
+}
+
+func NewSessionHandler(ss service.SessionService) *SessionHandler {
+	return &SessionHandler{SessionService: ss}
+}
+
+// ListSessions handles GET /sessions.
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	sessions, err := h.SessionService.ListSessions(identity.UserID, identity.SessionID)
+	if err != nil {
+		fmt.Println("error listing sessions:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to list sessions"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession handles DELETE /sessions/{id}. Revoking the current session logs the caller out.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	session, err := h.SessionService.RevokeUserSession(identity.UserID, r.PathValue("id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		utils.RespondJSON(w, http.StatusNotFound, utils.Response{Message: "Session not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error revoking session:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to revoke session"})
+		return
+	}
+
+	if session.ID == identity.SessionID {
+		auth.ClearSessionCookie(w)
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Session revoked"})
+}
+
+// RevokeOtherSessions handles DELETE /sessions?others=true, signing out every other device.
+// An access token has no session to keep, so it cannot be used here.
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+	if identity.SessionID == "" {
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Other sessions can only be revoked from a logged-in session"})
+		return
+	}
+
+	if r.URL.Query().Get("others") != "true" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Use others=true to revoke all other sessions"})
+		return
+	}
+
+	n, err := h.SessionService.RevokeOtherSessions(identity.UserID, identity.SessionID)
+	if err != nil {
+		fmt.Println("error revoking sessions:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to revoke sessions"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: fmt.Sprintf("Revoked %d other sessions", n)})
+}
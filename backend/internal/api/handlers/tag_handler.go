@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// TagHandler serves hashtag timelines and trends.
+type TagHandler struct {
+	TagService service.TagService
+}
+
+func NewTagHandler(ts service.TagService) *TagHandler {
+	return &TagHandler{TagService: ts}
+}
+
+// GetTagPosts handles GET /tags/{tag}/posts?before=&limit=, a page of the
+// posts tagged with tag, newest first. Pass the next_cursor of a page as
+// before to get the one after it.
+func (h *TagHandler) GetTagPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Unauthorized"})
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 15
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	page, err := h.TagService.GetTagTimeline(userID, r.PathValue("tag"), query.Get("before"), limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrInvalidCursor):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: err.Error()})
+	case err != nil:
+		fmt.Println("error getting tag timeline:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+	default:
+		utils.RespondJSON(w, http.StatusOK, page)
+	}
+}
+
+// GetTrendingTags handles GET /tags/trending?limit=, the tags most used
+// lately, with the number of uses and of people using each.
+func (h *TagHandler) GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	tags, err := h.TagService.TrendingTags(limit)
+	if err != nil {
+		fmt.Println("error getting trending tags:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Internal server error"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+func TestChangePassword(t *testing.T) {
+	db := setupEdgeCaseTestDB(t)
+	defer db.Close()
+	createEdgeCaseTestUser(t, db)
+
+	sessions := newTestSessionService(db)
+	authService := service.NewAuthService(store.NewAuthStore(db), sessions)
+	authHandler := handlers.NewAuthHandler(authService)
+
+	current, _ := sessions.CreateSession(1, models.SessionClient{})
+	other, _ := sessions.CreateSession(1, models.SessionClient{})
+
+	changePassword := func(body string) (*httptest.ResponseRecorder, utils.Response) {
+		req := httptest.NewRequest("PUT", "/password", strings.NewReader(body))
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1, SessionID: current.ID}))
+		rr := httptest.NewRecorder()
+		authHandler.ChangePassword(rr, req)
+
+		var resp utils.Response
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	rr, resp := changePassword(`{"current_password": "WrongPassword1!", "new_password": "N3w-Password"}`)
+	if rr.Code != http.StatusForbidden || resp.Code != "incorrect_password" {
+		t.Errorf("wrong current password: got %d %+v", rr.Code, resp)
+	}
+
+	rr, resp = changePassword(`{"current_password": "TestPassword123!", "new_password": "weak"}`)
+	if rr.Code != http.StatusBadRequest || resp.Code != "password_policy" {
+		t.Fatalf("weak password: got %d %+v", rr.Code, resp)
+	}
+	want := []string{
+		"password must be at least 8 characters",
+		"password must contain an uppercase letter",
+		"password must contain a number",
+		"password must contain a special character",
+	}
+	if strings.Join(resp.Errors, "|") != strings.Join(want, "|") {
+		t.Errorf("expected every failed rule, got %q", resp.Errors)
+	}
+
+	rr, resp = changePassword(`{"current_password": "TestPassword123!", "new_password": "TestPassword123!"}`)
+	if rr.Code != http.StatusBadRequest || len(resp.Errors) != 1 {
+		t.Errorf("reusing the current password: got %d %+v", rr.Code, resp)
+	}
+
+	if _, _, err := sessions.ValidateSession(other.ID); err != nil {
+		t.Fatalf("failed attempts must not revoke sessions: %v", err)
+	}
+
+	rr, _ = changePassword(`{"current_password": "TestPassword123!", "new_password": "N3w-Password"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if _, _, err := sessions.ValidateSession(other.ID); err == nil {
+		t.Error("other sessions must be revoked")
+	}
+	if _, _, err := sessions.ValidateSession(current.ID); err != nil {
+		t.Errorf("the current session must survive: %v", err)
+	}
+	if user, _, _ := authService.AuthenticateUser("test@example.com", "N3w-Password", models.SessionClient{}); user == nil {
+		t.Error("the new password should log in")
+	}
+	if user, _, _ := authService.AuthenticateUser("test@example.com", "TestPassword123!", models.SessionClient{}); user != nil {
+		t.Error("the old password should no longer log in")
+	}
+}
@@ -12,6 +12,8 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/internal/store"
@@ -51,6 +53,9 @@ func setupEdgeCaseTestDB(t *testing.T) *sql.DB {
 			id TEXT PRIMARY KEY,
 			user_id INTEGER NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME,
+			user_agent TEXT,
+			ip_address TEXT,
 			expires_at DATETIME NOT NULL,
 			FOREIGN KEY (user_id) REFERENCES Users(id)
 		)
@@ -62,6 +67,18 @@ func setupEdgeCaseTestDB(t *testing.T) *sql.DB {
 	return db
 }
 
+// newTestSessionService creates a session service backed by db with the default lifetimes
+func newTestSessionService(db *sql.DB) service.SessionService {
+	return service.NewSessionService(store.NewSessionStore(db), service.DefaultSessionConfig(), nil)
+}
+
+// plainUsers gives every caller the user role, for schemas without site roles.
+type plainUsers struct{ service.AdminService }
+
+func (plainUsers) GetRole(userID int64) (string, error) {
+	return auth.RoleUser, nil
+}
+
 // createEdgeCaseTestUser creates a test user in the database
 func createEdgeCaseTestUser(t *testing.T, db *sql.DB) {
 	passwordManager := utils.NewPasswordManager(utils.PasswordConfig{})
@@ -86,9 +103,7 @@ func TestExpiredSessions(t *testing.T) {
 
 	createEdgeCaseTestUser(t, db)
 
-	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
-	authHandler := handlers.NewAuthHandler(authService)
+	sessions := newTestSessionService(db)
 
 	// Create an expired session manually
 	expiredTime := time.Now().Add(-1 * time.Hour) // 1 hour ago
@@ -112,7 +127,7 @@ func TestExpiredSessions(t *testing.T) {
 		utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access granted"})
 	})
 
-	authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+	authMiddleware := middleware.AuthMiddleware(sessions, nil, plainUsers{})(protectedHandler)
 	authMiddleware.ServeHTTP(rr, req)
 
 	// Should deny access for expired session
@@ -138,9 +153,7 @@ func TestInvalidSessionIDs(t *testing.T) {
 
 	createEdgeCaseTestUser(t, db)
 
-	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
-	authHandler := handlers.NewAuthHandler(authService)
+	sessions := newTestSessionService(db)
 
 	invalidSessionIDs := []string{
 		"non-existent-session",
@@ -166,7 +179,7 @@ func TestInvalidSessionIDs(t *testing.T) {
 				utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access granted"})
 			})
 
-			authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+			authMiddleware := middleware.AuthMiddleware(sessions, nil, plainUsers{})(protectedHandler)
 			authMiddleware.ServeHTTP(rr, req)
 
 			// Should deny access for invalid session
@@ -186,7 +199,8 @@ func TestConcurrentLogins(t *testing.T) {
 	createEdgeCaseTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	sessions := newTestSessionService(db)
+	authService := service.NewAuthService(authStore, sessions)
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Reduce concurrency for SQLite compatibility
@@ -285,7 +299,7 @@ func TestConcurrentLogins(t *testing.T) {
 			utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access granted"})
 		})
 
-		authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+		authMiddleware := middleware.AuthMiddleware(sessions, nil, plainUsers{})(protectedHandler)
 		authMiddleware.ServeHTTP(rr, req)
 
 		if rr.Code != http.StatusOK {
@@ -310,7 +324,8 @@ func TestSessionCleanup(t *testing.T) {
 	createEdgeCaseTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	sessions := newTestSessionService(db)
+	authService := service.NewAuthService(authStore, sessions)
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Perform multiple logins for the same user
@@ -373,7 +388,7 @@ func TestSessionCleanup(t *testing.T) {
 			utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access granted"})
 		})
 
-		authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+		authMiddleware := middleware.AuthMiddleware(sessions, nil, plainUsers{})(protectedHandler)
 		authMiddleware.ServeHTTP(rr, req)
 
 		if rr.Code != http.StatusOK {
@@ -11,23 +11,28 @@ import (
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
 	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
 // MockAuthService is a mock implementation of the AuthService for testing.
 type MockAuthService struct {
-	AuthenticateUserFunc      func(email, password string) (*models.User, string, error)
-	DeleteSessionFunc         func(sessionID string) (int, error)
-	GetUserIDBySessionFunc    func(sessionID string) (int, error)
-	CreateUserFunc            func(user *models.User) (*models.User, error)
-	ValidateEmailFunc         func(email string) (bool, error)
-	UserExistsFunc            func(email string) (bool, error)
-	UserNewEditEmailExistFunc func(email string, userid int64) (bool, error)
-	EditUserProfileFunc       func(user *models.User, userid int64) error
+	AuthenticateUserFunc       func(email, password string, client models.SessionClient) (*models.User, string, error)
+	DeleteSessionFunc          func(sessionID string) (int, error)
+	GetSessionFunc             func(sessionID string) (*models.Session, error)
+	GetUserIDBySessionFunc     func(sessionID string) (int, error)
+	CreateUserFunc             func(user *models.User) (*models.User, error)
+	ValidateEmailFunc          func(email string) (bool, error)
+	UserExistsFunc             func(email string) (bool, error)
+	UserNewEditEmailExistFunc  func(email string, userid int64) (bool, error)
+	EditUserProfileFunc        func(user *models.User, userid int64) error
+	ChangePasswordFunc         func(userID int64, keepSessionID, currentPassword, newPassword string) error
+	CompleteTwoFactorLoginFunc func(token, code string, client models.SessionClient) (*models.Session, error)
+	LoginUserFunc              func(userID int64, client models.SessionClient) (*models.Session, string, error)
 }
 
-func (s *MockAuthService) AuthenticateUser(email, password string) (*models.User, string, error) {
-	return s.AuthenticateUserFunc(email, password)
+func (s *MockAuthService) AuthenticateUser(email, password string, client models.SessionClient) (*models.User, string, error) {
+	return s.AuthenticateUserFunc(email, password, client)
 }
 
 func (s *MockAuthService) DeleteSession(sessionID string) (int, error) {
@@ -37,6 +42,13 @@ func (s *MockAuthService) DeleteSession(sessionID string) (int, error) {
 	return 0, nil
 }
 
+func (s *MockAuthService) GetSession(sessionID string) (*models.Session, error) {
+	if s.GetSessionFunc != nil {
+		return s.GetSessionFunc(sessionID)
+	}
+	return &models.Session{ID: sessionID, UserID: 1, ExpiresAt: time.Now().Add(service.DefaultSessionConfig().IdleTimeout)}, nil
+}
+
 func (s *MockAuthService) GetUserIDBySession(sessionID string) (int, error) {
 	return s.GetUserIDBySessionFunc(sessionID)
 }
@@ -61,10 +73,22 @@ func (s *MockAuthService) EditUserProfile(user *models.User, userid int64) error
 	return s.EditUserProfileFunc(user, userid)
 }
 
+func (s *MockAuthService) CompleteTwoFactorLogin(token, code string, client models.SessionClient) (*models.Session, error) {
+	return s.CompleteTwoFactorLoginFunc(token, code, client)
+}
+
+func (s *MockAuthService) LoginUser(userID int64, client models.SessionClient) (*models.Session, string, error) {
+	return s.LoginUserFunc(userID, client)
+}
+
+func (s *MockAuthService) ChangePassword(userID int64, keepSessionID, currentPassword, newPassword string) error {
+	return s.ChangePasswordFunc(userID, keepSessionID, currentPassword, newPassword)
+}
+
 func TestLogin(t *testing.T) {
 	// Create a new mock auth service
 	mockAuthService := &MockAuthService{
-		AuthenticateUserFunc: func(email, password string) (*models.User, string, error) {
+		AuthenticateUserFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 			return &models.User{ID: 1, Email: "test@test.com"}, "session123", nil
 		},
 		CreateUserFunc: func(user *models.User) (*models.User, error) {
@@ -145,7 +169,7 @@ func TestLogin(t *testing.T) {
 
 func TestLogin_SessionFixation_NotPrevented(t *testing.T) {
 	mockAuthService := &MockAuthService{
-		AuthenticateUserFunc: func(email, password string) (*models.User, string, error) {
+		AuthenticateUserFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 			return &models.User{ID: 1, Email: "test@test.com"}, "new-session-id", nil
 		},
 		CreateUserFunc: func(user *models.User) (*models.User, error) {
@@ -188,13 +212,13 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 		name     string
 		email    string
 		password string
-		mockFunc func(email, password string) (*models.User, string, error)
+		mockFunc func(email, password string, client models.SessionClient) (*models.User, string, error)
 	}{
 		{
 			name:     "Invalid email",
 			email:    "nonexistent@test.com",
 			password: "password",
-			mockFunc: func(email, password string) (*models.User, string, error) {
+			mockFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 				return nil, "User does not exist", errors.New("user not found")
 			},
 		},
@@ -202,7 +226,7 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 			name:     "Invalid password",
 			email:    "test@test.com",
 			password: "wrongpassword",
-			mockFunc: func(email, password string) (*models.User, string, error) {
+			mockFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 				return nil, "Invalid password", errors.New("password mismatch")
 			},
 		},
@@ -210,7 +234,7 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 			name:     "Empty email",
 			email:    "",
 			password: "password",
-			mockFunc: func(email, password string) (*models.User, string, error) {
+			mockFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 				return nil, "User does not exist", errors.New("empty email")
 			},
 		},
@@ -218,7 +242,7 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 			name:     "Empty password",
 			email:    "test@test.com",
 			password: "",
-			mockFunc: func(email, password string) (*models.User, string, error) {
+			mockFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 				return nil, "Invalid password", errors.New("empty password")
 			},
 		},
@@ -251,6 +275,13 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 					status, http.StatusUnauthorized)
 			}
 
+			// Should not reveal whether the email exists
+			var resp utils.Response
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Message != service.INVALID_CREDENTIALS {
+				t.Errorf("expected generic message %q, got %q", service.INVALID_CREDENTIALS, resp.Message)
+			}
+
 			// Should not set session cookie for failed login
 			cookies := rr.Result().Cookies()
 			for _, cookie := range cookies {
@@ -265,7 +296,7 @@ func TestLogin_IncorrectCredentials(t *testing.T) {
 // Test login with form data instead of JSON
 func TestLogin_FormData(t *testing.T) {
 	mockAuthService := &MockAuthService{
-		AuthenticateUserFunc: func(email, password string) (*models.User, string, error) {
+		AuthenticateUserFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 			if email == "test@test.com" && password == "password" {
 				return &models.User{ID: 1, Email: "test@test.com"}, "session123", nil
 			}
@@ -309,10 +340,14 @@ func TestLogin_FormData(t *testing.T) {
 
 // Test session creation properties
 func TestLogin_SessionCookieProperties(t *testing.T) {
+	sessionExpiry := time.Now().Add(36 * time.Hour).UTC().Truncate(time.Second)
 	mockAuthService := &MockAuthService{
-		AuthenticateUserFunc: func(email, password string) (*models.User, string, error) {
+		AuthenticateUserFunc: func(email, password string, client models.SessionClient) (*models.User, string, error) {
 			return &models.User{ID: 1, Email: "test@test.com"}, "session123", nil
 		},
+		GetSessionFunc: func(sessionID string) (*models.Session, error) {
+			return &models.Session{ID: sessionID, UserID: 1, ExpiresAt: sessionExpiry}, nil
+		},
 		CreateUserFunc: func(user *models.User) (*models.User, error) {
 			return user, nil
 		},
@@ -357,10 +392,8 @@ func TestLogin_SessionCookieProperties(t *testing.T) {
 	if sessionCookie.Expires.IsZero() {
 		t.Error("cookie should have expiration time set")
 	}
-	// Check that expiration is approximately 7 days from now
-	expectedExpiry := time.Now().Add(7 * 24 * time.Hour)
-	if sessionCookie.Expires.Before(expectedExpiry.Add(-time.Minute)) ||
-		sessionCookie.Expires.After(expectedExpiry.Add(time.Minute)) {
-		t.Errorf("cookie expiration should be ~7 days from now, got %v", sessionCookie.Expires)
+	// The cookie must expire together with the session in the database
+	if !sessionCookie.Expires.Equal(sessionExpiry) {
+		t.Errorf("cookie expiration should match the session expiry %v, got %v", sessionExpiry, sessionCookie.Expires)
 	}
 }
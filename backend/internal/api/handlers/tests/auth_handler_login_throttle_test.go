@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+func TestLoginThrottling(t *testing.T) {
+	db := setupEdgeCaseTestDB(t)
+	defer db.Close()
+	createEdgeCaseTestUser(t, db)
+	if _, err := db.Exec(`
+		CREATE TABLE Login_Failures (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, user_id INTEGER, ip_address TEXT NOT NULL, user_agent TEXT, reason TEXT NOT NULL, created_at DATETIME NOT NULL);
+		CREATE TABLE Login_Throttles (throttle_key TEXT PRIMARY KEY, failures INTEGER NOT NULL DEFAULT 0, last_failure_at DATETIME NOT NULL, locked_until DATETIME);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	config := service.DefaultLoginThrottleConfig()
+	config.Account = service.ThrottleLimit{FreeAttempts: 10, LockoutAfter: 3}
+	authService := service.NewAuthService(store.NewAuthStore(db), newTestSessionService(db))
+	authService.Throttle = service.NewLoginThrottleService(store.NewLoginAttemptStore(db), config)
+	authHandler := handlers.NewAuthHandler(authService)
+
+	login := func(email, password string) (*httptest.ResponseRecorder, utils.Response) {
+		body := `{"email":"` + email + `","password":"` + password + `"}`
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		authHandler.Login(rr, req)
+		var resp utils.Response
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return rr, resp
+	}
+
+	// Unknown emails and wrong passwords are indistinguishable.
+	rrUnknown, unknown := login("nobody@example.com", "TestPassword123!")
+	rrWrong, wrong := login("test@example.com", "WrongPassword1!")
+	if rrUnknown.Code != http.StatusUnauthorized || rrWrong.Code != http.StatusUnauthorized || unknown.Message != wrong.Message || unknown.Code != wrong.Code {
+		t.Fatalf("responses differ: %d %+v vs %d %+v", rrUnknown.Code, unknown, rrWrong.Code, wrong)
+	}
+
+	// The third failure locks the account, even for the right password.
+	login("test@example.com", "WrongPassword1!")
+	login("test@example.com", "WrongPassword1!")
+	rr, resp := login("test@example.com", "TestPassword123!")
+	if rr.Code != http.StatusTooManyRequests || resp.Code != "too_many_attempts" {
+		t.Fatalf("expected 429 too_many_attempts, got %d %+v", rr.Code, resp)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	var audited, withUser int
+	db.QueryRow("SELECT COUNT(*), COUNT(user_id) FROM Login_Failures").Scan(&audited, &withUser)
+	if audited != 5 || withUser != 3 {
+		t.Errorf("audit log has %d rows (%d with a user), want 5 (3)", audited, withUser)
+	}
+}
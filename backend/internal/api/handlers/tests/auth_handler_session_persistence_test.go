@@ -8,10 +8,26 @@ import (
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
 	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
+// mockSessions validates sessions through MockAuthService.GetUserIDBySession.
+type mockSessions struct {
+	service.SessionService
+	auth *MockAuthService
+}
+
+func (s mockSessions) ValidateSession(sessionID string) (*models.Session, bool, error) {
+	userID, err := s.auth.GetUserIDBySession(sessionID)
+	if err != nil {
+		return nil, false, service.ErrSessionNotFound
+	}
+	return &models.Session{ID: sessionID, UserID: int64(userID)}, false, nil
+}
+
 // Test session persistence by accessing protected routes
 func TestSessionPersistence_ValidSession(t *testing.T) {
 	mockAuthService := &MockAuthService{
@@ -25,7 +41,6 @@ func TestSessionPersistence_ValidSession(t *testing.T) {
 			return user, nil
 		},
 	}
-	authHandler := handlers.NewAuthHandler(mockAuthService)
 
 	// Create a request with a valid session cookie
 	req := httptest.NewRequest("GET", "/protected", nil)
@@ -42,7 +57,7 @@ func TestSessionPersistence_ValidSession(t *testing.T) {
 	})
 
 	// Wrap with auth middleware
-	authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+	authMiddleware := middleware.AuthMiddleware(mockSessions{auth: mockAuthService}, nil, plainUsers{})(protectedHandler)
 	authMiddleware.ServeHTTP(rr, req)
 
 	// Should allow access
@@ -69,7 +84,6 @@ func TestSessionPersistence_InvalidSession(t *testing.T) {
 			return user, nil
 		},
 	}
-	authHandler := handlers.NewAuthHandler(mockAuthService)
 
 	// Create a request with an invalid session cookie
 	req := httptest.NewRequest("GET", "/protected", nil)
@@ -86,7 +100,7 @@ func TestSessionPersistence_InvalidSession(t *testing.T) {
 	})
 
 	// Wrap with auth middleware
-	authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+	authMiddleware := middleware.AuthMiddleware(mockSessions{auth: mockAuthService}, nil, plainUsers{})(protectedHandler)
 	authMiddleware.ServeHTTP(rr, req)
 
 	// Should deny access
@@ -113,7 +127,6 @@ func TestSessionPersistence_NoSession(t *testing.T) {
 			return user, nil
 		},
 	}
-	authHandler := handlers.NewAuthHandler(mockAuthService)
 
 	// Create a request without session cookie
 	req := httptest.NewRequest("GET", "/protected", nil)
@@ -126,7 +139,7 @@ func TestSessionPersistence_NoSession(t *testing.T) {
 	})
 
 	// Wrap with auth middleware
-	authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+	authMiddleware := middleware.AuthMiddleware(mockSessions{auth: mockAuthService}, nil, plainUsers{})(protectedHandler)
 	authMiddleware.ServeHTTP(rr, req)
 
 	// Should deny access
@@ -265,7 +278,7 @@ func TestLogout_SessionInvalidation(t *testing.T) {
 	protectedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Access granted"})
 	})
-	authMiddleware := authHandler.AuthMiddleware(protectedHandler)
+	authMiddleware := middleware.AuthMiddleware(mockSessions{auth: mockAuthService}, nil, plainUsers{})(protectedHandler)
 	authMiddleware.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
@@ -52,6 +52,9 @@ func setupLoginTestDB(t *testing.T) *sql.DB {
 			id TEXT PRIMARY KEY,
 			user_id INTEGER NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME,
+			user_agent TEXT,
+			ip_address TEXT,
 			expires_at DATETIME NOT NULL,
 			FOREIGN KEY (user_id) REFERENCES Users(id)
 		)
@@ -126,7 +129,7 @@ func TestLoginSQLInjection_JSON(t *testing.T) {
 	createLoginTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	for i, payload := range loginSQLInjectionPayloads {
@@ -177,7 +180,7 @@ func TestLoginSQLInjection_FormData(t *testing.T) {
 	createLoginTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	for i, payload := range loginSQLInjectionPayloads {
@@ -224,7 +227,7 @@ func TestLoginValidCredentials(t *testing.T) {
 	createLoginTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Test valid login
@@ -266,7 +269,7 @@ func TestLoginDatabaseIntegrityAfterInjectionAttempts(t *testing.T) {
 	createLoginTestUser(t, db)
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Count users before injection attempts
@@ -375,7 +378,7 @@ func TestSignupHandler_SQLInjectionAttempt(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	fields := map[string]string{
@@ -404,7 +407,7 @@ func TestSignupHandler_XSSPrevention(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	fields := map[string]string{
@@ -413,7 +416,7 @@ func TestSignupHandler_XSSPrevention(t *testing.T) {
 		"firstName":         "<script>alert('xss')</script>",
 		"lastName":          "<img src=x onerror=alert(1)>",
 		"dob":               "2000-01-01",
-		"nickname":          "&lt;script&gt;",
+		"nickname":          "xss_tester",
 		"aboutMe":           "Hello <b>world</b> & <script>alert('xss')</script>",
 		"profileVisibility": "public",
 	}
@@ -440,7 +443,7 @@ func TestSignupHandler_XSSPrevention(t *testing.T) {
 
 	expectedFirstName := "&lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;"
 	expectedLastName := "&lt;img src=x onerror=alert(1)&gt;"
-	expectedNickname := "&amp;lt;script&amp;gt;"
+	expectedNickname := "xss_tester"
 	expectedAboutMe := "Hello &lt;b&gt;world&lt;/b&gt; &amp; &lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;"
 
 	if firstName != expectedFirstName {
@@ -455,6 +458,21 @@ func TestSignupHandler_XSSPrevention(t *testing.T) {
 	if aboutMe != expectedAboutMe {
 		t.Errorf("about_me not properly escaped. Expected: %s, Got: %s", expectedAboutMe, aboutMe)
 	}
+
+	// nicknames are handles, so markup is rejected rather than escaped
+	fields["email"] = "other@example.com"
+	fields["nickname"] = "&lt;script&gt;"
+	body, contentType, err = createSignupMultipartForm(fields)
+	if err != nil {
+		t.Fatalf("Failed to create multipart form: %v", err)
+	}
+	req = httptest.NewRequest("POST", "/signup", body)
+	req.Header.Set("Content-Type", contentType)
+	w = httptest.NewRecorder()
+	authHandler.Signup(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("markup in nickname: expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
 }
 
 func TestSignupHandler_SQLInjectionVariants(t *testing.T) {
@@ -462,7 +480,7 @@ func TestSignupHandler_SQLInjectionVariants(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	injections := []string{
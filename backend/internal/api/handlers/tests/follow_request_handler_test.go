@@ -2,7 +2,6 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
@@ -82,6 +82,10 @@ func (s *MockFollowRequestService) CancelFollowRequest(followConnectionID int64)
 	return nil
 }
 
+func (s *MockFollowRequestService) ShouldNotify(recipientID, actorID int64, message string) bool {
+	return true
+}
+
 func TestFollowRequestRespond_AcceptSuccess(t *testing.T) {
 	mockFollowRequestService := &MockFollowRequestService{
 		AcceptedFollowConnectionFunc: func(followConnectionID int64) error {
@@ -100,7 +104,7 @@ func TestFollowRequestRespond_AcceptSuccess(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context (simulating authenticated user)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -139,7 +143,7 @@ func TestFollowRequestRespond_RejectSuccess(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -175,7 +179,7 @@ func TestFollowRequestRespond_RequestNotFound(t *testing.T) {
 	req.SetPathValue("requestId", "999")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -207,7 +211,7 @@ func TestFollowRequestRespond_InvalidRequestID(t *testing.T) {
 	req.SetPathValue("requestId", "invalid")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -239,7 +243,7 @@ func TestFollowRequestRespond_InvalidStatus(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -270,7 +274,7 @@ func TestFollowRequestRespond_InvalidJSON(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -336,7 +340,7 @@ func TestFollowRequestRespond_ServiceError(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -378,7 +382,7 @@ func TestCancelFollowRequest_Success(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context (user 1 is the follower)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -433,7 +437,7 @@ func TestCancelFollowRequest_InvalidRequestID(t *testing.T) {
 	req.SetPathValue("requestId", "invalid")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -466,7 +470,7 @@ func TestCancelFollowRequest_RequestNotFound(t *testing.T) {
 	req.SetPathValue("requestId", "999")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -502,7 +506,7 @@ func TestCancelFollowRequest_Forbidden(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context (user 1, but follower is user 2)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -541,7 +545,7 @@ func TestCancelFollowRequest_ServiceError(t *testing.T) {
 	req.SetPathValue("requestId", "123")
 
 	// Add user ID to context (user 1 is the follower)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -11,6 +11,8 @@ import (
 	"github.com/gorilla/websocket"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
 )
 
@@ -28,7 +30,8 @@ func setupTestDB(t *testing.T) *sql.DB {
             nickname TEXT,
             first_name TEXT,
             last_name TEXT,
-            avatar TEXT
+            avatar TEXT,
+            email_verified_at DATETIME DEFAULT CURRENT_TIMESTAMP
         );
         CREATE TABLE sessions (
             id TEXT PRIMARY KEY,
@@ -72,7 +75,7 @@ func TestWebSocketConnection(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	permissionChecker := ws.NewDBPermissionChecker(db)
+	permissionChecker := ws.NewDBPermissionChecker(db, service.NewBlockService(store.NewBlockStore(db)), service.NewMuteService(store.NewMuteStore(db)))
 	// Create manager with real dependencies
 	manager := ws.NewManager(
 		ws.NewDBSessionResolver(db),
@@ -85,7 +88,7 @@ func TestWebSocketConnection(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Add test session cookie
 		r.AddCookie(&http.Cookie{Name: "session_id", Value: "test-session"})
-		handlers.NewWebSocketHandler(manager).HandleConnection(w, r)
+		handlers.NewWebSocketHandler(manager, nil).HandleConnection(w, r)
 	}))
 	defer server.Close()
 
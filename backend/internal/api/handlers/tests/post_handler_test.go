@@ -2,7 +2,6 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -14,36 +13,37 @@ import (
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
-	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
 // MockPostService is a mock implementation of the PostService for testing.
 type MockPostService struct {
-	CreatePostFunc          func(post *models.Post, imageData []byte, imageMimeType string) (int64, error)
-	GetPostByIDFunc         func(id int64) (*models.Post, error)
+	CreatePostFunc          func(post *models.Post, images []models.ImageUpload) (int64, error)
+	GetPostByIDFunc         func(id, viewerID int64) (*models.Post, error)
 	GetPostsFunc            func(userID int64) ([]*models.Post, error)
-	CreateCommentFunc       func(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error)
+	GetFeedFunc             func(userID int64, before, after string, limit int) (*models.FeedPage, error)
+	CreateCommentFunc       func(comment *models.Comment, images []models.ImageUpload) (int64, error)
 	GetCommentsByPostIDFunc func(postID, userID int64) ([]*models.Comment, error)
 	DeletePostFunc          func(postID, userID int64) error
-	UpdateCommentFunc       func(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error)
+	UpdateCommentFunc       func(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error)
 	DeleteCommentFunc       func(commentID, userID int64) error
 	GetCommentByIDFunc      func(commentID int64) (*models.Comment, error)
 }
 
-func (s *MockPostService) CreatePost(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
-	return s.CreatePostFunc(post, imageData, imageMimeType)
+func (s *MockPostService) CreatePost(post *models.Post, images []models.ImageUpload) (int64, error) {
+	return s.CreatePostFunc(post, images)
 }
 
-func (s *MockPostService) CreateComment(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error) {
+func (s *MockPostService) CreateComment(comment *models.Comment, images []models.ImageUpload) (int64, error) {
 	if s.CreateCommentFunc != nil {
-		return s.CreateCommentFunc(comment, imageData, imageMimeType)
+		return s.CreateCommentFunc(comment, images)
 	}
 	return 0, fmt.Errorf("CreateCommentFunc not implemented")
 }
 
-func (s *MockPostService) GetPostByID(id int64) (*models.Post, error) {
-	return s.GetPostByIDFunc(id)
+func (s *MockPostService) GetPostByID(id, viewerID int64) (*models.Post, error) {
+	return s.GetPostByIDFunc(id, viewerID)
 }
 
 func (s *MockPostService) GetPosts(userID int64) ([]*models.Post, error) {
@@ -67,14 +67,14 @@ func (s *MockPostService) DeletePost(postID, userID int64) error {
 	return fmt.Errorf("DeletePostFunc not implemented")
 }
 
-func (s *MockPostService) CreatePostWithViewers(post *models.Post, imageData []byte, imageMimeType string, viewerIDs []int64) (int64, error) {
+func (s *MockPostService) CreatePostWithViewers(post *models.Post, images []models.ImageUpload, viewerIDs []int64) (int64, error) {
 	if s.CreatePostFunc != nil {
-		return s.CreatePostFunc(post, imageData, imageMimeType)
+		return s.CreatePostFunc(post, images)
 	}
 	return 0, fmt.Errorf("CreatePostFunc not implemented")
 }
 
-func (s *MockPostService) UpdatePost(postID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Post, error) {
+func (s *MockPostService) UpdatePost(postID, userID int64, content string, edit models.AttachmentEdit) (*models.Post, error) {
 	return nil, nil
 }
 
@@ -82,9 +82,9 @@ func (s *MockPostService) SearchUsers(query string, currentUserID int64) ([]*mod
 	return nil, nil
 }
 
-func (s *MockPostService) UpdateComment(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+func (s *MockPostService) UpdateComment(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 	if s.UpdateCommentFunc != nil {
-		return s.UpdateCommentFunc(commentID, userID, content, imageData, imageMimeType)
+		return s.UpdateCommentFunc(commentID, userID, content, edit)
 	}
 	return nil, fmt.Errorf("UpdateCommentFunc not implemented")
 }
@@ -111,6 +111,17 @@ func (s *MockPostService) GetPostsCount(userID int64) (int, error) {
 	return 0, fmt.Errorf("GetPostsCount not implemented")
 }
 
+func (s *MockPostService) GetFeed(userID int64, before, after string, limit int) (*models.FeedPage, error) {
+	if s.GetFeedFunc != nil {
+		return s.GetFeedFunc(userID, before, after, limit)
+	}
+	return nil, fmt.Errorf("GetFeedFunc not implemented")
+}
+
+func (s *MockPostService) CountNewPosts(userID int64, since string) (int, error) {
+	return 0, fmt.Errorf("CountNewPosts not implemented")
+}
+
 func TestDeletePost(t *testing.T) {
 	// Test case 1: Successful deletion
 	t.Run("Successful deletion", func(t *testing.T) {
@@ -130,7 +141,7 @@ func TestDeletePost(t *testing.T) {
 		}
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -192,7 +203,7 @@ func TestDeletePost(t *testing.T) {
 		}
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -218,7 +229,7 @@ func TestDeletePost(t *testing.T) {
 		}
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -244,7 +255,7 @@ func TestDeletePost(t *testing.T) {
 		}
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -260,9 +271,9 @@ func TestCreatePost(t *testing.T) {
 	// Test case 1: Successful post creation with image
 	t.Run("Successful post creation with image", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreatePostFunc: func(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
-				if post.Content != "Test post with image" || imageData == nil || imageMimeType != "image/jpeg" {
-					t.Errorf("unexpected input to CreatePost: content=%s, imageData present=%t, mimeType=%s", post.Content, imageData != nil, imageMimeType)
+			CreatePostFunc: func(post *models.Post, images []models.ImageUpload) (int64, error) {
+				if post.Content != "Test post with image" || len(images) != 1 || len(images[0].Data) == 0 {
+					t.Errorf("unexpected input to CreatePost: content=%s, images=%d", post.Content, len(images))
 				}
 				return 1, nil
 			},
@@ -291,7 +302,7 @@ func TestCreatePost(t *testing.T) {
 		}
 		req.Header.Set("Content-Type", w.FormDataContentType())
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -305,7 +316,7 @@ func TestCreatePost(t *testing.T) {
 	// Test case 2: Image size exceeds limit
 	t.Run("Image size exceeds limit", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreatePostFunc: func(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
+			CreatePostFunc: func(post *models.Post, images []models.ImageUpload) (int64, error) {
 				return 0, nil // Should not be called if validation fails
 			},
 		}
@@ -330,7 +341,7 @@ func TestCreatePost(t *testing.T) {
 		}
 		req.Header.Set("Content-Type", w.FormDataContentType())
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -348,9 +359,9 @@ func TestCreatePost(t *testing.T) {
 	// Test case 3: No image provided
 	t.Run("No image provided", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreatePostFunc: func(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
-				if post.Content != "Test post without image" || imageData != nil || imageMimeType != "" {
-					t.Errorf("unexpected input to CreatePost: content=%s, imageData present=%t, mimeType=%s", post.Content, imageData != nil, imageMimeType)
+			CreatePostFunc: func(post *models.Post, images []models.ImageUpload) (int64, error) {
+				if post.Content != "Test post without image" || len(images) != 0 {
+					t.Errorf("unexpected input to CreatePost: content=%s, images=%d", post.Content, len(images))
 				}
 				return 1, nil
 			},
@@ -368,7 +379,7 @@ func TestCreatePost(t *testing.T) {
 		}
 		req.Header.Set("Content-Type", w.FormDataContentType())
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -382,7 +393,7 @@ func TestCreatePost(t *testing.T) {
 	// Test case 4: Unauthorized (missing userID in context)
 	t.Run("Unauthorized missing userID", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreatePostFunc: func(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
+			CreatePostFunc: func(post *models.Post, images []models.ImageUpload) (int64, error) {
 				return 0, nil // Should not be called
 			},
 		}
@@ -415,10 +426,13 @@ func TestGetPostByID(t *testing.T) {
 	// Test case 1: Successful retrieval
 	t.Run("Successful retrieval", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			GetPostByIDFunc: func(id int64) (*models.Post, error) {
+			GetPostByIDFunc: func(id, viewerID int64) (*models.Post, error) {
 				if id != 1 {
 					t.Errorf("unexpected post ID: got %v want %v", id, 1)
 				}
+				if viewerID != 1 {
+					t.Errorf("unexpected viewer ID: got %v want %v", viewerID, 1)
+				}
 				return &models.Post{ID: 1, Content: "Test Post"}, nil
 			},
 		}
@@ -429,6 +443,8 @@ func TestGetPostByID(t *testing.T) {
 			t.Fatal(err)
 		}
 		req.SetPathValue("postId", "1")
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
+		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
 		postHandler.GetPostByID(rr, req)
@@ -450,7 +466,7 @@ func TestGetPostByID(t *testing.T) {
 	// Test case 2: Post not found
 	t.Run("Post not found", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			GetPostByIDFunc: func(id int64) (*models.Post, error) {
+			GetPostByIDFunc: func(id, viewerID int64) (*models.Post, error) {
 				return nil, sql.ErrNoRows
 			},
 		}
@@ -461,6 +477,8 @@ func TestGetPostByID(t *testing.T) {
 			t.Fatal(err)
 		}
 		req.SetPathValue("postId", "2")
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
+		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
 		postHandler.GetPostByID(rr, req)
@@ -493,11 +511,11 @@ func TestGetPosts(t *testing.T) {
 	// Test case 1: Successful retrieval
 	t.Run("Successful retrieval", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			GetPostsFunc: func(userID int64) ([]*models.Post, error) {
+			GetFeedFunc: func(userID int64, before, after string, limit int) (*models.FeedPage, error) {
 				if userID != 1 {
 					t.Errorf("unexpected user ID: got %v want %v", userID, 1)
 				}
-				return []*models.Post{{ID: 1, Content: "Test Post"}}, nil
+				return &models.FeedPage{Posts: []*models.Post{{ID: 1, Content: "Test Post"}}}, nil
 			},
 		}
 		postHandler := handlers.NewPostHandler(mockPostService)
@@ -507,7 +525,7 @@ func TestGetPosts(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -517,11 +535,12 @@ func TestGetPosts(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		var posts []*models.Post
-		if err := json.NewDecoder(rr.Body).Decode(&posts); err != nil {
+		var page models.FeedPage
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
 			t.Fatal(err)
 		}
 
+		posts := page.Posts
 		if len(posts) != 1 || posts[0].ID != 1 || posts[0].Content != "Test Post" {
 			t.Errorf("handler returned unexpected body: got %v", rr.Body.String())
 		}
@@ -564,7 +583,7 @@ func TestGetCommentsByPostID(t *testing.T) {
 		}
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -607,7 +626,7 @@ func TestCreateComment(t *testing.T) {
 	// Test case 1: Successful comment creation
 	t.Run("Successful comment creation", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreateCommentFunc: func(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error) {
+			CreateCommentFunc: func(comment *models.Comment, images []models.ImageUpload) (int64, error) {
 				if comment.Content != "Test comment" || comment.PostID != 1 {
 					t.Errorf("unexpected input to CreateComment: content=%s, postID=%d", comment.Content, comment.PostID)
 				}
@@ -628,7 +647,7 @@ func TestCreateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -684,7 +703,7 @@ func TestCreateComment(t *testing.T) {
 	// Test case 4: Service layer error
 	t.Run("Service layer error", func(t *testing.T) {
 		mockPostService := &MockPostService{
-			CreateCommentFunc: func(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error) {
+			CreateCommentFunc: func(comment *models.Comment, images []models.ImageUpload) (int64, error) {
 				return 0, fmt.Errorf("service error")
 			},
 		}
@@ -702,7 +721,7 @@ func TestCreateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("postId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -717,7 +736,7 @@ func TestUpdateComment(t *testing.T) {
 	// Test case 1: Successful comment update
 	t.Run("Successful comment update", func(t *testing.T) {
 		mockPostService := &MockPostService{}
-		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 			if commentID != 1 || userID != 100 || content != "Updated comment content" {
 				t.Errorf("unexpected input to UpdateComment: commentID=%d, userID=%d, content=%s", commentID, userID, content)
 			}
@@ -737,7 +756,7 @@ func TestUpdateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -814,7 +833,7 @@ func TestUpdateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -832,7 +851,7 @@ func TestUpdateComment(t *testing.T) {
 	// Test case 5: Unauthorized (service returns unauthorized error)
 	t.Run("Unauthorized service error", func(t *testing.T) {
 		mockPostService := &MockPostService{}
-		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 			return nil, fmt.Errorf("unauthorized")
 		}
 		postHandler := handlers.NewPostHandler(mockPostService)
@@ -849,7 +868,7 @@ func TestUpdateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -867,7 +886,7 @@ func TestUpdateComment(t *testing.T) {
 	// Test case 6: Comment not found
 	t.Run("Comment not found", func(t *testing.T) {
 		mockPostService := &MockPostService{}
-		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 			return nil, fmt.Errorf("comment not found")
 		}
 		postHandler := handlers.NewPostHandler(mockPostService)
@@ -884,7 +903,7 @@ func TestUpdateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -898,7 +917,7 @@ func TestUpdateComment(t *testing.T) {
 	// Test case 7: Internal server error
 	t.Run("Internal server error", func(t *testing.T) {
 		mockPostService := &MockPostService{}
-		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+		mockPostService.UpdateCommentFunc = func(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 			return nil, fmt.Errorf("database error")
 		}
 		postHandler := handlers.NewPostHandler(mockPostService)
@@ -915,7 +934,7 @@ func TestUpdateComment(t *testing.T) {
 		req.Header.Set("Content-Type", w.FormDataContentType())
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -945,7 +964,7 @@ func TestDeleteComment(t *testing.T) {
 		}
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -1006,7 +1025,7 @@ func TestDeleteComment(t *testing.T) {
 		}
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -1035,7 +1054,7 @@ func TestDeleteComment(t *testing.T) {
 		}
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -1060,7 +1079,7 @@ func TestDeleteComment(t *testing.T) {
 		}
 		req.SetPathValue("commentId", "1")
 
-		ctx := context.WithValue(req.Context(), utils.User_id, int64(100))
+		ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 100})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
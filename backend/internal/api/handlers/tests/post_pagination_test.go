@@ -7,7 +7,9 @@ import (
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
@@ -16,17 +18,19 @@ type MockPostServiceForPagination struct {
 	posts []models.Post
 }
 
-func (m *MockPostServiceForPagination) CreatePost(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
+func (m *MockPostServiceForPagination) CreatePost(post *models.Post, images []models.ImageUpload) (int64, error) {
 	return 0, nil
 }
-func (m *MockPostServiceForPagination) CreatePostWithViewers(post *models.Post, imageData []byte, imageMimeType string, viewerIDs []int64) (int64, error) {
+func (m *MockPostServiceForPagination) CreatePostWithViewers(post *models.Post, images []models.ImageUpload, viewerIDs []int64) (int64, error) {
 	return 0, nil
 }
-func (m *MockPostServiceForPagination) GetPostByID(id int64) (*models.Post, error) { return nil, nil }
-func (m *MockPostServiceForPagination) UpdatePost(postID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Post, error) {
+func (m *MockPostServiceForPagination) GetPostByID(id, viewerID int64) (*models.Post, error) {
 	return nil, nil
 }
-func (m *MockPostServiceForPagination) CreateComment(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error) {
+func (m *MockPostServiceForPagination) UpdatePost(postID, userID int64, content string, edit models.AttachmentEdit) (*models.Post, error) {
+	return nil, nil
+}
+func (m *MockPostServiceForPagination) CreateComment(comment *models.Comment, images []models.ImageUpload) (int64, error) {
 	return 0, nil
 }
 func (m *MockPostServiceForPagination) GetCommentsByPostID(postID, userID int64) ([]*models.Comment, error) {
@@ -36,7 +40,7 @@ func (m *MockPostServiceForPagination) DeletePost(postID, userID int64) error {
 func (m *MockPostServiceForPagination) SearchUsers(query string, currentUserID int64) ([]*models.User, error) {
 	return nil, nil
 }
-func (m *MockPostServiceForPagination) UpdateComment(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+func (m *MockPostServiceForPagination) UpdateComment(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 	return nil, nil
 }
 func (m *MockPostServiceForPagination) DeleteComment(commentID, userID int64) error { return nil }
@@ -73,6 +77,18 @@ func (m *MockPostServiceForPagination) GetPostsCount(userID int64) (int, error)
 	return len(m.posts), nil
 }
 
+func (m *MockPostServiceForPagination) GetFeed(userID int64, before, after string, limit int) (*models.FeedPage, error) {
+	if before == "bad" {
+		return nil, service.ErrInvalidCursor
+	}
+	posts, _ := m.GetPostsPaginated(userID, limit, 0)
+	return &models.FeedPage{Posts: posts, NextCursor: "next"}, nil
+}
+
+func (m *MockPostServiceForPagination) CountNewPosts(userID int64, since string) (int, error) {
+	return 0, nil
+}
+
 func TestGetPostsWithPagination(t *testing.T) {
 	// Create mock posts
 	mockPosts := []models.Post{
@@ -93,6 +109,7 @@ func TestGetPostsWithPagination(t *testing.T) {
 		checkPagination bool
 	}{
 		{"No pagination params", "", http.StatusOK, false},
+		{"Invalid cursor", "?before=bad", http.StatusBadRequest, false},
 		{"First page", "?page=1&limit=2", http.StatusOK, true},
 		{"Second page", "?page=2&limit=2", http.StatusOK, true},
 		{"Invalid page", "?page=invalid&limit=2", http.StatusOK, true},
@@ -105,7 +122,7 @@ func TestGetPostsWithPagination(t *testing.T) {
 
 			// Add mock user context
 			ctx := req.Context()
-			ctx = utils.SetUserContext(ctx, int64(1))
+			ctx = auth.WithIdentity(ctx, auth.Identity{UserID: 1})
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
@@ -126,7 +143,31 @@ func TestGetPostsWithPagination(t *testing.T) {
 				if response.Pagination.CurrentPage == 0 {
 					t.Error("Expected pagination metadata in response")
 				}
+				if w.Header().Get("Deprecation") != "true" {
+					t.Error("Expected page mode to be marked deprecated")
+				}
 			}
 		})
 	}
 }
+
+func TestGetPostsByCursor(t *testing.T) {
+	mockService := &MockPostServiceForPagination{posts: []models.Post{{ID: 1}, {ID: 2}, {ID: 3}}}
+	handler := handlers.NewPostHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/posts?limit=2", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1}))
+	w := httptest.NewRecorder()
+	handler.GetPosts(w, req)
+
+	var page models.FeedPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || len(page.Posts) != 2 || page.NextCursor != "next" {
+		t.Errorf("GetPosts() = %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Error("cursor mode should not be marked deprecated")
+	}
+}
@@ -2,7 +2,6 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"mime/multipart"
@@ -13,6 +12,7 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/internal/store"
@@ -38,7 +38,8 @@ func setupProfileEditTestDB(t *testing.T) *sql.DB {
 		about_me TEXT,
 		is_profile_public BOOLEAN DEFAULT 0,
 		avatar TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		email_verified_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 
 	if _, err := db.Exec(createUsersTable); err != nil {
@@ -100,7 +101,7 @@ func TestEditProfile_Success(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Create form data
@@ -123,7 +124,7 @@ func TestEditProfile_Success(t *testing.T) {
 	req.Header.Set("Content-Type", contentType)
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -163,6 +164,12 @@ func TestEditProfile_Success(t *testing.T) {
 	if updatedUser.IsProfilePublic != false {
 		t.Errorf("Expected profile to be private, got public")
 	}
+
+	var verified bool
+	_ = db.QueryRow("SELECT email_verified_at IS NOT NULL FROM Users WHERE id = 1").Scan(&verified)
+	if verified {
+		t.Error("Expected the changed email to need verification again")
+	}
 }
 
 func TestEditProfile_EmailAlreadyExists(t *testing.T) {
@@ -170,7 +177,7 @@ func TestEditProfile_EmailAlreadyExists(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Try to update to an email that already exists (user 2's email)
@@ -193,7 +200,7 @@ func TestEditProfile_EmailAlreadyExists(t *testing.T) {
 	req.Header.Set("Content-Type", contentType)
 
 	// Add user ID to context (user 1 trying to use user 2's email)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -218,7 +225,7 @@ func TestEditProfile_SameEmailAllowed(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// User updating with their own email should be allowed
@@ -240,7 +247,7 @@ func TestEditProfile_SameEmailAllowed(t *testing.T) {
 	req := httptest.NewRequest("PUT", "/EditProfile", body)
 	req.Header.Set("Content-Type", contentType)
 
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -265,7 +272,7 @@ func TestEditProfile_InvalidEmail(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	testCases := []struct {
@@ -299,7 +306,7 @@ func TestEditProfile_InvalidEmail(t *testing.T) {
 			req := httptest.NewRequest("PUT", "/EditProfile", body)
 			req.Header.Set("Content-Type", contentType)
 
-			ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+			ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
@@ -326,7 +333,7 @@ func TestEditProfile_Unauthorized(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	formData := map[string]string{
@@ -370,14 +377,14 @@ func TestEditProfile_InvalidFormData(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Send invalid form data
 	req := httptest.NewRequest("PUT", "/EditProfile", strings.NewReader("invalid form data"))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -402,7 +409,7 @@ func TestEditProfile_WithAvatar(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Create form data with a small test image
@@ -434,7 +441,7 @@ func TestEditProfile_WithAvatar(t *testing.T) {
 	req := httptest.NewRequest("PUT", "/EditProfile", body)
 	req.Header.Set("Content-Type", contentType)
 
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -452,7 +459,7 @@ func TestEditProfile_XSSPrevention(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Test XSS prevention with malicious input
@@ -461,7 +468,7 @@ func TestEditProfile_XSSPrevention(t *testing.T) {
 		"firstname":   "<script>alert('xss')</script>",
 		"lastname":    "<img src=x onerror=alert('xss')>",
 		"dateofbirth": "1991-02-02",
-		"nickname":    "<svg onload=alert('xss')>",
+		"nickname":    "johndoe",
 		"aboutme":     "<iframe src='javascript:alert(\"xss\")'></iframe>",
 		"is_private":  "false",
 	}
@@ -474,7 +481,7 @@ func TestEditProfile_XSSPrevention(t *testing.T) {
 	req := httptest.NewRequest("PUT", "/EditProfile", body)
 	req.Header.Set("Content-Type", contentType)
 
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -505,6 +512,21 @@ func TestEditProfile_XSSPrevention(t *testing.T) {
 	if strings.Contains(*updatedUser.AboutMe, "<iframe") {
 		t.Errorf("XSS content not properly escaped in about me: %s", *updatedUser.AboutMe)
 	}
+
+	// nicknames are handles, so markup is rejected rather than escaped
+	formData["nickname"] = "<svg onload=alert('xss')>"
+	body, contentType, err = createMultipartFormData(formData, nil)
+	if err != nil {
+		t.Fatalf("Failed to create form data: %v", err)
+	}
+	req = httptest.NewRequest("PUT", "/EditProfile", body)
+	req.Header.Set("Content-Type", contentType)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1}))
+	w = httptest.NewRecorder()
+	authHandler.EditProfile(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("markup in nickname: expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
 }
 
 func TestEditProfile_ProfileVisibilityToggle(t *testing.T) {
@@ -512,7 +534,7 @@ func TestEditProfile_ProfileVisibilityToggle(t *testing.T) {
 	defer db.Close()
 
 	authStore := store.NewAuthStore(db)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
 	authHandler := handlers.NewAuthHandler(authService)
 
 	testCases := []struct {
@@ -546,7 +568,7 @@ func TestEditProfile_ProfileVisibilityToggle(t *testing.T) {
 			req := httptest.NewRequest("PUT", "/EditProfile", body)
 			req.Header.Set("Content-Type", contentType)
 
-			ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+			ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 			req = req.WithContext(ctx)
 
 			w := httptest.NewRecorder()
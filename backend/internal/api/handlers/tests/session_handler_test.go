@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+func setupSessionHandlerTest(t *testing.T) (*handlers.SessionHandler, service.SessionService, *sql.DB) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE Sessions (
+			id TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME,
+			last_seen_at DATETIME,
+			user_agent TEXT,
+			ip_address TEXT
+		)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := service.NewSessionService(store.NewSessionStore(db), service.DefaultSessionConfig(), nil)
+	return handlers.NewSessionHandler(sessions), sessions, db
+}
+
+func sessionRequest(method, target string, session *models.Session) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: session.UserID, SessionID: session.ID}))
+}
+
+func TestListSessions(t *testing.T) {
+	h, sessions, _ := setupSessionHandlerTest(t)
+	laptop, _ := sessions.CreateSession(1, models.SessionClient{UserAgent: "Firefox", IPAddress: "10.0.0.1"})
+	_, _ = sessions.CreateSession(1, models.SessionClient{UserAgent: "Safari", IPAddress: "10.0.0.2"})
+	_, _ = sessions.CreateSession(2, models.SessionClient{UserAgent: "Chrome"})
+
+	rr := httptest.NewRecorder()
+	h.ListSessions(rr, sessionRequest("GET", "/sessions", laptop))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var listed []map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("expected the caller's 2 sessions, got %d", len(listed))
+	}
+	for _, s := range listed {
+		if s["id"] == laptop.ID {
+			t.Error("the session secret must not be exposed")
+		}
+		if current := s["user_agent"] == "Firefox"; s["current"] != current {
+			t.Errorf("session %v: current = %v, want %v", s["user_agent"], s["current"], current)
+		}
+		if s["ip_address"] == "" || s["last_seen_at"] == nil || s["created_at"] == nil {
+			t.Errorf("missing session details: %v", s)
+		}
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	h, sessions, _ := setupSessionHandlerTest(t)
+	laptop, _ := sessions.CreateSession(1, models.SessionClient{})
+	phone, _ := sessions.CreateSession(1, models.SessionClient{})
+	stranger, _ := sessions.CreateSession(2, models.SessionClient{})
+
+	tests := []struct {
+		name        string
+		target      *models.Session
+		wantStatus  int
+		wantCleared bool
+	}{
+		{"another user's session", stranger, http.StatusNotFound, false},
+		{"another device", phone, http.StatusOK, false},
+		{"already revoked", phone, http.StatusNotFound, false},
+		{"current session", laptop, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sessionRequest("DELETE", "/sessions/"+tt.target.PublicID, laptop)
+			req.SetPathValue("id", tt.target.PublicID)
+			rr := httptest.NewRecorder()
+			h.RevokeSession(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+			cleared := false
+			for _, c := range rr.Result().Cookies() {
+				if c.Name == "session_id" && c.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			if cleared != tt.wantCleared {
+				t.Errorf("session cookie cleared = %v, want %v", cleared, tt.wantCleared)
+			}
+		})
+	}
+
+	if _, _, err := sessions.ValidateSession(stranger.ID); err != nil {
+		t.Errorf("another user's session must survive: %v", err)
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	h, sessions, _ := setupSessionHandlerTest(t)
+	laptop, _ := sessions.CreateSession(1, models.SessionClient{})
+	phone, _ := sessions.CreateSession(1, models.SessionClient{})
+	stranger, _ := sessions.CreateSession(2, models.SessionClient{})
+
+	rr := httptest.NewRecorder()
+	h.RevokeOtherSessions(rr, sessionRequest("DELETE", "/sessions", laptop))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("without others=true: expected 400, got %d", rr.Code)
+	}
+
+	// a token caller has no session of its own, which would otherwise mean revoking every one
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/sessions?others=true", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1, TokenID: 1, Scope: auth.ScopeWrite}))
+	h.RevokeOtherSessions(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("with an access token: expected 403, got %d", rr.Code)
+	}
+	if _, _, err := sessions.ValidateSession(phone.ID); err != nil {
+		t.Errorf("an access token must not sign out the user's sessions: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	h.RevokeOtherSessions(rr, sessionRequest("DELETE", "/sessions?others=true", laptop))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	if _, _, err := sessions.ValidateSession(phone.ID); err == nil {
+		t.Error("other device should be signed out")
+	}
+	for _, s := range []*models.Session{laptop, stranger} {
+		if _, _, err := sessions.ValidateSession(s.ID); err != nil {
+			t.Errorf("session of user %d should survive: %v", s.UserID, err)
+		}
+	}
+}
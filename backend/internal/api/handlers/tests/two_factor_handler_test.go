@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/totp"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+func TestTwoFactorLoginFlow(t *testing.T) {
+	db := setupEdgeCaseTestDB(t)
+	defer db.Close()
+	createEdgeCaseTestUser(t, db)
+	if _, err := db.Exec(`
+		ALTER TABLE Users ADD COLUMN totp_secret TEXT;
+		ALTER TABLE Users ADD COLUMN totp_enabled_at DATETIME;
+		ALTER TABLE Users ADD COLUMN totp_last_step INTEGER;
+		CREATE TABLE Recovery_Codes (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, code_hash TEXT NOT NULL, used_at DATETIME);
+		CREATE TABLE Two_Factor_Challenges (token_hash TEXT PRIMARY KEY, user_id INTEGER NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, expires_at DATETIME NOT NULL);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	authStore := store.NewAuthStore(db)
+	box, _ := utils.NewSecretBox(make([]byte, 32))
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
+	twoFactorService := service.NewTwoFactorService(store.NewTwoFactorStore(db), authStore, authService.Passwords, box, service.TwoFactorConfigFromEnv())
+	authService.TwoFactor = twoFactorService
+	authHandler := handlers.NewAuthHandler(authService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	asUser := func(req *http.Request) *http.Request {
+		return req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1}))
+	}
+
+	// Enroll and confirm with a code from the "authenticator app".
+	rr := httptest.NewRecorder()
+	twoFactorHandler.Enroll(rr, asUser(httptest.NewRequest("POST", "/2fa/enroll", nil)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var enrollment models.TwoFactorEnrollment
+	json.NewDecoder(rr.Body).Decode(&enrollment)
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/") {
+		t.Errorf("unexpected provisioning URI %q", enrollment.ProvisioningURI)
+	}
+
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	rr = httptest.NewRecorder()
+	twoFactorHandler.Confirm(rr, asUser(httptest.NewRequest("POST", "/2fa/confirm", strings.NewReader(`{"code":"`+code+`"}`))))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// The password step now returns a pending token and no session cookie.
+	rr = httptest.NewRecorder()
+	authHandler.Login(rr, httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@example.com","password":"TestPassword123!"}`)))
+	var challenge struct {
+		Code  string `json:"code"`
+		Token string `json:"two_factor_token"`
+	}
+	json.NewDecoder(rr.Body).Decode(&challenge)
+	if rr.Code != http.StatusOK || challenge.Code != "two_factor_required" || challenge.Token == "" {
+		t.Fatalf("login: got %d %+v", rr.Code, challenge)
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Fatal("no cookies should be set before the second factor")
+	}
+
+	secondStep := func(code string) *httptest.ResponseRecorder {
+		body := `{"two_factor_token":"` + challenge.Token + `","code":"` + code + `"}`
+		rr := httptest.NewRecorder()
+		authHandler.LoginTwoFactor(rr, httptest.NewRequest("POST", "/login/2fa", strings.NewReader(body)))
+		return rr
+	}
+
+	if rr := secondStep("0000-0000"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong code: expected 401, got %d", rr.Code)
+	}
+	rr = secondStep(enrollment.RecoveryCodes[0])
+	if rr.Code != http.StatusOK {
+		t.Fatalf("recovery code: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var sessionCookie *http.Cookie
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "session_id" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil || sessionCookie.Value == "" {
+		t.Fatal("expected a session cookie after the second factor")
+	}
+
+	var resp utils.Response
+	if rr := secondStep(enrollment.RecoveryCodes[1]); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("reused pending token: expected 401, got %d", rr.Code)
+	} else if json.NewDecoder(rr.Body).Decode(&resp); resp.Code != "two_factor_expired" {
+		t.Errorf("expected two_factor_expired, got %q", resp.Code)
+	}
+}
+
+func TestTwoFactorLoginIsThrottled(t *testing.T) {
+	db := setupEdgeCaseTestDB(t)
+	defer db.Close()
+	createEdgeCaseTestUser(t, db)
+	if _, err := db.Exec(`
+		ALTER TABLE Users ADD COLUMN totp_secret TEXT;
+		ALTER TABLE Users ADD COLUMN totp_enabled_at DATETIME;
+		ALTER TABLE Users ADD COLUMN totp_last_step INTEGER;
+		CREATE TABLE Recovery_Codes (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, code_hash TEXT NOT NULL, used_at DATETIME);
+		CREATE TABLE Two_Factor_Challenges (token_hash TEXT PRIMARY KEY, user_id INTEGER NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, expires_at DATETIME NOT NULL);
+		CREATE TABLE Login_Failures (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, user_id INTEGER, ip_address TEXT NOT NULL, user_agent TEXT, reason TEXT NOT NULL, created_at DATETIME NOT NULL);
+		CREATE TABLE Login_Throttles (throttle_key TEXT PRIMARY KEY, failures INTEGER NOT NULL DEFAULT 0, last_failure_at DATETIME NOT NULL, locked_until DATETIME);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	authStore := store.NewAuthStore(db)
+	box, _ := utils.NewSecretBox(make([]byte, 32))
+	twoFactorService := service.NewTwoFactorService(store.NewTwoFactorStore(db), authStore, utils.NewPasswordManager(utils.PasswordConfig{}), box, service.TwoFactorConfigFromEnv())
+	enrollment, err := twoFactorService.Enroll(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	if err := twoFactorService.Confirm(1, code); err != nil {
+		t.Fatal(err)
+	}
+
+	config := service.DefaultLoginThrottleConfig()
+	config.Account = service.ThrottleLimit{FreeAttempts: 10, LockoutAfter: 3}
+	authService := service.NewAuthService(authStore, newTestSessionService(db))
+	authService.TwoFactor = twoFactorService
+	authService.Throttle = service.NewLoginThrottleService(store.NewLoginAttemptStore(db), config)
+	authHandler := handlers.NewAuthHandler(authService)
+
+	login := func() string {
+		rr := httptest.NewRecorder()
+		authHandler.Login(rr, httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@example.com","password":"TestPassword123!"}`)))
+		var challenge struct {
+			Token string `json:"two_factor_token"`
+		}
+		json.NewDecoder(rr.Body).Decode(&challenge)
+		if rr.Code != http.StatusOK || challenge.Token == "" {
+			t.Fatalf("login: got %d %s", rr.Code, rr.Body.String())
+		}
+		return challenge.Token
+	}
+	secondStep := func(token, code string) *httptest.ResponseRecorder {
+		body := `{"two_factor_token":"` + token + `","code":"` + code + `"}`
+		rr := httptest.NewRecorder()
+		authHandler.LoginTwoFactor(rr, httptest.NewRequest("POST", "/login/2fa", strings.NewReader(body)))
+		return rr
+	}
+
+	// a right password does not forget wrong codes, and a new challenge brings no new guesses
+	token := login()
+	secondStep(token, "0000-0000")
+	secondStep(token, "0000-0001")
+	token = login()
+	if rr := secondStep(token, "0000-0002"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("third wrong code: expected 401, got %d", rr.Code)
+	}
+
+	var resp utils.Response
+	rr := secondStep(token, enrollment.RecoveryCodes[0])
+	if json.NewDecoder(rr.Body).Decode(&resp); rr.Code != http.StatusTooManyRequests || resp.Code != "too_many_attempts" || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("a right code while locked out: got %d %+v", rr.Code, resp)
+	}
+
+	var audited int
+	db.QueryRow("SELECT COUNT(*) FROM Login_Failures WHERE user_id = 1").Scan(&audited)
+	if audited != 3 {
+		t.Errorf("audit log has %d failures for the user, want 3", audited)
+	}
+}
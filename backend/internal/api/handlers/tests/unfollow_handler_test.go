@@ -2,7 +2,6 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
@@ -57,7 +57,7 @@ func TestUnfollow_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add user ID to context (simulating authenticated user)
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -92,7 +92,7 @@ func TestUnfollow_FollowRelationshipNotFound(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -122,7 +122,7 @@ func TestUnfollow_InvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add user ID to context
-	ctx := context.WithValue(req.Context(), utils.User_id, int64(1))
+	ctx := auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -13,6 +13,9 @@ import (
 	"github.com/gorilla/websocket"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
+	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/internal/store"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
 )
 
@@ -35,11 +38,16 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB, *ws.Manager) {
 			date_of_birth DATE,
 			about_me TEXT,
 			is_profile_public INTEGER DEFAULT 1,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			email_verified_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		);
 		CREATE TABLE sessions (
 			id TEXT PRIMARY KEY,
 			user_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME,
+			user_agent TEXT,
+			ip_address TEXT,
 			expires_at DATETIME,
 			FOREIGN KEY (user_id) REFERENCES users(id)
 		);
@@ -81,6 +89,22 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB, *ws.Manager) {
 			FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
 			FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE
 		);
+		CREATE TABLE Blocks (
+			blocker_id INTEGER NOT NULL,
+			blocked_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (blocker_id, blocked_id)
+		);
+		CREATE TABLE Access_Tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			scope TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME,
+			last_used_at DATETIME
+		);
 	`)
 	if err != nil {
 		t.Fatalf("Failed to create test tables: %v", err)
@@ -95,7 +119,8 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB, *ws.Manager) {
 
 		INSERT INTO sessions (id, user_id, expires_at) VALUES
 		('test-session-1', 1, datetime('now', '+1 day')),
-		('test-session-2', 2, datetime('now', '+1 day'));
+		('test-session-2', 2, datetime('now', '+1 day')),
+		('test-session-1b', 1, datetime('now', '+1 day'));
 
 		INSERT INTO Groups (id, title, description, creator_id) VALUES (1, 'Test Group', 'A test group', 1);
 
@@ -109,7 +134,7 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB, *ws.Manager) {
 		t.Fatalf("Failed to insert test data: %v", err)
 	}
 
-	permissionChecker := ws.NewDBPermissionChecker(db)
+	permissionChecker := ws.NewDBPermissionChecker(db, service.NewBlockService(store.NewBlockStore(db)), service.NewMuteService(store.NewMuteStore(db)))
 	// Create WebSocket manager
 	manager := ws.NewManager(
 		ws.NewDBSessionResolver(db),
@@ -120,26 +145,21 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB, *ws.Manager) {
 
 	// Create chat handler
 	notifier := ws.NewDBNotificationSender(manager)
-	chatHandler := ws.NewChatHandler(db, ws.NewDBSessionResolver(db), ws.NewDBMessagePersister(db), notifier, manager, permissionChecker)
+	chatHandler := ws.NewChatHandler(db, ws.NewDBMessagePersister(db), notifier, manager, permissionChecker)
 
-	// Create test server with routes
+	// Create test server with routes, all behind the auth middleware like the real router
 	mux := http.NewServeMux()
+	sessions := service.NewSessionService(store.NewSessionStore(db), service.DefaultSessionConfig(), manager)
+	authMW := middleware.AuthMiddleware(sessions, service.NewAccessTokenService(store.NewAccessTokenStore(db), manager), plainUsers{})
 
 	// WebSocket endpoint
-	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		// Add test session cookie for authentication
-		if r.Header.Get("Cookie") == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-		handlers.NewWebSocketHandler(manager).HandleConnection(w, r)
-	})
+	mux.Handle("/ws", authMW(http.HandlerFunc(handlers.NewWebSocketHandler(manager, nil).HandleConnection)))
 
 	// HTTP API endpoints
-	mux.HandleFunc("/api/messages/private", chatHandler.GetPrivateMessages)
-	mux.HandleFunc("/api/messages/group", chatHandler.GetGroupMessages)
-	mux.HandleFunc("/api/groups/invite", chatHandler.SendGroupInvite)
-	mux.HandleFunc("/api/notifications", chatHandler.GetNotifications)
+	mux.Handle("/api/messages/private", authMW(http.HandlerFunc(chatHandler.GetPrivateMessages)))
+	mux.Handle("/api/messages/group", authMW(http.HandlerFunc(chatHandler.GetGroupMessages)))
+	mux.Handle("/api/groups/invite", authMW(http.HandlerFunc(chatHandler.SendGroupInvite)))
+	mux.Handle("/api/notifications", authMW(http.HandlerFunc(chatHandler.GetNotifications)))
 
 	server := httptest.NewServer(mux)
 	return server, db, manager
@@ -354,3 +374,250 @@ func TestMessagePersistence(t *testing.T) {
 
 	t.Log("Message persistence test passed!")
 }
+
+func TestBroadcastSkipsBlockedUsers(t *testing.T) {
+	server, db, _ := setupTestServer(t)
+	defer server.Close()
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO Blocks (blocker_id, blocked_id) VALUES (2, 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	dial := func(session string) *websocket.Conn {
+		headers := http.Header{}
+		headers.Set("Cookie", "session_id="+session)
+		conn, _, err := dialer.Dial(wsURL, headers)
+		if err != nil {
+			t.Fatalf("Failed to connect with %s: %v", session, err)
+		}
+		return conn
+	}
+	conn1 := dial("test-session-1")
+	defer conn1.Close()
+	conn2 := dial("test-session-2")
+	defer conn2.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	if err := conn1.WriteJSON(ws.Message{Type: "broadcast", Content: "Hello everyone!"}); err != nil {
+		t.Fatalf("Failed to send broadcast message: %v", err)
+	}
+
+	// received reports whether conn gets the broadcast before the deadline,
+	// skipping presence notifications
+	received := func(conn *websocket.Conn) bool {
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		for {
+			var msg ws.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return false
+			}
+			if msg.Type == "broadcast" {
+				return true
+			}
+		}
+	}
+	if !received(conn1) {
+		t.Error("the sender should get their own broadcast")
+	}
+	if received(conn2) {
+		t.Error("a user who blocked the sender got their broadcast")
+	}
+}
+
+func TestWebSocketClosedWhenSessionEnds(t *testing.T) {
+	server, db, manager := setupTestServer(t)
+	defer server.Close()
+	defer db.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+
+	headers := http.Header{}
+	headers.Set("Cookie", "session_id=test-session-1")
+	conn, _, err := dialer.Dial(wsURL, headers)
+	if err != nil {
+		t.Fatalf("Failed to connect with valid session: %v", err)
+	}
+	defer conn.Close()
+
+	// Wait for the connection to be registered
+	time.Sleep(100 * time.Millisecond)
+
+	// Expire the session and let the pruner find it
+	if _, err := db.Exec(`UPDATE sessions SET expires_at = datetime('now', '-1 minute') WHERE id = 'test-session-1'`); err != nil {
+		t.Fatal(err)
+	}
+	sessions := service.NewSessionService(store.NewSessionStore(db), service.DefaultSessionConfig(), manager)
+	if n, err := sessions.PruneExpiredSessions(); err != nil || n != 1 {
+		t.Fatalf("PruneExpiredSessions() = %d, %v; want 1, nil", n, err)
+	}
+
+	// Skip anything queued before the close frame
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("expected a policy-violation close, got %v", err)
+			}
+			break
+		}
+	}
+
+	// The session can no longer open a new connection
+	if _, _, err := dialer.Dial(wsURL, headers); err == nil {
+		t.Error("expected a pruned session to be rejected")
+	}
+}
+
+func TestRevokedSessionDropsReplacedWebSocket(t *testing.T) {
+	server, db, manager := setupTestServer(t)
+	defer server.Close()
+	defer db.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+
+	dial := func(session string) *websocket.Conn {
+		headers := http.Header{}
+		headers.Set("Cookie", "session_id="+session)
+		conn, _, err := dialer.Dial(wsURL, headers)
+		if err != nil {
+			t.Fatalf("Failed to connect with %s: %v", session, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		return conn
+	}
+
+	// The same user logs in on two devices; the second connection replaces the first as "online"
+	laptop := dial("test-session-1")
+	defer laptop.Close()
+	phone := dial("test-session-1b")
+	defer phone.Close()
+
+	sessions := service.NewSessionService(store.NewSessionStore(db), service.DefaultSessionConfig(), manager)
+	if err := sessions.RevokeSession("test-session-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = laptop.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := laptop.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("expected the revoked session's connection to be closed, got %v", err)
+			}
+			break
+		}
+	}
+
+	if !manager.IsOnline(1) {
+		t.Error("the user's other session should stay connected")
+	}
+}
+
+func TestUnverifiedUserCannotSendMessages(t *testing.T) {
+	server, db, _ := setupTestServer(t)
+	defer server.Close()
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE users SET email_verified_at = NULL WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	headers := http.Header{}
+	headers.Set("Cookie", "session_id=test-session-1")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, headers)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := conn.WriteJSON(ws.Message{Type: "private", To: 2, Content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg ws.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("expected an error message, got %v", err)
+		}
+		if msg.Type != "error" {
+			continue
+		}
+		if msg.Code != "email_unverified" {
+			t.Errorf("expected code email_unverified, got %q", msg.Code)
+		}
+		break
+	}
+
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM Messages WHERE sender_id = 1").Scan(&count)
+	if count != 0 {
+		t.Errorf("unverified user's message should not be saved, got %d", count)
+	}
+}
+
+func TestReadOnlyTokenWebSocket(t *testing.T) {
+	server, db, manager := setupTestServer(t)
+	defer server.Close()
+	defer db.Close()
+
+	tokens := service.NewAccessTokenService(store.NewAccessTokenStore(db), manager)
+	token, accessToken, err := tokens.CreateToken(1, "bot", "read", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer "+token)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, headers)
+	if err != nil {
+		t.Fatalf("Failed to connect with a bearer token: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := conn.WriteJSON(ws.Message{Type: "private", To: 2, Content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg ws.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("expected an error message, got %v", err)
+		}
+		if msg.Type != "error" {
+			continue
+		}
+		if msg.Code != "insufficient_scope" {
+			t.Errorf("expected code insufficient_scope, got %q", msg.Code)
+		}
+		break
+	}
+
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM Messages WHERE sender_id = 1").Scan(&count)
+	if count != 0 {
+		t.Errorf("read-only token's message should not be saved, got %d", count)
+	}
+
+	// Revoking the token ends the connection.
+	if err := tokens.RevokeToken(1, accessToken.ID); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("expected the revoked token's connection to be closed, got %v", err)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// TwoFactorHandler lets users turn TOTP two-factor authentication on and off.
+type TwoFactorHandler struct {
+	TwoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(tfs service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{TwoFactorService: tfs}
+}
+
+// Enroll handles POST /2fa/enroll. The secret and recovery codes are only shown in this response.
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	enrollment, err := h.TwoFactorService.Enroll(userID)
+	if errors.Is(err, service.ErrTwoFactorAlreadyEnabled) {
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: "Two-factor authentication is already enabled"})
+		return
+	}
+	if errors.Is(err, service.ErrTwoFactorUnavailable) {
+		utils.RespondJSON(w, http.StatusServiceUnavailable, utils.Response{Message: err.Error(), Code: "two_factor_unavailable"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error enrolling two-factor:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to start two-factor enrollment"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, enrollment)
+}
+
+// Confirm handles POST /2fa/confirm with a code from the newly enrolled app.
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Code is required"})
+		return
+	}
+
+	err := h.TwoFactorService.Confirm(userID, req.Code)
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Invalid code", Code: "invalid_two_factor_code"})
+		return
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled), errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: err.Error()})
+		return
+	case errors.Is(err, service.ErrTwoFactorUnavailable):
+		utils.RespondJSON(w, http.StatusServiceUnavailable, utils.Response{Message: err.Error(), Code: "two_factor_unavailable"})
+		return
+	case err != nil:
+		fmt.Println("error confirming two-factor:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to enable two-factor authentication"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Two-factor authentication enabled"})
+}
+
+// Disable handles POST /2fa/disable, which needs the password and a code or recovery code.
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "User not found in context"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" || req.Code == "" {
+		utils.RespondJSON(w, http.StatusBadRequest, utils.Response{Message: "Password and code are required"})
+		return
+	}
+
+	err := h.TwoFactorService.Disable(userID, req.Password, req.Code)
+	switch {
+	case errors.Is(err, service.ErrIncorrectPassword):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Password is incorrect", Code: "incorrect_password"})
+		return
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{Message: "Invalid code", Code: "invalid_two_factor_code"})
+		return
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		utils.RespondJSON(w, http.StatusConflict, utils.Response{Message: "Two-factor authentication is not enabled"})
+		return
+	case errors.Is(err, service.ErrTwoFactorUnavailable):
+		utils.RespondJSON(w, http.StatusServiceUnavailable, utils.Response{Message: err.Error(), Code: "two_factor_unavailable"})
+		return
+	case err != nil:
+		fmt.Println("error disabling two-factor:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to disable two-factor authentication"})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, utils.Response{Message: "Two-factor authentication disabled"})
+}
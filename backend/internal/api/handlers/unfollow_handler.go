@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
@@ -24,7 +25,7 @@ func (unf *UnfollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
 	status := http.StatusOK
 
 	// Get current user id from session
-	followerID, ok := r.Context().Value(utils.User_id).(int64)
+	followerID, ok := auth.UserID(r.Context())
 	if !ok {
 		serverResponse.Message = "User not found in context"
 		utils.RespondJSON(w, http.StatusUnauthorized, serverResponse)
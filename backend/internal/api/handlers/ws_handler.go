@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/websocket"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
 )
 
@@ -12,11 +13,11 @@ type WebSocketHandler struct {
 	Upgrader websocket.Upgrader
 }
 
-func NewWebSocketHandler(m *ws.Manager) *WebSocketHandler {
+// NewWebSocketHandler upgrades connections for m. checkOrigin decides which
+// browser origins may connect; nil allows same-origin pages only.
+func NewWebSocketHandler(m *ws.Manager, checkOrigin func(r *http.Request) bool) *WebSocketHandler {
 	var upgrader = websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		CheckOrigin: checkOrigin,
 	}
 	return &WebSocketHandler{
 		Manager:  m,
@@ -38,8 +39,13 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 	}
 
 	client := ws.NewClient(userID, nickname, avatar, conn)
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		client.SessionID = identity.SessionID
+		client.TokenID = identity.TokenID
+		client.ReadOnly = !identity.CanWrite()
+	}
 	h.Manager.Register(client)
-	defer h.Manager.Unregister(userID)
+	defer h.Manager.UnregisterClient(client)
 	defer conn.Close()
 
 	go h.Manager.WritePump(client)
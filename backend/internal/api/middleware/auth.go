@@ -1,34 +1,121 @@
 package middleware
 
 import (
-	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
-// AuthMiddleware retrieves the user ID from the session cookie and adds it to the request context.
-func AuthMiddleware(db *sql.DB) func(http.Handler) http.Handler {
+// AuthMiddleware resolves the caller and stores their auth.Identity in the request context.
+//
+// Requests with an "Authorization: Bearer" header are authenticated with a personal
+// access token; tokens with the read scope may only make safe (GET, HEAD, OPTIONS)
+// requests. tokens may be nil, in which case bearer credentials are rejected.
+//
+// Other requests use the session cookie. Expired sessions are rejected; when activity
+// renews a session the cookie is re-issued with the new expiry.
+//
+// The caller's site roles are loaded from roles on every request, so a change of
+// role applies at once.
+func AuthMiddleware(sessions service.SessionService, tokens service.AccessTokenService, roles service.AdminService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("session_id")
-			fmt.Println("AuthMiddleware: Cookie retrieved:", cookie)
+			if bearer, ok := bearerToken(r); ok {
+				serveWithToken(w, r, next, tokens, roles, bearer)
+				return
+			}
+
+			cookie, err := r.Cookie(auth.SessionCookieName)
 			if err != nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Authentication required"})
 				return
 			}
 
-			userID, err := store.GetUserIDFromSession(cookie.Value, db)
+			session, renewed, err := sessions.ValidateSession(cookie.Value)
 			if err != nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				if errors.Is(err, service.ErrSessionExpired) || errors.Is(err, service.ErrSessionNotFound) {
+					auth.ClearSessionCookie(w)
+				}
+				utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Invalid or expired session"})
 				return
 			}
+			if renewed {
+				auth.SetSessionCookie(w, session.ID, session.ExpiresAt)
+			}
+
+			serveWithIdentity(w, r, next, roles, auth.Identity{
+				UserID:    session.UserID,
+				SessionID: session.ID,
+			})
+		})
+	}
+}
+
+// serveWithIdentity fills in the caller's site roles and passes the request on.
+func serveWithIdentity(w http.ResponseWriter, r *http.Request, next http.Handler, roles service.AdminService, identity auth.Identity) {
+	role, err := roles.GetRole(identity.UserID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		utils.RespondJSON(w, http.StatusUnauthorized, utils.Response{Message: "Invalid or expired session"})
+		return
+	}
+	if err != nil {
+		fmt.Println("error loading site role:", err)
+		utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to check role"})
+		return
+	}
+	identity.Roles = auth.RolesFor(role)
+	next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+}
+
+// bearerToken returns the credential of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func serveWithToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokens service.AccessTokenService, roles service.AdminService, bearer string) {
+	if tokens == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-			ctx := context.WithValue(r.Context(), utils.User_id, userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+	token, err := tokens.ValidateToken(bearer)
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidAccessToken) {
+			fmt.Println("error validating access token:", err)
+			utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to check access token"})
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identity := auth.Identity{
+		UserID:  token.UserID,
+		TokenID: token.ID,
+		Scope:   token.Scope,
+	}
+	if !identity.CanWrite() && !isSafeMethod(r.Method) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="write"`)
+		utils.RespondJSON(w, http.StatusForbidden, utils.Response{
+			Message: "This access token is read-only",
+			Code:    "insufficient_scope",
 		})
+		return
 	}
+	serveWithIdentity(w, r, next, roles, identity)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
 }
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AllowedOrigins are the browser origins trusted to call the API with the
+// user's cookies. CORSMiddleware, CSRFMiddleware and the websocket upgrade
+// share one list.
+type AllowedOrigins []string
+
+// AllowedOriginsFromEnv reads ALLOWED_ORIGINS, a comma separated list such as
+// "https://social.example,http://localhost:3000". The default allows the
+// local frontend.
+func AllowedOriginsFromEnv() AllowedOrigins {
+	value := os.Getenv("ALLOWED_ORIGINS")
+	if value == "" {
+		return AllowedOrigins{"http://localhost:3000"}
+	}
+	var origins AllowedOrigins
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	return origins
+}
+
+// Allows reports whether origin is in the list.
+func (o AllowedOrigins) Allows(origin string) bool {
+	for _, allowed := range o {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin accepts requests from an allowed origin or from the API's own
+// origin. Requests without an Origin header do not come from a browser page
+// and are accepted too. It fits websocket.Upgrader.CheckOrigin.
+func (o AllowedOrigins) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || o.Allows(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// InsufficientRoleCode is returned to clients whose site role is too low for a route.
+const InsufficientRoleCode = "insufficient_role"
+
+// RequireRole rejects callers without the given site role (or a higher one)
+// with 403 and InsufficientRoleCode. It must run after AuthMiddleware, which
+// loads the caller's roles into the identity.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := auth.FromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !identity.HasRole(role) {
+				utils.RespondJSON(w, http.StatusForbidden, utils.Response{
+					Message: "You do not have permission to do this",
+					Code:    InsufficientRoleCode,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/service"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// EmailUnverifiedCode is returned to clients blocked until they verify their email.
+const EmailUnverifiedCode = "email_unverified"
+
+// RequireVerifiedEmail rejects callers whose email is not verified with 403
+// and EmailUnverifiedCode. It must run after AuthMiddleware.
+func RequireVerifiedEmail(verification service.EmailVerificationService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := auth.UserID(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			verified, err := verification.IsEmailVerified(userID)
+			if err != nil {
+				fmt.Println("error checking email verification:", err)
+				utils.RespondJSON(w, http.StatusInternalServerError, utils.Response{Message: "Failed to check email verification"})
+				return
+			}
+			if !verified {
+				utils.RespondJSON(w, http.StatusForbidden, utils.Response{
+					Message: "Verify your email address to continue",
+					Code:    EmailUnverifiedCode,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/pkg/oidc/oidctest"
+)
+
+// TestOIDCLogin drives the whole browser flow against an in-process issuer:
+// our login endpoint, the issuer's authorization endpoint, then our callback.
+func TestOIDCLogin(t *testing.T) {
+	issuer := oidctest.NewIssuer("social-network", "client-secret")
+	defer issuer.Close()
+
+	var router http.Handler
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	defer app.Close()
+
+	t.Setenv("OIDC_PROVIDERS", "test")
+	t.Setenv("OIDC_TEST_ISSUER", issuer.Issuer())
+	t.Setenv("OIDC_TEST_CLIENT_ID", "social-network")
+	t.Setenv("OIDC_TEST_CLIENT_SECRET", "client-secret")
+	t.Setenv("OIDC_TEST_REDIRECT_URL", app.URL+"/auth/oidc/test/callback")
+	t.Setenv("OIDC_LOGIN_REDIRECT", "/home")
+	db := setupRouterTestDB(t)
+	router = NewRouter(db)
+
+	// signIn follows the redirects by hand and returns the callback's response
+	signIn := func(user oidctest.User) *http.Response {
+		t.Helper()
+		issuer.SetUser(user)
+		jar, _ := cookiejar.New(nil)
+		client := &http.Client{
+			Jar:           jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		next := app.URL + "/auth/oidc/test/login"
+		for hop := 0; hop < 2; hop++ {
+			resp, err := client.Get(next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusFound {
+				t.Fatalf("GET %s: got %d, want 302", next, resp.StatusCode)
+			}
+			next = resp.Header.Get("Location")
+		}
+		resp, err := client.Get(next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	sessionCookie := func(resp *http.Response) string {
+		for _, c := range resp.Cookies() {
+			if c.Name == "session_id" && c.Value != "" {
+				return c.Value
+			}
+		}
+		return ""
+	}
+
+	t.Run("new account", func(t *testing.T) {
+		resp := signIn(oidctest.User{Subject: "grace-1", Email: "grace@example.com", EmailVerified: true, GivenName: "Grace"})
+		if got := resp.Header.Get("Location"); got != "/home" || sessionCookie(resp) == "" {
+			t.Fatalf("callback: got location %q, session %q", got, sessionCookie(resp))
+		}
+
+		var verified bool
+		var firstName string
+		err := db.QueryRow("SELECT email_verified_at IS NOT NULL, first_name FROM Users WHERE email = 'grace@example.com'").Scan(&verified, &firstName)
+		if err != nil || !verified || firstName != "Grace" {
+			t.Errorf("created user: verified=%v first_name=%q err=%v", verified, firstName, err)
+		}
+
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionCookie(resp)})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("GET /me with the new session: got %d", rr.Code)
+		}
+
+		again := signIn(oidctest.User{Subject: "grace-1", Email: "grace@example.com", EmailVerified: true})
+		var users int
+		db.QueryRow("SELECT COUNT(*) FROM Users WHERE email = 'grace@example.com'").Scan(&users)
+		if again.Header.Get("Location") != "/home" || users != 1 {
+			t.Errorf("second login: got location %q and %d users", again.Header.Get("Location"), users)
+		}
+	})
+
+	t.Run("existing account is linked only once its email is verified", func(t *testing.T) {
+		ada := oidctest.User{Subject: "ada-1", Email: "ada@example.com", EmailVerified: true}
+		resp := signIn(ada)
+		if got := resp.Header.Get("Location"); got != "/home?oidc_error=account_exists" || sessionCookie(resp) != "" {
+			t.Fatalf("unverified local account: got location %q, session %q", got, sessionCookie(resp))
+		}
+
+		if _, err := db.Exec("UPDATE Users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = 1"); err != nil {
+			t.Fatal(err)
+		}
+		resp = signIn(ada)
+		if got := resp.Header.Get("Location"); got != "/home" || sessionCookie(resp) == "" {
+			t.Fatalf("verified local account: got location %q, session %q", got, sessionCookie(resp))
+		}
+		var userID int64
+		db.QueryRow("SELECT user_id FROM External_Identities WHERE subject = 'ada-1'").Scan(&userID)
+		if userID != 1 {
+			t.Errorf("identity linked to user %d, want 1", userID)
+		}
+	})
+
+	t.Run("forged state", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/auth/oidc/test/callback?state=forged&code=code-1", nil)
+		req.AddCookie(&http.Cookie{Name: "oidc_state", Value: "other"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Location"); got != "/home?oidc_error=invalid_state" {
+			t.Errorf("got location %q", got)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/auth/oidc/nope/login", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("got %d, want 404", rr.Code)
+		}
+	})
+}
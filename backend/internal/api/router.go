@@ -1,20 +1,50 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/tajjjjr/social-network/backend/internal/api/handlers"
 	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/mail"
 	"github.com/tajjjjr/social-network/backend/internal/service"
 	"github.com/tajjjjr/social-network/backend/internal/store"
 	ws "github.com/tajjjjr/social-network/backend/internal/websocket"
+	"github.com/tajjjjr/social-network/backend/pkg/oidc"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
-func NewRouter(db *sql.DB) http.Handler {
+// Router serves the HTTP API. The background jobs its services need only run
+// once RunWorkers is called.
+type Router struct {
+	http.Handler
+	workers []func(context.Context)
+}
+
+// RunWorkers runs the background jobs, such as session pruning, until ctx is
+// cancelled, and returns once they have all stopped.
+func (rt *Router) RunWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, run := range rt.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func NewRouter(db *sql.DB) *Router {
 	mux := http.NewServeMux()
 
-	permissionChecker := ws.NewDBPermissionChecker(db)
+	blockService := service.NewBlockService(store.NewBlockStore(db))
+	muteService := service.NewMuteService(store.NewMuteStore(db))
+	permissionChecker := ws.NewDBPermissionChecker(db, blockService, muteService)
 	wsManager := ws.NewManager(
 		ws.NewDBSessionResolver(db),
 		ws.NewDBGroupMemberFetcher(db),
@@ -22,12 +52,22 @@ func NewRouter(db *sql.DB) http.Handler {
 		permissionChecker,
 	)
 
-	mux.Handle("GET /ws", middleware.AuthMiddleware(db)(http.HandlerFunc(handlers.NewWebSocketHandler(wsManager).HandleConnection)))
+	// Sessions end through logout, expiry or pruning; each closes the session's websocket.
+	sessionService := service.NewSessionService(store.NewSessionStore(db), service.SessionConfigFromEnv(), wsManager)
+
+	// Callers authenticate with the session cookie or a personal access token
+	accessTokenService := service.NewAccessTokenService(store.NewAccessTokenStore(db), wsManager)
+	adminService := service.NewAdminService(store.NewAdminStore(db), sessionService, accessTokenService)
+	requireAuth := middleware.AuthMiddleware(sessionService, accessTokenService, adminService)
+
+	// Browsers may only call in with the user's cookies from these origins
+	allowedOrigins := middleware.AllowedOriginsFromEnv()
+
+	mux.Handle("GET /ws", requireAuth(http.HandlerFunc(handlers.NewWebSocketHandler(wsManager, allowedOrigins.CheckOrigin).HandleConnection)))
 
 	notifier := ws.NewDBNotificationSender(wsManager)
 	chatHandler := ws.NewChatHandler(
 		db,
-		ws.NewDBSessionResolver(db),
 		ws.NewDBMessagePersister(db),
 		notifier,
 		wsManager,
@@ -47,15 +87,39 @@ func NewRouter(db *sql.DB) http.Handler {
 	groupMemberStore := store.NewGroupMemberStore(db)
 
 	postService := service.NewPostService(postStore)
-	authService := service.NewAuthService(authStore)
+	authService := service.NewAuthService(authStore, sessionService)
 	followService := service.NewFollowService(followStore)
 	unfollowService := service.NewUnfollowService(unfollowstore)
 	followRequestService := service.NewFollowRequestService(followRequestStore)
-	reactionService := service.NewReactionService(reactionStore)
-	profileService := service.NewProfileService(profilestore)
+	followService.Mutes = muteService
+	followRequestService.Mutes = muteService
+	mentionService := service.NewMentionService(store.NewMentionStore(db), postService.Visibility, muteService, notifier)
+	postService.Mentions = mentionService
+	wsManager.Mentions = mentionService
+	reactionService := service.NewReactionService(reactionStore, postService.Visibility)
+	profileService := service.NewProfileService(profilestore, postService.Visibility)
 	groupService := service.NewGroupService(groupStore)
-	groupRequestService := service.NewGroupRequestService(groupRequestStore, groupService)
+	groupRequestService := service.NewGroupRequestService(groupRequestStore, groupMemberStore, groupService, muteService, notifier)
 	groupChatMessageService := service.NewGroupChatMessageService(groupChatMessageStore, groupService, groupMemberStore)
+	mailer := mail.FromEnv()
+	passwordResetService := service.NewPasswordResetService(authStore, store.NewPasswordResetStore(db), sessionService, mailer, service.PasswordResetConfigFromEnv())
+	emailVerificationService := service.NewEmailVerificationService(store.NewEmailVerificationStore(db), mailer, service.EmailVerificationConfigFromEnv())
+	authService.EmailVerification = emailVerificationService
+	twoFactorService := service.NewTwoFactorService(store.NewTwoFactorStore(db), authStore, authService.Passwords, utils.SecretBoxFromEnv(), service.TwoFactorConfigFromEnv())
+	authService.TwoFactor = twoFactorService
+	authService.Throttle = service.NewLoginThrottleService(store.NewLoginAttemptStore(db), service.LoginThrottleConfigFromEnv())
+	accountDeletionService := service.NewAccountDeletionService(store.NewAccountDeletionStore(db), sessionService, accessTokenService, service.AccountDeletionConfigFromEnv())
+	authService.AccountDeletion = accountDeletionService
+	dataExportService := service.NewDataExportService(store.NewDataExportStore(db), service.DataExportConfigFromEnv())
+	authService.Suspensions = adminService
+	handleService := service.NewHandleService(store.NewHandleStore(db), service.HandleConfigFromEnv())
+	authService.Handles = handleService
+	var oidcProviders []*oidc.Provider
+	for _, config := range oidc.ConfigsFromEnv() {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(config, &http.Client{Timeout: 10 * time.Second}))
+	}
+	oidcConfig := service.OIDCConfigFromEnv()
+	oidcService := service.NewOIDCService(oidcProviders, store.NewOIDCStore(db), authService, emailVerificationService, oidcConfig)
 
 	postHandler := handlers.NewPostHandler(postService)
 	authHandler := handlers.NewAuthHandler(authService)
@@ -64,61 +128,137 @@ func NewRouter(db *sql.DB) http.Handler {
 	followRequestHandler := handlers.NewFollowRequestHandler(followRequestService, notifier)
 	reactionHandler := handlers.NewReactionHandler(reactionService)
 	profileHandler := handlers.NewProfileHandler(profileService)
+	profileHandler.Handles = handleService
 	groupHandler := handlers.NewGroupHandler(groupService, groupRequestService, groupChatMessageService)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	accountHandler := handlers.NewAccountHandler(accountDeletionService)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, authService, oidcConfig.AfterLoginURL)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	blockHandler := handlers.NewBlockHandler(blockService)
+	muteHandler := handlers.NewMuteHandler(muteService)
+	tagHandler := handlers.NewTagHandler(service.NewTagService(store.NewTagStore(db), service.TagConfigFromEnv()))
+	mentionHandler := handlers.NewMentionHandler(mentionService)
+	searchHandler := handlers.NewSearchHandler(service.NewSearchService(store.NewSearchStore(db)))
+
+	// Posting and messaging are held back until the account's email is verified
+	requireVerified := middleware.RequireVerifiedEmail(emailVerificationService)
+	// Site roles gate the admin API; admins can do everything moderators can
+	requireModerator := middleware.RequireRole(auth.RoleModerator)
+	requireAdmin := middleware.RequireRole(auth.RoleAdmin)
 
 	mux.HandleFunc("POST /validate/step1", authHandler.ValidateAccountStepOne)
 	mux.HandleFunc("POST /register", authHandler.Signup)
 	mux.HandleFunc("POST /login", authHandler.Login)
+	mux.HandleFunc("POST /login/2fa", authHandler.LoginTwoFactor)
+	mux.HandleFunc("GET /auth/oidc/providers", oidcHandler.ListProviders)
+	mux.HandleFunc("GET /auth/oidc/{provider}/login", oidcHandler.Login)
+	mux.HandleFunc("GET /auth/oidc/{provider}/callback", oidcHandler.Callback)
 	mux.HandleFunc("POST /logout", func(w http.ResponseWriter, r *http.Request) {
 		authHandler.LogoutHandler(w, r)
 	})
+	mux.HandleFunc("POST /password/forgot", passwordHandler.ForgotPassword)
+	mux.HandleFunc("POST /password/reset", passwordHandler.ResetPassword)
+	mux.HandleFunc("POST /email/verify", emailVerificationHandler.VerifyEmail)
+	mux.Handle("POST /email/verify/resend", requireAuth(http.HandlerFunc(emailVerificationHandler.ResendVerification)))
 
-	mux.Handle("POST /groups", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.CreateGroup)))
-	mux.Handle("POST /groups/{groupID}/join-request", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.SendJoinRequest)))
-	mux.Handle("PUT /groups/{groupID}/join-request/{requestID}/approve", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.ApproveJoinRequest)))
-	mux.Handle("PUT /groups/{groupID}/join-request/{requestID}/reject", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.RejectJoinRequest)))
-
-	mux.Handle("POST /groups/{groupID}/chat", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.SendGroupChatMessage)))
-	mux.Handle("GET /groups/{groupID}/chat", middleware.AuthMiddleware(db)(http.HandlerFunc(groupHandler.GetGroupChatMessages)))
-	mux.Handle("POST /posts", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.CreatePost)))
-	mux.Handle("GET /posts/{postId}", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.GetPostByID)))
-	mux.Handle("GET /posts", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.GetPosts)))
-	mux.Handle("PUT /posts/{postId}", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.UpdatePost)))
-	mux.Handle("POST /posts/{postId}/comments", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.CreateComment)))
-	mux.Handle("GET /posts/{postId}/comments", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.GetCommentsByPostID)))
-	mux.Handle("PUT /posts/{postId}/comments/{commentId}", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.UpdateComment)))
-	mux.Handle("DELETE /posts/{postId}/comments/{commentId}", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.DeleteComment)))
-	mux.Handle("DELETE /posts/{postId}", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.DeletePost)))
-	mux.Handle("GET /users/search", middleware.AuthMiddleware(db)(http.HandlerFunc(postHandler.SearchUsers)))
-
-	mux.Handle("POST /posts/{postId}/reaction", middleware.AuthMiddleware(db)(http.HandlerFunc(reactionHandler.ReactToPost)))
-	mux.Handle("DELETE /posts/{postId}/reaction", middleware.AuthMiddleware(db)(http.HandlerFunc(reactionHandler.UnreactToPost)))
-	mux.Handle("POST /comments/{commentId}/reaction", middleware.AuthMiddleware(db)(http.HandlerFunc(reactionHandler.ReactToComment)))
-	mux.Handle("DELETE /comments/{commentId}/reaction", middleware.AuthMiddleware(db)(http.HandlerFunc(reactionHandler.UnreactToComment)))
-
-	mux.Handle("POST /follow", middleware.AuthMiddleware(db)(http.HandlerFunc(followHandler.Follow)))
-	mux.Handle("DELETE /unfollow", middleware.AuthMiddleware(db)(http.HandlerFunc(unfollowHandler.Unfollow)))
-	mux.Handle("POST /follow-request/{requestId}/request", middleware.AuthMiddleware(db)(http.HandlerFunc(followRequestHandler.FollowRequestRespond)))
-	mux.Handle("DELETE /follow-request/{requestId}/cancel", middleware.AuthMiddleware(db)(http.HandlerFunc(followRequestHandler.CancelFollowRequest)))
-	mux.Handle("GET /follow-request-id/{followeeId}", middleware.AuthMiddleware(db)(http.HandlerFunc(followRequestHandler.GetRequestIDByUsers)))
-	mux.Handle("GET /pending-follow-requests", middleware.AuthMiddleware(db)(http.HandlerFunc(followRequestHandler.GetPendingFollowRequest)))
-
-	mux.Handle("GET /profile/{userid}", middleware.AuthMiddleware(db)(http.HandlerFunc(profileHandler.ProfileHandler)))
-	mux.Handle("GET /profile/{userid}/followers", middleware.AuthMiddleware(db)(http.HandlerFunc(profileHandler.GetFollowers)))
-	mux.Handle("GET /profile/{userid}/followees", middleware.AuthMiddleware(db)(http.HandlerFunc(profileHandler.GetFollowees)))
-	mux.Handle("PUT /EditProfile", middleware.AuthMiddleware(db)(http.HandlerFunc(authHandler.EditProfile))) // Edit profile handler
-
-	mux.Handle("GET /me", middleware.AuthMiddleware(db)(http.HandlerFunc(handlers.NewMeHandler(db))))
+	mux.Handle("POST /groups", requireAuth(http.HandlerFunc(groupHandler.CreateGroup)))
+	mux.Handle("POST /groups/{groupID}/join-request", requireAuth(http.HandlerFunc(groupHandler.SendJoinRequest)))
+	mux.Handle("PUT /groups/{groupID}/join-request/{requestID}/approve", requireAuth(http.HandlerFunc(groupHandler.ApproveJoinRequest)))
+	mux.Handle("PUT /groups/{groupID}/join-request/{requestID}/reject", requireAuth(http.HandlerFunc(groupHandler.RejectJoinRequest)))
+
+	mux.Handle("POST /groups/{groupID}/chat", requireAuth(requireVerified(http.HandlerFunc(groupHandler.SendGroupChatMessage))))
+	mux.Handle("GET /groups/{groupID}/chat", requireAuth(http.HandlerFunc(groupHandler.GetGroupChatMessages)))
+	mux.Handle("POST /posts", requireAuth(requireVerified(http.HandlerFunc(postHandler.CreatePost))))
+	mux.Handle("GET /posts/new", requireAuth(http.HandlerFunc(postHandler.CountNewPosts)))
+	mux.Handle("GET /posts/{postId}", requireAuth(http.HandlerFunc(postHandler.GetPostByID)))
+	mux.Handle("GET /posts", requireAuth(http.HandlerFunc(postHandler.GetPosts)))
+	mux.Handle("PUT /posts/{postId}", requireAuth(http.HandlerFunc(postHandler.UpdatePost)))
+	mux.Handle("POST /posts/{postId}/comments", requireAuth(requireVerified(http.HandlerFunc(postHandler.CreateComment))))
+	mux.Handle("GET /posts/{postId}/comments", requireAuth(http.HandlerFunc(postHandler.GetCommentsByPostID)))
+	mux.Handle("PUT /posts/{postId}/comments/{commentId}", requireAuth(http.HandlerFunc(postHandler.UpdateComment)))
+	mux.Handle("DELETE /posts/{postId}/comments/{commentId}", requireAuth(http.HandlerFunc(postHandler.DeleteComment)))
+	mux.Handle("DELETE /posts/{postId}", requireAuth(http.HandlerFunc(postHandler.DeletePost)))
+	mux.Handle("GET /tags/trending", requireAuth(http.HandlerFunc(tagHandler.GetTrendingTags)))
+	mux.Handle("GET /tags/{tag}/posts", requireAuth(http.HandlerFunc(tagHandler.GetTagPosts)))
+	mux.Handle("GET /users/search", requireAuth(http.HandlerFunc(postHandler.SearchUsers)))
+	mux.Handle("GET /users/mentions", requireAuth(http.HandlerFunc(mentionHandler.Autocomplete)))
+	mux.Handle("GET /search/posts", requireAuth(http.HandlerFunc(searchHandler.SearchPosts)))
+	mux.Handle("GET /users/blocked", requireAuth(http.HandlerFunc(blockHandler.ListBlocked)))
+	mux.Handle("POST /users/{id}/block", requireAuth(http.HandlerFunc(blockHandler.Block)))
+	mux.Handle("DELETE /users/{id}/block", requireAuth(http.HandlerFunc(blockHandler.Unblock)))
+	mux.Handle("GET /users/muted", requireAuth(http.HandlerFunc(muteHandler.ListMutedUsers)))
+	mux.Handle("POST /users/{id}/mute", requireAuth(http.HandlerFunc(muteHandler.MuteUser)))
+	mux.Handle("DELETE /users/{id}/mute", requireAuth(http.HandlerFunc(muteHandler.UnmuteUser)))
+	mux.Handle("GET /muted-keywords", requireAuth(http.HandlerFunc(muteHandler.ListMutedKeywords)))
+	mux.Handle("POST /muted-keywords", requireAuth(http.HandlerFunc(muteHandler.MuteKeyword)))
+	mux.Handle("DELETE /muted-keywords/{id}", requireAuth(http.HandlerFunc(muteHandler.UnmuteKeyword)))
+
+	mux.Handle("POST /posts/{postId}/reaction", requireAuth(http.HandlerFunc(reactionHandler.ReactToPost)))
+	mux.Handle("DELETE /posts/{postId}/reaction", requireAuth(http.HandlerFunc(reactionHandler.UnreactToPost)))
+	mux.Handle("POST /comments/{commentId}/reaction", requireAuth(http.HandlerFunc(reactionHandler.ReactToComment)))
+	mux.Handle("DELETE /comments/{commentId}/reaction", requireAuth(http.HandlerFunc(reactionHandler.UnreactToComment)))
+
+	mux.Handle("POST /follow", requireAuth(http.HandlerFunc(followHandler.Follow)))
+	mux.Handle("DELETE /unfollow", requireAuth(http.HandlerFunc(unfollowHandler.Unfollow)))
+	mux.Handle("POST /follow-request/{requestId}/request", requireAuth(http.HandlerFunc(followRequestHandler.FollowRequestRespond)))
+	mux.Handle("DELETE /follow-request/{requestId}/cancel", requireAuth(http.HandlerFunc(followRequestHandler.CancelFollowRequest)))
+	mux.Handle("GET /follow-request-id/{followeeId}", requireAuth(http.HandlerFunc(followRequestHandler.GetRequestIDByUsers)))
+	mux.Handle("GET /pending-follow-requests", requireAuth(http.HandlerFunc(followRequestHandler.GetPendingFollowRequest)))
+
+	mux.Handle("GET /profile/{userid}", requireAuth(http.HandlerFunc(profileHandler.ProfileHandler)))
+	mux.Handle("GET /profile/{userid}/followers", requireAuth(http.HandlerFunc(profileHandler.GetFollowers)))
+	mux.Handle("GET /users/by-handle/{handle}", requireAuth(http.HandlerFunc(profileHandler.ProfileByHandle)))
+	mux.Handle("GET /profile/{userid}/followees", requireAuth(http.HandlerFunc(profileHandler.GetFollowees)))
+	mux.Handle("POST /2fa/enroll", requireAuth(http.HandlerFunc(twoFactorHandler.Enroll)))
+	mux.Handle("POST /2fa/confirm", requireAuth(http.HandlerFunc(twoFactorHandler.Confirm)))
+	mux.Handle("POST /2fa/disable", requireAuth(http.HandlerFunc(twoFactorHandler.Disable)))
+	mux.Handle("PUT /password", requireAuth(http.HandlerFunc(authHandler.ChangePassword)))
+	mux.Handle("PUT /EditProfile", requireAuth(http.HandlerFunc(authHandler.EditProfile))) // Edit profile handler
+
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	mux.Handle("GET /sessions", requireAuth(http.HandlerFunc(sessionHandler.ListSessions)))
+	mux.Handle("DELETE /sessions", requireAuth(http.HandlerFunc(sessionHandler.RevokeOtherSessions)))
+	mux.Handle("DELETE /sessions/{id}", requireAuth(http.HandlerFunc(sessionHandler.RevokeSession)))
+
+	accessTokenHandler := handlers.NewAccessTokenHandler(accessTokenService)
+	mux.Handle("POST /tokens", requireAuth(http.HandlerFunc(accessTokenHandler.CreateToken)))
+	mux.Handle("GET /tokens", requireAuth(http.HandlerFunc(accessTokenHandler.ListTokens)))
+	mux.Handle("DELETE /tokens/{id}", requireAuth(http.HandlerFunc(accessTokenHandler.RevokeToken)))
+
+	mux.Handle("GET /me", requireAuth(http.HandlerFunc(handlers.NewMeHandler(db))))
+	mux.Handle("DELETE /me", requireAuth(http.HandlerFunc(accountHandler.DeleteAccount)))
+	mux.Handle("POST /me/exports", requireAuth(http.HandlerFunc(dataExportHandler.RequestExport)))
+	mux.Handle("GET /me/exports/{id}", requireAuth(http.HandlerFunc(dataExportHandler.GetExport)))
+	mux.Handle("GET /me/exports/{id}/download", requireAuth(http.HandlerFunc(dataExportHandler.DownloadExport)))
 	mux.Handle("GET /avatar", http.HandlerFunc(handlers.GetImage))
 
-	mux.Handle("GET /api/messages/private", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.GetPrivateMessages)))
-	mux.Handle("GET /api/messages/group", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.GetGroupMessages)))
-	mux.Handle("POST /api/groups/invite", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.SendGroupInvite)))
-	mux.Handle("GET /api/notifications", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.GetNotifications)))
-	mux.Handle("POST /api/notifications/read", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.MarkNotificationsRead)))
-	mux.Handle("GET /api/users/online", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.GetOnlineUsers)))
+	mux.Handle("GET /admin/users", requireAuth(requireModerator(http.HandlerFunc(adminHandler.ListUsers))))
+	mux.Handle("POST /admin/users/{id}/suspend", requireAuth(requireModerator(http.HandlerFunc(adminHandler.Suspend))))
+	mux.Handle("POST /admin/users/{id}/unsuspend", requireAuth(requireModerator(http.HandlerFunc(adminHandler.Unsuspend))))
+	mux.Handle("POST /admin/users/{id}/logout", requireAuth(requireModerator(http.HandlerFunc(adminHandler.ForceLogout))))
+	mux.Handle("PUT /admin/users/{id}/role", requireAuth(requireAdmin(http.HandlerFunc(adminHandler.SetRole))))
+	mux.Handle("GET /admin/stats", requireAuth(requireAdmin(http.HandlerFunc(adminHandler.Stats))))
+
+	mux.Handle("GET /api/messages/private", requireAuth(http.HandlerFunc(chatHandler.GetPrivateMessages)))
+	mux.Handle("GET /api/messages/group", requireAuth(http.HandlerFunc(chatHandler.GetGroupMessages)))
+	mux.Handle("POST /api/groups/invite", requireAuth(http.HandlerFunc(chatHandler.SendGroupInvite)))
+	mux.Handle("GET /api/notifications", requireAuth(http.HandlerFunc(chatHandler.GetNotifications)))
+	mux.Handle("POST /api/notifications/read", requireAuth(http.HandlerFunc(chatHandler.MarkNotificationsRead)))
+	mux.Handle("GET /api/users/online", requireAuth(http.HandlerFunc(chatHandler.GetOnlineUsers)))
+
+	mux.Handle("GET /api/users/messageable", requireAuth(http.HandlerFunc(chatHandler.GetMessageableUsers)))
 
-	mux.Handle("GET /api/users/messageable", middleware.AuthMiddleware(db)(http.HandlerFunc(chatHandler.GetMessageableUsers)))
+	mux.HandleFunc("GET /csrf", handlers.GetCSRFToken)
 
-	return mux
+	return &Router{
+		Handler: middleware.CORSMiddleware(allowedOrigins)(middleware.CSRFMiddleware(allowedOrigins)(mux)),
+		workers: []func(context.Context){
+			sessionService.RunPruner,
+			accountDeletionService.RunPurger,
+			dataExportService.RunWorker,
+		},
+	}
 }
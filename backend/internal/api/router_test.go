@@ -0,0 +1,1012 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/pkg/db/sqlite"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testMigrationDir = "../../pkg/db/migrations/sqlite"
+
+// setupRouterTestDB applies every up migration to a fresh database and seeds
+// one user with a live session.
+func setupRouterTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "router.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(testMigrationDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".up.sql") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := sqlite.ApplyMigrationInTx(db, testMigrationDir, f); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES (1, 'ada@example.com', 'x', 'ada');
+		INSERT INTO Sessions (id, user_id, expires_at) VALUES (?, 1, datetime('now', '+1 day'));
+	`, adaSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// withCSRF adds a matching csrf_token cookie and X-CSRF-Token header, which
+// frontend/lib/csrf.js sends with every unsafe request.
+func withCSRF(req *http.Request) *http.Request {
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "router-csrf"})
+	req.Header.Set("X-CSRF-Token", "router-csrf")
+	return req
+}
+
+// Sessions seeded by setupRouterTestDB and addBob.
+const (
+	adaSession = "router-session"
+	bobSession = "bob-session"
+)
+
+// serveRouter sends a request through router as the holder of session, with a
+// matching CSRF token. session and contentType may be empty.
+func serveRouter(router http.Handler, session, method, path string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := withCSRF(httptest.NewRequest(method, path, body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// addBob seeds user 2, bob, with a public profile and the live session bobSession.
+func addBob(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, first_name, nickname, is_profile_public) VALUES (2, 'bob@example.com', 'x', 'Bob', 'bob', 1);
+		INSERT INTO Sessions (id, user_id, expires_at) VALUES (?, 2, datetime('now', '+1 day'));
+	`, bobSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// verifyEmail marks the email of user id verified, which posting needs.
+func verifyEmail(t *testing.T, db *sql.DB, id int64) {
+	t.Helper()
+	if _, err := db.Exec(`UPDATE Users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// protectedRoutes lists one concrete request for every route registered
+// behind the auth middleware.
+var protectedRoutes = []struct {
+	method string
+	path   string
+}{
+	{"GET", "/ws"},
+	{"POST", "/groups"},
+	{"POST", "/groups/1/join-request"},
+	{"PUT", "/groups/1/join-request/1/approve"},
+	{"PUT", "/groups/1/join-request/1/reject"},
+	{"POST", "/groups/1/chat"},
+	{"GET", "/groups/1/chat"},
+	{"POST", "/posts"},
+	{"GET", "/posts/1"},
+	{"GET", "/posts"},
+	{"GET", "/posts/new"},
+	{"PUT", "/posts/1"},
+	{"POST", "/posts/1/comments"},
+	{"GET", "/posts/1/comments"},
+	{"PUT", "/posts/1/comments/1"},
+	{"DELETE", "/posts/1/comments/1"},
+	{"DELETE", "/posts/1"},
+	{"GET", "/tags/trending"},
+	{"GET", "/tags/golang/posts"},
+	{"GET", "/users/mentions"},
+	{"GET", "/search/posts"},
+	{"GET", "/users/search?q=ada"},
+	{"GET", "/users/blocked"},
+	{"POST", "/users/1/block"},
+	{"DELETE", "/users/1/block"},
+	{"GET", "/users/muted"},
+	{"POST", "/users/1/mute"},
+	{"DELETE", "/users/1/mute"},
+	{"GET", "/muted-keywords"},
+	{"POST", "/muted-keywords"},
+	{"DELETE", "/muted-keywords/1"},
+	{"POST", "/me/exports"},
+	{"GET", "/me/exports/1"},
+	{"GET", "/me/exports/1/download"},
+	{"POST", "/posts/1/reaction"},
+	{"DELETE", "/posts/1/reaction"},
+	{"POST", "/comments/1/reaction"},
+	{"DELETE", "/comments/1/reaction"},
+	{"POST", "/follow"},
+	{"DELETE", "/unfollow"},
+	{"POST", "/follow-request/1/request"},
+	{"DELETE", "/follow-request/1/cancel"},
+	{"GET", "/follow-request-id/2"},
+	{"GET", "/pending-follow-requests"},
+	{"GET", "/profile/1"},
+	{"GET", "/profile/1/followers"},
+	{"GET", "/profile/1/followees"},
+	{"GET", "/users/by-handle/ada"},
+	{"POST", "/2fa/enroll"},
+	{"POST", "/2fa/confirm"},
+	{"POST", "/2fa/disable"},
+	{"PUT", "/password"},
+	{"PUT", "/EditProfile"},
+	{"GET", "/me"},
+	{"DELETE", "/me"},
+	{"POST", "/email/verify/resend"},
+	{"GET", "/sessions"},
+	{"DELETE", "/sessions?others=true"},
+	{"DELETE", "/sessions/0123456789abcdef"},
+	{"POST", "/tokens"},
+	{"GET", "/tokens"},
+	{"DELETE", "/tokens/1"},
+	{"GET", "/admin/users?q=ada"},
+	{"POST", "/admin/users/2/suspend"},
+	{"POST", "/admin/users/2/unsuspend"},
+	{"POST", "/admin/users/2/logout"},
+	{"PUT", "/admin/users/2/role"},
+	{"GET", "/admin/stats"},
+	{"GET", "/api/messages/private?user=2"},
+	{"GET", "/api/messages/group?group=1"},
+	{"POST", "/api/groups/invite"},
+	{"GET", "/api/notifications"},
+	{"POST", "/api/notifications/read"},
+	{"GET", "/api/users/online"},
+	{"GET", "/api/users/messageable"},
+}
+
+func TestProtectedRoutesShareIdentity(t *testing.T) {
+	t.Setenv("DATA_EXPORT_DIR", t.TempDir())
+	db := setupRouterTestDB(t)
+	router := NewRouter(db)
+
+	for _, route := range protectedRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			// routes such as DELETE /me end the session, so restore it each time
+			_, err := db.Exec(`INSERT OR REPLACE INTO Sessions (id, user_id, expires_at) VALUES ('router-session', 1, datetime('now', '+1 day'))`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := withCSRF(httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("without a session: got status %d, want 401", rr.Code)
+			}
+
+			req = withCSRF(httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code == http.StatusUnauthorized {
+				t.Errorf("with a session: handler did not see the identity (body %q)", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnverifiedEmailBlocksPostingAndMessaging(t *testing.T) {
+	router := NewRouter(setupRouterTestDB(t))
+
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/posts"},
+		{"POST", "/posts/1/comments"},
+		{"POST", "/groups/1/chat"},
+	} {
+		req := withCSRF(httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var body utils.Response
+		_ = json.Unmarshal(rr.Body.Bytes(), &body)
+		if rr.Code != http.StatusForbidden || body.Code != "email_unverified" {
+			t.Errorf("%s %s: got %d %+v, want 403 email_unverified", route.method, route.path, rr.Code, body)
+		}
+	}
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	router := NewRouter(setupRouterTestDB(t))
+
+	do := func(method, path, body, cookie, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != "" {
+			withCSRF(req).AddCookie(&http.Cookie{Name: "session_id", Value: cookie})
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	create := func(scope string) (string, int64) {
+		rr := do("POST", "/tokens", `{"name":"script","scope":"`+scope+`","expires_in_days":30}`, "router-session", "")
+		var created struct {
+			Token       string `json:"token"`
+			AccessToken struct {
+				ID int64 `json:"id"`
+			} `json:"access_token"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &created); rr.Code != http.StatusCreated || err != nil {
+			t.Fatalf("create %s token: got %d %s", scope, rr.Code, rr.Body.String())
+		}
+		return created.Token, created.AccessToken.ID
+	}
+
+	readToken, _ := create("read")
+	writeToken, writeID := create("write")
+
+	if rr := do("GET", "/me", "", "", readToken); rr.Code != http.StatusOK {
+		t.Errorf("GET /me with a read token: got %d", rr.Code)
+	}
+
+	rr := do("POST", "/posts", "{}", "", readToken)
+	var body utils.Response
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	if rr.Code != http.StatusForbidden || body.Code != "insufficient_scope" {
+		t.Errorf("POST /posts with a read token: got %d %+v, want 403 insufficient_scope", rr.Code, body)
+	}
+
+	// Tokens cannot mint or list tokens, even with the write scope.
+	if rr := do("POST", "/tokens", `{"name":"x","scope":"write"}`, "", writeToken); rr.Code != http.StatusForbidden {
+		t.Errorf("POST /tokens with a token: got %d, want 403", rr.Code)
+	}
+
+	if rr := do("GET", "/tokens", "", "router-session", ""); !strings.Contains(rr.Body.String(), `"scope":"write"`) || strings.Contains(rr.Body.String(), writeToken) {
+		t.Errorf("GET /tokens: unexpected body %s", rr.Body.String())
+	}
+
+	if rr := do("DELETE", "/tokens/"+strconv.FormatInt(writeID, 10), "", "router-session", ""); rr.Code != http.StatusOK {
+		t.Fatalf("revoke: got %d", rr.Code)
+	}
+	if rr := do("GET", "/me", "", "", writeToken); rr.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: got %d, want 401", rr.Code)
+	}
+	// A bad bearer token is not rescued by a valid cookie.
+	if rr := do("GET", "/me", "", "router-session", "snpat_bogus"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("invalid bearer with a cookie: got %d, want 401", rr.Code)
+	}
+}
+
+func TestDeleteAccountIsCancelledByLoggingIn(t *testing.T) {
+	db := setupRouterTestDB(t)
+	router := NewRouter(db)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	if _, err := db.Exec("UPDATE Users SET password = ? WHERE id = 1", string(hash)); err != nil {
+		t.Fatal(err)
+	}
+	deleteAfter := func() sql.NullTime {
+		var at sql.NullTime
+		_ = db.QueryRow("SELECT delete_after FROM Users WHERE id = 1").Scan(&at)
+		return at
+	}
+
+	req := withCSRF(httptest.NewRequest("DELETE", "/me", nil))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted || !deleteAfter().Valid {
+		t.Fatalf("DELETE /me: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("the session should end with the deletion request: got %d", rr.Code)
+	}
+
+	req = withCSRF(httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"ada@example.com","password":"Secret123!"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || deleteAfter().Valid {
+		t.Errorf("logging in should cancel the deletion: got %d, delete_after %v", rr.Code, deleteAfter())
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	db := setupRouterTestDB(t)
+	router := NewRouter(db)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES (2, 'bob@example.com', ?, 'bob');
+		INSERT INTO Sessions (id, user_id, expires_at) VALUES ('bob-session', 2, datetime('now', '+1 day'));
+	`, string(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/admin/users", nil, ""); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "insufficient_role") {
+		t.Fatalf("a plain user: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if _, err := db.Exec("UPDATE Users SET role = 'moderator' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/admin/users?q=bob", nil, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "bob@example.com") || strings.Contains(rr.Body.String(), "ada@example.com") {
+		t.Errorf("moderator searching users: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/admin/stats", nil, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("stats are for admins only: got %d", rr.Code)
+	}
+
+	if rr := serveRouter(router, adaSession, "POST", "/admin/users/2/suspend", nil, ""); rr.Code != http.StatusOK {
+		t.Fatalf("suspend: got %d %s", rr.Code, rr.Body.String())
+	}
+	var sessions int
+	_ = db.QueryRow("SELECT COUNT(*) FROM Sessions WHERE user_id = 2").Scan(&sessions)
+	if sessions != 0 {
+		t.Errorf("suspension should end the user's sessions, %d left", sessions)
+	}
+	login := withCSRF(httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"bob@example.com","password":"Secret123!"}`)))
+	login.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, login)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "account_suspended") {
+		t.Errorf("a suspended user logging in: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if _, err := db.Exec("UPDATE Users SET role = 'admin' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/admin/stats", nil, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"suspended_users":1`) {
+		t.Errorf("stats: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "PUT", "/admin/users/2/role", strings.NewReader(`{"role":"superuser"}`), ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid role: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "PUT", "/admin/users/2/role", strings.NewReader(`{"role":"moderator"}`), ""); rr.Code != http.StatusOK {
+		t.Errorf("promote: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "PUT", "/admin/users/1/role", strings.NewReader(`{"role":"user"}`), ""); rr.Code != http.StatusForbidden {
+		t.Errorf("changing your own role: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "POST", "/admin/users/99/unsuspend", nil, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown user: got %d", rr.Code)
+	}
+}
+
+// multipartForm encodes fields the way the signup and profile forms send them.
+func multipartForm(t *testing.T, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, writer.FormDataContentType()
+}
+
+func TestHandles(t *testing.T) {
+	db := setupRouterTestDB(t)
+	router := NewRouter(db)
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/users/by-handle/@ADA"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"nickname":"ada"`) {
+		t.Fatalf("handles resolve ignoring case and a leading @: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/users/by-handle/nobody"); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown handle: got %d", rr.Code)
+	}
+
+	body, contentType := multipartForm(t, map[string]string{
+		"email": "bob@example.com", "password": "Secret123!", "nickname": "Ada",
+	})
+	req := withCSRF(httptest.NewRequest("POST", "/register", body))
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "handle_taken") {
+		t.Fatalf("signing up with a taken handle: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	body, contentType = multipartForm(t, map[string]string{"email": "ada@example.com", "nickname": "countess"})
+	req = withCSRF(httptest.NewRequest("PUT", "/EditProfile", body))
+	req.Header.Set("Content-Type", contentType)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "router-session"})
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("rename: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := get("/users/by-handle/ada"); rr.Code != http.StatusFound || rr.Header().Get("Location") != "/users/by-handle/countess" {
+		t.Errorf("old handle: got %d to %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	// the old handle stays reserved while it redirects
+	body, contentType = multipartForm(t, map[string]string{
+		"email": "bob@example.com", "password": "Secret123!", "nickname": "ada",
+	})
+	req = withCSRF(httptest.NewRequest("POST", "/register", body))
+	req.Header.Set("Content-Type", contentType)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("signing up with a redirecting handle: got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestFollowListsFollowProfilePrivacy(t *testing.T) {
+	db := setupRouterTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname, is_profile_public) VALUES
+			(2, 'bob@example.com', 'x', 'bob', 0), (3, 'cy@example.com', 'x', 'cy', 1);
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (3, 2, 'accepted');
+		INSERT INTO Sessions (id, user_id, expires_at) VALUES ('cy-session', 3, datetime('now', '+1 day'));
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+	get := func(path, session string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/profile/2/followers", "router-session"); rr.Code != http.StatusForbidden {
+		t.Errorf("private followers for a stranger: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/profile/2/followers?count_only=true", "router-session"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Errorf("follower count: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/profile/2/followers", "cy-session"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"nickname":"cy"`) {
+		t.Errorf("private followers for a follower: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/profile/3/followees", "router-session"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"nickname":"bob"`) {
+		t.Errorf("public followees: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/profile/3/followees?cursor=abc", "router-session"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: got %d", rr.Code)
+	}
+	if rr := get("/profile/99/followers", "router-session"); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown user: got %d", rr.Code)
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	db := setupRouterTestDB(t)
+	addBob(t, db)
+	_, err := db.Exec(`
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (2, 1, 'accepted');
+		INSERT INTO Posts (id, user_id, content, image, privacy) VALUES (10, 1, 'hello', '', 'public');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+
+	if rr := serveRouter(router, adaSession, "POST", "/users/2/block", nil, ""); rr.Code != http.StatusOK {
+		t.Fatalf("block: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/users/blocked", nil, ""); !strings.Contains(rr.Body.String(), `"nickname":"bob"`) {
+		t.Errorf("blocked list: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var follows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Followers").Scan(&follows); err != nil || follows != 0 {
+		t.Errorf("blocking should remove the follow: %d left, %v", follows, err)
+	}
+	if rr := serveRouter(router, bobSession, "POST", "/follow", strings.NewReader(`{"followeeid":1}`), ""); rr.Code != http.StatusForbidden {
+		t.Errorf("blocked user following: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, bobSession, "GET", "/posts/10", nil, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("blocked user reading a post: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, bobSession, "POST", "/posts/10/reaction", strings.NewReader(`{"reaction_type":"like"}`), ""); rr.Code == http.StatusOK || rr.Code == http.StatusCreated {
+		t.Errorf("blocked user reacting: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, bobSession, "GET", "/users/search?q=ada", nil, ""); strings.Contains(rr.Body.String(), `"ada"`) {
+		t.Errorf("blocked user finds the blocker: %s", rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/users/search?q=bob", nil, ""); strings.Contains(rr.Body.String(), `"bob"`) {
+		t.Errorf("blocker finds the blocked user: %s", rr.Body.String())
+	}
+
+	if rr := serveRouter(router, adaSession, "DELETE", "/users/2/block", nil, ""); rr.Code != http.StatusOK {
+		t.Fatalf("unblock: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, bobSession, "GET", "/posts/10", nil, ""); rr.Code != http.StatusOK {
+		t.Errorf("post after unblocking: got %d", rr.Code)
+	}
+}
+
+func TestMutes(t *testing.T) {
+	db := setupRouterTestDB(t)
+	addBob(t, db)
+	if _, err := db.Exec(`INSERT INTO Posts (id, user_id, content, image, privacy) VALUES (10, 2, 'hello', '', 'public')`); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+
+	if rr := serveRouter(router, adaSession, "POST", "/users/2/mute", strings.NewReader(`{"expires_in_hours":24}`), ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "expires_at") {
+		t.Fatalf("mute: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/users/muted", nil, ""); !strings.Contains(rr.Body.String(), `"nickname":"bob"`) {
+		t.Errorf("muted list: got %s", rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/posts", nil, ""); strings.Contains(rr.Body.String(), "hello") {
+		t.Errorf("muted user's post in the feed: %s", rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/posts/10", nil, ""); rr.Code != http.StatusOK {
+		t.Errorf("muted posts stay reachable directly: got %d", rr.Code)
+	}
+
+	// bob's follow goes through without telling ada
+	if rr := serveRouter(router, bobSession, "POST", "/follow", strings.NewReader(`{"followeeid":1}`), ""); rr.Code != http.StatusOK {
+		t.Fatalf("muted user following: got %d %s", rr.Code, rr.Body.String())
+	}
+	var notifications int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Notifications WHERE user_id = 1").Scan(&notifications); err != nil || notifications != 0 {
+		t.Errorf("notifications from a muted user: %d, %v", notifications, err)
+	}
+
+	rr := serveRouter(router, adaSession, "POST", "/muted-keywords", strings.NewReader(`{"keyword":"  Spoiler  "}`), "")
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"keyword":"Spoiler"`) {
+		t.Fatalf("mute keyword: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "POST", "/muted-keywords", strings.NewReader(`{"keyword":"spoiler"}`), ""); rr.Code != http.StatusConflict {
+		t.Errorf("duplicate keyword: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, bobSession, "DELETE", "/muted-keywords/1", nil, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("deleting another user's keyword: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "DELETE", "/muted-keywords/1", nil, ""); rr.Code != http.StatusOK {
+		t.Errorf("unmute keyword: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "DELETE", "/users/2/mute", nil, ""); rr.Code != http.StatusOK {
+		t.Errorf("unmute: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/posts", nil, ""); !strings.Contains(rr.Body.String(), "hello") {
+		t.Errorf("feed after unmuting: %s", rr.Body.String())
+	}
+}
+
+func TestDataExport(t *testing.T) {
+	t.Setenv("DATA_EXPORT_DIR", t.TempDir())
+	db := setupRouterTestDB(t)
+	addBob(t, db)
+	_, err := db.Exec(`
+		INSERT INTO Posts (id, user_id, content, image, privacy) VALUES (10, 1, 'ada was here', '', 'public');
+		INSERT INTO Messages (sender_id, receiver_id, content) VALUES (2, 1, 'hi ada');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		router.RunWorkers(ctx)
+		close(workersDone)
+	}()
+	t.Cleanup(func() {
+		stopWorkers()
+		<-workersDone
+	})
+	type export struct {
+		ID          int64  `json:"id"`
+		Status      string `json:"status"`
+		DownloadURL string `json:"download_url"`
+	}
+	decode := func(rr *httptest.ResponseRecorder) export {
+		var e export
+		if err := json.Unmarshal(rr.Body.Bytes(), &e); err != nil {
+			t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+		}
+		return e
+	}
+
+	rr := serveRouter(router, adaSession, "POST", "/me/exports", nil, "")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("request: got %d %s", rr.Code, rr.Body.String())
+	}
+	requested := decode(rr)
+	statusURL := rr.Header().Get("Location")
+
+	// the worker picks the export up on its own
+	var ready export
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(20 * time.Millisecond) {
+		ready = decode(serveRouter(router, adaSession, "GET", statusURL, nil, ""))
+		if ready.Status != "pending" && ready.Status != "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("export %d still %s", requested.ID, ready.Status)
+		}
+	}
+	if ready.Status != "ready" || ready.DownloadURL != statusURL+"/download" {
+		t.Fatalf("got %+v", ready)
+	}
+	if rr := serveRouter(router, bobSession, "GET", statusURL, nil, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("someone else's export status: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, bobSession, "GET", ready.DownloadURL, nil, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("someone else's download: got %d", rr.Code)
+	}
+
+	rr = serveRouter(router, adaSession, "GET", ready.DownloadURL, nil, "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/zip" ||
+		!strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Fatalf("download: got %d %v", rr.Code, rr.Header())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		_, _ = b.ReadFrom(r)
+		r.Close()
+		contents[f.Name] = b.String()
+	}
+	if !strings.Contains(contents["profile.json"], "ada@example.com") || strings.Contains(contents["profile.json"], "password") {
+		t.Errorf("profile.json: %s", contents["profile.json"])
+	}
+	if !strings.Contains(contents["posts.json"], "ada was here") {
+		t.Errorf("posts.json: %s", contents["posts.json"])
+	}
+	if !strings.Contains(contents["private_messages.json"], "hi ada") {
+		t.Errorf("private_messages.json: %s", contents["private_messages.json"])
+	}
+
+	if _, err := db.Exec("UPDATE Data_Exports SET expires_at = datetime('now', '-1 minute') WHERE id = ?", ready.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serveRouter(router, adaSession, "GET", ready.DownloadURL, nil, ""); rr.Code != http.StatusGone {
+		t.Errorf("expired download: got %d", rr.Code)
+	}
+	if e := decode(serveRouter(router, adaSession, "GET", statusURL, nil, "")); e.Status != "expired" || e.DownloadURL != "" {
+		t.Errorf("expired status: got %+v", e)
+	}
+}
+
+func TestFeedCursors(t *testing.T) {
+	db := setupRouterTestDB(t)
+	addBob(t, db)
+	_, err := db.Exec(`
+		INSERT INTO Posts (id, user_id, content, image, privacy, created_at) VALUES
+			(1, 2, 'first', '', 'public', '2020-01-01 10:00:00'),
+			(2, 2, 'second', '', 'public', '2020-01-01 11:00:00'),
+			(3, 2, 'third', '', 'public', '2020-01-01 12:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+	type feedPage struct {
+		Posts []struct {
+			ID int64 `json:"id"`
+		} `json:"posts"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	}
+	page := func(path string) feedPage {
+		rr := serveRouter(router, adaSession, "GET", path, nil, "")
+		var p feedPage
+		if err := json.Unmarshal(rr.Body.Bytes(), &p); rr.Code != http.StatusOK || err != nil {
+			t.Fatalf("GET %s: got %d %s", path, rr.Code, rr.Body.String())
+		}
+		return p
+	}
+
+	top := page("/posts?limit=2")
+	if len(top.Posts) != 2 || top.Posts[0].ID != 3 || top.NextCursor == "" {
+		t.Fatalf("first page = %+v", top)
+	}
+	if rest := page("/posts?limit=2&before=" + top.NextCursor); len(rest.Posts) != 1 || rest.Posts[0].ID != 1 || rest.NextCursor != "" {
+		t.Errorf("second page = %+v", rest)
+	}
+
+	countNew := func() string {
+		return serveRouter(router, adaSession, "GET", "/posts/new?since="+top.PrevCursor, nil, "").Body.String()
+	}
+	if body := countNew(); !strings.Contains(body, `"count":0`) {
+		t.Errorf("new posts before any arrive: %s", body)
+	}
+	if _, err := db.Exec(`INSERT INTO Posts (id, user_id, content, image, privacy, created_at) VALUES (4, 2, 'fourth', '', 'public', '2020-01-01 13:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+	if body := countNew(); !strings.Contains(body, `"count":1`) {
+		t.Errorf("new posts after one arrived: %s", body)
+	}
+	if newer := page("/posts?after=" + top.PrevCursor); len(newer.Posts) != 1 || newer.Posts[0].ID != 4 {
+		t.Errorf("posts after the first page = %+v", newer)
+	}
+
+	if rr := serveRouter(router, adaSession, "GET", "/posts?before=garbage", nil, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/posts?page=1&limit=2", nil, ""); rr.Code != http.StatusOK || rr.Header().Get("Deprecation") != "true" {
+		t.Errorf("page mode: got %d, Deprecation %q", rr.Code, rr.Header().Get("Deprecation"))
+	}
+}
+
+func TestPostGallery(t *testing.T) {
+	db := setupRouterTestDB(t)
+	verifyEmail(t, db, 1)
+	router := NewRouter(db)
+	type gallery struct {
+		ID          int64  `json:"id"`
+		Image       string `json:"image"`
+		Attachments []struct {
+			ID      int64  `json:"id"`
+			Path    string `json:"path"`
+			AltText string `json:"alt_text"`
+			Width   int    `json:"width"`
+		} `json:"attachments"`
+	}
+	send := func(method, path string, body *bytes.Buffer, contentType string) (*httptest.ResponseRecorder, gallery) {
+		rr := serveRouter(router, adaSession, method, path, body, contentType)
+		var g gallery
+		json.Unmarshal(rr.Body.Bytes(), &g)
+		return rr, g
+	}
+	form := func(fields [][2]string, images int) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for _, field := range fields {
+			writer.WriteField(field[0], field[1])
+		}
+		for i := 0; i < images; i++ {
+			part, err := writer.CreateFormFile("images", "pixel.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a 1x1 PNG
+			part.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89\x00\x00\x00\rIDATx\x9cc\xf8\x0f\x00\x00\x01\x01\x00\x05\x18\xd8N\x00\x00\x00\x00IEND\xaeB`\x82"))
+		}
+		writer.Close()
+		return &body, writer.FormDataContentType()
+	}
+
+	body, contentType := form([][2]string{{"content", "gallery"}, {"privacy", "public"}, {"alt_text", "left"}, {"alt_text", "right"}}, 2)
+	rr, created := send("POST", "/posts", body, contentType)
+	if rr.Code != http.StatusCreated || len(created.Attachments) != 2 || created.Attachments[1].AltText != "right" || created.Attachments[0].Width != 1 {
+		t.Fatalf("creating a gallery: got %d %s", rr.Code, rr.Body.String())
+	}
+	postPath := "/posts/" + strconv.FormatInt(created.ID, 10)
+	first, second := created.Attachments[0], created.Attachments[1]
+
+	// swap the two, then drop the first by keeping only the second
+	body, contentType = form([][2]string{{"content", "swapped"}, {"keep", strconv.FormatInt(second.ID, 10) + "," + strconv.FormatInt(first.ID, 10)}}, 0)
+	if rr, edited := send("PUT", postPath, body, contentType); rr.Code != http.StatusOK || len(edited.Attachments) != 2 || edited.Attachments[0].ID != second.ID || edited.Image != second.Path {
+		t.Fatalf("reordering: got %d %s", rr.Code, rr.Body.String())
+	}
+	body, contentType = form([][2]string{{"content", "trimmed"}, {"keep", strconv.FormatInt(second.ID, 10)}}, 0)
+	if rr, edited := send("PUT", postPath, body, contentType); rr.Code != http.StatusOK || len(edited.Attachments) != 1 {
+		t.Fatalf("removing: got %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join("attachments", first.Path)); !os.IsNotExist(err) {
+		t.Errorf("the removed image's file is still there: %v", err)
+	}
+
+	body, contentType = form([][2]string{{"content", "stolen"}, {"keep", "999"}}, 0)
+	if rr, _ := send("PUT", postPath, body, contentType); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "unknown_attachment") {
+		t.Errorf("keeping another post's image: got %d %s", rr.Code, rr.Body.String())
+	}
+	body, contentType = form([][2]string{{"content", "crowded"}}, 10)
+	if rr, _ := send("PUT", postPath, body, contentType); rr.Code != http.StatusBadRequest {
+		t.Errorf("going over the image limit: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr, _ := send("DELETE", postPath, bytes.NewBuffer(nil), ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("deleting: got %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join("attachments", second.Path)); !os.IsNotExist(err) {
+		t.Errorf("the deleted post's image is still there: %v", err)
+	}
+}
+
+func TestTagTimeline(t *testing.T) {
+	db := setupRouterTestDB(t)
+	verifyEmail(t, db, 1)
+	router := NewRouter(db)
+	for _, content := range []string{"learning #Go", "more #go and #sqlite", "nothing tagged"} {
+		body, contentType := multipartForm(t, map[string]string{"content": content, "privacy": "public"})
+		if rr := serveRouter(router, adaSession, "POST", "/posts", body, contentType); rr.Code != http.StatusCreated {
+			t.Fatalf("posting %q: got %d %s", content, rr.Code, rr.Body.String())
+		}
+	}
+
+	type tagPage struct {
+		Posts []struct {
+			Content string `json:"content"`
+		} `json:"posts"`
+		NextCursor string `json:"next_cursor"`
+	}
+	var page tagPage
+	rr := serveRouter(router, adaSession, "GET", "/tags/%23GO/posts?limit=1", nil, "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); rr.Code != http.StatusOK || err != nil || len(page.Posts) != 1 || page.Posts[0].Content != "more #go and #sqlite" || page.NextCursor == "" {
+		t.Fatalf("first page: got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = serveRouter(router, adaSession, "GET", "/tags/go/posts?before="+page.NextCursor, nil, "")
+	var rest tagPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &rest); rr.Code != http.StatusOK || err != nil || len(rest.Posts) != 1 || rest.NextCursor != "" {
+		t.Errorf("second page: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/tags/123/posts", nil, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("a tag without letters: got %d", rr.Code)
+	}
+
+	rr = serveRouter(router, adaSession, "GET", "/tags/trending", nil, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `{"tag":"go","uses":2,"users":1}`) {
+		t.Errorf("trending: got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMentions(t *testing.T) {
+	db := setupRouterTestDB(t)
+	verifyEmail(t, db, 1)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, first_name, nickname) VALUES
+			(2, 'bob@example.com', 'x', 'Bob', 'bob'), (3, 'bea@example.com', 'x', 'Bea', 'bea');
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (1, 3, 'accepted'), (3, 1, 'accepted');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db)
+	notified := func(userID int64) int {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM Notifications WHERE user_id = ? AND type = 'mention'", userID).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// only followers see this one, so bea is told and bob is not, though both are mentioned
+	body, contentType := multipartForm(t, map[string]string{"content": "hi @bob and @Bea", "privacy": "almost_private"})
+	if rr := serveRouter(router, adaSession, "POST", "/posts", body, contentType); rr.Code != http.StatusCreated {
+		t.Fatalf("posting: got %d %s", rr.Code, rr.Body.String())
+	}
+	var mentions int
+	db.QueryRow("SELECT COUNT(*) FROM Mentions").Scan(&mentions)
+	if mentions != 2 || notified(2) != 0 || notified(3) != 1 {
+		t.Errorf("got %d mentions and %d, %d notifications; want 2, 0 and 1", mentions, notified(2), notified(3))
+	}
+
+	body, contentType = multipartForm(t, map[string]string{"content": "@bob, this one is public", "privacy": "public"})
+	if rr := serveRouter(router, adaSession, "POST", "/posts", body, contentType); rr.Code != http.StatusCreated {
+		t.Fatalf("posting: got %d %s", rr.Code, rr.Body.String())
+	}
+	if notified(2) != 1 {
+		t.Errorf("bob was not told about the public post")
+	}
+
+	rr := serveRouter(router, adaSession, "GET", "/users/mentions?q=@b", nil, "")
+	var suggested struct {
+		Users []struct {
+			Nickname  string `json:"nickname"`
+			Following bool   `json:"following"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &suggested); rr.Code != http.StatusOK || err != nil || len(suggested.Users) != 2 ||
+		suggested.Users[0].Nickname != "bea" || !suggested.Users[0].Following || suggested.Users[1].Nickname != "bob" {
+		t.Errorf("autocomplete: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/users/mentions?q=b%25", nil, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"users":[]`) {
+		t.Errorf("a prefix that is no handle: got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestSearchPosts(t *testing.T) {
+	db := setupRouterTestDB(t)
+	verifyEmail(t, db, 1)
+	router := NewRouter(db)
+	if !sqlite.HasFTS5(db) {
+		if rr := serveRouter(router, adaSession, "GET", "/search/posts?q=go", nil, ""); rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"code":"search_unavailable"`) {
+			t.Errorf("without FTS5: got %d %s", rr.Code, rr.Body.String())
+		}
+		return
+	}
+
+	for _, content := range []string{"learning Go", "more go, and some sqlite", "nothing to find"} {
+		body, contentType := multipartForm(t, map[string]string{"content": content, "privacy": "public"})
+		if rr := serveRouter(router, adaSession, "POST", "/posts", body, contentType); rr.Code != http.StatusCreated {
+			t.Fatalf("posting %q: got %d %s", content, rr.Code, rr.Body.String())
+		}
+	}
+
+	type searchPage struct {
+		Results []struct {
+			Kind    string `json:"kind"`
+			Snippet string `json:"snippet"`
+		} `json:"results"`
+		NextCursor string `json:"next_cursor"`
+	}
+	var page searchPage
+	rr := serveRouter(router, adaSession, "GET", "/search/posts?q=GO&limit=1", nil, "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); rr.Code != http.StatusOK || err != nil || len(page.Results) != 1 || page.NextCursor == "" {
+		t.Fatalf("first page: got %d %s", rr.Code, rr.Body.String())
+	}
+	if page.Results[0].Kind != "post" || !strings.Contains(page.Results[0].Snippet, "<mark>") {
+		t.Errorf("first result: %+v", page.Results[0])
+	}
+	rr = serveRouter(router, adaSession, "GET", "/search/posts?q=go&limit=1&cursor="+page.NextCursor, nil, "")
+	var rest searchPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &rest); rr.Code != http.StatusOK || err != nil || len(rest.Results) != 1 || rest.NextCursor != "" {
+		t.Errorf("second page: got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// query syntax is ignored rather than breaking the search
+	rr = serveRouter(router, adaSession, "GET", `/search/posts?q=`+url.QueryEscape(`"sqlite*" (^`), nil, "")
+	var syntax searchPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &syntax); rr.Code != http.StatusOK || err != nil || len(syntax.Results) != 1 || syntax.Results[0].Snippet != "more go, and some <mark>sqlite</mark>" {
+		t.Errorf("a query with FTS5 syntax: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/search/posts?q=%3F%21", nil, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("a query without words: got %d", rr.Code)
+	}
+	if rr := serveRouter(router, adaSession, "GET", "/search/posts?q=go&cursor=x", nil, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("a bad cursor: got %d", rr.Code)
+	}
+}
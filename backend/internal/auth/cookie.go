@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+)
+
+// SessionCookieName is the cookie carrying the session ID.
+const SessionCookieName = "session_id"
+
+// SetSessionCookie issues the session cookie. expiresAt must be the session's
+// expiry in the database so the browser never outlives the server-side session.
+func SetSessionCookie(w http.ResponseWriter, sessionID string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false, // set true in production with HTTPS
+		Expires:  expiresAt,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie tells the browser to drop the session cookie.
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   false, // set true in production
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,107 @@
+// Package auth carries the authenticated caller of a request through its context.
+//
+// The auth middleware resolves the session once and stores an Identity;
+// handlers read it back through the accessor functions instead of touching
+// cookies or context keys themselves. The session cookie itself is only
+// written through SetSessionCookie and ClearSessionCookie.
+package auth
+
+import "context"
+
+// Site roles, from least to most privileged. Each role includes the ones before it.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var siteRoles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// ValidRole reports whether role is a site role.
+func ValidRole(role string) bool {
+	return RoleRank(role) >= 0
+}
+
+// RoleRank orders site roles by privilege; it is -1 for unknown roles.
+func RoleRank(role string) int {
+	for i, r := range siteRoles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// RolesFor returns role together with the roles it includes, for Identity.Roles.
+func RolesFor(role string) []string {
+	rank := RoleRank(role)
+	if rank < 0 {
+		return []string{RoleUser}
+	}
+	return append([]string(nil), siteRoles[:rank+1]...)
+}
+
+// Personal access token scopes. A write token can also read.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Identity is the authenticated caller of a request.
+// Callers authenticated with a personal access token have a TokenID and
+// Scope instead of a SessionID.
+type Identity struct {
+	UserID    int64
+	SessionID string
+	TokenID   int64
+	Scope     string
+	Roles     []string
+}
+
+// CanWrite reports whether the identity may change data. Sessions always can;
+// access tokens need the write scope.
+func (id Identity) CanWrite() bool {
+	return id.TokenID == 0 || id.Scope == ScopeWrite
+}
+
+// HasRole reports whether the identity holds role.
+func (id Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	if !ok || id.UserID == 0 {
+		return Identity{}, false
+	}
+	return id, true
+}
+
+// UserID returns the authenticated user's ID stored in ctx.
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := FromContext(ctx)
+	return id.UserID, ok
+}
+
+// SessionID returns the session the request was authenticated with.
+// It is empty for identities that were not resolved from a session.
+func SessionID(ctx context.Context) (string, bool) {
+	id, ok := FromContext(ctx)
+	if !ok || id.SessionID == "" {
+		return "", false
+	}
+	return id.SessionID, true
+}
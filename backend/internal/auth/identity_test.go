@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIdentityRoundTrip(t *testing.T) {
+	ctx := WithIdentity(context.Background(), Identity{UserID: 7, SessionID: "abc", Roles: []string{RoleUser}})
+
+	id, ok := FromContext(ctx)
+	if !ok || id.UserID != 7 || !id.HasRole(RoleUser) || id.HasRole("admin") {
+		t.Fatalf("unexpected identity %+v (ok=%v)", id, ok)
+	}
+	if uid, ok := UserID(ctx); !ok || uid != 7 {
+		t.Errorf("UserID = %d, %v; want 7, true", uid, ok)
+	}
+	if sid, ok := SessionID(ctx); !ok || sid != "abc" {
+		t.Errorf("SessionID = %q, %v; want abc, true", sid, ok)
+	}
+}
+
+func TestIdentityMissing(t *testing.T) {
+	for name, ctx := range map[string]context.Context{
+		"empty context": context.Background(),
+		"zero user":     WithIdentity(context.Background(), Identity{SessionID: "abc"}),
+	} {
+		if _, ok := UserID(ctx); ok {
+			t.Errorf("%s: UserID reported an identity", name)
+		}
+	}
+
+	ctx := WithIdentity(context.Background(), Identity{UserID: 7})
+	if _, ok := SessionID(ctx); ok {
+		t.Error("SessionID reported a session for an identity without one")
+	}
+}
+
+func TestRolesFor(t *testing.T) {
+	admin := Identity{UserID: 1, Roles: RolesFor(RoleAdmin)}
+	if !admin.HasRole(RoleUser) || !admin.HasRole(RoleModerator) || !admin.HasRole(RoleAdmin) {
+		t.Errorf("an admin should hold every role, got %v", admin.Roles)
+	}
+	moderator := Identity{UserID: 2, Roles: RolesFor(RoleModerator)}
+	if !moderator.HasRole(RoleModerator) || moderator.HasRole(RoleAdmin) {
+		t.Errorf("moderator roles: got %v", moderator.Roles)
+	}
+	if got := RolesFor("root"); len(got) != 1 || got[0] != RoleUser {
+		t.Errorf("unknown role: got %v, want only %q", got, RoleUser)
+	}
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewLogMailer(&buf, "no-reply@example.com").Send(Message{To: "ada@example.com", Subject: "Hello", Body: "Hi Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"From: no-reply@example.com", "To: ada@example.com", "Subject: Hello", "Hi Ada"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestFileMailerAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewFileMailer(path, "no-reply@example.com")
+
+	for _, subject := range []string{"first", "second"} {
+		if err := mailer.Send(Message{To: "ada@example.com", Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Subject: first") || !strings.Contains(string(data), "Subject: second") {
+		t.Errorf("expected both messages in the file, got:\n%s", data)
+	}
+}
@@ -0,0 +1,62 @@
+// Package mail delivers transactional email such as password reset links.
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// FromEnv returns an SMTP mailer when SMTP_HOST is set. Otherwise mail is
+// appended to MAIL_LOG_FILE, or written to stdout when that is unset too,
+// which is enough for local development.
+func FromEnv() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@social-network.local"
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := 587
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			if p, err := strconv.Atoi(value); err == nil {
+				port = p
+			} else {
+				log.Printf("ignoring invalid SMTP_PORT=%q", value)
+			}
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	}
+
+	if path := os.Getenv("MAIL_LOG_FILE"); path != "" {
+		return NewFileMailer(path, from)
+	}
+	return NewLogMailer(os.Stdout, from)
+}
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message) []byte {
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, msg.To, msg.Subject, msg.Body,
+	))
+}
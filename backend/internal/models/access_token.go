@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AccessToken is a personal access token for scripts and other non-browser
+// clients. The token itself is only shown once, when it is created.
+type AccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AdminUser is a user as shown to moderators and admins.
+type AdminUser struct {
+	ID              int64      `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       *string    `json:"first_name,omitempty"`
+	LastName        *string    `json:"last_name,omitempty"`
+	Nickname        *string    `json:"nickname,omitempty"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	DeleteAfter     *time.Time `json:"delete_after,omitempty"`
+}
+
+// PlatformStats are site-wide counts for the admin dashboard.
+type PlatformStats struct {
+	Users          int `json:"users"`
+	SuspendedUsers int `json:"suspended_users"`
+	PendingDeletes int `json:"pending_deletions"`
+	ActiveSessions int `json:"active_sessions"`
+	Posts          int `json:"posts"`
+	Comments       int `json:"comments"`
+	Groups         int `json:"groups"`
+	Messages       int `json:"messages"`
+}
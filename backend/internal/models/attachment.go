@@ -0,0 +1,29 @@
+package models
+
+// Attachment is one image in the gallery of a post or comment.
+type Attachment struct {
+	ID       int64  `json:"id"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	AltText  string `json:"alt_text"`
+	// Width and Height are in pixels, or 0 for formats whose size is not read.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// ImageUpload is an uploaded image with the alt text that describes it.
+type ImageUpload struct {
+	Data    []byte
+	AltText string
+}
+
+// AttachmentEdit changes the gallery of a post or comment being edited.
+type AttachmentEdit struct {
+	// Keep lists the existing attachments to retain, in their new order.
+	// A nil Keep leaves the gallery as it is; an empty one removes every image.
+	Keep []int64
+	// AltText replaces the alt text of kept attachments, by id.
+	AltText map[int64]string
+	// Add holds new images, shown after the kept ones.
+	Add []ImageUpload
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// BlockedUser is an entry of the list of users someone has blocked.
+type BlockedUser struct {
+	ID        int64     `json:"id"`
+	FirstName string    `json:"firstname"`
+	LastName  string    `json:"lastname"`
+	Nickname  string    `json:"nickname,omitempty"`
+	Avatar    string    `json:"avatar"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Data export statuses. An export moves from pending to running and ends up
+// ready or failed; a ready export expires once its archive is deleted.
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+// DataExport is a requested archive of everything stored about a user.
+type DataExport struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"-"`
+	Status      string     `json:"status"`
+	FileName    string     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// DataExportSection is one JSON file of an export: a named list of rows.
+type DataExportSection struct {
+	Name string
+	Rows []map[string]any
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// OIDCLoginState is an OpenID Connect login waiting for the provider to redirect back.
+type OIDCLoginState struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// ExternalIdentity links an account at an OpenID provider to a user.
+type ExternalIdentity struct {
+	UserID  int64
+	Issuer  string
+	Subject string
+	Email   string
+}
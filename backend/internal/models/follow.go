@@ -4,13 +4,27 @@ type Followee struct {
 	FolloweeId int `json:"followeeid"`
 }
 
+// FollowUser is an entry of a follower or followee list. The relationship
+// fields are relative to the user viewing the list.
 type FollowUser struct {
 	FirstName  string `json:"firstname"`
 	LastName   string `json:"lastname"`
+	Nickname   string `json:"nickname,omitempty"`
 	Avatar     string `json:"avatar"`
 	FollowerID int64  `json:"follower_id"`
+	Following  bool   `json:"following"`   // the viewer follows this user
+	Pending    bool   `json:"pending"`     // the viewer asked to follow this user
+	FollowsYou bool   `json:"follows_you"` // this user follows the viewer
 }
 
+// FollowListResponse is one page of a follower or followee list. NextCursor
+// is empty on the last page.
 type FollowListResponse struct {
-	Followers []FollowUser `json:"user"`
+	Followers  []FollowUser `json:"user"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// FollowCount answers a follower or followee list asked for its size only.
+type FollowCount struct {
+	Count int `json:"count"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LoginFailure is one row of the failed login audit log.
+type LoginFailure struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginThrottle counts recent failed logins for one account or client address.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
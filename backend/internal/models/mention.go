@@ -0,0 +1,13 @@
+package models
+
+// MentionCandidate is a user offered while typing an @mention. The
+// relationship fields are relative to the user typing.
+type MentionCandidate struct {
+	ID         int64  `json:"id"`
+	Nickname   string `json:"nickname"`
+	FirstName  string `json:"firstname"`
+	LastName   string `json:"lastname"`
+	Avatar     string `json:"avatar"`
+	Following  bool   `json:"following"`   // the typing user follows this user
+	FollowsYou bool   `json:"follows_you"` // this user follows the typing user
+}
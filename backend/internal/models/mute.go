@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MutedUser is an entry of the list of users someone has muted.
+type MutedUser struct {
+	ID        int64      `json:"id"`
+	FirstName string     `json:"firstname"`
+	LastName  string     `json:"lastname"`
+	Nickname  string     `json:"nickname,omitempty"`
+	Avatar    string     `json:"avatar"`
+	MutedAt   time.Time  `json:"muted_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// MutedKeyword is a word or phrase someone does not want to see in their feed or notifications.
+type MutedKeyword struct {
+	ID        int64     `json:"id"`
+	Keyword   string    `json:"keyword"`
+	CreatedAt time.Time `json:"created_at"`
+}
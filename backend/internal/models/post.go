@@ -3,16 +3,64 @@ package models
 import "time"
 
 type Post struct {
-	ID            int64      `json:"id"`
-	UserID        int64      `json:"user_id"`
-	Content       string     `json:"content"`
-	Image         string     `json:"image,omitempty"`
-	Privacy       string     `json:"privacy"` // "public", "private", "followers"
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
-	IsEdited      bool       `json:"is_edited"`
-	Author        User       `json:"author"`
-	LikesCount    int        `json:"likes_count"`
-	DislikesCount int        `json:"dislikes_count"`
-	UserReaction  *string    `json:"user_reaction,omitempty"`
+	ID            int64        `json:"id"`
+	UserID        int64        `json:"user_id"`
+	Content       string       `json:"content"`
+	Image         string       `json:"image,omitempty"` // the first attachment, for older clients
+	Attachments   []Attachment `json:"attachments,omitempty"`
+	Privacy       string       `json:"privacy"` // "public", "private", "followers"
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at,omitempty"`
+	IsEdited      bool         `json:"is_edited"`
+	Author        User         `json:"author"`
+	LikesCount    int          `json:"likes_count"`
+	DislikesCount int          `json:"dislikes_count"`
+	UserReaction  *string      `json:"user_reaction,omitempty"`
+}
+
+// FeedCursor is a position in the home feed, which runs newest first by
+// (created_at, id). CreatedAt is kept as stored so that comparisons match
+// the feed's own ordering.
+type FeedCursor struct {
+	CreatedAt string
+	ID        int64
+}
+
+// FeedPage is one page of the home feed in cursor mode.
+type FeedPage struct {
+	Posts []*Post `json:"posts"`
+	// NextCursor asks for older posts with before=; empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// PrevCursor asks for newer posts with after=, or counts them with GET /posts/new.
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// HasNewer is set on after= pages that stop short of the newest post.
+	HasNewer bool `json:"has_newer,omitempty"`
+}
+
+// TrendingTag is a hashtag ranked by its recent use in public posts and comments.
+type TrendingTag struct {
+	Tag string `json:"tag"`
+	// Uses counts posts and comments using the tag within the window.
+	Uses int `json:"uses"`
+	// Users counts the distinct authors among them.
+	Users int `json:"users"`
+}
+
+// SearchResult is a post or comment matching a search. Snippet is the part
+// of its content that matched, HTML-escaped, with the matching words wrapped
+// in <mark> tags.
+type SearchResult struct {
+	Kind      string    `json:"kind"` // "post" or "comment"
+	PostID    int64     `json:"post_id"`
+	CommentID int64     `json:"comment_id,omitempty"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    User      `json:"author"`
+}
+
+// SearchPage is one page of search results, best matches first. NextCursor
+// is empty on the last page.
+type SearchPage struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
@@ -26,7 +26,8 @@ type ProfileDetails struct {
 }
 
 type Photo struct {
-	Image string `json:"image"`
+	Image  string `json:"image"`
+	PostID int64  `json:"post_id,omitempty"` // Post the photo belongs to, or the post commented on
 }
 
 type ProfileResponse struct {
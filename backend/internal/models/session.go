@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Session is a logged-in browser session. ExpiresAt is the single source of
+// truth for its lifetime; the session cookie is always issued with the same expiry.
+//
+// ID is the cookie secret and is never serialized; clients refer to a session
+// by its PublicID instead.
+type Session struct {
+	ID         string    `json:"-"`
+	PublicID   string    `json:"id"`
+	UserID     int64     `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionClient describes the device a session is opened from.
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
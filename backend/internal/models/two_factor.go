@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TwoFactor is a user's TOTP state. Secret holds the encrypted seed as stored.
+type TwoFactor struct {
+	UserID    int64
+	Email     string
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  int64
+}
+
+// Enabled reports whether two-factor authentication is active for logins.
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TwoFactorEnrollment is shown to the user once, when they start enrolling.
+type TwoFactorEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
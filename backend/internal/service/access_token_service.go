@@ -0,0 +1,117 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// Errors returned by personal access tokens.
+var (
+	ErrInvalidAccessToken     = errors.New("invalid or expired access token")
+	ErrAccessTokenNotFound    = errors.New("access token not found")
+	ErrInvalidAccessTokenName = errors.New("token name must be 1 to 100 characters")
+	ErrInvalidAccessScope     = errors.New(`scope must be "read" or "write"`)
+)
+
+// AccessTokenPrefix starts every personal access token, so leaked tokens are easy to recognise.
+const AccessTokenPrefix = "snpat_"
+
+// accessTokenTouchInterval limits how often last_used_at is written for a busy token.
+const accessTokenTouchInterval = time.Minute
+
+type accessTokenService struct {
+	store  store.AccessTokenStore
+	closer AccessTokenCloser
+	now    func() time.Time
+}
+
+// NewAccessTokenService creates an access token service. closer may be nil
+// when no live connections need to be dropped as tokens are revoked.
+func NewAccessTokenService(tokens store.AccessTokenStore, closer AccessTokenCloser) AccessTokenService {
+	return &accessTokenService{
+		store:  tokens,
+		closer: closer,
+		now:    time.Now,
+	}
+}
+
+// CreateToken issues a token with the given scope. A zero ttl creates a token
+// that never expires. The returned string is the only copy of the token.
+func (s *accessTokenService) CreateToken(userID int64, name, scope string, ttl time.Duration) (string, *models.AccessToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", nil, ErrInvalidAccessTokenName
+	}
+	if scope != auth.ScopeRead && scope != auth.ScopeWrite {
+		return "", nil, ErrInvalidAccessScope
+	}
+
+	secret, hash, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := AccessTokenPrefix + secret
+
+	now := s.now()
+	accessToken := &models.AccessToken{UserID: userID, Name: name, Scope: scope, CreatedAt: now}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		accessToken.ExpiresAt = &expiresAt
+	}
+	if err := s.store.CreateAccessToken(accessToken, hash); err != nil {
+		return "", nil, err
+	}
+	return token, accessToken, nil
+}
+
+// ValidateToken returns the live token matching a bearer credential.
+func (s *accessTokenService) ValidateToken(token string) (*models.AccessToken, error) {
+	secret, ok := strings.CutPrefix(token, AccessTokenPrefix)
+	if !ok || secret == "" {
+		return nil, ErrInvalidAccessToken
+	}
+	accessToken, err := s.store.GetAccessTokenByHash(hashToken(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up access token: %w", err)
+	}
+
+	now := s.now()
+	if accessToken.ExpiresAt != nil && !now.Before(*accessToken.ExpiresAt) {
+		return nil, ErrInvalidAccessToken
+	}
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.store.TouchAccessToken(accessToken.ID, now); err != nil {
+			fmt.Println("error recording access token use:", err)
+		}
+	}
+	return accessToken, nil
+}
+
+func (s *accessTokenService) ListTokens(userID int64) ([]*models.AccessToken, error) {
+	return s.store.ListUserAccessTokens(userID)
+}
+
+// RevokeToken deletes one of the user's tokens and drops connections opened with it.
+func (s *accessTokenService) RevokeToken(userID, tokenID int64) error {
+	deleted, err := s.store.DeleteUserAccessToken(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAccessTokenNotFound
+	}
+	if s.closer != nil {
+		s.closer.CloseAccessTokens(tokenID)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeAccessTokenStore struct {
+	tokens map[string]*models.AccessToken // by hash
+	nextID int64
+}
+
+func (f *fakeAccessTokenStore) CreateAccessToken(token *models.AccessToken, tokenHash string) error {
+	f.nextID++
+	token.ID = f.nextID
+	stored := *token
+	f.tokens[tokenHash] = &stored
+	return nil
+}
+
+func (f *fakeAccessTokenStore) GetAccessTokenByHash(tokenHash string) (*models.AccessToken, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (f *fakeAccessTokenStore) ListUserAccessTokens(userID int64) ([]*models.AccessToken, error) {
+	var tokens []*models.AccessToken
+	for _, token := range f.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (f *fakeAccessTokenStore) TouchAccessToken(tokenID int64, lastUsedAt time.Time) error {
+	for _, token := range f.tokens {
+		if token.ID == tokenID {
+			token.LastUsedAt = &lastUsedAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeAccessTokenStore) DeleteUserAccessToken(userID, tokenID int64) (bool, error) {
+	for hash, token := range f.tokens {
+		if token.ID == tokenID && token.UserID == userID {
+			delete(f.tokens, hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeAccessTokenCloser struct {
+	closed []int64
+}
+
+func (f *fakeAccessTokenCloser) CloseAccessTokens(tokenIDs ...int64) {
+	f.closed = append(f.closed, tokenIDs...)
+}
+
+func newAccessTokenFixture() (*accessTokenService, *fakeAccessTokenStore, *fakeAccessTokenCloser, *time.Time) {
+	tokens := &fakeAccessTokenStore{tokens: map[string]*models.AccessToken{}}
+	closer := &fakeAccessTokenCloser{}
+	svc := NewAccessTokenService(tokens, closer).(*accessTokenService)
+
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+	return svc, tokens, closer, &now
+}
+
+func TestCreateAccessToken(t *testing.T) {
+	svc, tokens, _, _ := newAccessTokenFixture()
+
+	token, accessToken, err := svc.CreateToken(7, "  deploy script ", "write", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, AccessTokenPrefix) || accessToken.Name != "deploy script" || accessToken.ExpiresAt != nil {
+		t.Errorf("unexpected token %q %+v", token, accessToken)
+	}
+	for hash := range tokens.tokens {
+		if hash == strings.TrimPrefix(token, AccessTokenPrefix) {
+			t.Error("the token must only be stored hashed")
+		}
+	}
+
+	if _, _, err := svc.CreateToken(7, "", "write", 0); !errors.Is(err, ErrInvalidAccessTokenName) {
+		t.Errorf("empty name: got %v", err)
+	}
+	if _, _, err := svc.CreateToken(7, "x", "admin", 0); !errors.Is(err, ErrInvalidAccessScope) {
+		t.Errorf("unknown scope: got %v", err)
+	}
+}
+
+func TestValidateAccessToken(t *testing.T) {
+	svc, _, _, now := newAccessTokenFixture()
+	token, created, _ := svc.CreateToken(7, "ci", "read", time.Hour)
+
+	got, err := svc.ValidateToken(token)
+	if err != nil || got.ID != created.ID || got.UserID != 7 || got.Scope != "read" {
+		t.Fatalf("ValidateToken() = %+v, %v", got, err)
+	}
+	if got, _ := svc.ValidateToken(token); got.LastUsedAt == nil || !got.LastUsedAt.Equal(sessionEpoch) {
+		t.Errorf("expected last use to be recorded, got %v", got.LastUsedAt)
+	}
+
+	for _, bad := range []string{"", "snpat_", token[len(AccessTokenPrefix):], token + "x"} {
+		if _, err := svc.ValidateToken(bad); !errors.Is(err, ErrInvalidAccessToken) {
+			t.Errorf("ValidateToken(%q): got %v", bad, err)
+		}
+	}
+
+	*now = now.Add(time.Hour)
+	if _, err := svc.ValidateToken(token); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Errorf("expired token: got %v", err)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	svc, _, closer, _ := newAccessTokenFixture()
+	token, created, _ := svc.CreateToken(7, "ci", "write", 0)
+
+	if err := svc.RevokeToken(8, created.ID); !errors.Is(err, ErrAccessTokenNotFound) {
+		t.Fatalf("revoking another user's token: got %v", err)
+	}
+	if err := svc.RevokeToken(7, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(token); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Errorf("revoked token: got %v", err)
+	}
+	if len(closer.closed) != 1 || closer.closed[0] != created.ID {
+		t.Errorf("expected connections of token %d to be closed, got %v", created.ID, closer.closed)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// ErrAccountNotFound is returned when deleting an account that no longer exists.
+var ErrAccountNotFound = errors.New("account not found")
+
+// AccountDeletionConfig controls self-service account deletion.
+type AccountDeletionConfig struct {
+	// GracePeriod is how long a user has to change their mind by logging in again.
+	GracePeriod time.Duration
+	// PurgeInterval is how often accounts past their grace period are purged.
+	PurgeInterval time.Duration
+	// AttachmentsDir holds the uploaded files that image and avatar paths point into.
+	AttachmentsDir string
+}
+
+// DefaultAccountDeletionConfig returns the deletion settings used when none are configured.
+func DefaultAccountDeletionConfig() AccountDeletionConfig {
+	return AccountDeletionConfig{
+		GracePeriod:    14 * 24 * time.Hour,
+		PurgeInterval:  time.Hour,
+		AttachmentsDir: "attachments",
+	}
+}
+
+// AccountDeletionConfigFromEnv overrides the defaults with
+// ACCOUNT_DELETION_GRACE_PERIOD and ACCOUNT_PURGE_INTERVAL, each given as a
+// Go duration such as "336h".
+func AccountDeletionConfigFromEnv() AccountDeletionConfig {
+	config := DefaultAccountDeletionConfig()
+	for name, target := range map[string]*time.Duration{
+		"ACCOUNT_DELETION_GRACE_PERIOD": &config.GracePeriod,
+		"ACCOUNT_PURGE_INTERVAL":        &config.PurgeInterval,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Printf("ignoring invalid %s=%q", name, value)
+			continue
+		}
+		*target = d
+	}
+	return config
+}
+
+type accountDeletionService struct {
+	store    store.AccountDeletionStore
+	sessions SessionService
+	tokens   AccessTokenService
+	config   AccountDeletionConfig
+	now      func() time.Time
+}
+
+// NewAccountDeletionService creates the account deletion service. tokens may
+// be nil when personal access tokens are not in use.
+func NewAccountDeletionService(deletions store.AccountDeletionStore, sessions SessionService, tokens AccessTokenService, config AccountDeletionConfig) AccountDeletionService {
+	return &accountDeletionService{
+		store:    deletions,
+		sessions: sessions,
+		tokens:   tokens,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// ScheduleDeletion marks the account for deletion after the grace period and
+// signs it out everywhere, so that only a fresh login can cancel it.
+func (s *accountDeletionService) ScheduleDeletion(userID int64) (time.Time, error) {
+	deleteAfter := s.now().Add(s.config.GracePeriod).UTC()
+	if err := s.store.ScheduleDeletion(userID, deleteAfter); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrAccountNotFound
+		}
+		return time.Time{}, err
+	}
+	if err := signOutEverywhere(s.sessions, s.tokens, userID); err != nil {
+		return time.Time{}, err
+	}
+	return deleteAfter, nil
+}
+
+func (s *accountDeletionService) CancelDeletion(userID int64) (bool, error) {
+	return s.store.CancelDeletion(userID)
+}
+
+// PurgeDueAccounts deletes every account whose grace period is over, with all
+// of its content and uploaded files. It returns how many accounts were purged.
+func (s *accountDeletionService) PurgeDueAccounts() (int, error) {
+	due, err := s.store.ListDueDeletions(s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range due {
+		if err := signOutEverywhere(s.sessions, s.tokens, userID); err != nil {
+			return purged, err
+		}
+		files, err := s.store.PurgeUser(userID, GroupRoleAdmin)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge user %d: %w", userID, err)
+		}
+		purged++
+		s.removeFiles(files)
+	}
+	return purged, nil
+}
+
+// removeFiles deletes attachments of a purged account. Failures are only
+// logged since the rows pointing at them are already gone.
+func (s *accountDeletionService) removeFiles(paths []string) {
+	for _, p := range paths {
+		name, ok := attachmentPath(s.config.AttachmentsDir, p)
+		if !ok {
+			continue
+		}
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to remove attachment %s: %v", name, err)
+		}
+	}
+}
+
+// attachmentPath resolves a stored image path inside dir. Paths are stored
+// relative to it, though some older rows kept the directory prefix.
+func attachmentPath(dir, stored string) (string, bool) {
+	clean := filepath.Clean(filepath.FromSlash(stored))
+	clean = strings.TrimPrefix(clean, filepath.Clean(dir)+string(filepath.Separator))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(dir, clean), true
+}
+
+// RunPurger purges due accounts every PurgeInterval until ctx is done.
+func (s *accountDeletionService) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.PurgeDueAccounts(); err != nil {
+				log.Println(err)
+			} else if n > 0 {
+				log.Printf("purged %d deleted accounts", n)
+			}
+		}
+	}
+}
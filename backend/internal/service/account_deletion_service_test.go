@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeAccountDeletionStore struct {
+	deleteAfter map[int64]time.Time
+	files       map[int64][]string
+	purged      []int64
+}
+
+func (f *fakeAccountDeletionStore) ScheduleDeletion(userID int64, deleteAfter time.Time) error {
+	f.deleteAfter[userID] = deleteAfter
+	return nil
+}
+
+func (f *fakeAccountDeletionStore) CancelDeletion(userID int64) (bool, error) {
+	_, ok := f.deleteAfter[userID]
+	delete(f.deleteAfter, userID)
+	return ok, nil
+}
+
+func (f *fakeAccountDeletionStore) ListDueDeletions(now time.Time) ([]int64, error) {
+	var ids []int64
+	for id, at := range f.deleteAfter {
+		if !at.After(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeAccountDeletionStore) PurgeUser(userID int64, successorRole string) ([]string, error) {
+	delete(f.deleteAfter, userID)
+	f.purged = append(f.purged, userID)
+	return f.files[userID], nil
+}
+
+func TestAccountDeletion(t *testing.T) {
+	sessions, sessionStore, sessionCloser, _ := newSessionFixture()
+	tokens, tokenStore, tokenCloser, _ := newAccessTokenFixture()
+	deletions := &fakeAccountDeletionStore{deleteAfter: map[int64]time.Time{}, files: map[int64][]string{}}
+	dir := t.TempDir()
+	svc := NewAccountDeletionService(deletions, sessions, tokens, AccountDeletionConfig{
+		GracePeriod:    24 * time.Hour,
+		PurgeInterval:  time.Hour,
+		AttachmentsDir: dir,
+	}).(*accountDeletionService)
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+
+	_, _ = sessions.CreateSession(7, models.SessionClient{})
+	_, _ = sessions.CreateSession(8, models.SessionClient{})
+	_, _, _ = tokens.CreateToken(7, "script", "write", 0)
+
+	deleteAfter, err := svc.ScheduleDeletion(7)
+	if err != nil || !deleteAfter.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("got %v, %v", deleteAfter, err)
+	}
+	if len(sessionStore.sessions) != 1 || len(sessionCloser.closed) != 1 {
+		t.Errorf("user 7 should be signed out and user 8 left alone: %d sessions, closed %v", len(sessionStore.sessions), sessionCloser.closed)
+	}
+	if len(tokenStore.tokens) != 0 || len(tokenCloser.closed) != 1 {
+		t.Errorf("access tokens should be revoked: %d left, closed %v", len(tokenStore.tokens), tokenCloser.closed)
+	}
+
+	// logging in again cancels, so the grace period passing purges nothing
+	if cancelled, err := svc.CancelDeletion(7); err != nil || !cancelled {
+		t.Fatalf("cancel: got %v, %v", cancelled, err)
+	}
+	now = now.Add(48 * time.Hour)
+	if n, err := svc.PurgeDueAccounts(); err != nil || n != 0 {
+		t.Fatalf("purge after cancelling: got %d, %v", n, err)
+	}
+
+	if _, err := svc.ScheduleDeletion(7); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := svc.PurgeDueAccounts(); n != 0 {
+		t.Fatalf("purged %d accounts before the grace period ended", n)
+	}
+
+	for _, name := range []string{"avatar.png", "posts/photo.jpg", "keep.png"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "outside.png")
+	_ = os.WriteFile(outside, []byte("x"), 0o644)
+	deletions.files[7] = []string{"avatar.png", "posts/photo.jpg", "no profile photo", "../" + filepath.Base(outside), outside}
+
+	now = now.Add(24 * time.Hour)
+	if n, err := svc.PurgeDueAccounts(); err != nil || n != 1 || deletions.purged[0] != 7 {
+		t.Fatalf("purge: got %d, %v (purged %v)", n, err, deletions.purged)
+	}
+	for name, want := range map[string]bool{"avatar.png": false, "posts/photo.jpg": false, "keep.png": true} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if exists := !errors.Is(err, os.ErrNotExist); exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("a path outside the attachments directory was removed: %v", err)
+	}
+}
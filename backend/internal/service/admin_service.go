@@ -0,0 +1,163 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+var (
+	// ErrUserNotFound is returned when an admin action targets a user that does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidRole is returned for a role that is not a site role.
+	ErrInvalidRole = errors.New("role must be user, moderator or admin")
+	// ErrInsufficientRole is returned when acting on a user whose role is not below the actor's.
+	ErrInsufficientRole = errors.New("you can only manage users with a lower role than yours")
+	// ErrOwnRole is returned when users try to change their own role.
+	ErrOwnRole = errors.New("you cannot change your own role")
+	// ErrAdminExists is returned when bootstrapping an admin on a site that already has one.
+	ErrAdminExists = errors.New("an admin already exists; use the admin API to grant roles")
+	// ErrAccountSuspended is returned when a suspended user tries to log in.
+	ErrAccountSuspended = errors.New("account suspended")
+)
+
+type adminService struct {
+	store    store.AdminStore
+	sessions SessionService
+	tokens   AccessTokenService
+	now      func() time.Time
+}
+
+// NewAdminService creates the service behind the admin API. tokens may be nil
+// when personal access tokens are not in use.
+func NewAdminService(adminStore store.AdminStore, sessions SessionService, tokens AccessTokenService) AdminService {
+	return &adminService{
+		store:    adminStore,
+		sessions: sessions,
+		tokens:   tokens,
+		now:      time.Now,
+	}
+}
+
+func (s *adminService) GetRole(userID int64) (string, error) {
+	role, _, err := s.store.GetAccountStatus(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	return role, err
+}
+
+func (s *adminService) IsSuspended(userID int64) (bool, error) {
+	_, suspended, err := s.store.GetAccountStatus(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrUserNotFound
+	}
+	return suspended, err
+}
+
+// ListUsers returns a page of users, optionally filtered by email or name.
+func (s *adminService) ListUsers(query string, limit, offset int) ([]*models.AdminUser, error) {
+	return s.store.ListUsers(query, limit, offset)
+}
+
+// SetSuspended suspends or reinstates a user. Suspension signs them out
+// everywhere and revokes their access tokens.
+func (s *adminService) SetSuspended(actorID, userID int64, suspended bool) error {
+	if err := s.checkOutranks(actorID, userID); err != nil {
+		return err
+	}
+
+	var at *time.Time
+	if suspended {
+		now := s.now()
+		at = &now
+	}
+	if err := s.store.SetSuspended(userID, at); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if suspended {
+		return signOutEverywhere(s.sessions, s.tokens, userID)
+	}
+	return nil
+}
+
+// ForceLogout ends all of a user's sessions and returns how many there were.
+func (s *adminService) ForceLogout(actorID, userID int64) (int, error) {
+	if err := s.checkOutranks(actorID, userID); err != nil {
+		return 0, err
+	}
+	return s.sessions.RevokeAllSessions(userID)
+}
+
+// SetRole changes a user's site role; only admins can. Admins cannot change
+// their own role, so a site always keeps the admin who made the change.
+func (s *adminService) SetRole(actorID, userID int64, role string) error {
+	if !auth.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if actorID == userID {
+		return ErrOwnRole
+	}
+	actorRole, _, err := s.store.GetAccountStatus(actorID)
+	if err != nil {
+		return err
+	}
+	if actorRole != auth.RoleAdmin {
+		return ErrInsufficientRole
+	}
+	if err := s.store.SetUserRole(userID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// BootstrapAdmin makes the user with email the first admin. It refuses once
+// any admin exists, after which roles are granted through the admin API.
+func (s *adminService) BootstrapAdmin(email string) (int64, error) {
+	admins, err := s.store.CountUsersWithRole(auth.RoleAdmin)
+	if err != nil {
+		return 0, err
+	}
+	if admins > 0 {
+		return 0, ErrAdminExists
+	}
+	userID, err := s.store.SetUserRoleByEmail(email, auth.RoleAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserNotFound
+	}
+	return userID, err
+}
+
+func (s *adminService) Stats() (*models.PlatformStats, error) {
+	return s.store.GetPlatformStats(s.now())
+}
+
+// checkOutranks allows actors to manage only users with a lower role, so
+// moderators cannot act on each other and nobody acts on themselves.
+func (s *adminService) checkOutranks(actorID, userID int64) error {
+	actorRole, _, err := s.store.GetAccountStatus(actorID)
+	if err != nil {
+		return err
+	}
+	targetRole, _, err := s.store.GetAccountStatus(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if auth.RoleRank(targetRole) >= auth.RoleRank(actorRole) {
+		return ErrInsufficientRole
+	}
+	return nil
+}
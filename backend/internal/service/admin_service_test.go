@@ -0,0 +1,175 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeAdminUser struct {
+	email       string
+	role        string
+	suspendedAt *time.Time
+}
+
+type fakeAdminStore struct {
+	users map[int64]*fakeAdminUser
+}
+
+func (f *fakeAdminStore) GetAccountStatus(userID int64) (string, bool, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return "", false, sql.ErrNoRows
+	}
+	return user.role, user.suspendedAt != nil, nil
+}
+
+func (f *fakeAdminStore) SetUserRole(userID int64, role string) error {
+	user, ok := f.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	user.role = role
+	return nil
+}
+
+func (f *fakeAdminStore) SetUserRoleByEmail(email, role string) (int64, error) {
+	for id, user := range f.users {
+		if user.email == email {
+			user.role = role
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (f *fakeAdminStore) SetSuspended(userID int64, at *time.Time) error {
+	user, ok := f.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	user.suspendedAt = at
+	return nil
+}
+
+func (f *fakeAdminStore) CountUsersWithRole(role string) (int, error) {
+	n := 0
+	for _, user := range f.users {
+		if user.role == role {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeAdminStore) ListUsers(query string, limit, offset int) ([]*models.AdminUser, error) {
+	return []*models.AdminUser{}, nil
+}
+
+func (f *fakeAdminStore) GetPlatformStats(now time.Time) (*models.PlatformStats, error) {
+	return &models.PlatformStats{Users: len(f.users)}, nil
+}
+
+func newAdminFixture() (*adminService, *fakeAdminStore, *sessionService, *fakeSessionStore, *fakeAccessTokenStore) {
+	sessions, sessionStore, _, _ := newSessionFixture()
+	tokens, tokenStore, _, _ := newAccessTokenFixture()
+	adminStore := &fakeAdminStore{users: map[int64]*fakeAdminUser{
+		1: {email: "admin@example.com", role: "admin"},
+		2: {email: "mod@example.com", role: "moderator"},
+		3: {email: "mod2@example.com", role: "moderator"},
+		4: {email: "user@example.com", role: "user"},
+	}}
+	svc := NewAdminService(adminStore, sessions, tokens).(*adminService)
+	svc.now = func() time.Time { return sessionEpoch }
+	return svc, adminStore, sessions, sessionStore, tokenStore
+}
+
+func TestAdminSuspension(t *testing.T) {
+	svc, adminStore, sessions, sessionStore, tokenStore := newAdminFixture()
+	_, _ = sessions.CreateSession(4, models.SessionClient{})
+	_, _ = sessions.CreateSession(2, models.SessionClient{})
+	_, _, _ = svc.tokens.CreateToken(4, "script", "write", 0)
+
+	if err := svc.SetSuspended(2, 4, true); err != nil {
+		t.Fatalf("moderator suspending a user: %v", err)
+	}
+	if at := adminStore.users[4].suspendedAt; at == nil || !at.Equal(sessionEpoch) {
+		t.Errorf("suspended_at = %v, want %v", at, sessionEpoch)
+	}
+	if len(sessionStore.sessions) != 1 || len(tokenStore.tokens) != 0 {
+		t.Errorf("suspension should sign the user out: %d sessions, %d tokens left", len(sessionStore.sessions), len(tokenStore.tokens))
+	}
+	if suspended, err := svc.IsSuspended(4); err != nil || !suspended {
+		t.Errorf("IsSuspended = %v, %v", suspended, err)
+	}
+
+	if err := svc.SetSuspended(2, 4, false); err != nil || adminStore.users[4].suspendedAt != nil {
+		t.Errorf("unsuspend: %v, suspended_at %v", err, adminStore.users[4].suspendedAt)
+	}
+
+	// moderators cannot act on their peers, themselves or admins
+	for _, target := range []int64{3, 2, 1} {
+		if err := svc.SetSuspended(2, target, true); !errors.Is(err, ErrInsufficientRole) {
+			t.Errorf("moderator suspending %d: got %v, want ErrInsufficientRole", target, err)
+		}
+	}
+	if err := svc.SetSuspended(1, 2, true); err != nil {
+		t.Errorf("admin suspending a moderator: %v", err)
+	}
+	if err := svc.SetSuspended(1, 99, true); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestAdminForceLogout(t *testing.T) {
+	svc, _, sessions, sessionStore, _ := newAdminFixture()
+	_, _ = sessions.CreateSession(4, models.SessionClient{})
+	_, _ = sessions.CreateSession(4, models.SessionClient{})
+
+	if _, err := svc.ForceLogout(4, 2); !errors.Is(err, ErrInsufficientRole) {
+		t.Errorf("user signing out a moderator: got %v", err)
+	}
+	n, err := svc.ForceLogout(2, 4)
+	if err != nil || n != 2 || len(sessionStore.sessions) != 0 {
+		t.Errorf("got %d, %v with %d sessions left", n, err, len(sessionStore.sessions))
+	}
+}
+
+func TestAdminSetRole(t *testing.T) {
+	svc, adminStore, _, _, _ := newAdminFixture()
+
+	if err := svc.SetRole(1, 4, "moderator"); err != nil || adminStore.users[4].role != "moderator" {
+		t.Fatalf("promote: %v, role %q", err, adminStore.users[4].role)
+	}
+	if err := svc.SetRole(1, 4, "owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("invalid role: got %v", err)
+	}
+	if err := svc.SetRole(1, 1, "user"); !errors.Is(err, ErrOwnRole) {
+		t.Errorf("admin demoting themselves: got %v", err)
+	}
+	if err := svc.SetRole(2, 3, "user"); !errors.Is(err, ErrInsufficientRole) {
+		t.Errorf("moderator changing roles: got %v", err)
+	}
+	if err := svc.SetRole(1, 99, "user"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: got %v", err)
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	svc, adminStore, _, _, _ := newAdminFixture()
+	if _, err := svc.BootstrapAdmin("user@example.com"); !errors.Is(err, ErrAdminExists) {
+		t.Fatalf("with an admin already present: got %v", err)
+	}
+
+	adminStore.users[1].role = "user"
+	if _, err := svc.BootstrapAdmin("nobody@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown email: got %v", err)
+	}
+	id, err := svc.BootstrapAdmin("user@example.com")
+	if err != nil || id != 4 || adminStore.users[4].role != "admin" {
+		t.Errorf("got %d, %v, role %q", id, err, adminStore.users[4].role)
+	}
+}
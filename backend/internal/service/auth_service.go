@@ -1,90 +1,341 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/store"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthService handles the business logic for authentication.
 type AuthService struct {
 	AuthStore *store.AuthStore
+	Sessions  SessionService
+	// EmailVerification is told about new and changed email addresses; nil disables verification mail.
+	EmailVerification EmailVerificationService
+	// Passwords applies the configured password policy and bcrypt cost.
+	Passwords *utils.PasswordManager
+	// TwoFactor adds a second login step for users who enabled it; nil disables two-factor logins.
+	TwoFactor TwoFactorService
+	// Throttle slows down and locks out repeated failed logins; nil disables it.
+	Throttle LoginThrottleService
+	// AccountDeletion has pending deletions cancelled by logging in; nil disables it.
+	AccountDeletion AccountDeletionService
+	// Suspensions refuses logins to suspended accounts; nil disables the check.
+	Suspensions SuspensionChecker
+	// Handles reserves the old handles of renamed users; nil leaves uniqueness to the database.
+	Handles HandleService
 }
 
 const (
-	EXPIRED_SESSION  = "Session expired"
-	INVALID_PASSWORD = "Invalid password"
-	INVALID_EMAIL    = "User does not exist"
+	EXPIRED_SESSION     = "Session expired"
+	INVALID_CREDENTIALS = "Invalid email or password"
+)
+
+var (
+	// ErrInvalidCredentials is returned for a failed login, without saying whether the email exists.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrIncorrectPassword is returned when a password change is attempted with the wrong current password.
+	ErrIncorrectPassword = errors.New("current password is incorrect")
 )
 
 // NewAuthService creates a new AuthService.
-func NewAuthService(as *store.AuthStore) *AuthService {
-	return &AuthService{AuthStore: as}
+func NewAuthService(as *store.AuthStore, sessions SessionService) *AuthService {
+	return &AuthService{
+		AuthStore: as,
+		Sessions:  sessions,
+		Passwords: utils.NewPasswordManager(utils.PasswordConfigFromEnv()),
+	}
 }
 
 // AuthenticateUser authenticates a user by email and password.
-func (s *AuthService) AuthenticateUser(email, password string) (*models.User, string, error) {
+// When the user has two-factor authentication enabled no session is created:
+// the returned string is a short-lived 2FA pending token and the error is
+// ErrTwoFactorRequired. The login is finished by CompleteTwoFactorLogin.
+//
+// An unknown email and a wrong password both return INVALID_CREDENTIALS and
+// ErrInvalidCredentials. While the account or client address is throttled
+// the error is a *LoginThrottledError and the password is not checked.
+func (s *AuthService) AuthenticateUser(email, password string, client models.SessionClient) (*models.User, string, error) {
+	if s.Throttle != nil {
+		if err := s.Throttle.Check(email, client); err != nil {
+			return nil, "", err
+		}
+	}
+
 	user, err := s.AuthStore.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// hash anyway so unknown emails take as long to reject as wrong passwords
+		s.Passwords.ComparePassword(s.dummyPasswordHash(), password)
+		s.recordLoginFailure(email, 0, client)
+		return nil, INVALID_CREDENTIALS, ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, INVALID_EMAIL, err
+		return nil, EXPIRED_SESSION, err
 	}
 
-	fmt.Println("User found:", user)
-
-	passwordManager := utils.NewPasswordManager(utils.PasswordConfig{})
-	if err := passwordManager.ComparePassword(user.Password, password); err != nil {
+	if err := s.Passwords.ComparePassword(user.Password, password); err != nil {
 		fmt.Println("Password mismatch for user:", user.Email)
-		return nil, INVALID_PASSWORD, err
+		s.recordLoginFailure(email, user.ID, client)
+		return nil, INVALID_CREDENTIALS, ErrInvalidCredentials
 	}
 
-	sessionID := uuid.New().String()
-	expiresAt := time.Now().Add(24 * time.Hour)
-
-	if err := s.AuthStore.CreateSession(user.ID, sessionID, expiresAt); err != nil {
+	session, pending, err := s.LoginUser(user.ID, client)
+	if errors.Is(err, ErrTwoFactorRequired) {
+		// failures are forgotten once the second factor checks out too
+		return user, pending, err
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		return nil, "", err
+	}
+	if err != nil {
 		fmt.Println("Failed to create session:", err)
 		return nil, EXPIRED_SESSION, err
 	}
 
-	return user, sessionID, nil
+	s.recordLoginSuccess(email)
+	return user, session.ID, nil
+}
+
+// LoginUser signs in a user whose first factor has already been checked,
+// by password or by an OpenID provider. Users with two-factor authentication
+// get a 2FA pending token and ErrTwoFactorRequired instead of a session.
+// Suspended users get ErrAccountSuspended.
+func (s *AuthService) LoginUser(userID int64, client models.SessionClient) (*models.Session, string, error) {
+	if err := s.checkSuspended(userID); err != nil {
+		return nil, "", err
+	}
+	if s.TwoFactor != nil {
+		enabled, err := s.TwoFactor.Enabled(userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if enabled {
+			token, err := s.TwoFactor.BeginLogin(userID)
+			if err != nil {
+				return nil, "", err
+			}
+			return nil, token, ErrTwoFactorRequired
+		}
+	}
+
+	session, err := s.openSession(userID, client)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, "", nil
+}
+
+// openSession creates a session for a login. Logging in cancels a scheduled account deletion.
+func (s *AuthService) openSession(userID int64, client models.SessionClient) (*models.Session, error) {
+	if s.AccountDeletion != nil {
+		if _, err := s.AccountDeletion.CancelDeletion(userID); err != nil {
+			return nil, fmt.Errorf("failed to cancel account deletion: %w", err)
+		}
+	}
+	return s.Sessions.CreateSession(userID, client)
+}
+
+// checkSuspended returns ErrAccountSuspended when the user may not log in.
+func (s *AuthService) checkSuspended(userID int64) error {
+	if s.Suspensions == nil {
+		return nil
+	}
+	suspended, err := s.Suspensions.IsSuspended(userID)
+	if err != nil {
+		return fmt.Errorf("failed to check suspension: %w", err)
+	}
+	if suspended {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login; a tracking error does not change the response.
+func (s *AuthService) recordLoginFailure(email string, userID int64, client models.SessionClient) {
+	if s.Throttle == nil {
+		return
+	}
+	if err := s.Throttle.RecordFailure(email, userID, client); err != nil {
+		fmt.Println("error recording failed login:", err)
+	}
+}
+
+// recordLoginSuccess forgets the failed logins of the account once every factor checked out.
+func (s *AuthService) recordLoginSuccess(email string) {
+	if s.Throttle == nil {
+		return
+	}
+	if err := s.Throttle.RecordSuccess(email); err != nil {
+		fmt.Println("error clearing failed logins:", err)
+	}
+}
+
+var dummyPasswordHashes sync.Map // bcrypt cost -> hash of a random password
+
+// dummyPasswordHash returns a hash at the configured cost that no password matches.
+func (s *AuthService) dummyPasswordHash() string {
+	cost := s.Passwords.Config.BcryptCost
+	if hash, ok := dummyPasswordHashes.Load(cost); ok {
+		return hash.(string)
+	}
+	secret, _, err := newToken()
+	if err != nil {
+		return ""
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), cost)
+	if err != nil {
+		return ""
+	}
+	actual, _ := dummyPasswordHashes.LoadOrStore(cost, string(hash))
+	return actual.(string)
+}
+
+// CompleteTwoFactorLogin opens a session once the code for a 2FA pending token checks out.
+// Wrong codes count as failed logins of the account and the client address,
+// so new challenges do not buy more guesses; while either is throttled the
+// error is a *LoginThrottledError and the code is not checked.
+func (s *AuthService) CompleteTwoFactorLogin(token, code string, client models.SessionClient) (*models.Session, error) {
+	if s.TwoFactor == nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	userID, err := s.TwoFactor.ChallengeUser(token)
+	if err != nil {
+		return nil, err
+	}
+	email, err := s.AuthStore.GetEmail(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if s.Throttle != nil {
+		if err := s.Throttle.Check(email, client); err != nil {
+			return nil, err
+		}
+	}
+
+	// the token names the same user, so the id returned on success is not needed
+	_, err = s.TwoFactor.CompleteLogin(token, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		s.recordLoginFailure(email, userID, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the account may have been suspended since the password was checked
+	if err := s.checkSuspended(userID); err != nil {
+		return nil, err
+	}
+	session, err := s.openSession(userID, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLoginSuccess(email)
+	return session, nil
 }
 
+// DeleteSession revokes a session, closing any websocket bound to it.
 func (s *AuthService) DeleteSession(sessionID string) (int, error) {
-	if err := s.AuthStore.DeleteSession(sessionID); err != nil {
+	if err := s.Sessions.RevokeSession(sessionID); err != nil {
 		return 0, err
 	}
 	return http.StatusOK, nil
 }
 
+// GetSession returns a live session, renewing it if it is due.
+func (s *AuthService) GetSession(sessionID string) (*models.Session, error) {
+	session, _, err := s.Sessions.ValidateSession(sessionID)
+	return session, err
+}
+
 func (s *AuthService) GetUserIDBySession(sessionID string) (int, error) {
-	userID, err := s.AuthStore.GetUserIDBySession(sessionID)
+	session, err := s.GetSession(sessionID)
 	if err != nil {
 		return 0, err
 	}
-	return userID, nil
+	return int(session.UserID), nil
 }
 
 // CreateUser creates a new user with validation and password hashing
 func (s *AuthService) CreateUser(user *models.User) (*models.User, error) {
+	if err := s.insertUser(user, true); err != nil {
+		return nil, err
+	}
+	s.sendVerification(user.ID, user.Email)
+	return user, nil
+}
+
+// CreateExternalUser creates a user who signs in through an OpenID provider.
+// The account gets a random password nobody knows; emailVerified says whether
+// the provider vouched for the address, otherwise it is verified by email.
+func (s *AuthService) CreateExternalUser(user *models.User, emailVerified bool) (*models.User, error) {
+	secret, _, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	user.Password = secret
+	// the provider's nickname is only a suggestion; sign up without one that does not fit
+	if user.Nickname != nil {
+		if _, err := s.checkHandle(user.Nickname, 0); isHandleError(err) {
+			user.Nickname = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.insertUser(user, false); err != nil {
+		return nil, err
+	}
+
+	if !emailVerified {
+		s.sendVerification(user.ID, user.Email)
+	} else if s.EmailVerification != nil {
+		if err := s.EmailVerification.MarkVerified(user.ID, user.Email); err != nil {
+			return nil, fmt.Errorf("failed to mark email verified: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// insertUser hashes the password and stores a user whose email and handle are
+// not taken. checkStrength applies the password policy, which random
+// passwords generated for external users need not meet.
+func (s *AuthService) insertUser(user *models.User, checkStrength bool) error {
 	// Check if user already exists
 	exists, err := s.AuthStore.UserExists(user.Email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if user exists: %w", err)
+		return fmt.Errorf("failed to check if user exists: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("user with email %s already exists", user.Email)
+		return fmt.Errorf("user with email %s already exists", user.Email)
+	}
+
+	user.Nickname, err = s.checkHandle(user.Nickname, 0)
+	if err != nil {
+		return err
 	}
 
 	// Hash the password
-	passwordManager := utils.NewPasswordManager(utils.PasswordConfig{})
-	hashedPassword, err := passwordManager.HashPassword(user.Password)
+	var hashedPassword string
+	if checkStrength {
+		hashedPassword, err = s.Passwords.HashPassword(user.Password)
+	} else {
+		var hash []byte
+		hash, err = bcrypt.GenerateFromPassword([]byte(user.Password), s.Passwords.Config.BcryptCost)
+		hashedPassword = string(hash)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
 	user.Password = hashedPassword
 
@@ -93,12 +344,64 @@ func (s *AuthService) CreateUser(user *models.User) (*models.User, error) {
 
 	// Create user in database
 	userID, err := s.AuthStore.CreateUser(user)
+	if isHandleConflict(err) {
+		return ErrHandleTaken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	user.ID = userID
-	return user, nil
+	return nil
+}
+
+// sendVerification emails a verification link. Failures are only logged;
+// the user can ask for another link.
+func (s *AuthService) sendVerification(userID int64, email string) {
+	if s.EmailVerification == nil {
+		return
+	}
+	if err := s.EmailVerification.SendVerification(userID, email); err != nil {
+		fmt.Println("Failed to send verification email:", err)
+	}
+}
+
+// ChangePassword replaces the user's password after checking the current one
+// and the password policy, then signs out every session but keepSessionID.
+// Policy failures are returned as *utils.PasswordPolicyError.
+func (s *AuthService) ChangePassword(userID int64, keepSessionID, currentPassword, newPassword string) error {
+	hash, err := s.AuthStore.GetPasswordHash(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load password: %w", err)
+	}
+	if err := s.Passwords.ComparePassword(hash, currentPassword); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	var violations []string
+	var policyErr *utils.PasswordPolicyError
+	if errors.As(s.Passwords.ValidatePasswordStrength(newPassword), &policyErr) {
+		violations = policyErr.Violations
+	}
+	if newPassword == currentPassword {
+		violations = append(violations, "new password must be different from the current password")
+	}
+	if len(violations) > 0 {
+		return &utils.PasswordPolicyError{Violations: violations}
+	}
+
+	newHash, err := s.Passwords.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.AuthStore.UpdatePassword(userID, newHash); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := s.Sessions.RevokeOtherSessions(userID, keepSessionID); err != nil {
+		return fmt.Errorf("password changed but failed to revoke sessions: %w", err)
+	}
+	return nil
 }
 
 // validateEmail validates email format using regex
@@ -111,6 +414,15 @@ func (s *AuthService) ValidateEmail(email string) (bool, error) {
 	return re.MatchString(email), nil
 }
 
+// UserIDByEmail returns the id of the user with email, or sql.ErrNoRows.
+func (s *AuthService) UserIDByEmail(email string) (int64, error) {
+	user, err := s.AuthStore.GetUserByEmail(email)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
 func (s *AuthService) UserExists(email string) (bool, error) {
 	return s.AuthStore.UserExists(email)
 }
@@ -119,6 +431,68 @@ func (s *AuthService) UserNewEditEmailExist(email string, userid int64) (bool, e
 	return s.AuthStore.NewEditEmailExist(email, userid)
 }
 
+// EditUserProfile updates the profile; a changed email must be verified again.
+// A new nickname must follow the handle rules, while an unchanged one is kept
+// as it is. The old handle keeps resolving to the user for a while.
 func (s *AuthService) EditUserProfile(user *models.User, userid int64) error {
-	return s.AuthStore.EditProfile(user, userid)
+	oldHandle, err := s.AuthStore.GetNickname(userid)
+	if err != nil {
+		return err
+	}
+	if user.Nickname == nil || *user.Nickname != oldHandle {
+		user.Nickname, err = s.checkHandle(user.Nickname, userid)
+		if err != nil {
+			return err
+		}
+	}
+
+	emailChanged, err := s.AuthStore.EditProfile(user, userid)
+	if isHandleConflict(err) {
+		return ErrHandleTaken
+	}
+	if err != nil {
+		return err
+	}
+	if emailChanged {
+		s.sendVerification(userid, user.Email)
+	}
+
+	newHandle := ""
+	if user.Nickname != nil {
+		newHandle = *user.Nickname
+	}
+	if s.Handles != nil && newHandle != oldHandle {
+		if err := s.Handles.Renamed(userid, oldHandle, newHandle); err != nil {
+			return fmt.Errorf("failed to record handle change: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkHandle normalizes a nickname into a handle that userID may take. An
+// empty nickname means no handle and comes back nil.
+func (s *AuthService) checkHandle(nickname *string, userID int64) (*string, error) {
+	if nickname == nil || strings.TrimSpace(*nickname) == "" {
+		return nil, nil
+	}
+	handle, err := NormalizeHandle(*nickname)
+	if err != nil {
+		return nil, err
+	}
+	if s.Handles != nil {
+		if err := s.Handles.CheckAvailable(handle, userID); err != nil {
+			return nil, err
+		}
+	}
+	return &handle, nil
+}
+
+// isHandleError reports whether err rejects a nickname rather than failing.
+func isHandleError(err error) bool {
+	return errors.Is(err, ErrInvalidHandle) || errors.Is(err, ErrReservedHandle) || errors.Is(err, ErrHandleTaken)
+}
+
+// isHandleConflict reports whether a write lost a race for a handle to the unique index.
+func isHandleConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: Users.nickname")
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+var (
+	// ErrBlockSelf is returned when users try to block themselves.
+	ErrBlockSelf = errors.New("you cannot block yourself")
+	// ErrBlocked is returned for an action between two users when either has blocked the other.
+	ErrBlocked = errors.New("this user is not available")
+)
+
+type blockService struct {
+	store store.BlockStore
+	now   func() time.Time
+}
+
+// NewBlockService creates the service behind blocking.
+func NewBlockService(blocks store.BlockStore) BlockService {
+	return &blockService{store: blocks, now: time.Now}
+}
+
+// Block blocks blockedID for blockerID and drops any follow between them.
+func (s *blockService) Block(blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return ErrBlockSelf
+	}
+	err := s.store.BlockUser(blockerID, blockedID, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// Unblock lifts a block; unblocking someone who is not blocked does nothing.
+func (s *blockService) Unblock(blockerID, blockedID int64) error {
+	_, err := s.store.UnblockUser(blockerID, blockedID)
+	return err
+}
+
+func (s *blockService) ListBlocked(blockerID int64) ([]*models.BlockedUser, error) {
+	return s.store.ListBlockedUsers(blockerID)
+}
+
+func (s *blockService) IsBlocked(userID, otherID int64) (bool, error) {
+	return s.store.IsBlocked(userID, otherID)
+}
+
+func (s *blockService) BlockedUsers(userID int64) (map[int64]bool, error) {
+	ids, err := s.store.ListBlockRelations(userID)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		blocked[id] = true
+	}
+	return blocked, nil
+}
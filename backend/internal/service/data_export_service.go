@@ -0,0 +1,326 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+var (
+	// ErrExportNotFound is returned for an export that does not exist or belongs to someone else.
+	ErrExportNotFound = errors.New("export not found")
+	// ErrExportNotReady is returned when downloading an export that is still being built or failed.
+	ErrExportNotReady = errors.New("export is not ready")
+	// ErrExportExpired is returned when downloading an export whose archive has been deleted.
+	ErrExportExpired = errors.New("export has expired")
+)
+
+// DataExportConfig controls personal data exports.
+type DataExportConfig struct {
+	// Dir holds finished archives. Unlike AttachmentsDir it must never be served publicly.
+	Dir string
+	// AttachmentsDir holds the uploaded files that image and avatar paths point into.
+	AttachmentsDir string
+	// TTL is how long an archive can be downloaded before it is deleted.
+	TTL time.Duration
+	// Interval is how often the worker looks for pending and expired exports.
+	Interval time.Duration
+}
+
+// DefaultDataExportConfig returns the export settings used when none are configured.
+func DefaultDataExportConfig() DataExportConfig {
+	return DataExportConfig{
+		Dir:            "exports",
+		AttachmentsDir: "attachments",
+		TTL:            7 * 24 * time.Hour,
+		Interval:       time.Minute,
+	}
+}
+
+// DataExportConfigFromEnv overrides the defaults with DATA_EXPORT_DIR, and
+// with DATA_EXPORT_TTL and DATA_EXPORT_INTERVAL given as Go durations.
+func DataExportConfigFromEnv() DataExportConfig {
+	config := DefaultDataExportConfig()
+	if dir := os.Getenv("DATA_EXPORT_DIR"); dir != "" {
+		config.Dir = dir
+	}
+	for name, target := range map[string]*time.Duration{
+		"DATA_EXPORT_TTL":      &config.TTL,
+		"DATA_EXPORT_INTERVAL": &config.Interval,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Printf("ignoring invalid %s=%q", name, value)
+			continue
+		}
+		*target = d
+	}
+	return config
+}
+
+type dataExportService struct {
+	store  store.DataExportStore
+	config DataExportConfig
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+func NewDataExportService(exports store.DataExportStore, config DataExportConfig) DataExportService {
+	return &dataExportService{
+		store:  exports,
+		config: config,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// RequestExport queues an export of the user's data. A user has at most one
+// export in progress; asking again returns that one.
+func (s *dataExportService) RequestExport(userID int64) (*models.DataExport, error) {
+	export, err := s.store.GetActiveExport(userID)
+	if err == nil {
+		return export, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	export, err = s.store.CreateExport(userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return export, nil
+}
+
+// GetExport returns one of the user's exports. A ready export past its
+// expiry reads as expired even before the worker deletes its archive.
+func (s *dataExportService) GetExport(userID, exportID int64) (*models.DataExport, error) {
+	export, err := s.store.GetExport(userID, exportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if export.Status == models.DataExportReady && export.ExpiresAt != nil && !s.now().Before(*export.ExpiresAt) {
+		export.Status = models.DataExportExpired
+	}
+	return export, nil
+}
+
+// OpenExport opens the archive of a ready export for download.
+func (s *dataExportService) OpenExport(userID, exportID int64) (*os.File, *models.DataExport, error) {
+	export, err := s.GetExport(userID, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch export.Status {
+	case models.DataExportReady:
+	case models.DataExportExpired:
+		return nil, nil, ErrExportExpired
+	default:
+		return nil, nil, ErrExportNotReady
+	}
+
+	file, err := os.Open(filepath.Join(s.config.Dir, export.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, export, nil
+}
+
+// ProcessPending builds the archive of every pending export and returns how
+// many it handled. An export that cannot be built is marked failed.
+func (s *dataExportService) ProcessPending() (int, error) {
+	processed := 0
+	for {
+		export, err := s.store.ClaimPendingExport()
+		if errors.Is(err, sql.ErrNoRows) {
+			return processed, nil
+		}
+		if err != nil {
+			return processed, err
+		}
+		processed++
+
+		fileName, err := s.buildArchive(export)
+		if err != nil {
+			log.Printf("failed to build data export %d: %v", export.ID, err)
+			if err := s.store.MarkExportFailed(export.ID, "the export could not be built; please request a new one", s.now()); err != nil {
+				return processed, err
+			}
+			continue
+		}
+		now := s.now()
+		if err := s.store.MarkExportReady(export.ID, fileName, now, now.Add(s.config.TTL)); err != nil {
+			return processed, err
+		}
+	}
+}
+
+// buildArchive writes the export's zip into Dir: a JSON file per section and
+// the user's uploads under media/. It returns the archive's file name.
+func (s *dataExportService) buildArchive(export *models.DataExport) (string, error) {
+	sections, files, err := s.store.CollectUserData(export.UserID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.config.Dir, 0o700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(s.config.Dir, "export-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	archive := zip.NewWriter(tmp)
+	for _, section := range sections {
+		w, err := archive.Create(section.Name + ".json")
+		if err != nil {
+			return "", err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.Rows); err != nil {
+			return "", err
+		}
+	}
+
+	added := map[string]bool{}
+	for _, stored := range files {
+		name, ok := attachmentPath(s.config.AttachmentsDir, stored)
+		if !ok || added[name] {
+			continue
+		}
+		added[name] = true
+		if err := addFile(archive, name, s.config.AttachmentsDir); err != nil {
+			return "", err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("export-%d.zip", export.ID)
+	if err := os.Rename(tmp.Name(), filepath.Join(s.config.Dir, fileName)); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
+
+// addFile copies an attachment into the archive under media/. Uploads that
+// are referenced but missing on disk are skipped.
+func addFile(archive *zip.Writer, name, attachmentsDir string) error {
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rel, err := filepath.Rel(attachmentsDir, name)
+	if err != nil {
+		return err
+	}
+	w, err := archive.Create("media/" + filepath.ToSlash(rel))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// RemoveExpired deletes the archives of expired exports, and any archive no
+// export points at any more, such as those of purged accounts. It returns how
+// many exports expired.
+func (s *dataExportService) RemoveExpired() (int, error) {
+	expired, err := s.store.ListExpiredExports(s.now())
+	if err != nil {
+		return 0, err
+	}
+	for _, export := range expired {
+		if err := s.store.MarkExportExpired(export.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	live, err := s.store.ListExportFiles()
+	if err != nil {
+		return len(expired), err
+	}
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return len(expired), err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || live[entry.Name()] {
+			continue
+		}
+		name := filepath.Join(s.config.Dir, entry.Name())
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to remove data export %s: %v", name, err)
+		}
+	}
+	return len(expired), nil
+}
+
+// RunWorker builds exports as they are requested and, every Interval, picks
+// up any it missed and removes expired ones, until ctx is done. Exports a
+// previous run left unfinished are started over on the first tick.
+func (s *dataExportService) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	requeued := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+			if !requeued {
+				if err := s.store.RequeueRunningExports(); err != nil {
+					log.Println(err)
+				}
+				requeued = true
+			}
+			if n, err := s.RemoveExpired(); err != nil {
+				log.Println(err)
+			} else if n > 0 {
+				log.Printf("expired %d data exports", n)
+			}
+		}
+		if _, err := s.ProcessPending(); err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"archive/zip"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeDataExportStore struct {
+	exports  []*models.DataExport
+	sections []models.DataExportSection
+	files    []string
+}
+
+func (f *fakeDataExportStore) CreateExport(userID int64, now time.Time) (*models.DataExport, error) {
+	export := &models.DataExport{ID: int64(len(f.exports) + 1), UserID: userID, Status: models.DataExportPending, CreatedAt: now}
+	f.exports = append(f.exports, export)
+	copied := *export
+	return &copied, nil
+}
+
+func (f *fakeDataExportStore) find(match func(*models.DataExport) bool) (*models.DataExport, error) {
+	for _, e := range f.exports {
+		if match(e) {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDataExportStore) GetActiveExport(userID int64) (*models.DataExport, error) {
+	return f.find(func(e *models.DataExport) bool {
+		return e.UserID == userID && (e.Status == models.DataExportPending || e.Status == models.DataExportRunning)
+	})
+}
+
+func (f *fakeDataExportStore) GetExport(userID, exportID int64) (*models.DataExport, error) {
+	return f.find(func(e *models.DataExport) bool { return e.UserID == userID && e.ID == exportID })
+}
+
+func (f *fakeDataExportStore) ClaimPendingExport() (*models.DataExport, error) {
+	for _, e := range f.exports {
+		if e.Status == models.DataExportPending {
+			e.Status = models.DataExportRunning
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeDataExportStore) RequeueRunningExports() error { return nil }
+
+func (f *fakeDataExportStore) MarkExportReady(exportID int64, fileName string, completedAt, expiresAt time.Time) error {
+	e := f.exports[exportID-1]
+	e.Status, e.FileName, e.CompletedAt, e.ExpiresAt = models.DataExportReady, fileName, &completedAt, &expiresAt
+	return nil
+}
+
+func (f *fakeDataExportStore) MarkExportFailed(exportID int64, message string, completedAt time.Time) error {
+	e := f.exports[exportID-1]
+	e.Status, e.Error, e.CompletedAt = models.DataExportFailed, message, &completedAt
+	return nil
+}
+
+func (f *fakeDataExportStore) ListExpiredExports(now time.Time) ([]*models.DataExport, error) {
+	var expired []*models.DataExport
+	for _, e := range f.exports {
+		if e.Status == models.DataExportReady && !now.Before(*e.ExpiresAt) {
+			expired = append(expired, e)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeDataExportStore) MarkExportExpired(exportID int64) error {
+	e := f.exports[exportID-1]
+	e.Status, e.FileName = models.DataExportExpired, ""
+	return nil
+}
+
+func (f *fakeDataExportStore) ListExportFiles() (map[string]bool, error) {
+	files := map[string]bool{}
+	for _, e := range f.exports {
+		if e.FileName != "" {
+			files[e.FileName] = true
+		}
+	}
+	return files, nil
+}
+
+func (f *fakeDataExportStore) CollectUserData(userID int64) ([]models.DataExportSection, []string, error) {
+	return f.sections, f.files, nil
+}
+
+func TestDataExport(t *testing.T) {
+	attachments := t.TempDir()
+	exportDir := t.TempDir()
+	for _, name := range []string{"avatar.png", "posts/photo.jpg"} {
+		path := filepath.Join(attachments, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "outside.png")
+	_ = os.WriteFile(outside, []byte("x"), 0o644)
+
+	exports := &fakeDataExportStore{
+		sections: []models.DataExportSection{
+			{Name: "profile", Rows: []map[string]any{{"email": "ada@example.com"}}},
+			{Name: "posts", Rows: []map[string]any{}},
+		},
+		files: []string{"avatar.png", "posts/photo.jpg", "posts/../posts/photo.jpg", "gone.png", "../" + filepath.Base(outside), outside},
+	}
+	svc := NewDataExportService(exports, DataExportConfig{
+		Dir:            exportDir,
+		AttachmentsDir: attachments,
+		TTL:            24 * time.Hour,
+		Interval:       time.Hour,
+	}).(*dataExportService)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	requested, err := svc.RequestExport(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := svc.RequestExport(7); again.ID != requested.ID {
+		t.Errorf("a second request while one is pending made export %d", again.ID)
+	}
+	if _, _, err := svc.OpenExport(7, requested.ID); !errors.Is(err, ErrExportNotReady) {
+		t.Errorf("download before the export is built: got %v", err)
+	}
+	if _, err := svc.GetExport(8, requested.ID); !errors.Is(err, ErrExportNotFound) {
+		t.Errorf("someone else's export: got %v", err)
+	}
+
+	if n, err := svc.ProcessPending(); err != nil || n != 1 {
+		t.Fatalf("process: got %d, %v", n, err)
+	}
+	file, export, err := svc.OpenExport(7, requested.ID)
+	if err != nil || !export.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("open: got %+v, %v", export, err)
+	}
+	info, _ := file.Stat()
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		b, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(b)
+	}
+	file.Close()
+	want := map[string]string{
+		"profile.json":          "[\n  {\n    \"email\": \"ada@example.com\"\n  }\n]\n",
+		"posts.json":            "[]\n",
+		"media/avatar.png":      "avatar.png",
+		"media/posts/photo.jpg": "posts/photo.jpg",
+	}
+	if len(contents) != len(want) {
+		t.Errorf("archive holds %v", contents)
+	}
+	for name, body := range want {
+		if contents[name] != body {
+			t.Errorf("%s = %q, want %q", name, contents[name], body)
+		}
+	}
+
+	// expiry deletes the archive, and so does an account purge removing the row
+	stray := filepath.Join(exportDir, "export-99.zip")
+	_ = os.WriteFile(stray, []byte("x"), 0o600)
+	now = now.Add(24 * time.Hour)
+	if e, _ := svc.GetExport(7, requested.ID); e.Status != models.DataExportExpired {
+		t.Errorf("status past expiry = %s", e.Status)
+	}
+	if _, _, err := svc.OpenExport(7, requested.ID); !errors.Is(err, ErrExportExpired) {
+		t.Errorf("download past expiry: got %v", err)
+	}
+	if n, err := svc.RemoveExpired(); err != nil || n != 1 {
+		t.Fatalf("remove expired: got %d, %v", n, err)
+	}
+	if entries, _ := os.ReadDir(exportDir); len(entries) != 0 {
+		t.Errorf("archives left behind: %v", entries)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("a file outside the attachments directory was touched: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/mail"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// Errors returned by email verification.
+var (
+	ErrInvalidVerificationToken = errors.New("verification token is invalid or has expired")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+)
+
+// EmailVerificationConfig controls verification links. VerifyURL is the
+// frontend page that receives the token as its "token" query parameter.
+type EmailVerificationConfig struct {
+	TokenTTL  time.Duration
+	VerifyURL string
+}
+
+// EmailVerificationConfigFromEnv reads EMAIL_VERIFICATION_TTL (a Go duration)
+// and EMAIL_VERIFICATION_URL, falling back to a two day link to the local frontend.
+func EmailVerificationConfigFromEnv() EmailVerificationConfig {
+	config := EmailVerificationConfig{
+		TokenTTL:  48 * time.Hour,
+		VerifyURL: "http://localhost:3000/verify-email",
+	}
+	if value := os.Getenv("EMAIL_VERIFICATION_TTL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.TokenTTL = d
+		} else {
+			log.Printf("ignoring invalid EMAIL_VERIFICATION_TTL=%q", value)
+		}
+	}
+	if value := os.Getenv("EMAIL_VERIFICATION_URL"); value != "" {
+		config.VerifyURL = value
+	}
+	return config
+}
+
+type emailVerificationService struct {
+	store  store.EmailVerificationStore
+	mailer mail.Mailer
+	config EmailVerificationConfig
+	now    func() time.Time
+}
+
+func NewEmailVerificationService(verificationStore store.EmailVerificationStore, mailer mail.Mailer, config EmailVerificationConfig) EmailVerificationService {
+	return &emailVerificationService{
+		store:  verificationStore,
+		mailer: mailer,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// SendVerification emails a link confirming email for the user.
+func (s *emailVerificationService) SendVerification(userID int64, email string) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err := s.store.CreateVerificationToken(userID, email, hash, s.now().Add(s.config.TokenTTL)); err != nil {
+		return err
+	}
+
+	link := s.config.VerifyURL + "?token=" + url.QueryEscape(token)
+	return s.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Please confirm this email address to start posting and messaging.\n\nOpen this link within %s:\n\n%s\n\nIf you didn't sign up, you can ignore this email.",
+			s.config.TokenTTL, link,
+		),
+	})
+}
+
+// ResendVerification sends a fresh link to the user's current email.
+func (s *emailVerificationService) ResendVerification(userID int64) error {
+	email, verified, err := s.store.GetEmailStatus(userID)
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	if verified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.SendVerification(userID, email)
+}
+
+// VerifyEmail confirms the address a token was sent to. Tokens can be used once.
+func (s *emailVerificationService) VerifyEmail(token string) error {
+	_, err := s.store.VerifyEmail(hashToken(token), s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidVerificationToken
+	}
+	return err
+}
+
+// MarkVerified records an address already confirmed elsewhere, such as by an
+// OpenID provider, as verified.
+func (s *emailVerificationService) MarkVerified(userID int64, email string) error {
+	return s.store.MarkEmailVerified(userID, email, s.now())
+}
+
+func (s *emailVerificationService) IsEmailVerified(userID int64) (bool, error) {
+	_, verified, err := s.store.GetEmailStatus(userID)
+	return verified, err
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeVerificationToken struct {
+	userID    int64
+	email     string
+	expiresAt time.Time
+	used      bool
+}
+
+type fakeEmailVerificationStore struct {
+	emails   map[int64]string
+	verified map[int64]bool
+	tokens   map[string]*fakeVerificationToken
+}
+
+func (f *fakeEmailVerificationStore) CreateVerificationToken(userID int64, email, tokenHash string, expiresAt time.Time) error {
+	f.tokens[tokenHash] = &fakeVerificationToken{userID: userID, email: email, expiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakeEmailVerificationStore) VerifyEmail(tokenHash string, now time.Time) (int64, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok || token.used || !now.Before(token.expiresAt) || f.emails[token.userID] != token.email {
+		return 0, sql.ErrNoRows
+	}
+	token.used = true
+	f.verified[token.userID] = true
+	return token.userID, nil
+}
+
+func (f *fakeEmailVerificationStore) MarkEmailVerified(userID int64, email string, now time.Time) error {
+	if f.emails[userID] != email {
+		return sql.ErrNoRows
+	}
+	f.verified[userID] = true
+	return nil
+}
+
+func (f *fakeEmailVerificationStore) GetEmailStatus(userID int64) (string, bool, error) {
+	email, ok := f.emails[userID]
+	if !ok {
+		return "", false, sql.ErrNoRows
+	}
+	return email, f.verified[userID], nil
+}
+
+func newEmailVerificationFixture() (*emailVerificationService, *fakeEmailVerificationStore, *fakeMailer, *time.Time) {
+	verifications := &fakeEmailVerificationStore{
+		emails:   map[int64]string{7: "ada@example.com"},
+		verified: map[int64]bool{},
+		tokens:   map[string]*fakeVerificationToken{},
+	}
+	mailer := &fakeMailer{}
+	svc := NewEmailVerificationService(verifications, mailer, EmailVerificationConfig{
+		TokenTTL:  time.Hour,
+		VerifyURL: "https://example.com/verify",
+	}).(*emailVerificationService)
+
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+	return svc, verifications, mailer, &now
+}
+
+func verificationToken(t *testing.T, body string) string {
+	t.Helper()
+	for _, field := range strings.Fields(body) {
+		if strings.HasPrefix(field, "https://example.com/verify?") {
+			link, err := url.Parse(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no verification link in %q", body)
+	return ""
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		after   time.Duration
+		forge   bool
+		wantErr error
+	}{
+		{"valid link", 0, false, nil},
+		{"expired link", time.Hour, false, ErrInvalidVerificationToken},
+		{"forged token", 0, true, ErrInvalidVerificationToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, verifications, mailer, now := newEmailVerificationFixture()
+			if err := svc.SendVerification(7, "ada@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if len(mailer.sent) != 1 || mailer.sent[0].To != "ada@example.com" {
+				t.Fatalf("expected one email to ada, got %+v", mailer.sent)
+			}
+
+			token := verificationToken(t, mailer.sent[0].Body)
+			if _, ok := verifications.tokens[token]; ok {
+				t.Error("the raw token must not be stored")
+			}
+			if tt.forge {
+				token = "forged"
+			}
+
+			*now = now.Add(tt.after)
+			if err := svc.VerifyEmail(token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if verified, _ := svc.IsEmailVerified(7); verified != (tt.wantErr == nil) {
+				t.Errorf("verified = %v", verified)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	svc, verifications, mailer, _ := newEmailVerificationFixture()
+
+	if err := svc.ResendVerification(7); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected a verification email, got %d", len(mailer.sent))
+	}
+
+	verifications.verified[7] = true
+	if err := svc.ResendVerification(7); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("got %v, want ErrEmailAlreadyVerified", err)
+	}
+}
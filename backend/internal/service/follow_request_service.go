@@ -7,6 +7,8 @@ import (
 
 type FollowRequestService struct {
 	FollowRequestStore *store.FollowRequestStore
+	// Mutes, when set, drops notifications the recipient muted.
+	Mutes MuteService
 }
 
 func NewFollowRequestService(fr *store.FollowRequestStore) *FollowRequestService {
@@ -44,3 +46,8 @@ func (fr *FollowRequestService) GetRequestIDByUsers(followerID, followeeID int64
 func (fr *FollowRequestService) GetPendingFollowRequest(userid int64) (models.FollowRequestUserResponse, error) {
 	return fr.FollowRequestStore.GetPendingFollowRequest(userid)
 }
+
+// ShouldNotify reports whether recipientID wants to be told about actorID's activity.
+func (fr *FollowRequestService) ShouldNotify(recipientID, actorID int64, message string) bool {
+	return fr.Mutes == nil || fr.Mutes.ShouldNotify(recipientID, actorID, message)
+}
@@ -6,6 +6,8 @@ import (
 
 type FollowService struct {
 	FollowStore *store.FollowStore
+	// Mutes, when set, drops notifications the recipient muted.
+	Mutes MuteService
 }
 
 func NewFollowService(ff *store.FollowStore) *FollowService {
@@ -17,13 +19,31 @@ func (Follow *FollowService) IsAccountPublic(followee int64) (bool, error) {
 }
 
 func (Follow *FollowService) CreateFollowForPublicAccount(followerid, followeeid int64) (int64, error) {
+	if err := Follow.checkNotBlocked(followerid, followeeid); err != nil {
+		return 0, err
+	}
 	return Follow.FollowStore.CreatePublicFollowConnection(followerid, followeeid)
 }
 
 func (Follow *FollowService) CreateFollowForPrivateAccount(followrid, followeeid int64) (int64, error) {
+	if err := Follow.checkNotBlocked(followrid, followeeid); err != nil {
+		return 0, err
+	}
 	return Follow.FollowStore.CreatePrivateFollowConnection(followrid, followeeid)
 }
 
+// checkNotBlocked refuses follows and follow requests between users when either blocked the other.
+func (Follow *FollowService) checkNotBlocked(followerid, followeeid int64) error {
+	blocked, err := Follow.FollowStore.IsBlocked(followerid, followeeid)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
 func (Follow *FollowService) GetUserInfo(userID int64) (string, string, error) {
 	return Follow.FollowStore.UserInfo(userID)
 }
@@ -31,3 +51,8 @@ func (Follow *FollowService) GetUserInfo(userID int64) (string, string, error) {
 func (Follow *FollowService) AddtoNotification(follower_id int64, message string) error {
 	return Follow.FollowStore.AddtoNotification(follower_id, message)
 }
+
+// ShouldNotify reports whether recipientID wants to be told about actorID's activity.
+func (Follow *FollowService) ShouldNotify(recipientID, actorID int64, message string) bool {
+	return Follow.Mutes == nil || Follow.Mutes.ShouldNotify(recipientID, actorID, message)
+}
@@ -1,19 +1,46 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/store"
 )
 
+// Errors returned by the group join workflow.
+var (
+	ErrNotGroupAdmin        = errors.New("only the group creator or an admin can manage join requests")
+	ErrDuplicateJoinRequest = errors.New("a join request for this group is already pending")
+	ErrAlreadyGroupMember   = errors.New("user is already a member of this group")
+	ErrRequestNotPending    = errors.New("request is not pending")
+)
+
+// Group member roles.
+const (
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
 type groupRequestService struct {
 	groupRequestStore store.GroupRequestStore
+	groupMemberStore  store.GroupMemberStore
 	groupService      GroupService
+	mutes             MuteService
+	notifier          Notifier
 }
 
-func NewGroupRequestService(groupRequestStore store.GroupRequestStore, groupService GroupService) GroupRequestService {
-	return &groupRequestService{groupRequestStore: groupRequestStore, groupService: groupService}
+// NewGroupRequestService creates a GroupRequestService. mutes may be nil to
+// skip checking mutes before telling requesters how their request went.
+func NewGroupRequestService(groupRequestStore store.GroupRequestStore, groupMemberStore store.GroupMemberStore, groupService GroupService, mutes MuteService, notifier Notifier) GroupRequestService {
+	return &groupRequestService{
+		groupRequestStore: groupRequestStore,
+		groupMemberStore:  groupMemberStore,
+		groupService:      groupService,
+		mutes:             mutes,
+		notifier:          notifier,
+	}
 }
 
 func (s *groupRequestService) SendJoinRequest(groupID, userID int64) (*models.GroupRequest, error) {
@@ -26,6 +53,25 @@ func (s *groupRequestService) SendJoinRequest(groupID, userID int64) (*models.Gr
 		return nil, fmt.Errorf("cannot send join request to a private group")
 	}
 
+	if group.CreatorID == userID {
+		return nil, ErrAlreadyGroupMember
+	}
+	isMember, err := s.groupMemberStore.IsGroupMember(groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group membership: %w", err)
+	}
+	if isMember {
+		return nil, ErrAlreadyGroupMember
+	}
+
+	pending, err := s.groupRequestStore.HasPendingGroupRequest(groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending requests: %w", err)
+	}
+	if pending {
+		return nil, ErrDuplicateJoinRequest
+	}
+
 	request := &models.GroupRequest{
 		GroupID: int64(groupID),
 		UserID:  int64(userID),
@@ -41,37 +87,102 @@ func (s *groupRequestService) SendJoinRequest(groupID, userID int64) (*models.Gr
 }
 
 func (s *groupRequestService) ApproveJoinRequest(requestID int64, approverID int64) error {
-	request, err := s.groupRequestStore.GetGroupRequestByID(int64(requestID))
+	request, group, err := s.pendingRequestFor(requestID, approverID)
 	if err != nil {
-		return fmt.Errorf("failed to get group request: %w", err)
+		return err
 	}
 
-	if request.Status != "pending" {
-		return fmt.Errorf("request is not pending")
+	message := fmt.Sprintf("Your request to join %s was approved", group.Title)
+	if !s.shouldNotify(request.UserID, approverID, message) {
+		message = "" // handled all the same, but the requester muted the actor
 	}
-
-	err = s.groupRequestStore.UpdateGroupRequestStatus(requestID, "approved")
+	err = s.groupRequestStore.ApproveGroupRequest(request, GroupRoleMember, message)
 	if err != nil {
+		if errors.Is(err, store.ErrGroupRequestNotPending) {
+			return ErrRequestNotPending
+		}
 		return fmt.Errorf("failed to approve group request: %w", err)
 	}
 
+	if message != "" {
+		s.notifyRequester(request, group, "group_request_approved", message)
+	}
 	return nil
 }
 
 func (s *groupRequestService) RejectJoinRequest(requestID int64, rejecterID int64) error {
-	request, err := s.groupRequestStore.GetGroupRequestByID(int64(requestID))
+	request, group, err := s.pendingRequestFor(requestID, rejecterID)
 	if err != nil {
-		return fmt.Errorf("failed to get group request: %w", err)
+		return err
 	}
 
-	if request.Status != "pending" {
-		return fmt.Errorf("request is not pending")
+	message := fmt.Sprintf("Your request to join %s was rejected", group.Title)
+	if !s.shouldNotify(request.UserID, rejecterID, message) {
+		message = "" // handled all the same, but the requester muted the actor
 	}
-
-	err = s.groupRequestStore.UpdateGroupRequestStatus(requestID, "rejected")
+	err = s.groupRequestStore.RejectGroupRequest(request, message)
 	if err != nil {
+		if errors.Is(err, store.ErrGroupRequestNotPending) {
+			return ErrRequestNotPending
+		}
 		return fmt.Errorf("failed to reject group request: %w", err)
 	}
 
+	if message != "" {
+		s.notifyRequester(request, group, "group_request_rejected", message)
+	}
 	return nil
 }
+
+// pendingRequestFor loads a pending join request and checks that actorID may manage
+// requests for its group, i.e. is the group creator or one of its admins.
+func (s *groupRequestService) pendingRequestFor(requestID, actorID int64) (*models.GroupRequest, *models.Group, error) {
+	request, err := s.groupRequestStore.GetGroupRequestByID(requestID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get group request: %w", err)
+	}
+
+	group, err := s.groupService.GetGroupByID(request.GroupID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("group not found: %w", err)
+	}
+
+	if group.CreatorID != actorID {
+		role, err := s.groupMemberStore.GetGroupMemberRole(group.ID, actorID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check group role: %w", err)
+		}
+		if role != GroupRoleAdmin {
+			return nil, nil, ErrNotGroupAdmin
+		}
+	}
+
+	if request.Status != "pending" {
+		return nil, nil, ErrRequestNotPending
+	}
+
+	return request, group, nil
+}
+
+// shouldNotify reports whether the requester wants to hear about actorID
+// handling their request.
+func (s *groupRequestService) shouldNotify(requesterID, actorID int64, message string) bool {
+	return s.mutes == nil || s.mutes.ShouldNotify(requesterID, actorID, message)
+}
+
+// notifyRequester pushes the outcome of a join request to the requester if they are online.
+// The notification itself is already stored with the request update.
+func (s *groupRequestService) notifyRequester(request *models.GroupRequest, group *models.Group, subtype, message string) {
+	if s.notifier == nil || !s.notifier.IsOnline(request.UserID) {
+		return
+	}
+	s.notifier.SendNotification(request.UserID, map[string]interface{}{
+		"type":        "notification",
+		"subtype":     subtype,
+		"group_id":    group.ID,
+		"group_title": group.Title,
+		"request_id":  request.ID,
+		"message":     message,
+		"timestamp":   time.Now().Unix(),
+	})
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+type fakeGroupRequestStore struct {
+	requests      map[int64]*models.GroupRequest
+	approved      []int64
+	rejected      []int64
+	roles         map[int64]string // request ID -> role granted
+	notifications []string
+}
+
+func (f *fakeGroupRequestStore) CreateGroupRequest(request *models.GroupRequest) (*models.GroupRequest, error) {
+	request.ID = int64(len(f.requests) + 1)
+	f.requests[request.ID] = request
+	return request, nil
+}
+
+func (f *fakeGroupRequestStore) GetGroupRequestByID(requestID int64) (*models.GroupRequest, error) {
+	request, ok := f.requests[requestID]
+	if !ok {
+		return nil, errors.New("group request not found")
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (f *fakeGroupRequestStore) UpdateGroupRequestStatus(requestID int64, status string) error {
+	f.requests[requestID].Status = status
+	return nil
+}
+
+func (f *fakeGroupRequestStore) HasPendingGroupRequest(groupID, userID int64) (bool, error) {
+	for _, r := range f.requests {
+		if r.GroupID == groupID && r.UserID == userID && r.Status == "pending" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeGroupRequestStore) ApproveGroupRequest(request *models.GroupRequest, role, notification string) error {
+	if f.requests[request.ID].Status != "pending" {
+		return store.ErrGroupRequestNotPending
+	}
+	f.requests[request.ID].Status = "approved"
+	f.approved = append(f.approved, request.ID)
+	f.roles[request.ID] = role
+	if notification != "" {
+		f.notifications = append(f.notifications, notification)
+	}
+	return nil
+}
+
+func (f *fakeGroupRequestStore) RejectGroupRequest(request *models.GroupRequest, notification string) error {
+	if f.requests[request.ID].Status != "pending" {
+		return store.ErrGroupRequestNotPending
+	}
+	f.requests[request.ID].Status = "rejected"
+	f.rejected = append(f.rejected, request.ID)
+	if notification != "" {
+		f.notifications = append(f.notifications, notification)
+	}
+	return nil
+}
+
+type fakeGroupMemberStore struct {
+	roles map[[2]int64]string // [group, user] -> role
+}
+
+func (f *fakeGroupMemberStore) IsGroupMember(groupID, userID int64) (bool, error) {
+	_, ok := f.roles[[2]int64{groupID, userID}]
+	return ok, nil
+}
+
+func (f *fakeGroupMemberStore) GetGroupMemberRole(groupID, userID int64) (string, error) {
+	return f.roles[[2]int64{groupID, userID}], nil
+}
+
+func (f *fakeGroupMemberStore) AddGroupMember(groupID, userID int64, role string) (*models.GroupMember, error) {
+	f.roles[[2]int64{groupID, userID}] = role
+	return &models.GroupMember{GroupID: groupID, UserID: userID, Role: role}, nil
+}
+
+func (f *fakeGroupMemberStore) RemoveGroupMember(groupID, userID int64) error {
+	delete(f.roles, [2]int64{groupID, userID})
+	return nil
+}
+
+type fakeGroupService struct {
+	groups map[int64]*models.Group
+}
+
+func (f *fakeGroupService) CreateGroup(group *models.Group) (*models.Group, error) {
+	return group, nil
+}
+
+func (f *fakeGroupService) GetGroupByID(groupID int64) (*models.Group, error) {
+	group, ok := f.groups[groupID]
+	if !ok {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+type fakeNotifier struct {
+	online map[int64]bool
+	sent   map[int64][]map[string]interface{}
+}
+
+func (f *fakeNotifier) SendNotification(userID int64, data map[string]interface{}) {
+	f.sent[userID] = append(f.sent[userID], data)
+}
+
+func (f *fakeNotifier) IsOnline(userID int64) bool {
+	return f.online[userID]
+}
+
+// fakeMutes implements only ShouldNotify; muted holds [recipient, actor] pairs.
+type fakeMutes struct {
+	MuteService
+	muted map[[2]int64]bool
+}
+
+func (f *fakeMutes) ShouldNotify(recipientID, actorID int64, message string) bool {
+	return !f.muted[[2]int64{recipientID, actorID}]
+}
+
+const (
+	groupCreatorID int64 = 1
+	groupAdminID   int64 = 2
+	groupMemberID  int64 = 3
+	requesterID    int64 = 4
+	outsiderID     int64 = 5
+)
+
+func newGroupRequestFixture() (*groupRequestService, *fakeGroupRequestStore, *fakeNotifier) {
+	svc, requests, notifier, _ := newGroupRequestFixtureWithMutes()
+	return svc, requests, notifier
+}
+
+func newGroupRequestFixtureWithMutes() (*groupRequestService, *fakeGroupRequestStore, *fakeNotifier, *fakeMutes) {
+	requests := &fakeGroupRequestStore{
+		requests: map[int64]*models.GroupRequest{
+			1: {ID: 1, GroupID: 10, UserID: requesterID, Status: "pending"},
+		},
+		roles: map[int64]string{},
+	}
+	members := &fakeGroupMemberStore{roles: map[[2]int64]string{
+		{10, groupAdminID}:  GroupRoleAdmin,
+		{10, groupMemberID}: GroupRoleMember,
+	}}
+	groups := &fakeGroupService{groups: map[int64]*models.Group{
+		10: {ID: 10, Title: "Gophers", CreatorID: groupCreatorID, Privacy: "public"},
+	}}
+	notifier := &fakeNotifier{online: map[int64]bool{requesterID: true}, sent: map[int64][]map[string]interface{}{}}
+
+	mutes := &fakeMutes{muted: map[[2]int64]bool{}}
+
+	svc := NewGroupRequestService(requests, members, groups, mutes, notifier).(*groupRequestService)
+	return svc, requests, notifier, mutes
+}
+
+func TestApproveJoinRequest_Authority(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID int64
+		wantErr error
+	}{
+		{"group creator", groupCreatorID, nil},
+		{"group admin", groupAdminID, nil},
+		{"plain member", groupMemberID, ErrNotGroupAdmin},
+		{"outsider", outsiderID, ErrNotGroupAdmin},
+		{"requester", requesterID, ErrNotGroupAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range []string{"approve", "reject"} {
+				svc, requests, notifier := newGroupRequestFixture()
+
+				var err error
+				if action == "approve" {
+					err = svc.ApproveJoinRequest(1, tt.actorID)
+				} else {
+					err = svc.RejectJoinRequest(1, tt.actorID)
+				}
+
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s: got error %v, want %v", action, err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					if requests.requests[1].Status != "pending" {
+						t.Errorf("%s: request should stay pending, got %q", action, requests.requests[1].Status)
+					}
+					if len(notifier.sent[requesterID]) != 0 {
+						t.Errorf("%s: requester should not be notified", action)
+					}
+					continue
+				}
+
+				if len(notifier.sent[requesterID]) != 1 {
+					t.Fatalf("%s: expected one notification, got %d", action, len(notifier.sent[requesterID]))
+				}
+				want := "group_request_" + map[string]string{"approve": "approved", "reject": "rejected"}[action]
+				if got := notifier.sent[requesterID][0]["subtype"]; got != want {
+					t.Errorf("%s: notification subtype = %v, want %v", action, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApproveJoinRequest_AddsMember(t *testing.T) {
+	svc, requests, _ := newGroupRequestFixture()
+
+	if err := svc.ApproveJoinRequest(1, groupCreatorID); err != nil {
+		t.Fatalf("ApproveJoinRequest failed: %v", err)
+	}
+	if len(requests.approved) != 1 || requests.roles[1] != GroupRoleMember {
+		t.Errorf("expected request to be approved with role %q, got %v %q", GroupRoleMember, requests.approved, requests.roles[1])
+	}
+
+	if err := svc.ApproveJoinRequest(1, groupCreatorID); !errors.Is(err, ErrRequestNotPending) {
+		t.Errorf("expected ErrRequestNotPending on second approval, got %v", err)
+	}
+}
+
+func TestApproveJoinRequest_OfflineRequester(t *testing.T) {
+	svc, _, notifier := newGroupRequestFixture()
+	notifier.online[requesterID] = false
+
+	if err := svc.ApproveJoinRequest(1, groupCreatorID); err != nil {
+		t.Fatalf("ApproveJoinRequest failed: %v", err)
+	}
+	if len(notifier.sent[requesterID]) != 0 {
+		t.Error("offline requester should not receive a websocket notification")
+	}
+}
+
+func TestJoinRequestOutcomeRespectsMutes(t *testing.T) {
+	for _, action := range []string{"approve", "reject"} {
+		svc, requests, notifier, mutes := newGroupRequestFixtureWithMutes()
+		mutes.muted[[2]int64{requesterID, groupAdminID}] = true
+
+		var err error
+		if action == "approve" {
+			err = svc.ApproveJoinRequest(1, groupAdminID)
+		} else {
+			err = svc.RejectJoinRequest(1, groupAdminID)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", action, err)
+		}
+		if requests.requests[1].Status == "pending" {
+			t.Errorf("%s: a mute must not stop the request being handled", action)
+		}
+		if len(requests.notifications) != 0 || len(notifier.sent[requesterID]) != 0 {
+			t.Errorf("%s: requester who muted the admin was notified: %v, %v", action, requests.notifications, notifier.sent[requesterID])
+		}
+	}
+}
+
+func TestSendJoinRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int64
+		wantErr error
+	}{
+		{"new requester", outsiderID, nil},
+		{"already pending", requesterID, ErrDuplicateJoinRequest},
+		{"existing member", groupMemberID, ErrAlreadyGroupMember},
+		{"group creator", groupCreatorID, ErrAlreadyGroupMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newGroupRequestFixture()
+
+			request, err := svc.SendJoinRequest(10, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (request == nil || request.Status != "pending") {
+				t.Errorf("expected a pending request, got %+v", request)
+			}
+		})
+	}
+}
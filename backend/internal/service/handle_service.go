@@ -0,0 +1,151 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+var (
+	// ErrInvalidHandle is returned for a nickname that breaks the handle rules.
+	ErrInvalidHandle = errors.New("nicknames must be 3 to 30 letters, digits or underscores and start with a letter")
+	// ErrReservedHandle is returned for a nickname kept for the site itself.
+	ErrReservedHandle = errors.New("this nickname is reserved")
+	// ErrHandleTaken is returned for a nickname someone else holds, now or until their old handle expires.
+	ErrHandleTaken = errors.New("nickname already taken")
+	// ErrHandleNotFound is returned when no user holds a handle.
+	ErrHandleNotFound = errors.New("handle not found")
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 30
+)
+
+// reservedHandles could be mistaken for the site, its staff or its routes.
+// Migration 000039 renamed the nicknames on this list that were already taken.
+var reservedHandles = map[string]bool{
+	"admin": true, "administrator": true, "moderator": true, "mod": true, "staff": true,
+	"support": true, "help": true, "official": true, "root": true, "system": true,
+	"api": true, "auth": true, "login": true, "logout": true, "register": true, "signup": true,
+	"settings": true, "me": true, "profile": true, "users": true, "posts": true, "groups": true,
+	"search": true, "everyone": true, "here": true, "anonymous": true, "null": true, "undefined": true,
+}
+
+// NormalizeHandle trims a nickname and any leading "@" and checks it against
+// the handle rules. Case is kept for display; uniqueness ignores it.
+func NormalizeHandle(nickname string) (string, error) {
+	handle := strings.TrimPrefix(strings.TrimSpace(nickname), "@")
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return "", ErrInvalidHandle
+	}
+	for i, c := range handle {
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if i == 0 && !letter {
+			return "", ErrInvalidHandle
+		}
+		if !letter && !(c >= '0' && c <= '9') && c != '_' {
+			return "", ErrInvalidHandle
+		}
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return "", ErrReservedHandle
+	}
+	return handle, nil
+}
+
+// HandleConfig controls what happens to a handle after a rename.
+type HandleConfig struct {
+	// RedirectTTL is how long an old handle keeps resolving to the renamed user.
+	RedirectTTL time.Duration
+}
+
+// HandleConfigFromEnv reads HANDLE_REDIRECT_TTL, a Go duration such as "720h".
+func HandleConfigFromEnv() HandleConfig {
+	config := HandleConfig{RedirectTTL: 30 * 24 * time.Hour}
+	if value := os.Getenv("HANDLE_REDIRECT_TTL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.RedirectTTL = d
+		} else {
+			log.Printf("ignoring invalid HANDLE_REDIRECT_TTL=%q", value)
+		}
+	}
+	return config
+}
+
+type handleService struct {
+	store  store.HandleStore
+	config HandleConfig
+	now    func() time.Time
+}
+
+func NewHandleService(handleStore store.HandleStore, config HandleConfig) HandleService {
+	return &handleService{
+		store:  handleStore,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// CheckAvailable returns ErrHandleTaken unless the handle is free or already
+// belongs to userID. Use 0 for a user who is signing up.
+func (s *handleService) CheckAvailable(handle string, userID int64) error {
+	owner, _, err := s.store.FindHandleOwner(handle, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return ErrHandleTaken
+	}
+	return nil
+}
+
+// Renamed keeps oldHandle resolving to the user for RedirectTTL. Taking back
+// an old handle ends its redirect; changing only its case needs none.
+func (s *handleService) Renamed(userID int64, oldHandle, newHandle string) error {
+	if newHandle != "" {
+		if err := s.store.DeleteHandleRedirect(newHandle); err != nil {
+			return err
+		}
+	}
+	if oldHandle == "" || strings.EqualFold(oldHandle, newHandle) {
+		return nil
+	}
+	now := s.now()
+	return s.store.AddHandleRedirect(oldHandle, userID, now, now.Add(s.config.RedirectTTL))
+}
+
+// Resolve returns the user holding handle and their current handle, which
+// differs from the one asked for when it is an old handle of a renamed user.
+func (s *handleService) Resolve(handle string) (int64, string, error) {
+	handle = strings.TrimPrefix(strings.TrimSpace(handle), "@")
+	userID, current, err := s.store.FindHandleOwner(handle, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrHandleNotFound
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	if current {
+		return userID, handle, nil
+	}
+
+	currentHandle, err := s.store.GetHandle(userID)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load current handle: %w", err)
+	}
+	if currentHandle == "" {
+		// the user gave up handles altogether; the old one still finds them
+		return userID, handle, nil
+	}
+	return userID, currentHandle, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeHandle(t *testing.T) {
+	tests := []struct {
+		nickname string
+		want     string
+		err      error
+	}{
+		{"ada", "ada", nil},
+		{"  @Ada_Lovelace ", "Ada_Lovelace", nil},
+		{"a1_", "a1_", nil},
+		{"ab", "", ErrInvalidHandle},
+		{strings.Repeat("a", 31), "", ErrInvalidHandle},
+		{"1ada", "", ErrInvalidHandle},
+		{"_ada", "", ErrInvalidHandle},
+		{"ada lovelace", "", ErrInvalidHandle},
+		{"ada.l", "", ErrInvalidHandle},
+		{"adá", "", ErrInvalidHandle},
+		{"&lt;b&gt;", "", ErrInvalidHandle},
+		{"Admin", "", ErrReservedHandle},
+		{"@everyone", "", ErrReservedHandle},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeHandle(tt.nickname)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("NormalizeHandle(%q) = %q, %v; want %q, %v", tt.nickname, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+type fakeHandleRedirect struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+type fakeHandleStore struct {
+	handles   map[int64]string
+	redirects map[string]fakeHandleRedirect
+}
+
+func (f *fakeHandleStore) GetHandle(userID int64) (string, error) {
+	handle, ok := f.handles[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return handle, nil
+}
+
+func (f *fakeHandleStore) FindHandleOwner(handle string, now time.Time) (int64, bool, error) {
+	for id, h := range f.handles {
+		if strings.EqualFold(h, handle) {
+			return id, true, nil
+		}
+	}
+	if r, ok := f.redirects[strings.ToLower(handle)]; ok && r.expiresAt.After(now) {
+		return r.userID, false, nil
+	}
+	return 0, false, sql.ErrNoRows
+}
+
+func (f *fakeHandleStore) AddHandleRedirect(handle string, userID int64, now, expiresAt time.Time) error {
+	f.redirects[strings.ToLower(handle)] = fakeHandleRedirect{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakeHandleStore) DeleteHandleRedirect(handle string) error {
+	delete(f.redirects, strings.ToLower(handle))
+	return nil
+}
+
+func TestHandleRenames(t *testing.T) {
+	handleStore := &fakeHandleStore{
+		handles:   map[int64]string{1: "ada", 2: "grace"},
+		redirects: map[string]fakeHandleRedirect{},
+	}
+	svc := NewHandleService(handleStore, HandleConfig{RedirectTTL: 24 * time.Hour}).(*handleService)
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+
+	if err := svc.CheckAvailable("GRACE", 1); !errors.Is(err, ErrHandleTaken) {
+		t.Errorf("someone else's handle: got %v", err)
+	}
+	if err := svc.CheckAvailable("Ada", 1); err != nil {
+		t.Errorf("your own handle in another case: got %v", err)
+	}
+
+	handleStore.handles[1] = "countess"
+	if err := svc.Renamed(1, "ada", "countess"); err != nil {
+		t.Fatal(err)
+	}
+	if id, current, err := svc.Resolve("@ADA"); err != nil || id != 1 || current != "countess" {
+		t.Errorf("old handle: got %d, %q, %v", id, current, err)
+	}
+	if err := svc.CheckAvailable("ada", 2); !errors.Is(err, ErrHandleTaken) {
+		t.Errorf("an old handle stays reserved while it redirects: got %v", err)
+	}
+
+	// a case change needs no redirect, and taking a handle back ends its redirect
+	if err := svc.Renamed(1, "countess", "Countess"); err != nil || len(handleStore.redirects) != 1 {
+		t.Errorf("case change: %v, redirects %v", err, handleStore.redirects)
+	}
+	handleStore.handles[1] = "ada"
+	if err := svc.Renamed(1, "Countess", "ada"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := handleStore.redirects["ada"]; ok {
+		t.Error("taking a handle back should drop its redirect")
+	}
+
+	now = now.Add(25 * time.Hour)
+	if _, _, err := svc.Resolve("countess"); !errors.Is(err, ErrHandleNotFound) {
+		t.Errorf("expired redirect: got %v", err)
+	}
+	if err := svc.CheckAvailable("countess", 2); err != nil {
+		t.Errorf("an expired handle is free again: got %v", err)
+	}
+}
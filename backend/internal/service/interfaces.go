@@ -1,35 +1,45 @@
 package service
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/tajjjjr/social-network/backend/internal/models"
 )
 
 // AuthServiceInterface defines the interface for the auth service.
 type AuthServiceInterface interface {
-	AuthenticateUser(email, password string) (*models.User, string, error)
+	AuthenticateUser(email, password string, client models.SessionClient) (*models.User, string, error)
 	DeleteSession(sessionID string) (int, error)
+	GetSession(sessionID string) (*models.Session, error)
 	GetUserIDBySession(sessionID string) (int, error)
 	CreateUser(user *models.User) (*models.User, error)
 	ValidateEmail(email string) (bool, error)
 	UserExists(email string) (bool, error)
 	UserNewEditEmailExist(email string, userid int64) (bool, error)
 	EditUserProfile(user *models.User, userid int64) error
+	ChangePassword(userID int64, keepSessionID, currentPassword, newPassword string) error
+	CompleteTwoFactorLogin(token, code string, client models.SessionClient) (*models.Session, error)
+	LoginUser(userID int64, client models.SessionClient) (*models.Session, string, error)
 }
 
 // PostServiceInterface defines the interface for the post service.
 type PostServiceInterface interface {
-	CreatePost(post *models.Post, imageData []byte, imageMimeType string) (int64, error)
-	CreatePostWithViewers(post *models.Post, imageData []byte, imageMimeType string, viewerIDs []int64) (int64, error)
-	GetPostByID(id int64) (*models.Post, error)
+	CreatePost(post *models.Post, images []models.ImageUpload) (int64, error)
+	CreatePostWithViewers(post *models.Post, images []models.ImageUpload, viewerIDs []int64) (int64, error)
+	GetPostByID(id, viewerID int64) (*models.Post, error)
 	GetPosts(userID int64) ([]*models.Post, error)
 	GetPostsPaginated(userID int64, limit, offset int) ([]*models.Post, error)
 	GetPostsCount(userID int64) (int, error)
-	UpdatePost(postID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Post, error)
-	CreateComment(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error)
+	GetFeed(userID int64, before, after string, limit int) (*models.FeedPage, error)
+	CountNewPosts(userID int64, since string) (int, error)
+	UpdatePost(postID, userID int64, content string, edit models.AttachmentEdit) (*models.Post, error)
+	CreateComment(comment *models.Comment, images []models.ImageUpload) (int64, error)
 	GetCommentsByPostID(postID, userID int64) ([]*models.Comment, error)
 	DeletePost(postID, userID int64) error
 	SearchUsers(query string, currentUserID int64) ([]*models.User, error)
-	UpdateComment(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error)
+	UpdateComment(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error)
 	DeleteComment(commentID, userID int64) error
 	GetCommentByID(commentID int64) (*models.Comment, error)
 }
@@ -40,6 +50,7 @@ type FollowServiceInterface interface {
 	CreateFollowForPrivateAccount(followrid, followeeid int64) (int64, error)
 	GetUserInfo(userID int64) (string, string, error)
 	AddtoNotification(follower_id int64, message string) error
+	ShouldNotify(recipientID, actorID int64, message string) bool
 }
 
 type UnfollowServiceInterface interface {
@@ -56,15 +67,97 @@ type FollowRequestServiceInterface interface {
 	GetRequestIDByUsers(followerID, followeeID int64) (int64, error)
 	AddtoNotification(follower_id int64, message string) error
 	GetPendingFollowRequest(userid int64) (models.FollowRequestUserResponse, error)
+	ShouldNotify(recipientID, actorID int64, message string) bool
 }
 
 type ProfileServiceInterface interface {
 	GetUserOwnProfile(userid int64) (models.ProfileDetails, error)
 	GetUserProfile(userid, LoggedInUser int64) (models.ProfileDetails, error)
-	GetUserPosts(userid int64) ([]models.Post, error)
-	GetFollowersList(userid int64) (models.FollowListResponse, error)
-	GetFolloweesList(userid int64) (models.FollowListResponse, error)
-	GetUserPhotos(userId int64) ([]models.Photo, error)
+	GetUserPosts(userid, viewerID int64) ([]models.Post, error)
+	GetFollowersList(userid, viewerID int64, cursor string, limit int) (*models.FollowListResponse, error)
+	GetFolloweesList(userid, viewerID int64, cursor string, limit int) (*models.FollowListResponse, error)
+	CountFollowers(userid int64) (followers, followees int, err error)
+	GetUserPhotos(userId, viewerID int64) ([]models.Photo, error)
+}
+
+// SessionService owns the lifecycle of login sessions.
+type SessionService interface {
+	CreateSession(userID int64, client models.SessionClient) (*models.Session, error)
+	ValidateSession(sessionID string) (*models.Session, bool, error)
+	ListSessions(userID int64, currentID string) ([]*models.Session, error)
+	RevokeSession(sessionID string) error
+	RevokeUserSession(userID int64, publicID string) (*models.Session, error)
+	RevokeOtherSessions(userID int64, keepID string) (int, error)
+	RevokeAllSessions(userID int64) (int, error)
+	PruneExpiredSessions() (int, error)
+	RunPruner(ctx context.Context)
+}
+
+// PasswordResetService lets users who forgot their password choose a new one.
+type PasswordResetService interface {
+	RequestReset(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+// EmailVerificationService confirms that users own their email address.
+type EmailVerificationService interface {
+	SendVerification(userID int64, email string) error
+	ResendVerification(userID int64) error
+	VerifyEmail(token string) error
+	MarkVerified(userID int64, email string) error
+	IsEmailVerified(userID int64) (bool, error)
+}
+
+// TwoFactorService manages TOTP two-factor authentication and the second login step.
+type TwoFactorService interface {
+	Enroll(userID int64) (*models.TwoFactorEnrollment, error)
+	Confirm(userID int64, code string) error
+	Disable(userID int64, password, code string) error
+	Enabled(userID int64) (bool, error)
+	BeginLogin(userID int64) (string, error)
+	ChallengeUser(token string) (int64, error)
+	CompleteLogin(token, code string) (int64, error)
+}
+
+// LoginThrottleService tracks failed logins per account and client address.
+type LoginThrottleService interface {
+	Check(email string, client models.SessionClient) error
+	RecordFailure(email string, userID int64, client models.SessionClient) error
+	RecordSuccess(email string) error
+}
+
+// PasswordHashStore reads stored password hashes.
+type PasswordHashStore interface {
+	GetPasswordHash(userID int64) (string, error)
+}
+
+// UserFinder looks up accounts by email.
+type UserFinder interface {
+	GetUserByEmail(email string) (*models.User, error)
+}
+
+// SessionCloser drops live connections bound to sessions that have ended.
+type SessionCloser interface {
+	CloseSessions(sessionIDs ...string)
+}
+
+// AccessTokenService issues and checks personal access tokens.
+type AccessTokenService interface {
+	CreateToken(userID int64, name, scope string, ttl time.Duration) (string, *models.AccessToken, error)
+	ValidateToken(token string) (*models.AccessToken, error)
+	ListTokens(userID int64) ([]*models.AccessToken, error)
+	RevokeToken(userID, tokenID int64) error
+}
+
+// AccessTokenCloser drops live connections opened with revoked access tokens.
+type AccessTokenCloser interface {
+	CloseAccessTokens(tokenIDs ...int64)
+}
+
+// Notifier delivers real-time notifications to connected users.
+type Notifier interface {
+	SendNotification(userID int64, data map[string]interface{})
+	IsOnline(userID int64) bool
 }
 
 type GroupService interface {
@@ -82,3 +175,101 @@ type GroupChatMessageService interface {
 	SendGroupChatMessage(groupID, senderID int64, content string) (*models.GroupChatMessage, error)
 	GetGroupChatMessages(groupID int64, userID int64, limit, offset int) ([]*models.GroupChatMessage, error)
 }
+
+// ExternalAccountService finds and creates the local users behind external identities.
+type ExternalAccountService interface {
+	UserIDByEmail(email string) (int64, error)
+	CreateExternalUser(user *models.User, emailVerified bool) (*models.User, error)
+}
+
+// OIDCService signs users in through OpenID Connect providers.
+type OIDCService interface {
+	Providers() []string
+	BeginLogin(ctx context.Context, provider string) (authURL, state string, err error)
+	CompleteLogin(ctx context.Context, provider, state, code string) (int64, error)
+}
+
+// AccountDeletionService schedules account deletion and purges accounts once their grace period is over.
+type AccountDeletionService interface {
+	ScheduleDeletion(userID int64) (time.Time, error)
+	CancelDeletion(userID int64) (bool, error)
+	PurgeDueAccounts() (int, error)
+	RunPurger(ctx context.Context)
+}
+
+// AdminService backs the admin API: site roles, suspensions and platform counts.
+type AdminService interface {
+	GetRole(userID int64) (string, error)
+	IsSuspended(userID int64) (bool, error)
+	ListUsers(query string, limit, offset int) ([]*models.AdminUser, error)
+	SetSuspended(actorID, userID int64, suspended bool) error
+	ForceLogout(actorID, userID int64) (int, error)
+	SetRole(actorID, userID int64, role string) error
+	BootstrapAdmin(email string) (int64, error)
+	Stats() (*models.PlatformStats, error)
+}
+
+// SuspensionChecker reports whether a user is barred from logging in.
+type SuspensionChecker interface {
+	IsSuspended(userID int64) (bool, error)
+}
+
+// HandleService keeps nickname handles unique and old handles resolving after a rename.
+type HandleService interface {
+	CheckAvailable(handle string, userID int64) error
+	Renamed(userID int64, oldHandle, newHandle string) error
+	Resolve(handle string) (int64, string, error)
+}
+
+// BlockService lets users block each other. A block hides the two users from
+// each other everywhere: follows, chat, posts, comments, reactions and search.
+type BlockService interface {
+	Block(blockerID, blockedID int64) error
+	Unblock(blockerID, blockedID int64) error
+	ListBlocked(blockerID int64) ([]*models.BlockedUser, error)
+	IsBlocked(userID, otherID int64) (bool, error)
+	// BlockedUsers returns the users userID blocked or was blocked by.
+	BlockedUsers(userID int64) (map[int64]bool, error)
+}
+
+// MuteService quiets users and keywords in one's feed and notifications
+// without the muted users being told.
+type MuteService interface {
+	MuteUser(userID, mutedID int64, duration time.Duration) (*time.Time, error)
+	UnmuteUser(userID, mutedID int64) error
+	ListMutedUsers(userID int64) ([]*models.MutedUser, error)
+	MuteKeyword(userID int64, keyword string) (*models.MutedKeyword, error)
+	UnmuteKeyword(userID, keywordID int64) error
+	ListMutedKeywords(userID int64) ([]*models.MutedKeyword, error)
+	ShouldNotify(recipientID, actorID int64, message string) bool
+}
+
+// DataExportService builds downloadable archives of a user's data in the background.
+type DataExportService interface {
+	RequestExport(userID int64) (*models.DataExport, error)
+	GetExport(userID, exportID int64) (*models.DataExport, error)
+	OpenExport(userID, exportID int64) (*os.File, *models.DataExport, error)
+	ProcessPending() (int, error)
+	RemoveExpired() (int, error)
+	RunWorker(ctx context.Context)
+}
+
+// TagService serves the timelines of hashtags and the tags trending now.
+type TagService interface {
+	GetTagTimeline(userID int64, tag, before string, limit int) (*models.FeedPage, error)
+	TrendingTags(limit int) ([]models.TrendingTag, error)
+}
+
+// MentionService records @mentions and tells the people mentioned who can see
+// them, and suggests handles to mention.
+type MentionService interface {
+	MentionsInPost(post *models.Post)
+	MentionsInComment(comment *models.Comment)
+	MentionsInMessage(messageID, senderID, groupID int64, content string, readers []int64)
+	Autocomplete(userID int64, prefix string, limit int) ([]models.MentionCandidate, error)
+}
+
+// SearchService finds posts and comments by their content.
+type SearchService interface {
+	SearchPosts(userID int64, query, cursor string, limit int) (*models.SearchPage, error)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// ErrLoginThrottled is matched by the *LoginThrottledError returned while
+// an account or client address has to wait before trying to log in again.
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginThrottledError tells the client how long to wait before the next attempt.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrLoginThrottled, e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+// Reasons recorded in the failed login audit log.
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureThrottled          = "throttled"
+)
+
+// ThrottleLimit sets how many failures are tolerated before backoff and lockout.
+type ThrottleLimit struct {
+	FreeAttempts int // failures allowed before each attempt must wait
+	LockoutAfter int // failures that lock logins for LockoutDuration
+}
+
+// LoginThrottleConfig controls failed login tracking. After FreeAttempts
+// failures the wait before the next attempt starts at BaseDelay and doubles
+// with each further failure, up to MaxDelay. Failures older than
+// FailureWindow are forgotten.
+type LoginThrottleConfig struct {
+	Account         ThrottleLimit
+	IP              ThrottleLimit
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	FailureWindow   time.Duration
+}
+
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		Account:         ThrottleLimit{FreeAttempts: 3, LockoutAfter: 10},
+		IP:              ThrottleLimit{FreeAttempts: 10, LockoutAfter: 100},
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		FailureWindow:   time.Hour,
+	}
+}
+
+// LoginThrottleConfigFromEnv overrides the defaults with LOGIN_LOCKOUT_DURATION
+// and LOGIN_FAILURE_WINDOW (Go durations), and LOGIN_ACCOUNT_LOCKOUT_AFTER and
+// LOGIN_IP_LOCKOUT_AFTER (failure counts).
+func LoginThrottleConfigFromEnv() LoginThrottleConfig {
+	config := DefaultLoginThrottleConfig()
+	for name, target := range map[string]*time.Duration{
+		"LOGIN_LOCKOUT_DURATION": &config.LockoutDuration,
+		"LOGIN_FAILURE_WINDOW":   &config.FailureWindow,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				*target = d
+			} else {
+				log.Printf("ignoring invalid %s=%q", name, value)
+			}
+		}
+	}
+	for name, target := range map[string]*int{
+		"LOGIN_ACCOUNT_LOCKOUT_AFTER": &config.Account.LockoutAfter,
+		"LOGIN_IP_LOCKOUT_AFTER":      &config.IP.LockoutAfter,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				*target = n
+			} else {
+				log.Printf("ignoring invalid %s=%q", name, value)
+			}
+		}
+	}
+	return config
+}
+
+type loginThrottleService struct {
+	store  store.LoginAttemptStore
+	config LoginThrottleConfig
+	now    func() time.Time
+}
+
+func NewLoginThrottleService(attempts store.LoginAttemptStore, config LoginThrottleConfig) LoginThrottleService {
+	return &loginThrottleService{
+		store:  attempts,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// accountKey is the same for every spelling of an email, whether or not an account uses it.
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// keys lists the throttles an attempt counts against with their limits.
+func (s *loginThrottleService) keys(email, ip string) map[string]ThrottleLimit {
+	keys := map[string]ThrottleLimit{accountKey(email): s.config.Account}
+	if ip != "" {
+		keys[ipKey(ip)] = s.config.IP
+	}
+	return keys
+}
+
+// active reports whether t still counts at now, i.e. it is neither past its
+// failure window nor at the end of a lockout.
+func (s *loginThrottleService) active(t *models.LoginThrottle, now time.Time) bool {
+	if t.LockedUntil != nil {
+		return now.Before(*t.LockedUntil)
+	}
+	return now.Sub(t.LastFailureAt) < s.config.FailureWindow
+}
+
+// wait is how long after now the next attempt against t must wait.
+func (s *loginThrottleService) wait(t *models.LoginThrottle, limit ThrottleLimit, now time.Time) time.Duration {
+	if !s.active(t, now) {
+		return 0
+	}
+	if t.LockedUntil != nil {
+		return t.LockedUntil.Sub(now)
+	}
+	if t.Failures <= limit.FreeAttempts {
+		return 0
+	}
+
+	delay := s.config.MaxDelay
+	if shift := t.Failures - limit.FreeAttempts - 1; shift < 16 {
+		delay = min(s.config.BaseDelay<<shift, s.config.MaxDelay)
+	}
+	return max(t.LastFailureAt.Add(delay).Sub(now), 0)
+}
+
+// Check returns a *LoginThrottledError when the account or the client address
+// must wait before the next attempt. Blocked attempts go to the audit log.
+func (s *loginThrottleService) Check(email string, client models.SessionClient) error {
+	now := s.now()
+	var retryAfter time.Duration
+	for key, limit := range s.keys(email, client.IPAddress) {
+		t, err := s.store.GetThrottle(key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		retryAfter = max(retryAfter, s.wait(t, limit, now))
+	}
+	if retryAfter == 0 {
+		return nil
+	}
+
+	if err := s.audit(email, 0, client, LoginFailureThrottled, now); err != nil {
+		return err
+	}
+	return &LoginThrottledError{RetryAfter: retryAfter}
+}
+
+// RecordFailure counts a failed login against the account and the client
+// address and writes it to the audit log. userID is 0 for unknown emails.
+func (s *loginThrottleService) RecordFailure(email string, userID int64, client models.SessionClient) error {
+	now := s.now()
+	for key, limit := range s.keys(email, client.IPAddress) {
+		t, err := s.store.GetThrottle(key)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !s.active(t, now)) {
+			t, err = &models.LoginThrottle{Key: key}, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		t.Failures++
+		t.LastFailureAt = now
+		if t.Failures >= limit.LockoutAfter {
+			lockedUntil := now.Add(s.config.LockoutDuration)
+			t.LockedUntil = &lockedUntil
+		}
+		if err := s.store.SaveThrottle(t); err != nil {
+			return err
+		}
+	}
+	return s.audit(email, userID, client, LoginFailureInvalidCredentials, now)
+}
+
+// RecordSuccess forgets the failures of the account. Failures from the client
+// address still count, so one good login does not reset an attack.
+func (s *loginThrottleService) RecordSuccess(email string) error {
+	return s.store.ClearThrottle(accountKey(email))
+}
+
+func (s *loginThrottleService) audit(email string, userID int64, client models.SessionClient, reason string, now time.Time) error {
+	failure := &models.LoginFailure{
+		Email:     email,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if userID != 0 {
+		failure.UserID = &userID
+	}
+	return s.store.RecordFailure(failure)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeLoginAttemptStore struct {
+	failures  []*models.LoginFailure
+	throttles map[string]models.LoginThrottle
+}
+
+func (f *fakeLoginAttemptStore) RecordFailure(failure *models.LoginFailure) error {
+	f.failures = append(f.failures, failure)
+	return nil
+}
+
+func (f *fakeLoginAttemptStore) GetThrottle(key string) (*models.LoginThrottle, error) {
+	t, ok := f.throttles[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+func (f *fakeLoginAttemptStore) SaveThrottle(t *models.LoginThrottle) error {
+	f.throttles[t.Key] = *t
+	return nil
+}
+
+func (f *fakeLoginAttemptStore) ClearThrottle(key string) error {
+	delete(f.throttles, key)
+	return nil
+}
+
+func newLoginThrottleFixture() (*loginThrottleService, *fakeLoginAttemptStore, *time.Time) {
+	attempts := &fakeLoginAttemptStore{throttles: map[string]models.LoginThrottle{}}
+	svc := NewLoginThrottleService(attempts, LoginThrottleConfig{
+		Account:         ThrottleLimit{FreeAttempts: 2, LockoutAfter: 5},
+		IP:              ThrottleLimit{FreeAttempts: 4, LockoutAfter: 8},
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+		FailureWindow:   time.Hour,
+	}).(*loginThrottleService)
+
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+	return svc, attempts, &now
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("expected *LoginThrottledError, got %v", err)
+	}
+	return throttled.RetryAfter
+}
+
+func TestLoginThrottleBackoffAndLockout(t *testing.T) {
+	svc, attempts, now := newLoginThrottleFixture()
+	client := models.SessionClient{IPAddress: "10.0.0.1"}
+
+	for i := 0; i < 2; i++ {
+		svc.RecordFailure("ada@example.com", 7, client)
+	}
+	if err := svc.Check("ada@example.com", client); err != nil {
+		t.Fatalf("free attempts should not wait: %v", err)
+	}
+
+	// Each further failure doubles the wait: 1s, 2s, 4s.
+	for i, want := range []time.Duration{time.Second, 2 * time.Second} {
+		svc.RecordFailure("ADA@example.com", 7, client)
+		if got := retryAfter(t, svc.Check("ada@example.com", client)); got != want {
+			t.Errorf("failure %d: retry after %s, want %s", i+3, got, want)
+		}
+	}
+	*now = now.Add(2 * time.Second)
+	if err := svc.Check("ada@example.com", client); err != nil {
+		t.Fatalf("backoff should have passed: %v", err)
+	}
+
+	// The fifth failure locks the account, from any address.
+	svc.RecordFailure("ada@example.com", 7, client)
+	elsewhere := models.SessionClient{IPAddress: "10.0.0.2"}
+	if got := retryAfter(t, svc.Check("ada@example.com", elsewhere)); got != 15*time.Minute {
+		t.Errorf("lockout retry after %s, want 15m", got)
+	}
+
+	*now = now.Add(15 * time.Minute)
+	if err := svc.Check("ada@example.com", elsewhere); err != nil {
+		t.Fatalf("lockout should have ended: %v", err)
+	}
+	svc.RecordFailure("ada@example.com", 7, elsewhere)
+	if got := attempts.throttles[accountKey("ada@example.com")].Failures; got != 1 {
+		t.Errorf("failures after lockout = %d, want a fresh count of 1", got)
+	}
+
+	var throttled int
+	for _, f := range attempts.failures {
+		if f.Reason == LoginFailureThrottled {
+			throttled++
+		}
+	}
+	if len(attempts.failures) != 9 || throttled != 3 {
+		t.Errorf("audit log has %d rows (%d throttled), want 9 (3 throttled)", len(attempts.failures), throttled)
+	}
+}
+
+func TestLoginThrottlePerIP(t *testing.T) {
+	svc, _, _ := newLoginThrottleFixture()
+	client := models.SessionClient{IPAddress: "10.0.0.1"}
+
+	// Spraying one password over many accounts is caught by the address limit.
+	for i, email := range []string{"a@x.io", "b@x.io", "c@x.io", "d@x.io", "e@x.io"} {
+		svc.RecordFailure(email, int64(i), client)
+	}
+	if got := retryAfter(t, svc.Check("f@x.io", client)); got != time.Second {
+		t.Errorf("retry after %s, want 1s", got)
+	}
+	if err := svc.Check("f@x.io", models.SessionClient{IPAddress: "10.0.0.2"}); err != nil {
+		t.Errorf("other addresses should not wait: %v", err)
+	}
+}
+
+func TestLoginThrottleSuccessAndWindow(t *testing.T) {
+	svc, attempts, now := newLoginThrottleFixture()
+	client := models.SessionClient{IPAddress: "10.0.0.1"}
+
+	for i := 0; i < 3; i++ {
+		svc.RecordFailure("ada@example.com", 7, client)
+	}
+	if err := svc.RecordSuccess("Ada@Example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := attempts.throttles[accountKey("ada@example.com")]; ok {
+		t.Error("success should clear the account failures")
+	}
+	if got := attempts.throttles[ipKey("10.0.0.1")].Failures; got != 3 {
+		t.Errorf("address failures = %d, want 3", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		svc.RecordFailure("bob@example.com", 0, client)
+	}
+	*now = now.Add(time.Hour)
+	if err := svc.Check("bob@example.com", client); err != nil {
+		t.Errorf("failures outside the window should be forgotten: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+type mentionService struct {
+	store      store.MentionStore
+	visibility *PostVisibilityPolicy
+	mutes      MuteService
+	notifier   Notifier
+	now        func() time.Time
+}
+
+// NewMentionService creates a MentionService. mutes and notifier may be nil
+// to skip checking mutes and sending real-time notifications.
+func NewMentionService(mentions store.MentionStore, visibility *PostVisibilityPolicy, mutes MuteService, notifier Notifier) MentionService {
+	return &mentionService{
+		store:      mentions,
+		visibility: visibility,
+		mutes:      mutes,
+		notifier:   notifier,
+		now:        time.Now,
+	}
+}
+
+// MentionsInPost records who post mentions and notifies those newly
+// mentioned who may see the post. Failures are logged; the post stands.
+func (s *mentionService) MentionsInPost(post *models.Post) {
+	added, err := s.store.SetPostMentions(post.ID, post.UserID, utils.Mentions(post.Content), s.now())
+	if err != nil {
+		log.Printf("failed to record mentions in post %d: %v", post.ID, err)
+		return
+	}
+	for _, userID := range added {
+		allowed, err := s.visibility.CanView(userID, post)
+		if err != nil {
+			log.Printf("failed to check whether user %d may see post %d: %v", userID, post.ID, err)
+			continue
+		}
+		if allowed {
+			s.notify(userID, post.UserID, "a post", post.Content, map[string]interface{}{"post_id": post.ID})
+		}
+	}
+}
+
+// MentionsInComment is MentionsInPost for a comment, which those mentioned
+// may see when they may see its post and its author.
+func (s *mentionService) MentionsInComment(comment *models.Comment) {
+	added, err := s.store.SetCommentMentions(comment.ID, comment.UserID, utils.Mentions(comment.Content), s.now())
+	if err != nil {
+		log.Printf("failed to record mentions in comment %d: %v", comment.ID, err)
+		return
+	}
+	for _, userID := range added {
+		if _, err := s.visibility.VisibleComment(userID, comment.ID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("failed to check whether user %d may see comment %d: %v", userID, comment.ID, err)
+			}
+			continue
+		}
+		s.notify(userID, comment.UserID, "a comment", comment.Content, map[string]interface{}{
+			"post_id":    comment.PostID,
+			"comment_id": comment.ID,
+		})
+	}
+}
+
+// MentionsInMessage records who a chat message mentions and notifies those
+// among readers, the users the message was delivered to. groupID is 0 for a
+// private message.
+func (s *mentionService) MentionsInMessage(messageID, senderID, groupID int64, content string, readers []int64) {
+	added, err := s.store.SetMessageMentions(messageID, senderID, utils.Mentions(content), s.now())
+	if err != nil {
+		log.Printf("failed to record mentions in message %d: %v", messageID, err)
+		return
+	}
+	canRead := map[int64]bool{}
+	for _, id := range readers {
+		canRead[id] = true
+	}
+	for _, userID := range added {
+		if !canRead[userID] {
+			continue
+		}
+		data := map[string]interface{}{"message_id": messageID}
+		if groupID != 0 {
+			data["group_id"] = groupID
+		}
+		s.notify(userID, senderID, "a message", content, data)
+	}
+}
+
+// notify stores a mention notification for userID and pushes it if they are
+// online, unless they muted the author or a keyword of the content.
+func (s *mentionService) notify(userID, authorID int64, where, content string, data map[string]interface{}) {
+	if s.mutes != nil && !s.mutes.ShouldNotify(userID, authorID, content) {
+		return
+	}
+	name, avatar, err := s.store.AuthorInfo(authorID)
+	if err != nil {
+		log.Printf("failed to load author %d of a mention: %v", authorID, err)
+		return
+	}
+	message := name + " mentioned you in " + where
+	if err := s.store.AddMentionNotification(userID, message); err != nil {
+		log.Printf("failed to notify user %d of a mention: %v", userID, err)
+		return
+	}
+
+	if s.notifier == nil || !s.notifier.IsOnline(userID) {
+		return
+	}
+	data["type"] = "notification"
+	data["subtype"] = "mention"
+	data["user_id"] = authorID
+	data["user_name"] = name
+	data["avatar"] = avatar
+	data["message"] = message
+	data["timestamp"] = s.now().Unix()
+	s.notifier.SendNotification(userID, data)
+}
+
+// Autocomplete returns up to limit users whose handle starts with prefix,
+// people userID follows first. A prefix that cannot start a handle matches
+// nobody.
+func (s *mentionService) Autocomplete(userID int64, prefix string, limit int) ([]models.MentionCandidate, error) {
+	prefix, ok := utils.MentionPrefix(prefix)
+	if !ok {
+		return []models.MentionCandidate{}, nil
+	}
+	return s.store.MentionCandidates(userID, prefix, limit)
+}
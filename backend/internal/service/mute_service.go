@@ -0,0 +1,113 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+var (
+	// ErrMuteSelf is returned when users try to mute themselves.
+	ErrMuteSelf = errors.New("you cannot mute yourself")
+	// ErrInvalidMuteDuration is returned for a negative mute duration.
+	ErrInvalidMuteDuration = errors.New("mute duration cannot be negative")
+	// ErrInvalidKeyword is returned for an empty or overlong muted keyword.
+	ErrInvalidKeyword = errors.New("keywords must be 1 to 100 characters")
+	// ErrKeywordExists is returned when muting a keyword twice.
+	ErrKeywordExists = errors.New("keyword already muted")
+	// ErrKeywordNotFound is returned when unmuting a keyword that is not muted.
+	ErrKeywordNotFound = errors.New("muted keyword not found")
+)
+
+const maxKeywordLength = 100
+
+type muteService struct {
+	store store.MuteStore
+	now   func() time.Time
+}
+
+// NewMuteService creates the service behind user and keyword mutes.
+func NewMuteService(mutes store.MuteStore) MuteService {
+	return &muteService{store: mutes, now: time.Now}
+}
+
+// MuteUser mutes mutedID for userID for duration, or until unmuted when
+// duration is 0, and returns when the mute ends.
+func (s *muteService) MuteUser(userID, mutedID int64, duration time.Duration) (*time.Time, error) {
+	if userID == mutedID {
+		return nil, ErrMuteSelf
+	}
+	if duration < 0 {
+		return nil, ErrInvalidMuteDuration
+	}
+
+	now := s.now()
+	var expiresAt *time.Time
+	if duration > 0 {
+		expires := now.Add(duration).UTC()
+		expiresAt = &expires
+	}
+	if err := s.store.MuteUser(userID, mutedID, expiresAt, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return expiresAt, nil
+}
+
+// UnmuteUser lifts a mute; unmuting someone who is not muted does nothing.
+func (s *muteService) UnmuteUser(userID, mutedID int64) error {
+	_, err := s.store.UnmuteUser(userID, mutedID)
+	return err
+}
+
+func (s *muteService) ListMutedUsers(userID int64) ([]*models.MutedUser, error) {
+	return s.store.ListMutedUsers(userID, s.now())
+}
+
+// MuteKeyword hides posts and notifications containing keyword, ignoring case.
+func (s *muteService) MuteKeyword(userID int64, keyword string) (*models.MutedKeyword, error) {
+	keyword = strings.Join(strings.Fields(keyword), " ")
+	if keyword == "" || utf8.RuneCountInString(keyword) > maxKeywordLength {
+		return nil, ErrInvalidKeyword
+	}
+	muted, err := s.store.AddMutedKeyword(userID, keyword, s.now())
+	if errors.Is(err, store.ErrMutedKeywordExists) {
+		return nil, ErrKeywordExists
+	}
+	return muted, err
+}
+
+func (s *muteService) UnmuteKeyword(userID, keywordID int64) error {
+	removed, err := s.store.DeleteMutedKeyword(userID, keywordID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrKeywordNotFound
+	}
+	return nil
+}
+
+func (s *muteService) ListMutedKeywords(userID int64) ([]*models.MutedKeyword, error) {
+	return s.store.ListMutedKeywords(userID)
+}
+
+// ShouldNotify reports whether recipientID wants a notification about
+// actorID's activity that reads message. If the mutes cannot be checked the
+// notification is sent anyway.
+func (s *muteService) ShouldNotify(recipientID, actorID int64, message string) bool {
+	muted, err := s.store.IsMuted(recipientID, actorID, message, s.now())
+	if err != nil {
+		log.Printf("failed to check mutes of user %d: %v", recipientID, err)
+		return true
+	}
+	return !muted
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/oidc"
+)
+
+var (
+	// ErrUnknownOIDCProvider is returned for a provider name that is not configured.
+	ErrUnknownOIDCProvider = errors.New("unknown sign-in provider")
+	// ErrInvalidOIDCState is returned when the login state is unknown, expired or for another provider.
+	ErrInvalidOIDCState = errors.New("sign-in request expired or is invalid")
+	// ErrOIDCLoginFailed wraps failures to exchange the code or verify the ID token.
+	ErrOIDCLoginFailed = errors.New("sign-in with the provider failed")
+	// ErrOIDCEmailRequired is returned when the provider does not share an email address.
+	ErrOIDCEmailRequired = errors.New("the provider did not share an email address")
+	// ErrOIDCAccountExists is returned when an account with the email exists but
+	// cannot be linked because one of the two sides has not verified the address.
+	ErrOIDCAccountExists = errors.New("an account with this email already exists; sign in with your password")
+)
+
+// OIDCConfig controls OpenID Connect sign-in.
+type OIDCConfig struct {
+	// StateTTL is how long a user has to finish signing in at the provider.
+	StateTTL time.Duration
+	// AfterLoginURL is where the browser is sent once the login completes or fails.
+	AfterLoginURL string
+}
+
+// DefaultOIDCConfig returns the OpenID Connect settings used when none are configured.
+func DefaultOIDCConfig() OIDCConfig {
+	return OIDCConfig{StateTTL: 10 * time.Minute, AfterLoginURL: "/"}
+}
+
+// OIDCConfigFromEnv reads OIDC_LOGIN_REDIRECT over the defaults.
+func OIDCConfigFromEnv() OIDCConfig {
+	config := DefaultOIDCConfig()
+	if v := os.Getenv("OIDC_LOGIN_REDIRECT"); v != "" {
+		config.AfterLoginURL = v
+	}
+	return config
+}
+
+type oidcService struct {
+	providers    map[string]*oidc.Provider
+	store        store.OIDCStore
+	accounts     ExternalAccountService
+	verification EmailVerificationService
+	config       OIDCConfig
+	now          func() time.Time
+}
+
+// NewOIDCService signs users in with the given providers. verification may be
+// nil, in which case existing accounts are never linked by email.
+func NewOIDCService(providers []*oidc.Provider, oidcStore store.OIDCStore, accounts ExternalAccountService, verification EmailVerificationService, config OIDCConfig) OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Config.Name] = p
+	}
+	return &oidcService{
+		providers:    byName,
+		store:        oidcStore,
+		accounts:     accounts,
+		verification: verification,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin returns the provider URL to send the user to and the state the
+// callback must present. The PKCE verifier and nonce stay on the server.
+func (s *oidcService) BeginLogin(ctx context.Context, provider string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	now := s.now()
+	if err := s.store.DeleteExpiredLoginStates(now); err != nil {
+		return "", "", err
+	}
+	err = s.store.CreateLoginState(hashToken(state), &models.OIDCLoginState{
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.config.StateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteLogin finishes a login started by BeginLogin and returns the local user.
+//
+// A provider account already linked signs in its user. Otherwise the account
+// is linked to the user with the same email, but only if the provider and the
+// user have both verified it; an unverified match returns ErrOIDCAccountExists
+// so nobody can take over an account by claiming its address. When no user has
+// the email a new account is created through the signup path.
+func (s *oidcService) CompleteLogin(ctx context.Context, provider, state, code string) (int64, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return 0, ErrUnknownOIDCProvider
+	}
+
+	now := s.now()
+	login, err := s.store.ConsumeLoginState(hashToken(state), now)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && login.Provider != provider) {
+		return 0, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rawIDToken, err := p.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	claims, err := p.VerifyIDToken(ctx, rawIDToken, login.Nonce, now)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	userID, err := s.store.FindExternalIdentity(claims.Issuer, claims.Subject, now)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return 0, ErrOIDCEmailRequired
+	}
+	userID, err = s.accountFor(email, bool(claims.EmailVerified), claims)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.store.LinkExternalIdentity(&models.ExternalIdentity{
+		UserID:  userID,
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   email,
+	}, now)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// accountFor returns the user to link a new external identity to, creating one if needed.
+func (s *oidcService) accountFor(email string, emailVerified bool, claims *oidc.Claims) (int64, error) {
+	existing, err := s.accounts.UserIDByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err := s.accounts.CreateExternalUser(newExternalUser(email, claims), emailVerified)
+		if err != nil {
+			return 0, err
+		}
+		return user.ID, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !emailVerified || s.verification == nil {
+		return 0, ErrOIDCAccountExists
+	}
+	verified, err := s.verification.IsEmailVerified(existing)
+	if err != nil {
+		return 0, err
+	}
+	if !verified {
+		return 0, ErrOIDCAccountExists
+	}
+	return existing, nil
+}
+
+// newExternalUser fills a signup from the profile claims the provider shared.
+func newExternalUser(email string, claims *oidc.Claims) *models.User {
+	user := &models.User{Email: email, IsProfilePublic: true}
+	if claims.GivenName != "" {
+		user.FirstName = &claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		user.LastName = &claims.FamilyName
+	}
+	switch {
+	case claims.Nickname != "":
+		user.Nickname = &claims.Nickname
+	case claims.PreferredUsername != "":
+		user.Nickname = &claims.PreferredUsername
+	}
+	return user
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/pkg/oidc"
+	"github.com/tajjjjr/social-network/backend/pkg/oidc/oidctest"
+)
+
+type fakeOIDCStore struct {
+	states     map[string]models.OIDCLoginState
+	identities map[[2]string]int64 // issuer, subject -> user
+}
+
+func (f *fakeOIDCStore) CreateLoginState(stateHash string, state *models.OIDCLoginState) error {
+	f.states[stateHash] = *state
+	return nil
+}
+
+func (f *fakeOIDCStore) ConsumeLoginState(stateHash string, now time.Time) (*models.OIDCLoginState, error) {
+	state, ok := f.states[stateHash]
+	delete(f.states, stateHash)
+	if !ok || !now.Before(state.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	return &state, nil
+}
+
+func (f *fakeOIDCStore) DeleteExpiredLoginStates(now time.Time) error { return nil }
+
+func (f *fakeOIDCStore) FindExternalIdentity(issuer, subject string, now time.Time) (int64, error) {
+	userID, ok := f.identities[[2]string{issuer, subject}]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+func (f *fakeOIDCStore) LinkExternalIdentity(identity *models.ExternalIdentity, now time.Time) error {
+	f.identities[[2]string{identity.Issuer, identity.Subject}] = identity.UserID
+	return nil
+}
+
+type fakeExternalAccounts struct {
+	users    map[string]int64 // email -> id
+	verified map[int64]bool
+	created  []*models.User
+}
+
+func (f *fakeExternalAccounts) UserIDByEmail(email string) (int64, error) {
+	id, ok := f.users[email]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+func (f *fakeExternalAccounts) CreateExternalUser(user *models.User, emailVerified bool) (*models.User, error) {
+	user.ID = int64(100 + len(f.created))
+	f.users[user.Email] = user.ID
+	f.verified[user.ID] = emailVerified
+	f.created = append(f.created, user)
+	return user, nil
+}
+
+func (f *fakeExternalAccounts) SendVerification(userID int64, email string) error { return nil }
+func (f *fakeExternalAccounts) ResendVerification(userID int64) error             { return nil }
+func (f *fakeExternalAccounts) VerifyEmail(token string) error                    { return nil }
+func (f *fakeExternalAccounts) MarkVerified(userID int64, email string) error {
+	f.verified[userID] = true
+	return nil
+}
+func (f *fakeExternalAccounts) IsEmailVerified(userID int64) (bool, error) {
+	return f.verified[userID], nil
+}
+
+func newOIDCFixture(t *testing.T) (*oidcService, *oidctest.Issuer, *fakeExternalAccounts) {
+	t.Helper()
+	issuer := oidctest.NewIssuer("client", "secret")
+	t.Cleanup(issuer.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "test",
+		Issuer:       issuer.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://social.example/auth/oidc/test/callback",
+	}, nil)
+	accounts := &fakeExternalAccounts{
+		users:    map[string]int64{"ada@example.com": 7},
+		verified: map[int64]bool{},
+	}
+	oidcStore := &fakeOIDCStore{states: map[string]models.OIDCLoginState{}, identities: map[[2]string]int64{}}
+	svc := NewOIDCService([]*oidc.Provider{provider}, oidcStore, accounts, accounts, DefaultOIDCConfig()).(*oidcService)
+	return svc, issuer, accounts
+}
+
+// authorize starts a login as user and returns the state and code the provider sends back.
+func authorize(t *testing.T, svc *oidcService, issuer *oidctest.Issuer, user oidctest.User) (string, string) {
+	t.Helper()
+	issuer.SetUser(user)
+	authURL, state, err := svc.BeginLogin(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || back.Query().Get("state") != state {
+		t.Fatalf("authorize redirect %q does not carry the state", resp.Header.Get("Location"))
+	}
+	return state, back.Query().Get("code")
+}
+
+func TestOIDCCompleteLogin(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          oidctest.User
+		localVerified bool
+		wantUser      int64
+		wantErr       error
+	}{
+		{"new account", oidctest.User{Subject: "s1", Email: "grace@example.com", EmailVerified: true, Nickname: "grace"}, false, 100, nil},
+		{"new account with unverified email", oidctest.User{Subject: "s1", Email: "grace@example.com"}, false, 100, nil},
+		{"links verified email", oidctest.User{Subject: "s1", Email: "ada@example.com", EmailVerified: true}, true, 7, nil},
+		{"local email unverified", oidctest.User{Subject: "s1", Email: "ada@example.com", EmailVerified: true}, false, 0, ErrOIDCAccountExists},
+		{"provider email unverified", oidctest.User{Subject: "s1", Email: "ada@example.com"}, true, 0, ErrOIDCAccountExists},
+		{"no email", oidctest.User{Subject: "s1"}, false, 0, ErrOIDCEmailRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, issuer, accounts := newOIDCFixture(t)
+			accounts.verified[7] = tt.localVerified
+
+			state, code := authorize(t, svc, issuer, tt.user)
+			userID, err := svc.CompleteLogin(context.Background(), "test", state, code)
+			if !errors.Is(err, tt.wantErr) || userID != tt.wantUser {
+				t.Fatalf("got user %d, %v; want %d, %v", userID, err, tt.wantUser, tt.wantErr)
+			}
+			if tt.wantUser == 100 && accounts.verified[100] != tt.user.EmailVerified {
+				t.Errorf("new account verified = %v, want %v", accounts.verified[100], tt.user.EmailVerified)
+			}
+
+			if err == nil {
+				// the identity is linked now, so a later login finds the same user
+				state, code = authorize(t, svc, issuer, tt.user)
+				again, err := svc.CompleteLogin(context.Background(), "test", state, code)
+				if err != nil || again != userID || len(accounts.created) > 1 {
+					t.Errorf("second login: got user %d, %v with %d accounts created", again, err, len(accounts.created))
+				}
+			}
+		})
+	}
+}
+
+func TestOIDCLoginStateIsSingleUse(t *testing.T) {
+	svc, issuer, _ := newOIDCFixture(t)
+	user := oidctest.User{Subject: "s1", Email: "grace@example.com", EmailVerified: true}
+
+	state, code := authorize(t, svc, issuer, user)
+	if _, err := svc.CompleteLogin(context.Background(), "test", state, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CompleteLogin(context.Background(), "test", state, code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed state: got %v, want ErrInvalidOIDCState", err)
+	}
+
+	state, code = authorize(t, svc, issuer, user)
+	svc.now = func() time.Time { return time.Now().Add(11 * time.Minute) }
+	if _, err := svc.CompleteLogin(context.Background(), "test", state, code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("expired state: got %v, want ErrInvalidOIDCState", err)
+	}
+
+	if _, _, err := svc.BeginLogin(context.Background(), "other"); !errors.Is(err, ErrUnknownOIDCProvider) {
+		t.Errorf("unknown provider: got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/mail"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// Errors returned when a password reset is rejected.
+var (
+	ErrInvalidResetToken = errors.New("reset token is invalid or has expired")
+	ErrWeakPassword      = errors.New("password does not meet the requirements")
+)
+
+// PasswordResetConfig controls reset links. ResetURL is the frontend page
+// that receives the token as its "token" query parameter.
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	ResetURL string
+}
+
+// PasswordResetConfigFromEnv reads PASSWORD_RESET_TTL (a Go duration) and
+// PASSWORD_RESET_URL, falling back to a one hour link to the local frontend.
+func PasswordResetConfigFromEnv() PasswordResetConfig {
+	config := PasswordResetConfig{
+		TokenTTL: time.Hour,
+		ResetURL: "http://localhost:3000/reset-password",
+	}
+	if value := os.Getenv("PASSWORD_RESET_TTL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.TokenTTL = d
+		} else {
+			log.Printf("ignoring invalid PASSWORD_RESET_TTL=%q", value)
+		}
+	}
+	if value := os.Getenv("PASSWORD_RESET_URL"); value != "" {
+		config.ResetURL = value
+	}
+	return config
+}
+
+type passwordResetService struct {
+	users     UserFinder
+	resets    store.PasswordResetStore
+	sessions  SessionService
+	mailer    mail.Mailer
+	config    PasswordResetConfig
+	passwords *utils.PasswordManager
+	now       func() time.Time
+}
+
+func NewPasswordResetService(users UserFinder, resets store.PasswordResetStore, sessions SessionService, mailer mail.Mailer, config PasswordResetConfig) PasswordResetService {
+	return &passwordResetService{
+		users:     users,
+		resets:    resets,
+		sessions:  sessions,
+		mailer:    mailer,
+		config:    config,
+		passwords: utils.NewPasswordManager(utils.PasswordConfigFromEnv()),
+		now:       time.Now,
+	}
+}
+
+// RequestReset emails a reset link to email. Unknown addresses are ignored
+// without error so the endpoint does not reveal which accounts exist.
+func (s *passwordResetService) RequestReset(email string) error {
+	user, err := s.users.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err := s.resets.CreateResetToken(user.ID, hash, s.now().Add(s.config.TokenTTL)); err != nil {
+		return err
+	}
+
+	link := s.config.ResetURL + "?token=" + url.QueryEscape(token)
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password for your account.\n\nTo choose a new password, open this link within %s:\n\n%s\n\nIf this wasn't you, you can ignore this email.",
+			s.config.TokenTTL, link,
+		),
+	})
+}
+
+// ResetPassword sets a new password using an emailed token. The token can be
+// used once, and every existing session of the user is revoked. Policy
+// failures wrap both ErrWeakPassword and *utils.PasswordPolicyError.
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	if err := s.passwords.ValidatePasswordStrength(newPassword); err != nil {
+		return fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+	hashed, err := s.passwords.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := s.resets.ResetPassword(hashToken(token), hashed, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.sessions.RevokeAllSessions(userID); err != nil {
+		return fmt.Errorf("password changed but failed to revoke sessions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/mail"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeUserFinder map[string]*models.User
+
+func (f fakeUserFinder) GetUserByEmail(email string) (*models.User, error) {
+	if user, ok := f[email]; ok {
+		return user, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeResetToken struct {
+	userID    int64
+	expiresAt time.Time
+	used      bool
+}
+
+type fakePasswordResetStore struct {
+	tokens    map[string]*fakeResetToken
+	passwords map[int64]string
+}
+
+func (f *fakePasswordResetStore) CreateResetToken(userID int64, tokenHash string, expiresAt time.Time) error {
+	f.tokens[tokenHash] = &fakeResetToken{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakePasswordResetStore) ResetPassword(tokenHash, passwordHash string, now time.Time) (int64, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok || token.used || !now.Before(token.expiresAt) {
+		return 0, sql.ErrNoRows
+	}
+	token.used = true
+	f.passwords[token.userID] = passwordHash
+	return token.userID, nil
+}
+
+type fakeMailer struct {
+	sent []mail.Message
+}
+
+func (f *fakeMailer) Send(msg mail.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func newPasswordResetFixture() (*passwordResetService, *fakePasswordResetStore, *fakeMailer, *sessionService, *time.Time) {
+	sessions, _, _, now := newSessionFixture()
+	resets := &fakePasswordResetStore{tokens: map[string]*fakeResetToken{}, passwords: map[int64]string{}}
+	mailer := &fakeMailer{}
+	users := fakeUserFinder{"ada@example.com": {ID: 7, Email: "ada@example.com"}}
+
+	svc := NewPasswordResetService(users, resets, sessions, mailer, PasswordResetConfig{
+		TokenTTL: time.Hour,
+		ResetURL: "https://example.com/reset",
+	}).(*passwordResetService)
+	svc.now = func() time.Time { return *now }
+	return svc, resets, mailer, sessions, now
+}
+
+// emailedToken extracts the token from the reset link in msg.
+func emailedToken(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	for _, field := range strings.Fields(msg.Body) {
+		if strings.HasPrefix(field, "https://example.com/reset?") {
+			link, err := url.Parse(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no reset link in %q", msg.Body)
+	return ""
+}
+
+func TestRequestReset_EmailsLinkAndStoresHash(t *testing.T) {
+	svc, resets, mailer, _, _ := newPasswordResetFixture()
+
+	if err := svc.RequestReset("ada@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "ada@example.com" {
+		t.Fatalf("expected one email to ada, got %+v", mailer.sent)
+	}
+
+	token := emailedToken(t, mailer.sent[0])
+	if _, ok := resets.tokens[token]; ok {
+		t.Error("the raw token must not be stored")
+	}
+	stored, ok := resets.tokens[hashToken(token)]
+	if !ok {
+		t.Fatal("token hash was not stored")
+	}
+	if want := sessionEpoch.Add(time.Hour); !stored.expiresAt.Equal(want) {
+		t.Errorf("expiresAt = %v, want %v", stored.expiresAt, want)
+	}
+}
+
+func TestRequestReset_UnknownEmailIsSilent(t *testing.T) {
+	svc, resets, mailer, _, _ := newPasswordResetFixture()
+
+	if err := svc.RequestReset("nobody@example.com"); err != nil {
+		t.Errorf("unknown email should not be reported, got %v", err)
+	}
+	if len(mailer.sent) != 0 || len(resets.tokens) != 0 {
+		t.Error("no token should be issued for an unknown email")
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    func(issued string) string
+		password string
+		after    time.Duration
+		wantErr  error
+	}{
+		{"valid token", func(issued string) string { return issued }, "N3w-Password", 0, nil},
+		{"unknown token", func(string) string { return "forged" }, "N3w-Password", 0, ErrInvalidResetToken},
+		{"expired token", func(issued string) string { return issued }, "N3w-Password", time.Hour, ErrInvalidResetToken},
+		{"weak password", func(issued string) string { return issued }, "short", 0, ErrWeakPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, resets, mailer, sessions, now := newPasswordResetFixture()
+			laptop, _ := sessions.CreateSession(7, models.SessionClient{})
+			other, _ := sessions.CreateSession(8, models.SessionClient{})
+
+			_ = svc.RequestReset("ada@example.com")
+			*now = now.Add(tt.after)
+
+			err := svc.ResetPassword(tt.token(emailedToken(t, mailer.sent[0])), tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			_, _, laptopErr := sessions.ValidateSession(laptop.ID)
+			if tt.wantErr != nil {
+				if _, changed := resets.passwords[7]; changed {
+					t.Error("password must not change")
+				}
+				if laptopErr != nil && tt.after == 0 {
+					t.Error("sessions must survive a failed reset")
+				}
+				return
+			}
+
+			if resets.passwords[7] == "" || resets.passwords[7] == tt.password {
+				t.Errorf("expected a password hash, got %q", resets.passwords[7])
+			}
+			if laptopErr == nil {
+				t.Error("existing sessions must be revoked after a reset")
+			}
+			if _, _, err := sessions.ValidateSession(other.ID); err != nil {
+				t.Errorf("other users' sessions must survive: %v", err)
+			}
+		})
+	}
+}
+
+func TestResetPassword_TokenIsSingleUse(t *testing.T) {
+	svc, _, mailer, _, _ := newPasswordResetFixture()
+	_ = svc.RequestReset("ada@example.com")
+	token := emailedToken(t, mailer.sent[0])
+
+	if err := svc.ResetPassword(token, "N3w-Password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ResetPassword(token, "An0ther-Password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("reusing a token: got %v, want ErrInvalidResetToken", err)
+	}
+}
@@ -2,9 +2,20 @@ package service
 
 import (
 	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/tajjjjr/social-network/backend/internal/models"
@@ -12,60 +23,109 @@ import (
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
 
+// ErrCursorConflict is returned when a feed page is asked for both before and after a cursor.
+var ErrCursorConflict = errors.New("use either before or after, not both")
+
+const (
+	// MaxAttachments is how many images a post or comment can carry.
+	MaxAttachments = 10
+	// MaxAltTextLength caps the alt text of one image, in characters.
+	MaxAltTextLength = 1000
+)
+
+var (
+	// ErrTooManyAttachments is returned when a post or comment would carry more than MaxAttachments images.
+	ErrTooManyAttachments = fmt.Errorf("a post or comment can have at most %d images", MaxAttachments)
+	// ErrAltTextTooLong is returned for alt text longer than MaxAltTextLength.
+	ErrAltTextTooLong = fmt.Errorf("alt text can be at most %d characters", MaxAltTextLength)
+	// ErrUnknownAttachment is returned when an edit names an image the post or comment does not have.
+	ErrUnknownAttachment = errors.New("attachment not found on this post or comment")
+)
+
 type PostService struct {
-	PostStore store.PostStoreInterface
+	PostStore  store.PostStoreInterface
+	Visibility *PostVisibilityPolicy
+	// Mentions, when set, records the @mentions in posts and comments.
+	Mentions MentionService
 }
 
 func NewPostService(ps store.PostStoreInterface) *PostService {
-	return &PostService{PostStore: ps}
+	return &PostService{PostStore: ps, Visibility: NewPostVisibilityPolicy(ps)}
 }
 
-func (s *PostService) CreatePost(post *models.Post, imageData []byte, imageMimeType string) (int64, error) {
+func (s *PostService) CreatePost(post *models.Post, images []models.ImageUpload) (int64, error) {
 	if post.Content == "" {
 		return 0, fmt.Errorf("post content is required")
 	}
-	if len(imageData) > 0 {
-		// Perform image signature check and get detected format
-		imagePath, err := s.saveImage(imageData, "posts")
-		if err != nil {
-			return 0, err
-		}
-		post.Image = imagePath
+	if err := s.addGallery(post, images); err != nil {
+		return 0, err
 	}
 
-	return s.PostStore.CreatePost(post)
+	postID, err := s.PostStore.CreatePost(post)
+	if err != nil {
+		removeAttachmentFiles(post.Attachments)
+		return 0, err
+	}
+	post.ID = postID
+	s.mentionsInPost(post)
+	return postID, nil
 }
 
-func (s *PostService) CreatePostWithViewers(post *models.Post, imageData []byte, imageMimeType string, viewerIDs []int64) (int64, error) {
+func (s *PostService) CreatePostWithViewers(post *models.Post, images []models.ImageUpload, viewerIDs []int64) (int64, error) {
 	if post.Content == "" {
 		return 0, fmt.Errorf("post content is required")
 	}
-	if len(imageData) > 0 {
-		// Perform image signature check and get detected format
-		imagePath, err := s.saveImage(imageData, "posts")
-		if err != nil {
-			return 0, err
-		}
-		post.Image = imagePath
+	if err := s.addGallery(post, images); err != nil {
+		return 0, err
 	}
 
 	// Create the post
 	postID, err := s.PostStore.CreatePost(post)
 	if err != nil {
+		removeAttachmentFiles(post.Attachments)
 		return 0, err
 	}
 
 	// If it's a private post and has viewers, add them to Post_Visibility
-	if post.Privacy == "private" && len(viewerIDs) > 0 {
+	if post.Privacy == PrivacyPrivate && len(viewerIDs) > 0 {
 		err = s.PostStore.AddPostViewers(postID, viewerIDs)
 		if err != nil {
 			return 0, fmt.Errorf("failed to add post viewers: %w", err)
 		}
 	}
 
+	// only now can those mentioned be checked against the viewers
+	post.ID = postID
+	s.mentionsInPost(post)
 	return postID, nil
 }
 
+func (s *PostService) mentionsInPost(post *models.Post) {
+	if s.Mentions != nil {
+		s.Mentions.MentionsInPost(post)
+	}
+}
+
+func (s *PostService) mentionsInComment(comment *models.Comment) {
+	if s.Mentions != nil {
+		s.Mentions.MentionsInComment(comment)
+	}
+}
+
+// addGallery saves the images of a new post and sets them as its attachments.
+func (s *PostService) addGallery(post *models.Post, images []models.ImageUpload) error {
+	if len(images) == 0 {
+		return nil
+	}
+	attachments, err := s.editGallery(nil, models.AttachmentEdit{Add: images}, "posts")
+	if err != nil {
+		return err
+	}
+	post.Attachments = attachments
+	post.Image = attachments[0].Path
+	return nil
+}
+
 func (s *PostService) SearchUsers(query string, currentUserID int64) ([]*models.User, error) {
 	if query == "" {
 		return []*models.User{}, nil
@@ -73,23 +133,35 @@ func (s *PostService) SearchUsers(query string, currentUserID int64) ([]*models.
 	return s.PostStore.SearchUsers(query, currentUserID)
 }
 
-func (s *PostService) CreateComment(comment *models.Comment, imageData []byte, imageMimeType string) (int64, error) {
+func (s *PostService) CreateComment(comment *models.Comment, images []models.ImageUpload) (int64, error) {
 	if comment.Content == "" {
 		return 0, fmt.Errorf("comment content is required")
 	}
-	if len(imageData) > 0 {
-		// Perform image signature check and get detected format
-		imagePath, err := s.saveImage(imageData, "comments")
+	// Commenting requires the same access as reading the post
+	if _, err := s.Visibility.VisiblePost(comment.UserID, comment.PostID); err != nil {
+		return 0, err
+	}
+	if len(images) > 0 {
+		attachments, err := s.editGallery(nil, models.AttachmentEdit{Add: images}, "comments")
 		if err != nil {
 			return 0, err
 		}
-		comment.Image = imagePath
+		comment.Attachments = attachments
+		comment.Image = attachments[0].Path
 	}
-	return s.PostStore.CreateComment(comment)
+
+	commentID, err := s.PostStore.CreateComment(comment)
+	if err != nil {
+		removeAttachmentFiles(comment.Attachments)
+		return 0, err
+	}
+	comment.ID = commentID
+	s.mentionsInComment(comment)
+	return commentID, nil
 }
 
-func (s *PostService) GetPostByID(id int64) (*models.Post, error) {
-	return s.PostStore.GetPostByID(id)
+func (s *PostService) GetPostByID(id, viewerID int64) (*models.Post, error) {
+	return s.Visibility.VisiblePost(viewerID, id)
 }
 
 func (s *PostService) GetPosts(userID int64) ([]*models.Post, error) {
@@ -104,11 +176,105 @@ func (s *PostService) GetPostsCount(userID int64) (int, error) {
 	return s.PostStore.GetPostsCount(userID)
 }
 
+// GetFeed returns a page of userID's home feed, newest first. before and
+// after are cursors handed out on earlier pages; with neither, the page
+// starts at the newest post.
+func (s *PostService) GetFeed(userID int64, before, after string, limit int) (*models.FeedPage, error) {
+	if before != "" && after != "" {
+		return nil, ErrCursorConflict
+	}
+	beforeCursor, err := optionalFeedCursor(before)
+	if err != nil {
+		return nil, err
+	}
+	afterCursor, err := optionalFeedCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// one post more than asked for tells whether there is another page
+	posts, cursors, err := s.PostStore.GetFeedPage(userID, beforeCursor, afterCursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.FeedPage{}
+	if len(posts) > limit {
+		if afterCursor != nil {
+			// pages above a cursor fill up from it, leaving out the newest posts
+			posts, cursors = posts[1:], cursors[1:]
+			page.HasNewer = true
+		} else {
+			posts, cursors = posts[:limit], cursors[:limit]
+			page.NextCursor = encodeFeedCursor(cursors[limit-1])
+		}
+	}
+	if afterCursor != nil && len(posts) > 0 {
+		page.NextCursor = encodeFeedCursor(cursors[len(cursors)-1])
+	}
+	switch {
+	case len(posts) > 0:
+		page.PrevCursor = encodeFeedCursor(cursors[0])
+	case afterCursor != nil:
+		// nothing newer yet; keep polling from the same place
+		page.PrevCursor = after
+	}
+
+	page.Posts = posts
+	if page.Posts == nil {
+		page.Posts = []*models.Post{}
+	}
+	return page, nil
+}
+
+// CountNewPosts returns how many posts of userID's home feed are newer than
+// the since cursor, for the feed to offer a refresh.
+func (s *PostService) CountNewPosts(userID int64, since string) (int, error) {
+	cursor, err := decodeFeedCursor(since)
+	if err != nil {
+		return 0, err
+	}
+	return s.PostStore.CountFeedAfter(userID, cursor)
+}
+
+// Feed cursors are opaque to clients: the post id and its stored created_at.
+func encodeFeedCursor(c models.FeedCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.ID, 10) + "|" + c.CreatedAt))
+}
+
+func optionalFeedCursor(encoded string) (*models.FeedCursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	cursor, err := decodeFeedCursor(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func decodeFeedCursor(encoded string) (models.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.FeedCursor{}, ErrInvalidCursor
+	}
+	id, createdAt, found := strings.Cut(string(raw), "|")
+	postID, err := strconv.ParseInt(id, 10, 64)
+	if !found || err != nil || postID <= 0 || createdAt == "" {
+		return models.FeedCursor{}, ErrInvalidCursor
+	}
+	return models.FeedCursor{CreatedAt: createdAt, ID: postID}, nil
+}
+
 func (s *PostService) GetCommentsByPostID(postID, userID int64) ([]*models.Comment, error) {
+	if _, err := s.Visibility.VisiblePost(userID, postID); err != nil {
+		return nil, err
+	}
 	return s.PostStore.GetCommentsByPostID(postID, userID)
 }
 
-func (s *PostService) UpdatePost(postID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Post, error) {
+// UpdatePost replaces a post's content and changes its gallery as edit
+// says. The files of removed images are deleted.
+func (s *PostService) UpdatePost(postID, userID int64, content string, edit models.AttachmentEdit) (*models.Post, error) {
 	// Get the existing post
 	post, err := s.PostStore.GetPostByID(postID)
 	if err != nil {
@@ -125,24 +291,19 @@ func (s *PostService) UpdatePost(postID, userID int64, content string, imageData
 		return nil, fmt.Errorf("post content is required")
 	}
 
-	// Handle image update if provided
-	var imagePath string
-	if len(imageData) > 0 {
-		savedImagePath, err := s.saveImage(imageData, "posts")
-		if err != nil {
-			return nil, err
-		}
-		imagePath = savedImagePath
-	} else {
-		// Keep existing image if no new image provided
-		imagePath = post.Image
+	gallery, err := s.editGallery(post.Attachments, edit, "posts")
+	if err != nil {
+		return nil, err
 	}
 
 	// Update the post in the store
-	updatedPost, err := s.PostStore.UpdatePost(postID, content, imagePath)
+	updatedPost, err := s.PostStore.UpdatePost(postID, content, gallery)
 	if err != nil {
+		removeAttachmentFiles(addedAttachments(gallery))
 		return nil, err
 	}
+	removeAttachmentFiles(droppedAttachments(post.Attachments, gallery))
+	s.mentionsInPost(updatedPost)
 
 	return updatedPost, nil
 }
@@ -157,10 +318,15 @@ func (s *PostService) DeletePost(postID, userID int64) error {
 		return fmt.Errorf("unauthorized")
 	}
 
-	return s.PostStore.DeletePost(postID)
+	if err := s.PostStore.DeletePost(postID); err != nil {
+		return err
+	}
+	removeAttachmentFiles(post.Attachments)
+	return nil
 }
 
-func (s *PostService) UpdateComment(commentID, userID int64, content string, imageData []byte, imageMimeType string) (*models.Comment, error) {
+// UpdateComment replaces a comment's content and changes its gallery, like UpdatePost.
+func (s *PostService) UpdateComment(commentID, userID int64, content string, edit models.AttachmentEdit) (*models.Comment, error) {
 	// Get the existing comment
 	comment, err := s.PostStore.GetCommentByID(commentID)
 	if err != nil {
@@ -177,24 +343,19 @@ func (s *PostService) UpdateComment(commentID, userID int64, content string, ima
 		return nil, fmt.Errorf("comment content is required")
 	}
 
-	// Handle image update if provided
-	var imagePath string
-	if len(imageData) > 0 {
-		savedImagePath, err := s.saveImage(imageData, "comments")
-		if err != nil {
-			return nil, err
-		}
-		imagePath = savedImagePath
-	} else {
-		// Keep existing image if no new image provided
-		imagePath = comment.Image
+	gallery, err := s.editGallery(comment.Attachments, edit, "comments")
+	if err != nil {
+		return nil, err
 	}
 
 	// Update the comment in the store
-	updatedComment, err := s.PostStore.UpdateComment(commentID, content, imagePath)
+	updatedComment, err := s.PostStore.UpdateComment(commentID, content, gallery)
 	if err != nil {
+		removeAttachmentFiles(addedAttachments(gallery))
 		return nil, err
 	}
+	removeAttachmentFiles(droppedAttachments(comment.Attachments, gallery))
+	s.mentionsInComment(updatedComment)
 
 	return updatedComment, nil
 }
@@ -209,13 +370,130 @@ func (s *PostService) DeleteComment(commentID, userID int64) error {
 		return fmt.Errorf("unauthorized")
 	}
 
-	return s.PostStore.DeleteComment(commentID)
+	if err := s.PostStore.DeleteComment(commentID); err != nil {
+		return err
+	}
+	removeAttachmentFiles(comment.Attachments)
+	return nil
 }
 
 func (s *PostService) GetCommentByID(commentID int64) (*models.Comment, error) {
 	return s.PostStore.GetCommentByID(commentID)
 }
 
+// editGallery applies edit to the current gallery of a post or comment and
+// returns the new one, saving added images under subDir. New attachments are
+// the ones without an id. Nothing is saved if the edit is invalid.
+func (s *PostService) editGallery(current []models.Attachment, edit models.AttachmentEdit, subDir string) ([]models.Attachment, error) {
+	gallery := current
+	if edit.Keep != nil {
+		byID := make(map[int64]models.Attachment, len(current))
+		for _, a := range current {
+			byID[a.ID] = a
+		}
+		gallery = make([]models.Attachment, 0, len(edit.Keep)+len(edit.Add))
+		for _, id := range edit.Keep {
+			a, ok := byID[id]
+			if !ok {
+				return nil, ErrUnknownAttachment
+			}
+			if !slices.ContainsFunc(gallery, func(b models.Attachment) bool { return b.ID == id }) {
+				gallery = append(gallery, a)
+			}
+		}
+	} else {
+		gallery = append(make([]models.Attachment, 0, len(current)+len(edit.Add)), current...)
+	}
+
+	for id, altText := range edit.AltText {
+		i := slices.IndexFunc(gallery, func(a models.Attachment) bool { return a.ID == id })
+		if i < 0 {
+			return nil, ErrUnknownAttachment
+		}
+		gallery[i].AltText = strings.TrimSpace(altText)
+	}
+	if len(gallery)+len(edit.Add) > MaxAttachments {
+		return nil, ErrTooManyAttachments
+	}
+	for _, a := range gallery {
+		if utf8.RuneCountInString(a.AltText) > MaxAltTextLength {
+			return nil, ErrAltTextTooLong
+		}
+	}
+	for _, image := range edit.Add {
+		if utf8.RuneCountInString(strings.TrimSpace(image.AltText)) > MaxAltTextLength {
+			return nil, ErrAltTextTooLong
+		}
+	}
+
+	var added []models.Attachment
+	for _, image := range edit.Add {
+		imagePath, err := s.saveImage(image.Data, subDir)
+		if err != nil {
+			removeAttachmentFiles(added)
+			return nil, err
+		}
+		width, height := imageSize(image.Data)
+		added = append(added, models.Attachment{
+			Path:    imagePath,
+			AltText: strings.TrimSpace(image.AltText),
+			Width:   width,
+			Height:  height,
+		})
+	}
+	gallery = append(gallery, added...)
+	for i := range gallery {
+		gallery[i].Position = i
+	}
+	return gallery, nil
+}
+
+// addedAttachments returns the attachments of a gallery that are not stored yet.
+func addedAttachments(gallery []models.Attachment) []models.Attachment {
+	var added []models.Attachment
+	for _, a := range gallery {
+		if a.ID == 0 {
+			added = append(added, a)
+		}
+	}
+	return added
+}
+
+// droppedAttachments returns the attachments of before that are not in after.
+func droppedAttachments(before, after []models.Attachment) []models.Attachment {
+	var dropped []models.Attachment
+	for _, a := range before {
+		if !slices.ContainsFunc(after, func(b models.Attachment) bool { return b.ID == a.ID }) {
+			dropped = append(dropped, a)
+		}
+	}
+	return dropped
+}
+
+// removeAttachmentFiles deletes the files of attachments no row points at any
+// more. Failures are only logged.
+func removeAttachmentFiles(attachments []models.Attachment) {
+	for _, a := range attachments {
+		name, ok := attachmentPath("attachments", a.Path)
+		if !ok {
+			continue
+		}
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to remove attachment %s: %v", name, err)
+		}
+	}
+}
+
+// imageSize reads the dimensions of an image, or returns zeros for formats
+// the standard library cannot decode.
+func imageSize(data []byte) (width, height int) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
 // saveImage handles the logic for validating, naming, and saving an uploaded image.
 // It takes the image data and a sub-directory (e.g., "posts", "comments") to save the image in.
 // It returns the saved file path or an error.
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
@@ -17,7 +19,7 @@ func (m *MockPostStorePagination) CreateComment(comment *models.Comment) (int64,
 }
 func (m *MockPostStorePagination) GetPostByID(id int64) (*models.Post, error)    { return nil, nil }
 func (m *MockPostStorePagination) GetPosts(userID int64) ([]*models.Post, error) { return nil, nil }
-func (m *MockPostStorePagination) UpdatePost(postID int64, content, imagePath string) (*models.Post, error) {
+func (m *MockPostStorePagination) UpdatePost(postID int64, content string, attachments []models.Attachment) (*models.Post, error) {
 	return nil, nil
 }
 func (m *MockPostStorePagination) GetCommentsByPostID(postID, userID int64) ([]*models.Comment, error) {
@@ -28,13 +30,22 @@ func (m *MockPostStorePagination) AddPostViewers(postID int64, viewerIDs []int64
 func (m *MockPostStorePagination) SearchUsers(query string, currentUserID int64) ([]*models.User, error) {
 	return nil, nil
 }
-func (m *MockPostStorePagination) UpdateComment(commentID int64, content, imagePath string) (*models.Comment, error) {
+func (m *MockPostStorePagination) UpdateComment(commentID int64, content string, attachments []models.Attachment) (*models.Comment, error) {
 	return nil, nil
 }
 func (m *MockPostStorePagination) DeleteComment(commentID int64) error { return nil }
 func (m *MockPostStorePagination) GetCommentByID(commentID int64) (*models.Comment, error) {
 	return nil, nil
 }
+func (m *MockPostStorePagination) IsAcceptedFollower(followerID, followeeID int64) (bool, error) {
+	return false, nil
+}
+func (m *MockPostStorePagination) IsPostViewer(postID, viewerID int64) (bool, error) {
+	return false, nil
+}
+func (m *MockPostStorePagination) IsBlocked(userID, otherID int64) (bool, error) {
+	return false, nil
+}
 
 func (m *MockPostStorePagination) GetPostsPaginated(userID int64, limit, offset int) ([]*models.Post, error) {
 	if limit == 0 {
@@ -66,6 +77,42 @@ func (m *MockPostStorePagination) GetPostsCount(userID int64) (int, error) {
 	return len(m.posts), nil
 }
 
+// GetFeedPage treats posts as the feed, newest first, and each cursor as the
+// position of its post.
+func (m *MockPostStorePagination) GetFeedPage(userID int64, before, after *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error) {
+	start, end := 0, len(m.posts)
+	switch {
+	case after != nil:
+		end = m.indexOf(after.ID)
+		start = max(0, end-limit)
+	case before != nil:
+		start = m.indexOf(before.ID) + 1
+		end = min(start+limit, end)
+	default:
+		end = min(limit, end)
+	}
+	var posts []*models.Post
+	var cursors []models.FeedCursor
+	for i := start; i < end; i++ {
+		posts = append(posts, &m.posts[i])
+		cursors = append(cursors, models.FeedCursor{CreatedAt: "2026-01-01 00:00:00", ID: m.posts[i].ID})
+	}
+	return posts, cursors, nil
+}
+
+func (m *MockPostStorePagination) CountFeedAfter(userID int64, after models.FeedCursor) (int, error) {
+	return m.indexOf(after.ID), nil
+}
+
+func (m *MockPostStorePagination) indexOf(postID int64) int {
+	for i, post := range m.posts {
+		if post.ID == postID {
+			return i
+		}
+	}
+	return len(m.posts)
+}
+
 func TestGetPostsPaginated(t *testing.T) {
 	// Create mock posts
 	mockPosts := []models.Post{
@@ -132,3 +179,61 @@ func TestGetPostsCount(t *testing.T) {
 		t.Errorf("GetPostsCount() = %d, want 3", count)
 	}
 }
+
+func TestGetFeed(t *testing.T) {
+	mockStore := &MockPostStorePagination{posts: []models.Post{{ID: 5}, {ID: 4}, {ID: 3}, {ID: 2}, {ID: 1}}}
+	service := NewPostService(mockStore)
+	ids := func(page *models.FeedPage) []int64 {
+		var ids []int64
+		for _, post := range page.Posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	// walk down the feed two posts at a time
+	var pages [][]int64
+	var last *models.FeedPage
+	for cursor := ""; ; {
+		page, err := service.GetFeed(1, cursor, "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, ids(page))
+		last = page
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if fmt.Sprint(pages) != "[[5 4] [3 2] [1]]" {
+		t.Errorf("pages = %v", pages)
+	}
+
+	// from the oldest post back up, the page next to the cursor comes first
+	newer, err := service.GetFeed(1, "", last.PrevCursor, 2)
+	if err != nil || fmt.Sprint(ids(newer)) != "[3 2]" || !newer.HasNewer {
+		t.Fatalf("after the last page: got %v (has newer %v), %v", ids(newer), newer.HasNewer, err)
+	}
+	top, _ := service.GetFeed(1, "", newer.PrevCursor, 2)
+	if fmt.Sprint(ids(top)) != "[5 4]" || top.HasNewer {
+		t.Errorf("top of the feed: got %v (has newer %v)", ids(top), top.HasNewer)
+	}
+	polled, _ := service.GetFeed(1, "", top.PrevCursor, 2)
+	if len(polled.Posts) != 0 || polled.PrevCursor != top.PrevCursor {
+		t.Errorf("nothing newer: got %v, cursor %q", ids(polled), polled.PrevCursor)
+	}
+	if n, err := service.CountNewPosts(1, last.PrevCursor); err != nil || n != 4 {
+		t.Errorf("CountNewPosts() = %d, %v", n, err)
+	}
+
+	if _, err := service.GetFeed(1, "not a cursor", "", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("bad cursor: got %v", err)
+	}
+	if _, err := service.GetFeed(1, last.PrevCursor, last.PrevCursor, 2); !errors.Is(err, ErrCursorConflict) {
+		t.Errorf("both cursors: got %v", err)
+	}
+	if _, err := service.CountNewPosts(1, ""); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("counting without a cursor: got %v", err)
+	}
+}
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
@@ -17,6 +22,7 @@ type MockPostStore struct {
 	GetPostByIDFunc         func(id int64) (*models.Post, error)
 	DeletePostFunc          func(postID int64) error
 	AddPostViewersFunc      func(postID int64, viewerIDs []int64) error
+	UpdatePostFunc          func(postID int64, content string, attachments []models.Attachment) (*models.Post, error)
 }
 
 func (s *MockPostStore) CreatePost(post *models.Post) (int64, error) {
@@ -53,7 +59,10 @@ func (s *MockPostStore) AddPostViewers(postID int64, viewerIDs []int64) error {
 	return nil
 }
 
-func (s *MockPostStore) UpdatePost(postID int64, content, imagePath string) (*models.Post, error) {
+func (s *MockPostStore) UpdatePost(postID int64, content string, attachments []models.Attachment) (*models.Post, error) {
+	if s.UpdatePostFunc != nil {
+		return s.UpdatePostFunc(postID, content, attachments)
+	}
 	return nil, nil
 }
 
@@ -61,7 +70,7 @@ func (s *MockPostStore) SearchUsers(query string, currentUserID int64) ([]*model
 	return nil, nil
 }
 
-func (s *MockPostStore) UpdateComment(commentID int64, content, imagePath string) (*models.Comment, error) {
+func (s *MockPostStore) UpdateComment(commentID int64, content string, attachments []models.Attachment) (*models.Comment, error) {
 	return nil, nil
 }
 
@@ -81,6 +90,26 @@ func (s *MockPostStore) GetPostsCount(userID int64) (int, error) {
 	return 0, nil
 }
 
+func (s *MockPostStore) GetFeedPage(userID int64, before, after *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error) {
+	return nil, nil, nil
+}
+
+func (s *MockPostStore) CountFeedAfter(userID int64, after models.FeedCursor) (int, error) {
+	return 0, nil
+}
+
+func (s *MockPostStore) IsAcceptedFollower(followerID, followeeID int64) (bool, error) {
+	return false, nil
+}
+
+func (s *MockPostStore) IsPostViewer(postID, viewerID int64) (bool, error) {
+	return false, nil
+}
+
+func (s *MockPostStore) IsBlocked(userID, otherID int64) (bool, error) {
+	return false, nil
+}
+
 func TestCreatePost(t *testing.T) {
 	// Test case 1: Successful post creation
 	t.Run("Successful post creation", func(t *testing.T) {
@@ -97,7 +126,7 @@ func TestCreatePost(t *testing.T) {
 		post := &models.Post{
 			Content: "Test Post",
 		}
-		_, err := postService.CreatePost(post, nil, "")
+		_, err := postService.CreatePost(post, nil)
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -111,7 +140,7 @@ func TestCreatePost(t *testing.T) {
 		post := &models.Post{
 			Content: "",
 		}
-		_, err := postService.CreatePost(post, nil, "")
+		_, err := postService.CreatePost(post, nil)
 		if err == nil {
 			t.Fatalf("expected an error, got nil")
 		}
@@ -145,7 +174,7 @@ func TestCreatePost(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to read image file: %v", err)
 		}
-		_, err = postService.CreatePost(post, imageData, "image/png")
+		_, err = postService.CreatePost(post, []models.ImageUpload{{Data: imageData}})
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -161,7 +190,7 @@ func TestCreatePost(t *testing.T) {
 		}
 		// a simple fake image data
 		imageData := []byte("fake-image-data")
-		_, err := postService.CreatePost(post, imageData, "image/jpeg")
+		_, err := postService.CreatePost(post, []models.ImageUpload{{Data: imageData}})
 		if err == nil {
 			t.Fatalf("expected an error, got nil")
 		}
@@ -192,7 +221,7 @@ func TestCreatePost(t *testing.T) {
 			Privacy: "private",
 		}
 		viewerIDs := []int64{101, 102}
-		postID, err := postService.CreatePostWithViewers(post, nil, "", viewerIDs)
+		postID, err := postService.CreatePostWithViewers(post, nil, viewerIDs)
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -268,3 +297,69 @@ func TestDeletePost(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdatePostGallery(t *testing.T) {
+	existing := func(id int64) (*models.Post, error) {
+		return &models.Post{ID: id, UserID: 100, Content: "gallery", Attachments: []models.Attachment{
+			{ID: 1, Path: "posts/gone-1.png", Position: 0},
+			{ID: 2, Path: "posts/gone-2.png", Position: 1},
+			{ID: 3, Path: "posts/gone-3.png", Position: 2},
+		}}, nil
+	}
+	var pixels bytes.Buffer
+	if err := png.Encode(&pixels, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Reorder, remove and add", func(t *testing.T) {
+		var gallery []models.Attachment
+		postService := NewPostService(&MockPostStore{
+			GetPostByIDFunc: existing,
+			UpdatePostFunc: func(postID int64, content string, attachments []models.Attachment) (*models.Post, error) {
+				gallery = attachments
+				return &models.Post{ID: postID, Content: content, Attachments: attachments}, nil
+			},
+		})
+		_, err := postService.UpdatePost(1, 100, "edited", models.AttachmentEdit{
+			Keep:    []int64{3, 1},
+			AltText: map[int64]string{1: " a cat "},
+			Add:     []models.ImageUpload{{Data: pixels.Bytes(), AltText: "new"}},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(gallery) != 3 {
+			t.Fatalf("expected 3 attachments, got %+v", gallery)
+		}
+		defer os.Remove(filepath.Join("attachments", gallery[2].Path))
+		if gallery[0].ID != 3 || gallery[1].ID != 1 || gallery[1].AltText != "a cat" || gallery[2].ID != 0 {
+			t.Errorf("unexpected gallery %+v", gallery)
+		}
+		if a := gallery[2]; a.Position != 2 || a.AltText != "new" || a.Width != 3 || a.Height != 2 {
+			t.Errorf("unexpected new attachment %+v", a)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		edit models.AttachmentEdit
+		want error
+	}{
+		"Unknown attachment":          {models.AttachmentEdit{Keep: []int64{1, 9}}, ErrUnknownAttachment},
+		"Alt text of a removed image": {models.AttachmentEdit{Keep: []int64{1}, AltText: map[int64]string{2: "x"}}, ErrUnknownAttachment},
+		"Too many images":             {models.AttachmentEdit{Add: make([]models.ImageUpload, MaxAttachments-2)}, ErrTooManyAttachments},
+		"Alt text too long":           {models.AttachmentEdit{AltText: map[int64]string{1: strings.Repeat("é", MaxAltTextLength+1)}}, ErrAltTextTooLong},
+	} {
+		t.Run(name, func(t *testing.T) {
+			postService := NewPostService(&MockPostStore{
+				GetPostByIDFunc: existing,
+				UpdatePostFunc: func(int64, string, []models.Attachment) (*models.Post, error) {
+					t.Error("the store should not be called")
+					return nil, nil
+				},
+			})
+			if _, err := postService.UpdatePost(1, 100, "edited", tc.edit); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"database/sql"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// Post privacy levels.
+const (
+	PrivacyPublic        = "public"
+	PrivacyAlmostPrivate = "almost_private"
+	PrivacyPrivate       = "private"
+)
+
+// PostVisibilityPolicy decides whether a viewer may see a post and, through it,
+// the post's comments and reactions.
+//
+// The rules are:
+//   - authors always see their own posts
+//   - public posts are visible to everyone
+//   - almost_private posts are visible to accepted followers of the author
+//   - private posts are visible to the viewers listed on the post
+//   - nobody sees posts or comments of a user they blocked or who blocked them
+//
+// Feed queries apply the same rules in SQL (see store.visiblePostsClause).
+type PostVisibilityPolicy struct {
+	store store.PostVisibilityStore
+}
+
+// NewPostVisibilityPolicy creates a new PostVisibilityPolicy.
+func NewPostVisibilityPolicy(s store.PostVisibilityStore) *PostVisibilityPolicy {
+	return &PostVisibilityPolicy{store: s}
+}
+
+// CanView reports whether viewerID may see post.
+func (p *PostVisibilityPolicy) CanView(viewerID int64, post *models.Post) (bool, error) {
+	if post.UserID == viewerID {
+		return true, nil
+	}
+	if blocked, err := p.store.IsBlocked(viewerID, post.UserID); err != nil || blocked {
+		return false, err
+	}
+
+	switch post.Privacy {
+	case PrivacyPublic:
+		return true, nil
+	case PrivacyAlmostPrivate:
+		return p.store.IsAcceptedFollower(viewerID, post.UserID)
+	case PrivacyPrivate:
+		return p.store.IsPostViewer(post.ID, viewerID)
+	default:
+		return false, nil
+	}
+}
+
+// VisiblePost loads a post and checks that viewerID may see it.
+// Posts the viewer may not see are reported as sql.ErrNoRows so that their existence is not revealed.
+func (p *PostVisibilityPolicy) VisiblePost(viewerID, postID int64) (*models.Post, error) {
+	post, err := p.store.GetPostByID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := p.CanView(viewerID, post)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, sql.ErrNoRows
+	}
+	return post, nil
+}
+
+// VisibleComment loads a comment and checks that viewerID may see the post it
+// belongs to and is not blocked from its author.
+func (p *PostVisibilityPolicy) VisibleComment(viewerID, commentID int64) (*models.Comment, error) {
+	comment, err := p.store.GetCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != viewerID {
+		blocked, err := p.store.IsBlocked(viewerID, comment.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	if _, err := p.VisiblePost(viewerID, comment.PostID); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// FilterVisible returns the posts from posts that viewerID may see, keeping their order.
+func (p *PostVisibilityPolicy) FilterVisible(viewerID int64, posts []models.Post) ([]models.Post, error) {
+	visible := make([]models.Post, 0, len(posts))
+	for i := range posts {
+		allowed, err := p.CanView(viewerID, &posts[i])
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			visible = append(visible, posts[i])
+		}
+	}
+	return visible, nil
+}
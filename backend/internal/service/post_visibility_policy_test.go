@@ -0,0 +1,217 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// fakeVisibilityStore is an in-memory implementation of store.PostVisibilityStore.
+type fakeVisibilityStore struct {
+	posts     map[int64]*models.Post
+	comments  map[int64]*models.Comment
+	followers map[[2]int64]string // [follower, followee] -> status
+	viewers   map[[2]int64]bool   // [post, viewer]
+	blocks    map[[2]int64]bool   // [blocker, blocked]
+}
+
+func (f *fakeVisibilityStore) GetPostByID(id int64) (*models.Post, error) {
+	post, ok := f.posts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return post, nil
+}
+
+func (f *fakeVisibilityStore) GetCommentByID(commentID int64) (*models.Comment, error) {
+	comment, ok := f.comments[commentID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return comment, nil
+}
+
+func (f *fakeVisibilityStore) IsAcceptedFollower(followerID, followeeID int64) (bool, error) {
+	return f.followers[[2]int64{followerID, followeeID}] == "accepted", nil
+}
+
+func (f *fakeVisibilityStore) IsPostViewer(postID, viewerID int64) (bool, error) {
+	return f.viewers[[2]int64{postID, viewerID}], nil
+}
+
+func (f *fakeVisibilityStore) IsBlocked(userID, otherID int64) (bool, error) {
+	return f.blocks[[2]int64{userID, otherID}] || f.blocks[[2]int64{otherID, userID}], nil
+}
+
+const (
+	authorID          int64 = 1
+	acceptedFollower  int64 = 2
+	pendingFollower   int64 = 3
+	listedViewer      int64 = 4
+	strangerID        int64 = 5
+	publicPostID      int64 = 10
+	almostPrivatePost int64 = 11
+	privatePostID     int64 = 12
+)
+
+func newFakeVisibilityStore() *fakeVisibilityStore {
+	return &fakeVisibilityStore{
+		posts: map[int64]*models.Post{
+			publicPostID:      {ID: publicPostID, UserID: authorID, Privacy: PrivacyPublic},
+			almostPrivatePost: {ID: almostPrivatePost, UserID: authorID, Privacy: PrivacyAlmostPrivate},
+			privatePostID:     {ID: privatePostID, UserID: authorID, Privacy: PrivacyPrivate},
+		},
+		comments: map[int64]*models.Comment{
+			100: {ID: 100, PostID: publicPostID, UserID: authorID},
+			101: {ID: 101, PostID: almostPrivatePost, UserID: authorID},
+			102: {ID: 102, PostID: privatePostID, UserID: authorID},
+		},
+		followers: map[[2]int64]string{
+			{acceptedFollower, authorID}: "accepted",
+			{pendingFollower, authorID}:  "pending",
+			// A listed viewer who is not a follower must not see almost_private posts.
+		},
+		viewers: map[[2]int64]bool{
+			{privatePostID, listedViewer}: true,
+		},
+	}
+}
+
+func TestPostVisibilityPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		viewer  int64
+		post    int64
+		comment int64
+		want    bool
+	}{
+		{"author sees public post", authorID, publicPostID, 100, true},
+		{"author sees almost_private post", authorID, almostPrivatePost, 101, true},
+		{"author sees private post", authorID, privatePostID, 102, true},
+
+		{"accepted follower sees public post", acceptedFollower, publicPostID, 100, true},
+		{"accepted follower sees almost_private post", acceptedFollower, almostPrivatePost, 101, true},
+		{"accepted follower does not see unlisted private post", acceptedFollower, privatePostID, 102, false},
+
+		{"pending follower sees public post", pendingFollower, publicPostID, 100, true},
+		{"pending follower does not see almost_private post", pendingFollower, almostPrivatePost, 101, false},
+		{"pending follower does not see private post", pendingFollower, privatePostID, 102, false},
+
+		{"listed viewer sees public post", listedViewer, publicPostID, 100, true},
+		{"listed viewer does not see almost_private post", listedViewer, almostPrivatePost, 101, false},
+		{"listed viewer sees private post", listedViewer, privatePostID, 102, true},
+
+		{"stranger sees public post", strangerID, publicPostID, 100, true},
+		{"stranger does not see almost_private post", strangerID, almostPrivatePost, 101, false},
+		{"stranger does not see private post", strangerID, privatePostID, 102, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeVisibilityStore()
+			policy := NewPostVisibilityPolicy(fake)
+
+			got, err := policy.CanView(tt.viewer, fake.posts[tt.post])
+			if err != nil {
+				t.Fatalf("CanView returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanView = %v, want %v", got, tt.want)
+			}
+
+			post, err := policy.VisiblePost(tt.viewer, tt.post)
+			if tt.want {
+				if err != nil || post == nil || post.ID != tt.post {
+					t.Errorf("VisiblePost = %v, %v; want post %d", post, err, tt.post)
+				}
+			} else if err != sql.ErrNoRows {
+				t.Errorf("VisiblePost error = %v, want sql.ErrNoRows", err)
+			}
+
+			_, err = policy.VisibleComment(tt.viewer, tt.comment)
+			if tt.want && err != nil {
+				t.Errorf("VisibleComment returned error: %v", err)
+			}
+			if !tt.want && err != sql.ErrNoRows {
+				t.Errorf("VisibleComment error = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
+
+func TestPostVisibilityPolicy_UnknownPrivacy(t *testing.T) {
+	policy := NewPostVisibilityPolicy(newFakeVisibilityStore())
+
+	got, err := policy.CanView(strangerID, &models.Post{ID: 99, UserID: authorID, Privacy: "followers"})
+	if err != nil {
+		t.Fatalf("CanView returned error: %v", err)
+	}
+	if got {
+		t.Error("expected posts with an unknown privacy level to be hidden")
+	}
+}
+
+func TestPostVisibilityPolicy_MissingPost(t *testing.T) {
+	policy := NewPostVisibilityPolicy(newFakeVisibilityStore())
+
+	if _, err := policy.VisiblePost(authorID, 404); err != sql.ErrNoRows {
+		t.Errorf("VisiblePost error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := policy.VisibleComment(authorID, 404); err != sql.ErrNoRows {
+		t.Errorf("VisibleComment error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestPostVisibilityPolicy_Blocks(t *testing.T) {
+	fake := newFakeVisibilityStore()
+	fake.blocks = map[[2]int64]bool{{authorID, acceptedFollower}: true}
+	fake.comments[103] = &models.Comment{ID: 103, PostID: publicPostID, UserID: acceptedFollower}
+	policy := NewPostVisibilityPolicy(fake)
+
+	if _, err := policy.VisiblePost(acceptedFollower, publicPostID); err != sql.ErrNoRows {
+		t.Errorf("blocked user sees the blocker's post: err = %v", err)
+	}
+	// the block hides the blocked user's comments from the blocker too
+	if _, err := policy.VisibleComment(authorID, 103); err != sql.ErrNoRows {
+		t.Errorf("blocker sees the blocked user's comment: err = %v", err)
+	}
+	if _, err := policy.VisibleComment(strangerID, 103); err != nil {
+		t.Errorf("others still see the comment: err = %v", err)
+	}
+}
+
+func TestPostVisibilityPolicy_FilterVisible(t *testing.T) {
+	fake := newFakeVisibilityStore()
+	policy := NewPostVisibilityPolicy(fake)
+	posts := []models.Post{*fake.posts[publicPostID], *fake.posts[almostPrivatePost], *fake.posts[privatePostID]}
+
+	tests := []struct {
+		name   string
+		viewer int64
+		want   []int64
+	}{
+		{"author", authorID, []int64{publicPostID, almostPrivatePost, privatePostID}},
+		{"accepted follower", acceptedFollower, []int64{publicPostID, almostPrivatePost}},
+		{"pending follower", pendingFollower, []int64{publicPostID}},
+		{"listed viewer", listedViewer, []int64{publicPostID, privatePostID}},
+		{"stranger", strangerID, []int64{publicPostID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visible, err := policy.FilterVisible(tt.viewer, posts)
+			if err != nil {
+				t.Fatalf("FilterVisible returned error: %v", err)
+			}
+			if len(visible) != len(tt.want) {
+				t.Fatalf("FilterVisible returned %d posts, want %d", len(visible), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if visible[i].ID != id {
+					t.Errorf("post %d: got ID %d, want %d", i, visible[i].ID, id)
+				}
+			}
+		})
+	}
+}
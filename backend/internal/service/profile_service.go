@@ -1,19 +1,30 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"html"
+	"strconv"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
 	"github.com/tajjjjr/social-network/backend/internal/store"
 )
 
+var (
+	// ErrPrivateProfile is returned when a viewer may not see a private profile's follow lists.
+	ErrPrivateProfile = errors.New("this profile is private")
+	// ErrInvalidCursor is returned for a pagination cursor the server did not hand out.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
 type ProfileService struct {
 	ProfileStore *store.ProfileStore
+	Visibility   *PostVisibilityPolicy
 }
 
-func NewProfileService(ps *store.ProfileStore) *ProfileService {
-	return &ProfileService{ProfileStore: ps}
+func NewProfileService(ps *store.ProfileStore, visibility *PostVisibilityPolicy) *ProfileService {
+	return &ProfileService{ProfileStore: ps, Visibility: visibility}
 }
 
 func (ps *ProfileService) GetUserOwnProfile(userid int64) (models.ProfileDetails, error) {
@@ -88,19 +99,93 @@ func (ps *ProfileService) GetUserProfile(userid, LoggedInUser int64) (models.Pro
 	return userDetails, nil
 }
 
-func (ps *ProfileService) GetUserPosts(userid int64) ([]models.Post, error) {
-	return ps.ProfileStore.GetPostsOfUser(userid)
+// GetUserPosts returns the posts of userid that viewerID is allowed to see.
+func (ps *ProfileService) GetUserPosts(userid, viewerID int64) ([]models.Post, error) {
+	posts, err := ps.ProfileStore.GetPostsOfUser(userid)
+	if err != nil {
+		return nil, err
+	}
+	return ps.Visibility.FilterVisible(viewerID, posts)
+}
+
+// GetFollowersList returns a page of userid's followers as viewerID may see
+// them. cursor is "" for the first page, then the NextCursor of the last one.
+func (ps *ProfileService) GetFollowersList(userid, viewerID int64, cursor string, limit int) (*models.FollowListResponse, error) {
+	return ps.followList(userid, viewerID, true, cursor, limit)
+}
+
+// GetFolloweesList returns a page of the users userid follows, like GetFollowersList.
+func (ps *ProfileService) GetFolloweesList(userid, viewerID int64, cursor string, limit int) (*models.FollowListResponse, error) {
+	return ps.followList(userid, viewerID, false, cursor, limit)
+}
+
+// CountFollowers returns the size of userid's follower and followee lists.
+// Like the profile itself, the counts are shown even on private profiles.
+func (ps *ProfileService) CountFollowers(userid int64) (followers, followees int, err error) {
+	if _, err := ps.ProfileStore.IsProfilePublic(userid); errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrUserNotFound
+	} else if err != nil {
+		return 0, 0, err
+	}
+	return ps.ProfileStore.GetFollowersStat(userid)
 }
 
-func (ps *ProfileService) GetFollowersList(userid int64) (models.FollowListResponse, error) {
-	return ps.ProfileStore.GetUserFollowers(userid)
+func (ps *ProfileService) followList(userid, viewerID int64, followers bool, cursor string, limit int) (*models.FollowListResponse, error) {
+	var afterID int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		afterID = id
+	}
+	if err := ps.canSeeConnections(userid, viewerID); err != nil {
+		return nil, err
+	}
+
+	users, nextID, err := ps.ProfileStore.ListFollowConnections(userid, viewerID, followers, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].FirstName = html.UnescapeString(users[i].FirstName)
+		users[i].LastName = html.UnescapeString(users[i].LastName)
+		users[i].Nickname = html.UnescapeString(users[i].Nickname)
+	}
+	list := &models.FollowListResponse{Followers: users}
+	if nextID != 0 {
+		list.NextCursor = strconv.FormatInt(nextID, 10)
+	}
+	return list, nil
 }
 
-func (ps *ProfileService) GetFolloweesList(userid int64) (models.FollowListResponse, error) {
-	return ps.ProfileStore.GetUserFollowees(userid)
+// canSeeConnections applies the profile's privacy rules to its follow lists:
+// those of a private profile are only shown to the user and their accepted followers.
+func (ps *ProfileService) canSeeConnections(userid, viewerID int64) error {
+	public, err := ps.ProfileStore.IsProfilePublic(userid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if public || userid == viewerID {
+		return nil
+	}
+
+	status, err := ps.ProfileStore.GetFollowStatus(userid, viewerID)
+	if err != nil {
+		return err
+	}
+	if status != "following" {
+		return ErrPrivateProfile
+	}
+	return nil
 }
 
-func (ps *ProfileService) GetUserPhotos(userId int64) ([]models.Photo, error) {
+// GetUserPhotos returns the images userId attached to posts and comments,
+// leaving out those on posts viewerID is not allowed to see.
+func (ps *ProfileService) GetUserPhotos(userId, viewerID int64) ([]models.Photo, error) {
 	postphotos, err := ps.ProfileStore.GetUserPostPhotos(userId)
 	if err != nil {
 		return nil, err
@@ -112,8 +197,21 @@ func (ps *ProfileService) GetUserPhotos(userId int64) ([]models.Photo, error) {
 	photos := append(postphotos, commentphots...)
 
 	var actual []models.Photo
+	visible := make(map[int64]bool)
 	for i := range photos {
-		if photos[i].Image != "" {
+		if photos[i].Image == "" {
+			continue
+		}
+		allowed, checked := visible[photos[i].PostID]
+		if !checked {
+			_, err := ps.Visibility.VisiblePost(viewerID, photos[i].PostID)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			allowed = err == nil
+			visible[photos[i].PostID] = allowed
+		}
+		if allowed {
 			actual = append(actual, photos[i])
 		}
 	}
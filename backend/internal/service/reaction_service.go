@@ -6,14 +6,20 @@ import (
 )
 
 type ReactionService struct {
-	store *store.ReactionStore
+	store      *store.ReactionStore
+	visibility *PostVisibilityPolicy
 }
 
-func NewReactionService(store *store.ReactionStore) *ReactionService {
-	return &ReactionService{store}
+func NewReactionService(store *store.ReactionStore, visibility *PostVisibilityPolicy) *ReactionService {
+	return &ReactionService{store, visibility}
 }
 
+// ReactToPost records a reaction if the user may see the post.
+// Hidden or missing posts are reported as sql.ErrNoRows.
 func (s *ReactionService) ReactToPost(reaction *models.Reaction) error {
+	if _, err := s.visibility.VisiblePost(int64(reaction.UserID), int64(*reaction.PostID)); err != nil {
+		return err
+	}
 	return s.store.AddPostReaction(reaction)
 }
 
@@ -21,7 +27,12 @@ func (s *ReactionService) UnreactToPost(userID, postID int) error {
 	return s.store.RemovePostReaction(userID, postID)
 }
 
+// ReactToComment records a reaction if the user may see the post the comment belongs to.
+// Hidden or missing comments are reported as sql.ErrNoRows.
 func (s *ReactionService) ReactToComment(reaction *models.Reaction) error {
+	if _, err := s.visibility.VisibleComment(int64(reaction.UserID), int64(*reaction.CommentID)); err != nil {
+		return err
+	}
 	return s.store.AddCommentReaction(reaction)
 }
 
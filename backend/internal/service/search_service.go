@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// maxSearchTerms caps how many words of a query are searched for.
+const maxSearchTerms = 10
+
+var (
+	// ErrInvalidSearch is returned for a query with no words to search for.
+	ErrInvalidSearch = errors.New("search for at least one word")
+	// ErrSearchUnavailable is returned when the database has no full-text
+	// index, because SQLite was built without FTS5.
+	ErrSearchUnavailable = errors.New("search is unavailable")
+)
+
+type searchService struct {
+	store store.SearchStore
+}
+
+func NewSearchService(search store.SearchStore) SearchService {
+	return &searchService{store: search}
+}
+
+// SearchPosts returns a page of the posts and comments userID may see that
+// hold every word of query, best matches first. cursor is the NextCursor of
+// the previous page, or "" for the first.
+func (s *searchService) SearchPosts(userID int64, query, cursor string, limit int) (*models.SearchPage, error) {
+	if !s.store.SearchAvailable() {
+		return nil, ErrSearchUnavailable
+	}
+	match, ok := searchMatch(query)
+	if !ok {
+		return nil, ErrInvalidSearch
+	}
+	offset := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		offset = n
+	}
+
+	// one result more than asked for tells whether there is another page
+	results, err := s.store.SearchPosts(userID, match, offset, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.SearchPage{Results: results}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		page.NextCursor = strconv.Itoa(offset + limit)
+	}
+	return page, nil
+}
+
+// searchMatch turns a query into an FTS5 expression matching text that holds
+// every word of it, the last one also as a prefix. Words are quoted, so the
+// query syntax of FTS5 cannot be used, or broken, from the search box.
+func searchMatch(query string) (string, bool) {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "", false
+	}
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ") + "*", true
+}
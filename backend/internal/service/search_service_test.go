@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestSearchMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		ok    bool
+	}{
+		{"go", `"go"*`, true},
+		{"  Learning   Go! ", `"Learning" "Go"*`, true},
+		{`sqlite" OR NEAR(x`, `"sqlite" "OR" "NEAR" "x"*`, true},
+		{"café-au-lait", `"café" "au" "lait"*`, true},
+		{"1 2 3 4 5 6 7 8 9 10 11", `"1" "2" "3" "4" "5" "6" "7" "8" "9" "10"*`, true},
+		{`"*^()`, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := searchMatch(tt.query)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("searchMatch(%q) = %q, %v; want %q, %v", tt.query, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+)
+
+// Errors returned when a session cannot be used.
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExpired  = errors.New("session expired")
+)
+
+// SessionConfig controls how long sessions live.
+//
+// A session expires after IdleTimeout without activity, and never later than
+// AbsoluteTimeout after it was created. Activity slides the idle deadline
+// forward, but at most once per RenewAfter so that busy clients do not write
+// on every request.
+type SessionConfig struct {
+	IdleTimeout     time.Duration
+	AbsoluteTimeout time.Duration
+	RenewAfter      time.Duration
+	PruneInterval   time.Duration
+}
+
+// DefaultSessionConfig returns the session lifetimes used when none are configured.
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		IdleTimeout:     7 * 24 * time.Hour,
+		AbsoluteTimeout: 30 * 24 * time.Hour,
+		RenewAfter:      5 * time.Minute,
+		PruneInterval:   10 * time.Minute,
+	}
+}
+
+// SessionConfigFromEnv overrides the defaults with SESSION_IDLE_TIMEOUT,
+// SESSION_ABSOLUTE_TIMEOUT, SESSION_RENEW_AFTER and SESSION_PRUNE_INTERVAL,
+// each given as a Go duration such as "12h".
+func SessionConfigFromEnv() SessionConfig {
+	config := DefaultSessionConfig()
+	for name, target := range map[string]*time.Duration{
+		"SESSION_IDLE_TIMEOUT":     &config.IdleTimeout,
+		"SESSION_ABSOLUTE_TIMEOUT": &config.AbsoluteTimeout,
+		"SESSION_RENEW_AFTER":      &config.RenewAfter,
+		"SESSION_PRUNE_INTERVAL":   &config.PruneInterval,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Printf("ignoring invalid %s=%q", name, value)
+			continue
+		}
+		*target = d
+	}
+	return config
+}
+
+type sessionService struct {
+	store  store.SessionStore
+	config SessionConfig
+	closer SessionCloser
+	now    func() time.Time
+}
+
+// NewSessionService creates a session service. closer may be nil when no
+// live connections need to be dropped as sessions end.
+func NewSessionService(sessionStore store.SessionStore, config SessionConfig, closer SessionCloser) SessionService {
+	return &sessionService{
+		store:  sessionStore,
+		config: config,
+		closer: closer,
+		now:    time.Now,
+	}
+}
+
+// deadline is the expiry of a session created at createdAt and last used at lastSeenAt.
+func (s *sessionService) deadline(createdAt, lastSeenAt time.Time) time.Time {
+	idle := lastSeenAt.Add(s.config.IdleTimeout)
+	absolute := createdAt.Add(s.config.AbsoluteTimeout)
+	if absolute.Before(idle) {
+		return absolute
+	}
+	return idle
+}
+
+func (s *sessionService) CreateSession(userID int64, client models.SessionClient) (*models.Session, error) {
+	now := s.now()
+	session := &models.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  s.deadline(now, now),
+	}
+	if err := s.store.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ValidateSession returns the live session for sessionID and reports whether
+// its expiry moved, in which case the caller must re-issue the cookie.
+// Expired sessions are deleted on sight.
+func (s *sessionService) ValidateSession(sessionID string) (*models.Session, bool, error) {
+	session, err := s.store.GetSession(sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := s.now()
+	if !now.Before(session.ExpiresAt) {
+		if err := s.RevokeSession(sessionID); err != nil {
+			log.Printf("failed to delete expired session: %v", err)
+		}
+		return nil, false, ErrSessionExpired
+	}
+
+	if now.Sub(session.LastSeenAt) < s.config.RenewAfter {
+		return session, false, nil
+	}
+
+	expiresAt := s.deadline(session.CreatedAt, now)
+	if err := s.store.TouchSession(sessionID, now, expiresAt); err != nil {
+		return nil, false, err
+	}
+	renewed := !expiresAt.Equal(session.ExpiresAt)
+	session.LastSeenAt = now
+	session.ExpiresAt = expiresAt
+	return session, renewed, nil
+}
+
+func (s *sessionService) RevokeSession(sessionID string) error {
+	if err := s.store.DeleteSession(sessionID); err != nil {
+		return err
+	}
+	if s.closer != nil {
+		s.closer.CloseSessions(sessionID)
+	}
+	return nil
+}
+
+// ListSessions returns the user's live sessions, flagging currentID as the caller's own.
+func (s *sessionService) ListSessions(userID int64, currentID string) ([]*models.Session, error) {
+	sessions, err := s.store.ListUserSessions(userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession revokes one of the user's sessions by its public ID and
+// returns it. Sessions of other users are reported as not found.
+func (s *sessionService) RevokeUserSession(userID int64, publicID string) (*models.Session, error) {
+	sessions, err := s.store.ListUserSessions(userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if session.PublicID == publicID {
+			return session, s.RevokeSession(session.ID)
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+// RevokeOtherSessions signs the user out everywhere except keepID.
+func (s *sessionService) RevokeOtherSessions(userID int64, keepID string) (int, error) {
+	ids, err := s.store.DeleteUserSessionsExcept(userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 && s.closer != nil {
+		s.closer.CloseSessions(ids...)
+	}
+	return len(ids), nil
+}
+
+// RevokeAllSessions signs the user out everywhere.
+func (s *sessionService) RevokeAllSessions(userID int64) (int, error) {
+	ids, err := s.store.DeleteUserSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 && s.closer != nil {
+		s.closer.CloseSessions(ids...)
+	}
+	return len(ids), nil
+}
+
+// signOutEverywhere ends the user's sessions and revokes their access tokens,
+// closing any websocket opened with them. tokens may be nil.
+func signOutEverywhere(sessions SessionService, tokens AccessTokenService, userID int64) error {
+	if _, err := sessions.RevokeAllSessions(userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if tokens == nil {
+		return nil
+	}
+	list, err := tokens.ListTokens(userID)
+	if err != nil {
+		return fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	for _, token := range list {
+		if err := tokens.RevokeToken(userID, token.ID); err != nil && !errors.Is(err, ErrAccessTokenNotFound) {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *sessionService) PruneExpiredSessions() (int, error) {
+	ids, err := s.store.DeleteExpiredSessions(s.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune sessions: %w", err)
+	}
+	if len(ids) > 0 && s.closer != nil {
+		s.closer.CloseSessions(ids...)
+	}
+	return len(ids), nil
+}
+
+// RunPruner prunes expired sessions every PruneInterval until ctx is done.
+func (s *sessionService) RunPruner(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.PruneExpiredSessions(); err != nil {
+				log.Println(err)
+			} else if n > 0 {
+				log.Printf("pruned %d expired sessions", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type fakeSessionStore struct {
+	sessions map[string]*models.Session
+	touches  int
+}
+
+func (f *fakeSessionStore) CreateSession(session *models.Session) error {
+	session.PublicID = "public-" + session.ID
+	copied := *session
+	f.sessions[session.ID] = &copied
+	return nil
+}
+
+func (f *fakeSessionStore) GetSession(sessionID string) (*models.Session, error) {
+	session, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (f *fakeSessionStore) ListUserSessions(userID int64, now time.Time) ([]*models.Session, error) {
+	var sessions []*models.Session
+	for _, session := range f.sessions {
+		if session.UserID == userID && now.Before(session.ExpiresAt) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (f *fakeSessionStore) DeleteUserSessions(userID int64) ([]string, error) {
+	return f.DeleteUserSessionsExcept(userID, "")
+}
+
+func (f *fakeSessionStore) DeleteUserSessionsExcept(userID int64, keepID string) ([]string, error) {
+	var ids []string
+	for id, session := range f.sessions {
+		if session.UserID == userID && id != keepID {
+			ids = append(ids, id)
+			delete(f.sessions, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeSessionStore) TouchSession(sessionID string, lastSeenAt, expiresAt time.Time) error {
+	f.touches++
+	f.sessions[sessionID].LastSeenAt = lastSeenAt
+	f.sessions[sessionID].ExpiresAt = expiresAt
+	return nil
+}
+
+func (f *fakeSessionStore) DeleteSession(sessionID string) error {
+	delete(f.sessions, sessionID)
+	return nil
+}
+
+func (f *fakeSessionStore) DeleteExpiredSessions(now time.Time) ([]string, error) {
+	var ids []string
+	for id, session := range f.sessions {
+		if !now.Before(session.ExpiresAt) {
+			ids = append(ids, id)
+			delete(f.sessions, id)
+		}
+	}
+	return ids, nil
+}
+
+type fakeSessionCloser struct {
+	closed []string
+}
+
+func (f *fakeSessionCloser) CloseSessions(sessionIDs ...string) {
+	f.closed = append(f.closed, sessionIDs...)
+}
+
+var sessionEpoch = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newSessionFixture() (*sessionService, *fakeSessionStore, *fakeSessionCloser, *time.Time) {
+	sessions := &fakeSessionStore{sessions: map[string]*models.Session{}}
+	closer := &fakeSessionCloser{}
+	svc := NewSessionService(sessions, SessionConfig{
+		IdleTimeout:     2 * time.Hour,
+		AbsoluteTimeout: 5 * time.Hour,
+		RenewAfter:      10 * time.Minute,
+		PruneInterval:   time.Minute,
+	}, closer).(*sessionService)
+
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+	return svc, sessions, closer, &now
+}
+
+func TestCreateSession_ExpiresAfterIdleTimeout(t *testing.T) {
+	svc, _, _, _ := newSessionFixture()
+
+	session, err := svc.CreateSession(7, models.SessionClient{})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if want := sessionEpoch.Add(2 * time.Hour); !session.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", session.ExpiresAt, want)
+	}
+}
+
+func TestValidateSession_Lifecycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		activity    []time.Duration // offsets from creation at which the session is used
+		check       time.Duration
+		wantErr     error
+		wantRenewed bool
+		wantExpiry  time.Duration
+	}{
+		{"fresh session is not renewed", nil, 5 * time.Minute, nil, false, 2 * time.Hour},
+		{"activity slides the idle deadline", nil, time.Hour, nil, true, 3 * time.Hour},
+		{"idle session expires", nil, 2 * time.Hour, ErrSessionExpired, false, 0},
+		{"active session outlives the idle timeout", []time.Duration{time.Hour, 2 * time.Hour}, 3 * time.Hour, nil, true, 5 * time.Hour},
+		{"renewal is capped by the absolute timeout", []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}, 4 * time.Hour, nil, false, 5 * time.Hour},
+		{"absolute timeout ends an active session", []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour}, 5 * time.Hour, ErrSessionExpired, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, sessions, closer, now := newSessionFixture()
+			created, _ := svc.CreateSession(7, models.SessionClient{})
+
+			for _, at := range tt.activity {
+				*now = sessionEpoch.Add(at)
+				if _, _, err := svc.ValidateSession(created.ID); err != nil {
+					t.Fatalf("activity at %v: %v", at, err)
+				}
+			}
+
+			*now = sessionEpoch.Add(tt.check)
+			session, renewed, err := svc.ValidateSession(created.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, ok := sessions.sessions[created.ID]; ok {
+					t.Error("expired session should be deleted")
+				}
+				if len(closer.closed) != 1 || closer.closed[0] != created.ID {
+					t.Errorf("expired session's websocket should be closed, got %v", closer.closed)
+				}
+				return
+			}
+
+			if renewed != tt.wantRenewed {
+				t.Errorf("renewed = %v, want %v", renewed, tt.wantRenewed)
+			}
+			if want := sessionEpoch.Add(tt.wantExpiry); !session.ExpiresAt.Equal(want) {
+				t.Errorf("ExpiresAt = %v, want %v", session.ExpiresAt, want)
+			}
+			if stored := sessions.sessions[created.ID].ExpiresAt; !stored.Equal(session.ExpiresAt) {
+				t.Errorf("stored expiry %v differs from returned expiry %v", stored, session.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestValidateSession_RenewalIsThrottled(t *testing.T) {
+	svc, sessions, _, now := newSessionFixture()
+	created, _ := svc.CreateSession(7, models.SessionClient{})
+
+	for _, at := range []time.Duration{time.Minute, 5 * time.Minute, 9 * time.Minute} {
+		*now = sessionEpoch.Add(at)
+		if _, _, err := svc.ValidateSession(created.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sessions.touches != 0 {
+		t.Errorf("expected no writes within RenewAfter, got %d", sessions.touches)
+	}
+}
+
+func TestValidateSession_Unknown(t *testing.T) {
+	svc, _, _, _ := newSessionFixture()
+
+	if _, _, err := svc.ValidateSession("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("got error %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestRevokeSession_ClosesWebsocket(t *testing.T) {
+	svc, sessions, closer, _ := newSessionFixture()
+	created, _ := svc.CreateSession(7, models.SessionClient{})
+
+	if err := svc.RevokeSession(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions.sessions) != 0 {
+		t.Error("revoked session should be deleted")
+	}
+	if len(closer.closed) != 1 || closer.closed[0] != created.ID {
+		t.Errorf("closed = %v, want [%s]", closer.closed, created.ID)
+	}
+}
+
+func TestPruneExpiredSessions(t *testing.T) {
+	svc, sessions, closer, now := newSessionFixture()
+	old, _ := svc.CreateSession(7, models.SessionClient{})
+	*now = sessionEpoch.Add(time.Hour)
+	live, _ := svc.CreateSession(8, models.SessionClient{})
+
+	*now = sessionEpoch.Add(2 * time.Hour)
+	n, err := svc.PruneExpiredSessions()
+	if err != nil || n != 1 {
+		t.Fatalf("PruneExpiredSessions() = %d, %v; want 1, nil", n, err)
+	}
+	if _, ok := sessions.sessions[live.ID]; !ok {
+		t.Error("live session should survive pruning")
+	}
+	if len(closer.closed) != 1 || closer.closed[0] != old.ID {
+		t.Errorf("closed = %v, want [%s]", closer.closed, old.ID)
+	}
+}
+
+func TestListSessions_FlagsCurrent(t *testing.T) {
+	svc, _, _, _ := newSessionFixture()
+	current, _ := svc.CreateSession(7, models.SessionClient{UserAgent: "laptop"})
+	other, _ := svc.CreateSession(7, models.SessionClient{UserAgent: "phone"})
+	_, _ = svc.CreateSession(8, models.SessionClient{})
+
+	sessions, err := svc.ListSessions(7, current.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected only the user's 2 sessions, got %d", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == current.ID) {
+			t.Errorf("session %s: Current = %v", session.UserAgent, session.Current)
+		}
+		if session.ID == other.ID && session.UserAgent != "phone" {
+			t.Errorf("client details not kept: %+v", session)
+		}
+	}
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	svc, sessions, closer, _ := newSessionFixture()
+	mine, _ := svc.CreateSession(7, models.SessionClient{})
+	theirs, _ := svc.CreateSession(8, models.SessionClient{})
+
+	if _, err := svc.RevokeUserSession(7, theirs.PublicID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking another user's session: got %v, want ErrSessionNotFound", err)
+	}
+	if _, ok := sessions.sessions[theirs.ID]; !ok {
+		t.Fatal("another user's session must survive")
+	}
+
+	revoked, err := svc.RevokeUserSession(7, mine.PublicID)
+	if err != nil || revoked.ID != mine.ID {
+		t.Fatalf("RevokeUserSession() = %v, %v", revoked, err)
+	}
+	if len(closer.closed) != 1 || closer.closed[0] != mine.ID {
+		t.Errorf("closed = %v, want [%s]", closer.closed, mine.ID)
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	svc, sessions, closer, _ := newSessionFixture()
+	current, _ := svc.CreateSession(7, models.SessionClient{})
+	_, _ = svc.CreateSession(7, models.SessionClient{})
+	_, _ = svc.CreateSession(7, models.SessionClient{})
+	theirs, _ := svc.CreateSession(8, models.SessionClient{})
+
+	n, err := svc.RevokeOtherSessions(7, current.ID)
+	if err != nil || n != 2 {
+		t.Fatalf("RevokeOtherSessions() = %d, %v; want 2, nil", n, err)
+	}
+	if _, ok := sessions.sessions[current.ID]; !ok {
+		t.Error("current session must survive")
+	}
+	if _, ok := sessions.sessions[theirs.ID]; !ok {
+		t.Error("another user's session must survive")
+	}
+	if len(closer.closed) != 2 {
+		t.Errorf("expected 2 websockets closed, got %v", closer.closed)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// ErrInvalidTag is returned for a tag that could never be indexed.
+var ErrInvalidTag = errors.New("tags are letters, digits and underscores, with at least one letter")
+
+// TagConfig controls hashtag trends.
+type TagConfig struct {
+	// TrendingWindow is how far back uses of a tag count towards trending.
+	TrendingWindow time.Duration
+}
+
+// TagConfigFromEnv reads TRENDING_TAGS_WINDOW, a Go duration such as "6h".
+func TagConfigFromEnv() TagConfig {
+	config := TagConfig{TrendingWindow: 24 * time.Hour}
+	if value := os.Getenv("TRENDING_TAGS_WINDOW"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.TrendingWindow = d
+		} else {
+			log.Printf("ignoring invalid TRENDING_TAGS_WINDOW=%q", value)
+		}
+	}
+	return config
+}
+
+type tagService struct {
+	store  store.TagStore
+	config TagConfig
+	now    func() time.Time
+}
+
+func NewTagService(tags store.TagStore, config TagConfig) TagService {
+	return &tagService{store: tags, config: config, now: time.Now}
+}
+
+// GetTagTimeline returns a page of the posts tagged with tag that userID may
+// see, newest first. before is the NextCursor of the previous page, or "" to
+// start at the newest post. The tag may be given with its #.
+func (s *tagService) GetTagTimeline(userID int64, tag, before string, limit int) (*models.FeedPage, error) {
+	name, ok := utils.NormalizeHashtag(tag)
+	if !ok {
+		return nil, ErrInvalidTag
+	}
+	cursor, err := optionalFeedCursor(before)
+	if err != nil {
+		return nil, err
+	}
+
+	// one post more than asked for tells whether there is another page
+	posts, cursors, err := s.store.GetTagPage(userID, name, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.FeedPage{Posts: posts}
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+		page.NextCursor = encodeFeedCursor(cursors[limit-1])
+	}
+	if page.Posts == nil {
+		page.Posts = []*models.Post{}
+	}
+	return page, nil
+}
+
+// TrendingTags returns up to limit tags ranked by their use within the
+// trending window.
+func (s *tagService) TrendingTags(limit int) ([]models.TrendingTag, error) {
+	return s.store.TrendingTags(s.now().Add(-s.config.TrendingWindow), limit)
+}
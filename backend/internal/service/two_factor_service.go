@@ -0,0 +1,300 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/internal/store"
+	"github.com/tajjjjr/social-network/backend/pkg/totp"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// Errors returned by two-factor authentication.
+var (
+	ErrTwoFactorRequired         = errors.New("two-factor authentication required")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode      = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorChallenge = errors.New("two-factor login has expired, please log in again")
+	ErrTwoFactorUnavailable      = errors.New("two-factor authentication is not configured on this server")
+)
+
+// TwoFactorConfig controls TOTP enrollment and the second login step.
+type TwoFactorConfig struct {
+	Issuer            string        // shown in authenticator apps
+	ChallengeTTL      time.Duration // how long a 2FA pending token is valid
+	MaxAttempts       int           // wrong codes allowed per pending token
+	Skew              int           // time steps of clock drift accepted either way
+	RecoveryCodeCount int
+}
+
+// TwoFactorConfigFromEnv returns the defaults, with the issuer taken from TOTP_ISSUER when set.
+func TwoFactorConfigFromEnv() TwoFactorConfig {
+	config := TwoFactorConfig{
+		Issuer:            "Social Network",
+		ChallengeTTL:      5 * time.Minute,
+		MaxAttempts:       5,
+		Skew:              1,
+		RecoveryCodeCount: 10,
+	}
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		config.Issuer = issuer
+	}
+	return config
+}
+
+type twoFactorService struct {
+	store     store.TwoFactorStore
+	passwords PasswordHashStore
+	manager   *utils.PasswordManager
+	box       *utils.SecretBox
+	config    TwoFactorConfig
+	now       func() time.Time
+}
+
+// NewTwoFactorService creates a two-factor service. Secrets are sealed with box before they are stored.
+// Without a box nobody can enroll and codes from apps are refused, but recovery codes still work.
+// Passwords are checked with manager, which should be the one the auth service uses.
+func NewTwoFactorService(twoFactorStore store.TwoFactorStore, passwords PasswordHashStore, manager *utils.PasswordManager, box *utils.SecretBox, config TwoFactorConfig) TwoFactorService {
+	return &twoFactorService{
+		store:     twoFactorStore,
+		passwords: passwords,
+		manager:   manager,
+		box:       box,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a code such as "k3vq-7mzd" to show the user once.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 5)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+	return code[:4] + "-" + code[4:], nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes without the dash or in any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// Enroll starts two-factor enrollment with a new secret and recovery codes.
+// Nothing changes for logins until the user confirms a code from their app.
+func (s *twoFactorService) Enroll(userID int64) (*models.TwoFactorEnrollment, error) {
+	if s.box == nil {
+		return nil, ErrTwoFactorUnavailable
+	}
+	tf, err := s.store.GetTwoFactor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load two-factor state: %w", err)
+	}
+	if tf.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, s.config.RecoveryCodeCount)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := s.store.SetPendingSecret(userID, sealed, hashes); err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.config.Issuer, tf.Email, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their app
+// produces valid codes for the enrolled secret.
+func (s *twoFactorService) Confirm(userID int64, code string) error {
+	tf, err := s.store.GetTwoFactor(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load two-factor state: %w", err)
+	}
+	if tf.Enabled() {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	if tf.Secret == "" {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	secret, err := s.openSecret(tf.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Verify(secret, code, s.now(), s.config.Skew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return s.store.EnableTwoFactor(userID, s.now(), step)
+}
+
+// Disable turns two-factor authentication off. It needs the account password
+// and a current code or an unused recovery code.
+func (s *twoFactorService) Disable(userID int64, password, code string) error {
+	hash, err := s.passwords.GetPasswordHash(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load password: %w", err)
+	}
+	if err := s.manager.ComparePassword(hash, password); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	tf, err := s.store.GetTwoFactor(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load two-factor state: %w", err)
+	}
+	if !tf.Enabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.verifyCode(tf, code); err != nil {
+		return err
+	}
+	return s.store.DisableTwoFactor(userID)
+}
+
+func (s *twoFactorService) Enabled(userID int64) (bool, error) {
+	tf, err := s.store.GetTwoFactor(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled(), nil
+}
+
+// BeginLogin issues the short-lived 2FA pending token returned in place of a
+// session after the password step.
+func (s *twoFactorService) BeginLogin(userID int64) (string, error) {
+	now := s.now()
+	if err := s.store.DeleteExpiredChallenges(now); err != nil {
+		return "", err
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.CreateChallenge(userID, hash, now.Add(s.config.ChallengeTTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ChallengeUser returns the user a live 2FA pending token was issued to,
+// without using up the token.
+func (s *twoFactorService) ChallengeUser(token string) (int64, error) {
+	userID, _, err := s.store.GetChallenge(hashToken(token), s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+	return userID, err
+}
+
+// CompleteLogin checks the code for a 2FA pending token and returns the user
+// to open a session for. Each token completes at most one login, and is
+// discarded after MaxAttempts wrong codes.
+func (s *twoFactorService) CompleteLogin(token, code string) (int64, error) {
+	tokenHash := hashToken(token)
+	userID, attempts, err := s.store.GetChallenge(tokenHash, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+	if attempts >= s.config.MaxAttempts {
+		_, _ = s.store.DeleteChallenge(tokenHash)
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+
+	tf, err := s.store.GetTwoFactor(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load two-factor state: %w", err)
+	}
+	if err := s.verifyCode(tf, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if recordErr := s.store.RecordChallengeFailure(tokenHash); recordErr != nil {
+				return 0, recordErr
+			}
+		}
+		return 0, err
+	}
+
+	won, err := s.store.DeleteChallenge(tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	if !won {
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+	return userID, nil
+}
+
+// openSecret decrypts a stored TOTP secret.
+func (s *twoFactorService) openSecret(sealed string) (string, error) {
+	if s.box == nil {
+		return "", ErrTwoFactorUnavailable
+	}
+	return s.box.Open(sealed)
+}
+
+// verifyCode accepts a TOTP code that has not been used yet, or an unused recovery code.
+func (s *twoFactorService) verifyCode(tf *models.TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		secret, err := s.openSecret(tf.Secret)
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Verify(secret, code, s.now(), s.config.Skew)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		fresh, err := s.store.UseStep(tf.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.store.UseRecoveryCode(tf.UserID, hashToken(normalizeRecoveryCode(code)), s.now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/pkg/totp"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeChallenge struct {
+	userID    int64
+	attempts  int
+	expiresAt time.Time
+}
+
+type fakeTwoFactorStore struct {
+	state         map[int64]*models.TwoFactor
+	recoveryCodes map[int64]map[string]bool // hash -> used
+	challenges    map[string]*fakeChallenge
+}
+
+func (f *fakeTwoFactorStore) GetTwoFactor(userID int64) (*models.TwoFactor, error) {
+	tf, ok := f.state[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *tf
+	return &copied, nil
+}
+
+func (f *fakeTwoFactorStore) SetPendingSecret(userID int64, secret string, hashes []string) error {
+	tf := f.state[userID]
+	tf.Secret, tf.EnabledAt, tf.LastStep = secret, nil, 0
+	f.recoveryCodes[userID] = map[string]bool{}
+	for _, hash := range hashes {
+		f.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+
+func (f *fakeTwoFactorStore) EnableTwoFactor(userID int64, enabledAt time.Time, step int64) error {
+	tf := f.state[userID]
+	tf.EnabledAt, tf.LastStep = &enabledAt, step
+	return nil
+}
+
+func (f *fakeTwoFactorStore) DisableTwoFactor(userID int64) error {
+	tf := f.state[userID]
+	tf.Secret, tf.EnabledAt, tf.LastStep = "", nil, 0
+	delete(f.recoveryCodes, userID)
+	return nil
+}
+
+func (f *fakeTwoFactorStore) UseStep(userID, step int64) (bool, error) {
+	tf := f.state[userID]
+	if step <= tf.LastStep {
+		return false, nil
+	}
+	tf.LastStep = step
+	return true, nil
+}
+
+func (f *fakeTwoFactorStore) UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error) {
+	used, ok := f.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	f.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (f *fakeTwoFactorStore) CreateChallenge(userID int64, tokenHash string, expiresAt time.Time) error {
+	f.challenges[tokenHash] = &fakeChallenge{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakeTwoFactorStore) GetChallenge(tokenHash string, now time.Time) (int64, int, error) {
+	c, ok := f.challenges[tokenHash]
+	if !ok || !now.Before(c.expiresAt) {
+		return 0, 0, sql.ErrNoRows
+	}
+	return c.userID, c.attempts, nil
+}
+
+func (f *fakeTwoFactorStore) RecordChallengeFailure(tokenHash string) error {
+	if c, ok := f.challenges[tokenHash]; ok {
+		c.attempts++
+	}
+	return nil
+}
+
+func (f *fakeTwoFactorStore) DeleteChallenge(tokenHash string) (bool, error) {
+	_, ok := f.challenges[tokenHash]
+	delete(f.challenges, tokenHash)
+	return ok, nil
+}
+
+func (f *fakeTwoFactorStore) DeleteExpiredChallenges(now time.Time) error {
+	for hash, c := range f.challenges {
+		if !now.Before(c.expiresAt) {
+			delete(f.challenges, hash)
+		}
+	}
+	return nil
+}
+
+type fakePasswordHashStore map[int64]string
+
+func (f fakePasswordHashStore) GetPasswordHash(userID int64) (string, error) {
+	hash, ok := f[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return hash, nil
+}
+
+func newTwoFactorFixture(t *testing.T) (*twoFactorService, *fakeTwoFactorStore, *time.Time) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := utils.NewSecretBox(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tfStore := &fakeTwoFactorStore{
+		state:         map[int64]*models.TwoFactor{7: {UserID: 7, Email: "ada@example.com"}},
+		recoveryCodes: map[int64]map[string]bool{},
+		challenges:    map[string]*fakeChallenge{},
+	}
+	svc := NewTwoFactorService(tfStore, fakePasswordHashStore{7: string(hash)}, utils.NewPasswordManager(utils.PasswordConfig{}), box, TwoFactorConfig{
+		Issuer:            "Test",
+		ChallengeTTL:      5 * time.Minute,
+		MaxAttempts:       3,
+		Skew:              1,
+		RecoveryCodeCount: 4,
+	}).(*twoFactorService)
+
+	now := sessionEpoch
+	svc.now = func() time.Time { return now }
+	return svc, tfStore, &now
+}
+
+// enrollAndConfirm enables 2FA for user 7 and returns the secret and recovery codes.
+func enrollAndConfirm(t *testing.T, svc *twoFactorService, now time.Time) (string, []string) {
+	t.Helper()
+	enrollment, err := svc.Enroll(7)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	code, err := totp.Code(enrollment.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Confirm(7, code); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	return enrollment.Secret, enrollment.RecoveryCodes
+}
+
+func TestTwoFactorEnrollAndConfirm(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+
+	enrollment, err := svc.Enroll(7)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if len(enrollment.RecoveryCodes) != 4 {
+		t.Errorf("expected 4 recovery codes, got %d", len(enrollment.RecoveryCodes))
+	}
+	if enabled, _ := svc.Enabled(7); enabled {
+		t.Fatal("2FA must stay disabled until confirmed")
+	}
+
+	if err := svc.Confirm(7, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+
+	code, _ := totp.Code(enrollment.Secret, *now)
+	if err := svc.Confirm(7, code); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if enabled, _ := svc.Enabled(7); !enabled {
+		t.Fatal("expected 2FA to be enabled")
+	}
+	if _, err := svc.Enroll(7); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("expected ErrTwoFactorAlreadyEnabled, got %v", err)
+	}
+}
+
+func TestTwoFactorSecretIsStoredEncrypted(t *testing.T) {
+	svc, tfStore, _ := newTwoFactorFixture(t)
+
+	enrollment, err := svc.Enroll(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := tfStore.state[7].Secret; stored == "" || stored == enrollment.Secret {
+		t.Fatalf("expected an encrypted secret, got %q", stored)
+	}
+}
+
+func TestTwoFactorLoginRejectsReplayedCode(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+	secret, _ := enrollAndConfirm(t, svc, *now)
+
+	// The code used to confirm enrollment cannot be reused to log in.
+	token, err := svc.BeginLogin(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := totp.Code(secret, *now)
+	if _, err := svc.CompleteLogin(token, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected replayed code to fail, got %v", err)
+	}
+
+	*now = now.Add(totp.Period)
+	code, _ = totp.Code(secret, *now)
+	userID, err := svc.CompleteLogin(token, code)
+	if err != nil || userID != 7 {
+		t.Fatalf("CompleteLogin = %d, %v", userID, err)
+	}
+
+	// A pending token completes only one login.
+	if _, err := svc.CompleteLogin(token, code); !errors.Is(err, ErrInvalidTwoFactorChallenge) {
+		t.Fatalf("expected ErrInvalidTwoFactorChallenge, got %v", err)
+	}
+}
+
+func TestTwoFactorRecoveryCodesAreSingleUse(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+	_, codes := enrollAndConfirm(t, svc, *now)
+
+	token, _ := svc.BeginLogin(7)
+	if _, err := svc.CompleteLogin(token, codes[0]); err != nil {
+		t.Fatalf("recovery code login: %v", err)
+	}
+
+	token, _ = svc.BeginLogin(7)
+	if _, err := svc.CompleteLogin(token, codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected reused recovery code to fail, got %v", err)
+	}
+}
+
+func TestTwoFactorChallengeLimits(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+	secret, _ := enrollAndConfirm(t, svc, *now)
+	*now = now.Add(totp.Period)
+	code, _ := totp.Code(secret, *now)
+
+	t.Run("too many attempts", func(t *testing.T) {
+		token, _ := svc.BeginLogin(7)
+		for i := 0; i < 3; i++ {
+			if _, err := svc.CompleteLogin(token, "bad-code"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+				t.Fatalf("attempt %d: expected ErrInvalidTwoFactorCode, got %v", i, err)
+			}
+		}
+		if _, err := svc.CompleteLogin(token, code); !errors.Is(err, ErrInvalidTwoFactorChallenge) {
+			t.Fatalf("expected ErrInvalidTwoFactorChallenge, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token, _ := svc.BeginLogin(7)
+		*now = now.Add(5 * time.Minute)
+		if _, err := svc.CompleteLogin(token, code); !errors.Is(err, ErrInvalidTwoFactorChallenge) {
+			t.Fatalf("expected ErrInvalidTwoFactorChallenge, got %v", err)
+		}
+	})
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+	_, codes := enrollAndConfirm(t, svc, *now)
+
+	if err := svc.Disable(7, "wrong", codes[0]); !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("expected ErrIncorrectPassword, got %v", err)
+	}
+	if err := svc.Disable(7, "Secret123!", "nope-nope"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+	if err := svc.Disable(7, "Secret123!", codes[0]); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if enabled, _ := svc.Enabled(7); enabled {
+		t.Fatal("expected 2FA to be disabled")
+	}
+	if err := svc.Disable(7, "Secret123!", codes[1]); !errors.Is(err, ErrTwoFactorNotEnabled) {
+		t.Fatalf("expected ErrTwoFactorNotEnabled, got %v", err)
+	}
+}
+
+func TestTwoFactorWithoutEncryptionKey(t *testing.T) {
+	svc, _, now := newTwoFactorFixture(t)
+	secret, codes := enrollAndConfirm(t, svc, *now)
+	svc.box = nil
+
+	if _, err := svc.Enroll(8); !errors.Is(err, ErrTwoFactorUnavailable) {
+		t.Errorf("Enroll: expected ErrTwoFactorUnavailable, got %v", err)
+	}
+	*now = now.Add(totp.Period)
+	code, _ := totp.Code(secret, *now)
+	token, _ := svc.BeginLogin(7)
+	if _, err := svc.CompleteLogin(token, code); !errors.Is(err, ErrTwoFactorUnavailable) {
+		t.Fatalf("app code: expected ErrTwoFactorUnavailable, got %v", err)
+	}
+	if _, err := svc.CompleteLogin(token, codes[0]); err != nil {
+		t.Errorf("recovery codes need no key: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type accessTokenStore struct {
+	db *sql.DB
+}
+
+func NewAccessTokenStore(db *sql.DB) AccessTokenStore {
+	return &accessTokenStore{db: db}
+}
+
+const accessTokenColumns = "id, user_id, name, scope, created_at, expires_at, last_used_at"
+
+func scanAccessToken(row interface{ Scan(...any) error }) (*models.AccessToken, error) {
+	var token models.AccessToken
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Scope, &token.CreatedAt, &expiresAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+// CreateAccessToken stores token under tokenHash and sets its ID.
+func (s *accessTokenStore) CreateAccessToken(token *models.AccessToken, tokenHash string) error {
+	var expiresAt sql.NullTime
+	if token.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: token.ExpiresAt.UTC(), Valid: true}
+	}
+	result, err := s.db.Exec(
+		"INSERT INTO Access_Tokens (user_id, name, token_hash, scope, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		token.UserID, token.Name, tokenHash, token.Scope, token.CreatedAt.UTC(), expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating access token: %w", err)
+	}
+	token.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAccessTokenByHash returns the token with tokenHash, expired or not, or sql.ErrNoRows.
+func (s *accessTokenStore) GetAccessTokenByHash(tokenHash string) (*models.AccessToken, error) {
+	return scanAccessToken(s.db.QueryRow(
+		"SELECT "+accessTokenColumns+" FROM Access_Tokens WHERE token_hash = ?",
+		tokenHash,
+	))
+}
+
+// ListUserAccessTokens returns every token of the user, newest first.
+func (s *accessTokenStore) ListUserAccessTokens(userID int64) ([]*models.AccessToken, error) {
+	rows, err := s.db.Query(
+		"SELECT "+accessTokenColumns+" FROM Access_Tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.AccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *accessTokenStore) TouchAccessToken(tokenID int64, lastUsedAt time.Time) error {
+	_, err := s.db.Exec("UPDATE Access_Tokens SET last_used_at = ? WHERE id = ?", lastUsedAt.UTC(), tokenID)
+	if err != nil {
+		return fmt.Errorf("error updating access token: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserAccessToken deletes one of the user's tokens, reporting whether it existed.
+func (s *accessTokenStore) DeleteUserAccessToken(userID, tokenID int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM Access_Tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting access token: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func setupAccessTokenTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "access_tokens.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE Access_Tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		scope TEXT NOT NULL CHECK (scope IN ('read', 'write')),
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME
+	);`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAccessTokenStore(t *testing.T) {
+	db := setupAccessTokenTestDB(t)
+	tokens := NewAccessTokenStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(24 * time.Hour)
+
+	token := &models.AccessToken{UserID: 1, Name: "ci", Scope: "read", CreatedAt: now, ExpiresAt: &expiresAt}
+	if err := tokens.CreateAccessToken(token, "hash"); err != nil || token.ID == 0 {
+		t.Fatalf("CreateAccessToken() = %v, id %d", err, token.ID)
+	}
+	if err := tokens.CreateAccessToken(&models.AccessToken{UserID: 1, Name: "bad", Scope: "admin", CreatedAt: now}, "hash2"); err == nil {
+		t.Error("unknown scopes should be rejected")
+	}
+
+	if err := tokens.TouchAccessToken(token.ID, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tokens.GetAccessTokenByHash("hash")
+	if err != nil || got.Name != "ci" || !got.ExpiresAt.Equal(expiresAt) || got.LastUsedAt == nil {
+		t.Fatalf("GetAccessTokenByHash() = %+v, %v", got, err)
+	}
+
+	if list, _ := tokens.ListUserAccessTokens(1); len(list) != 1 {
+		t.Errorf("expected 1 token, got %d", len(list))
+	}
+	if list, _ := tokens.ListUserAccessTokens(2); list == nil || len(list) != 0 {
+		t.Errorf("expected an empty list for another user, got %v", list)
+	}
+
+	if deleted, _ := tokens.DeleteUserAccessToken(2, token.ID); deleted {
+		t.Error("another user must not delete the token")
+	}
+	if deleted, _ := tokens.DeleteUserAccessToken(1, token.ID); !deleted {
+		t.Error("expected the token to be deleted")
+	}
+	if _, err := tokens.GetAccessTokenByHash("hash"); err != sql.ErrNoRows {
+		t.Errorf("deleted token: got %v, want sql.ErrNoRows", err)
+	}
+}
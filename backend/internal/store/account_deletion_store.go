@@ -0,0 +1,263 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type accountDeletionStore struct {
+	db *sql.DB
+}
+
+func NewAccountDeletionStore(db *sql.DB) AccountDeletionStore {
+	return &accountDeletionStore{db: db}
+}
+
+func (s *accountDeletionStore) ScheduleDeletion(userID int64, deleteAfter time.Time) error {
+	result, err := s.db.Exec("UPDATE Users SET delete_after = ? WHERE id = ?", deleteAfter.UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("error scheduling account deletion: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error scheduling account deletion: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CancelDeletion clears a scheduled deletion and reports whether there was one.
+func (s *accountDeletionStore) CancelDeletion(userID int64) (bool, error) {
+	result, err := s.db.Exec("UPDATE Users SET delete_after = NULL WHERE id = ? AND delete_after IS NOT NULL", userID)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling account deletion: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error cancelling account deletion: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *accountDeletionStore) ListDueDeletions(now time.Time) ([]int64, error) {
+	rows, err := s.db.Query("SELECT id FROM Users WHERE delete_after IS NOT NULL AND delete_after <= ?", now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error listing due account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning account deletion: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// userPosts, userGroupPosts and doomedGroups select rows removed with a user.
+// doomedGroups is only complete after owned groups with members are handed over.
+const (
+	userPosts      = "SELECT id FROM Posts WHERE user_id = ?"
+	userGroupPosts = "SELECT id FROM Group_Posts WHERE user_id = ?"
+	doomedGroups   = "SELECT id FROM Groups WHERE creator_id = ?"
+	doomedComments = "SELECT id FROM Comments WHERE user_id = ? OR post_id IN (" + userPosts + ")"
+	// rows of Post_Attachments and Post_Tags on the user's posts and comments, and on comments on their posts
+	doomedPostContent = "user_id = ? OR post_id IN (" + userPosts + ") OR comment_id IN (" + doomedComments + ")"
+	// a user's group comments take the replies beneath them along
+	doomedGroupComments = `WITH RECURSIVE doomed(id) AS (
+		SELECT id FROM Group_Post_Comments
+		WHERE user_id = ?
+			OR group_post_id IN (` + userGroupPosts + `)
+			OR group_post_id IN (SELECT id FROM Group_Posts WHERE group_id IN (` + doomedGroups + `))
+		UNION
+		SELECT c.id FROM Group_Post_Comments c JOIN doomed d ON c.parent_comment_id = d.id
+	) `
+)
+
+// purgeStatements remove everything a user owns, children before parents.
+// Each takes the user id once per placeholder. Foreign keys are not enforced,
+// so nothing here can rely on ON DELETE CASCADE.
+var purgeStatements = []string{
+	// mentions by or of the user, and those in anything removed below
+	"DELETE FROM Mentions WHERE user_id = ? OR author_id = ? OR post_id IN (" + userPosts + ") OR comment_id IN (" + doomedComments + ")" +
+		" OR message_id IN (SELECT id FROM Messages WHERE sender_id = ? OR receiver_id = ? OR group_id IN (" + doomedGroups + "))",
+
+	// groups nobody took over, with all their content
+	"DELETE FROM Group_Comment_Reactions WHERE comment_id IN (SELECT id FROM Group_Post_Comments WHERE group_post_id IN (SELECT id FROM Group_Posts WHERE group_id IN (" + doomedGroups + ")))",
+	"DELETE FROM Group_Post_Comments WHERE group_post_id IN (SELECT id FROM Group_Posts WHERE group_id IN (" + doomedGroups + "))",
+	"DELETE FROM Group_Post_Reactions WHERE group_post_id IN (SELECT id FROM Group_Posts WHERE group_id IN (" + doomedGroups + "))",
+	"DELETE FROM Group_Posts WHERE group_id IN (" + doomedGroups + ")",
+	"DELETE FROM Event_Responses WHERE event_id IN (SELECT id FROM Group_Events WHERE group_id IN (" + doomedGroups + "))",
+	"DELETE FROM Group_Events WHERE group_id IN (" + doomedGroups + ")",
+	"DELETE FROM Group_Requests WHERE group_id IN (" + doomedGroups + ")",
+	"DELETE FROM Group_Members WHERE group_id IN (" + doomedGroups + ")",
+	"DELETE FROM Messages WHERE group_id IN (" + doomedGroups + ")",
+	"DELETE FROM Groups WHERE creator_id = ?",
+
+	// posts, and comments on them or by the user
+	"DELETE FROM Post_Attachments WHERE " + doomedPostContent,
+	"DELETE FROM Post_Tags WHERE " + doomedPostContent,
+	"DELETE FROM Comment_Reactions WHERE user_id = ? OR comment_id IN (" + doomedComments + ")",
+	"DELETE FROM Comments WHERE user_id = ? OR post_id IN (" + userPosts + ")",
+	"DELETE FROM Post_Reactions WHERE user_id = ? OR post_id IN (" + userPosts + ")",
+	"DELETE FROM Post_Visibility WHERE viewer_id = ? OR post_id IN (" + userPosts + ")",
+	"DELETE FROM Posts WHERE user_id = ?",
+	"DELETE FROM Tags WHERE id NOT IN (SELECT tag_id FROM Post_Tags)",
+
+	// activity in other people's groups
+	doomedGroupComments + "DELETE FROM Group_Comment_Reactions WHERE user_id = ? OR comment_id IN (SELECT id FROM doomed)",
+	doomedGroupComments + "DELETE FROM Group_Post_Comments WHERE id IN (SELECT id FROM doomed)",
+	"DELETE FROM Group_Post_Reactions WHERE user_id = ? OR group_post_id IN (" + userGroupPosts + ")",
+	"DELETE FROM Group_Posts WHERE user_id = ?",
+	"DELETE FROM Event_Responses WHERE user_id = ? OR event_id IN (SELECT id FROM Group_Events WHERE created_by = ?)",
+	"DELETE FROM Group_Events WHERE created_by = ?",
+	"DELETE FROM Group_Requests WHERE user_id = ?",
+	"DELETE FROM Group_Members WHERE user_id = ?",
+	"UPDATE Group_Members SET invited_by = NULL WHERE invited_by = ?",
+
+	"DELETE FROM Messages WHERE sender_id = ? OR receiver_id = ?",
+	"DELETE FROM Notifications WHERE user_id = ?",
+	"DELETE FROM Followers WHERE follower_id = ? OR followee_id = ?",
+	"DELETE FROM Blocks WHERE blocker_id = ? OR blocked_id = ?",
+	"DELETE FROM Muted_Users WHERE user_id = ? OR muted_id = ?",
+	"DELETE FROM Muted_Keywords WHERE user_id = ?",
+	"DELETE FROM Handle_Redirects WHERE user_id = ?",
+	"DELETE FROM Data_Exports WHERE user_id = ?",
+
+	// credentials and security records
+	"DELETE FROM Sessions WHERE user_id = ?",
+	"DELETE FROM Access_Tokens WHERE user_id = ?",
+	"DELETE FROM External_Identities WHERE user_id = ?",
+	"DELETE FROM Password_Reset_Tokens WHERE user_id = ?",
+	"DELETE FROM Email_Verification_Tokens WHERE user_id = ?",
+	"DELETE FROM Recovery_Codes WHERE user_id = ?",
+	"DELETE FROM Two_Factor_Challenges WHERE user_id = ?",
+	"DELETE FROM Login_Failures WHERE user_id = ?",
+	"DELETE FROM Login_Throttles WHERE throttle_key = (SELECT 'account:' || LOWER(email) FROM Users WHERE id = ?)",
+
+	"DELETE FROM Users WHERE id = ?",
+}
+
+// userFiles select the attachment paths of everything purgeStatements remove.
+var userFiles = []string{
+	"SELECT avatar FROM Users WHERE id = ?",
+	"SELECT image FROM Posts WHERE user_id = ?",
+	"SELECT image FROM Comments WHERE id IN (" + doomedComments + ")",
+	"SELECT image FROM Group_Posts WHERE user_id = ? OR group_id IN (" + doomedGroups + ")",
+	"SELECT path FROM Post_Attachments WHERE " + doomedPostContent,
+}
+
+// PurgeUser deletes a user and everything they own in one transaction and
+// returns the attachment paths that referenced files, for the caller to remove.
+//
+// Groups the user created pass to their longest-standing accepted member, who
+// becomes successorRole; groups without other members are deleted.
+func (s *accountDeletionStore) PurgeUser(userID int64, successorRole string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := transferGroups(tx, userID, successorRole); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, query := range userFiles {
+		paths, err := queryPaths(tx, query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error listing attachments: %w", err)
+		}
+		files = append(files, paths...)
+	}
+
+	for _, stmt := range purgeStatements {
+		if _, err := tx.Exec(stmt, repeatArg(stmt, userID)...); err != nil {
+			return nil, fmt.Errorf("error purging user: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing user purge: %w", err)
+	}
+	return files, nil
+}
+
+// transferGroups hands each group the user created to another accepted member.
+func transferGroups(tx *sql.Tx, userID int64, successorRole string) error {
+	rows, err := tx.Query(`
+		SELECT g.id, (
+			SELECT m.user_id FROM Group_Members m
+			WHERE m.group_id = g.id AND m.user_id != g.creator_id AND m.is_accepted = 1
+			ORDER BY m.role = ? DESC, m.created_at, m.user_id
+			LIMIT 1
+		)
+		FROM Groups g WHERE g.creator_id = ?`,
+		successorRole, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error finding group successors: %w", err)
+	}
+	successors := map[int64]int64{}
+	for rows.Next() {
+		var groupID int64
+		var successor sql.NullInt64
+		if err := rows.Scan(&groupID, &successor); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning group successor: %w", err)
+		}
+		if successor.Valid {
+			successors[groupID] = successor.Int64
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for groupID, successor := range successors {
+		if _, err := tx.Exec("UPDATE Groups SET creator_id = ? WHERE id = ?", successor, groupID); err != nil {
+			return fmt.Errorf("error transferring group: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE Group_Members SET role = ? WHERE group_id = ? AND user_id = ?", successorRole, groupID, successor); err != nil {
+			return fmt.Errorf("error transferring group: %w", err)
+		}
+	}
+	return nil
+}
+
+func queryPaths(tx *sql.Tx, query string, userID int64) ([]string, error) {
+	rows, err := tx.Query(query, repeatArg(query, userID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path sql.NullString
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		if path.Valid && path.String != "" {
+			paths = append(paths, path.String)
+		}
+	}
+	return paths, rows.Err()
+}
+
+// repeatArg binds arg to every placeholder in query.
+func repeatArg(query string, arg any) []any {
+	args := make([]any, 0, 4)
+	for _, c := range query {
+		if c == '?' {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/pkg/db/sqlite"
+)
+
+// setupMigratedTestDB applies every up migration, so purges are checked
+// against the real schema and its triggers.
+func setupMigratedTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrated.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	migrateTestDB(t, db, "", "")
+	return db
+}
+
+// migrateTestDB applies the up migrations from from (inclusive) to until
+// (exclusive), by file name; empty bounds are open.
+func migrateTestDB(t *testing.T, db *sql.DB, from, until string) {
+	t.Helper()
+	const dir = "../../pkg/db/migrations/sqlite"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".up.sql") && name >= from && (until == "" || name < until) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := sqlite.ApplyMigrationInTx(db, dir, f); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, avatar) VALUES
+			(1, 'Gone@example.com', 'x', 'gone.png'), (2, 'stays@example.com', 'x', 'stays.png'), (3, 'third@example.com', 'x', NULL);
+		INSERT INTO Posts (id, user_id, content, image) VALUES (10, 1, 'mine', 'posts/mine.jpg'), (20, 2, 'theirs', NULL);
+		INSERT INTO Comments (id, post_id, user_id, content, image) VALUES
+			(100, 10, 2, 'on my post', 'comments/reply.png'), (200, 20, 1, 'my comment', NULL), (201, 20, 3, 'kept', NULL);
+		INSERT INTO Post_Reactions (user_id, post_id, reaction_type) VALUES (1, 20, 'like'), (3, 20, 'like'), (2, 10, 'like');
+		INSERT INTO Comment_Reactions (user_id, comment_id, reaction_type) VALUES (2, 200, 'like'), (1, 201, 'like');
+		INSERT INTO Post_Attachments (post_id, comment_id, user_id, path) VALUES
+			(10, NULL, 1, 'posts/second.jpg'), (NULL, 100, 2, 'comments/reply-2.png'), (20, NULL, 2, 'posts/theirs.jpg');
+		INSERT INTO Mentions (user_id, author_id, post_id, comment_id, created_at) VALUES
+			(2, 1, 10, NULL, CURRENT_TIMESTAMP), (1, 2, 20, NULL, CURRENT_TIMESTAMP), (3, 2, NULL, 100, CURRENT_TIMESTAMP), (3, 2, 20, NULL, CURRENT_TIMESTAMP);
+		INSERT INTO Tags (id, name) VALUES (1, 'mine'), (2, 'shared');
+		INSERT INTO Post_Tags (tag_id, post_id, comment_id, user_id, used_at) VALUES
+			(1, 10, NULL, 1, CURRENT_TIMESTAMP), (2, NULL, 100, 2, CURRENT_TIMESTAMP), (2, NULL, 201, 3, CURRENT_TIMESTAMP);
+		INSERT INTO Post_Visibility (post_id, viewer_id) VALUES (20, 1), (10, 2);
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (1, 2, 'accepted'), (2, 1, 'accepted'), (2, 3, 'accepted');
+		INSERT INTO Messages (sender_id, receiver_id, content) VALUES (1, 2, 'hi'), (2, 1, 'hey'), (2, 3, 'kept');
+		INSERT INTO Notifications (user_id, type, message) VALUES (1, 'follow', 'x'), (2, 'follow', 'kept');
+		INSERT INTO Sessions (id, user_id) VALUES ('gone', 1), ('stays', 2);
+		INSERT INTO Login_Throttles (throttle_key, failures, last_failure_at) VALUES ('account:gone@example.com', 2, CURRENT_TIMESTAMP);
+
+		-- group 1 passes to its member; group 2 has nobody else and goes
+		INSERT INTO Groups (id, title, creator_id) VALUES (1, 'shared', 1), (2, 'lonely', 1), (3, 'other', 2);
+		INSERT INTO Group_Members (group_id, user_id, role, is_accepted, invited_by) VALUES
+			(1, 2, 'member', 1, 1), (1, 3, 'member', 0, NULL), (3, 1, 'member', 1, NULL), (3, 3, 'member', 1, 1);
+		INSERT INTO Group_Posts (id, group_id, user_id, content, image) VALUES
+			(1, 2, 1, 'lonely post', 'groups/lonely.png'), (2, 3, 2, 'their group post', NULL);
+		INSERT INTO Group_Post_Comments (id, group_post_id, user_id, parent_comment_id, content) VALUES
+			(1, 2, 1, NULL, 'my group comment'), (2, 2, 3, 1, 'reply to it'), (3, 2, 3, NULL, 'kept');
+		INSERT INTO Group_Comment_Reactions (comment_id, user_id, reaction_type) VALUES (2, 2, 'like'), (3, 2, 'like');
+		INSERT INTO Messages (sender_id, group_id, content) VALUES (2, 2, 'in the lonely group'), (2, 1, 'kept');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewAccountDeletionStore(db)
+	if err := s.ScheduleDeletion(1, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	due, err := s.ListDueDeletions(time.Date(2024, 3, 1, 0, 0, 1, 0, time.UTC))
+	if err != nil || len(due) != 1 || due[0] != 1 {
+		t.Fatalf("due deletions: got %v, %v", due, err)
+	}
+
+	files, err := s.PurgeUser(1, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if got := strings.Join(files, " "); got != "comments/reply-2.png comments/reply.png gone.png groups/lonely.png posts/mine.jpg posts/second.jpg" {
+		t.Errorf("files = %q", got)
+	}
+
+	counts := map[string]int{
+		"SELECT COUNT(*) FROM Users":                  2,
+		"SELECT COUNT(*) FROM Posts":                  1,
+		"SELECT COUNT(*) FROM Comments":               1,
+		"SELECT COUNT(*) FROM Post_Reactions":         1,
+		"SELECT likes_count FROM Posts WHERE id = 20": 1,
+		"SELECT COUNT(*) FROM Comment_Reactions":      0,
+		"SELECT COUNT(*) FROM Post_Attachments":       1,
+		"SELECT COUNT(*) FROM Post_Visibility":        0,
+		"SELECT COUNT(*) FROM Post_Tags":              1,
+		"SELECT COUNT(*) FROM Mentions":               1,
+		"SELECT COUNT(*) FROM Tags":                   1,
+		"SELECT COUNT(*) FROM Followers":              1,
+		"SELECT COUNT(*) FROM Messages":               2,
+		"SELECT COUNT(*) FROM Notifications":          1,
+		"SELECT COUNT(*) FROM Sessions":               1,
+		"SELECT COUNT(*) FROM Login_Throttles":        0,
+		"SELECT COUNT(*) FROM Groups":                 2,
+		"SELECT creator_id FROM Groups WHERE id = 1":  2,
+		"SELECT COUNT(*) FROM Group_Members":          3,
+		"SELECT COUNT(*) FROM Group_Members WHERE role = 'admin' AND group_id = 1 AND user_id = 2": 1,
+		"SELECT COUNT(*) FROM Group_Members WHERE invited_by = 1":                                  0,
+		"SELECT COUNT(*) FROM Group_Posts":                                                         1,
+		"SELECT COUNT(*) FROM Group_Post_Comments":                                                 1,
+		"SELECT COUNT(*) FROM Group_Comment_Reactions":                                             1,
+	}
+	for query, want := range counts {
+		var got int
+		if err := db.QueryRow(query).Scan(&got); err != nil || got != want {
+			t.Errorf("%s: got %d, %v; want %d", query, got, err, want)
+		}
+	}
+
+	if cancelled, err := s.CancelDeletion(1); err != nil || cancelled {
+		t.Errorf("cancelling a purged account: got %v, %v", cancelled, err)
+	}
+}
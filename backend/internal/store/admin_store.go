@@ -0,0 +1,143 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type adminStore struct {
+	db *sql.DB
+}
+
+func NewAdminStore(db *sql.DB) AdminStore {
+	return &adminStore{db: db}
+}
+
+// GetAccountStatus returns the user's site role and whether they are suspended.
+func (s *adminStore) GetAccountStatus(userID int64) (string, bool, error) {
+	var role string
+	var suspendedAt sql.NullTime
+	err := s.db.QueryRow("SELECT role, suspended_at FROM Users WHERE id = ?", userID).Scan(&role, &suspendedAt)
+	if err != nil {
+		return "", false, err
+	}
+	return role, suspendedAt.Valid, nil
+}
+
+func (s *adminStore) SetUserRole(userID int64, role string) error {
+	return expectOneRow(s.db.Exec("UPDATE Users SET role = ? WHERE id = ?", role, userID))
+}
+
+// SetUserRoleByEmail gives the user with email a role and returns their id.
+func (s *adminStore) SetUserRoleByEmail(email, role string) (int64, error) {
+	var userID int64
+	err := s.db.QueryRow("UPDATE Users SET role = ? WHERE email = ? RETURNING id", role, email).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// SetSuspended suspends the user at the given time, or lifts the suspension when at is nil.
+func (s *adminStore) SetSuspended(userID int64, at *time.Time) error {
+	var suspendedAt any
+	if at != nil {
+		suspendedAt = at.UTC()
+	}
+	return expectOneRow(s.db.Exec("UPDATE Users SET suspended_at = ? WHERE id = ?", suspendedAt, userID))
+}
+
+func (s *adminStore) CountUsersWithRole(role string) (int, error) {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM Users WHERE role = ?", role).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting users: %w", err)
+	}
+	return count, nil
+}
+
+// ListUsers returns users whose email or names contain query, newest first.
+// An empty query lists everyone.
+func (s *adminStore) ListUsers(query string, limit, offset int) ([]*models.AdminUser, error) {
+	pattern := "%" + escapeLike(query) + "%"
+	rows, err := s.db.Query(`
+		SELECT id, email, first_name, last_name, nickname, role, created_at, email_verified_at, suspended_at, delete_after
+		FROM Users
+		WHERE ? = '' OR email LIKE ? ESCAPE '\' OR nickname LIKE ? ESCAPE '\'
+			OR (COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) LIKE ? ESCAPE '\'
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`,
+		query, pattern, pattern, pattern, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.AdminUser{}
+	for rows.Next() {
+		var user models.AdminUser
+		var verifiedAt, suspendedAt, deleteAfter sql.NullTime
+		err := rows.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Nickname, &user.Role,
+			&user.CreatedAt, &verifiedAt, &suspendedAt, &deleteAfter)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		user.EmailVerifiedAt = nullTimePtr(verifiedAt)
+		user.SuspendedAt = nullTimePtr(suspendedAt)
+		user.DeleteAfter = nullTimePtr(deleteAfter)
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+func (s *adminStore) GetPlatformStats(now time.Time) (*models.PlatformStats, error) {
+	var stats models.PlatformStats
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM Users),
+			(SELECT COUNT(*) FROM Users WHERE suspended_at IS NOT NULL),
+			(SELECT COUNT(*) FROM Users WHERE delete_after IS NOT NULL),
+			(SELECT COUNT(*) FROM Sessions WHERE expires_at > ?),
+			(SELECT COUNT(*) FROM Posts),
+			(SELECT COUNT(*) FROM Comments),
+			(SELECT COUNT(*) FROM Groups),
+			(SELECT COUNT(*) FROM Messages)`,
+		now.UTC(),
+	).Scan(&stats.Users, &stats.SuspendedUsers, &stats.PendingDeletes, &stats.ActiveSessions,
+		&stats.Posts, &stats.Comments, &stats.Groups, &stats.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("error counting platform stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// expectOneRow turns an update that matched no row into sql.ErrNoRows.
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdminStore(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, first_name, last_name, nickname, created_at) VALUES
+			(1, 'ada@example.com', 'x', 'Ada', 'Lovelace', 'ada', '2024-01-01 00:00:00'),
+			(2, 'grace@example.com', 'x', 'Grace', 'Hopper', 'amazing_grace', '2024-02-01 00:00:00'),
+			(3, 'alan@example.com', 'x', NULL, NULL, NULL, '2024-03-01 00:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewAdminStore(db)
+
+	if role, suspended, err := s.GetAccountStatus(1); err != nil || role != "user" || suspended {
+		t.Fatalf("new users are plain users: got %q, %v, %v", role, suspended, err)
+	}
+	if _, _, err := s.GetAccountStatus(99); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unknown user: got %v", err)
+	}
+	if err := s.SetUserRole(2, "superuser"); err == nil {
+		t.Error("the schema should reject unknown roles")
+	}
+	if err := s.SetUserRole(99, "admin"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unknown user: got %v", err)
+	}
+	if id, err := s.SetUserRoleByEmail("grace@example.com", "admin"); err != nil || id != 2 {
+		t.Fatalf("by email: got %d, %v", id, err)
+	}
+	if n, err := s.CountUsersWithRole("admin"); err != nil || n != 1 {
+		t.Errorf("admins: got %d, %v", n, err)
+	}
+
+	if err := s.SetSuspended(3, &time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, suspended, _ := s.GetAccountStatus(3); !suspended {
+		t.Error("user 3 should be suspended")
+	}
+
+	all, err := s.ListUsers("", 10, 0)
+	if err != nil || len(all) != 3 || all[0].ID != 3 || all[0].SuspendedAt == nil || all[1].Role != "admin" {
+		t.Fatalf("newest first: got %+v, %v", all, err)
+	}
+	for query, want := range map[string]int64{"LOVELACE": 1, "ada lovelace": 1, "amazing_": 2, "alan@": 3} {
+		users, err := s.ListUsers(query, 10, 0)
+		if err != nil || len(users) != 1 || users[0].ID != want {
+			t.Errorf("query %q: got %+v, %v", query, users, err)
+		}
+	}
+	// LIKE wildcards in the query are taken literally
+	if users, _ := s.ListUsers("_", 10, 0); len(users) != 1 {
+		t.Errorf("underscore should only match the nickname containing one, got %d users", len(users))
+	}
+	if page, _ := s.ListUsers("", 2, 2); len(page) != 1 || page[0].ID != 1 {
+		t.Errorf("second page: got %+v", page)
+	}
+
+	stats, err := s.GetPlatformStats(time.Now())
+	if err != nil || stats.Users != 3 || stats.SuspendedUsers != 1 {
+		t.Errorf("stats: got %+v, %v", stats, err)
+	}
+}
@@ -2,7 +2,6 @@ package store
 
 import (
 	"database/sql"
-	"time"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
 )
@@ -47,40 +46,6 @@ func (s *UserStore) CreateSession(userID int) (string, error) {
 	return sessionID, nil
 }
 */
-// CreateSession creates a new session for a user.
-func (s *AuthStore) CreateSession(userID int64, sessionID string, expiresAt time.Time) error {
-	_, err := s.DB.Exec("INSERT INTO Sessions (user_id, id, created_at, expires_at) VALUES (?, ?, ?, ?)", userID, sessionID, time.Now(), expiresAt)
-	return err
-}
-
-// -----------------------------------------------
-
-// GetUserIDBySession returns the user ID associated with a valid session ID
-func (s *AuthStore) GetUserIDBySession(sessionID string) (int, error) {
-	var userID int
-	var expiresAt time.Time
-
-	err := s.DB.QueryRow(
-		"SELECT user_id, expires_at FROM Sessions WHERE id = ?",
-		sessionID,
-	).Scan(&userID, &expiresAt)
-	if err != nil {
-		return 0, err
-	}
-
-	if expiresAt.Before(time.Now()) {
-		return 0, sql.ErrNoRows // session expired
-	}
-
-	return userID, nil
-}
-
-// DeleteSession deletes a session by ID
-func (s *AuthStore) DeleteSession(sessionID string) error {
-	_, err := s.DB.Exec("DELETE FROM Sessions WHERE id = ?", sessionID)
-	return err
-}
-
 // CreateUser creates a new user in the database
 func (s *AuthStore) CreateUser(user *models.User) (int64, error) {
 	stmt, err := s.DB.Prepare(`
@@ -111,6 +76,19 @@ func (s *AuthStore) CreateUser(user *models.User) (int64, error) {
 	return result.LastInsertId()
 }
 
+// GetPasswordHash returns the stored password hash of a user
+func (s *AuthStore) GetPasswordHash(userID int64) (string, error) {
+	var hash string
+	err := s.DB.QueryRow("SELECT password FROM Users WHERE id = ?", userID).Scan(&hash)
+	return hash, err
+}
+
+// UpdatePassword replaces the password hash of a user
+func (s *AuthStore) UpdatePassword(userID int64, hash string) error {
+	_, err := s.DB.Exec("UPDATE Users SET password = ? WHERE id = ?", hash, userID)
+	return err
+}
+
 // UserExists checks if a user with the given email already exists
 func (s *AuthStore) UserExists(email string) (bool, error) {
 	var count int
@@ -121,6 +99,20 @@ func (s *AuthStore) UserExists(email string) (bool, error) {
 	return count > 0, nil
 }
 
+// GetNickname returns the nickname of a user, or "" when they have none
+func (s *AuthStore) GetNickname(userID int64) (string, error) {
+	var nickname sql.NullString
+	err := s.DB.QueryRow("SELECT nickname FROM Users WHERE id = ?", userID).Scan(&nickname)
+	return nickname.String, err
+}
+
+// GetEmail returns the email address of a user
+func (s *AuthStore) GetEmail(userID int64) (string, error) {
+	var email string
+	err := s.DB.QueryRow("SELECT email FROM Users WHERE id = ?", userID).Scan(&email)
+	return email, err
+}
+
 func (s *AuthStore) NewEditEmailExist(email string, userid int64) (bool, error) {
 	var count int
 	err := s.DB.QueryRow("SELECT COUNT(*) FROM Users WHERE email = ? and id != ?", email, userid).Scan(&count)
@@ -130,21 +122,34 @@ func (s *AuthStore) NewEditEmailExist(email string, userid int64) (bool, error)
 	return count > 0, nil
 }
 
-func GetUserIDFromSession(sessionID string, db *sql.DB) (int64, error) {
-	var userID int64
-	err := db.QueryRow("SELECT user_id FROM Sessions WHERE id = ?", sessionID).Scan(&userID)
+// EditProfile updates the user's profile and reports whether the email
+// changed. A new email is unverified until the user confirms it.
+func (s *AuthStore) EditProfile(user *models.User, userid int64) (bool, error) {
+	tx, err := s.DB.Begin()
 	if err != nil {
-		return 0, err
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var oldEmail string
+	if err := tx.QueryRow("SELECT email FROM Users WHERE id = ?", userid).Scan(&oldEmail); err != nil {
+		return false, err
 	}
-	return userID, nil
-}
 
-func (s *AuthStore) EditProfile(user *models.User, userid int64) error {
-	var err error
 	if *user.Avatar != "no profile photo" {
-		_, err= s.DB.Exec("UPDATE Users SET email = ?, first_name = ?, last_name = ?, date_of_birth = ?, nickname = ?, about_me = ?, is_profile_public = ?, avatar = ? WHERE id = ?", user.Email, user.FirstName, user.LastName, user.DateOfBirth, user.Nickname, user.AboutMe, user.IsProfilePublic, user.Avatar, userid)
+		_, err= tx.Exec("UPDATE Users SET email = ?, first_name = ?, last_name = ?, date_of_birth = ?, nickname = ?, about_me = ?, is_profile_public = ?, avatar = ? WHERE id = ?", user.Email, user.FirstName, user.LastName, user.DateOfBirth, user.Nickname, user.AboutMe, user.IsProfilePublic, user.Avatar, userid)
 	} else {
-		_, err= s.DB.Exec("UPDATE Users SET email = ?, first_name = ?, last_name = ?, date_of_birth = ?, nickname = ?, about_me = ?, is_profile_public = ? WHERE id = ?", user.Email, user.FirstName, user.LastName, user.DateOfBirth, user.Nickname, user.AboutMe, user.IsProfilePublic, userid)
+		_, err= tx.Exec("UPDATE Users SET email = ?, first_name = ?, last_name = ?, date_of_birth = ?, nickname = ?, about_me = ?, is_profile_public = ? WHERE id = ?", user.Email, user.FirstName, user.LastName, user.DateOfBirth, user.Nickname, user.AboutMe, user.IsProfilePublic, userid)
 	}
-	return err
+	if err != nil {
+		return false, err
+	}
+
+	emailChanged := oldEmail != user.Email
+	if emailChanged {
+		if _, err := tx.Exec("UPDATE Users SET email_verified_at = NULL WHERE id = ?", userid); err != nil {
+			return false, err
+		}
+	}
+	return emailChanged, tx.Commit()
 }
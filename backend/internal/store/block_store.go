@@ -0,0 +1,151 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// notBlockedClause returns a condition that holds unless the user in
+// userColumn and the user bound to its placeholders have blocked each other,
+// in either direction. Bind the viewer's id to both placeholders.
+func notBlockedClause(userColumn string) string {
+	return `NOT EXISTS (
+            SELECT 1 FROM Blocks b
+            WHERE (b.blocker_id = ? AND b.blocked_id = ` + userColumn + `)
+               OR (b.blocker_id = ` + userColumn + ` AND b.blocked_id = ?)
+        )`
+}
+
+// isBlocked reports whether either user has blocked the other.
+func isBlocked(db *sql.DB, userID, otherID int64) (bool, error) {
+	var blocked bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM Blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))",
+		userID, otherID, otherID, userID,
+	).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("error checking block: %w", err)
+	}
+	return blocked, nil
+}
+
+type blockStore struct {
+	db *sql.DB
+}
+
+func NewBlockStore(db *sql.DB) BlockStore {
+	return &blockStore{db: db}
+}
+
+// BlockUser records the block and removes any follow or follow request
+// between the two users, in both directions. Blocking a user twice keeps the
+// first block. It returns sql.ErrNoRows when blockedID is not a user.
+func (s *blockStore) BlockUser(blockerID, blockedID int64, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM Users WHERE id = ?)", blockedID).Scan(&exists); err != nil {
+		return fmt.Errorf("error looking up user: %w", err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(
+		"INSERT OR IGNORE INTO Blocks (blocker_id, blocked_id, created_at) VALUES (?, ?, ?)",
+		blockerID, blockedID, now.UTC(),
+	); err != nil {
+		return fmt.Errorf("error blocking user: %w", err)
+	}
+	if _, err := tx.Exec(
+		"DELETE FROM Followers WHERE (follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+		blockerID, blockedID, blockedID, blockerID,
+	); err != nil {
+		return fmt.Errorf("error removing follows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing block: %w", err)
+	}
+	return nil
+}
+
+// UnblockUser lifts a block and reports whether there was one. Follows removed
+// by the block are not restored.
+func (s *blockStore) UnblockUser(blockerID, blockedID int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM Blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("error unblocking user: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unblocking user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListBlockedUsers returns the users blockerID has blocked, most recent first.
+func (s *blockStore) ListBlockedUsers(blockerID int64) ([]*models.BlockedUser, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.first_name, u.last_name, u.nickname, u.avatar, b.created_at
+		FROM Blocks b
+		JOIN Users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = ?
+		ORDER BY b.created_at DESC, u.id DESC`,
+		blockerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing blocked users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.BlockedUser{}
+	for rows.Next() {
+		var user models.BlockedUser
+		var firstName, lastName, nickname, avatar sql.NullString
+		if err := rows.Scan(&user.ID, &firstName, &lastName, &nickname, &avatar, &user.BlockedAt); err != nil {
+			return nil, fmt.Errorf("error scanning blocked user: %w", err)
+		}
+		user.FirstName = firstName.String
+		user.LastName = lastName.String
+		user.Nickname = nickname.String
+		user.Avatar = avatar.String
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+func (s *blockStore) IsBlocked(userID, otherID int64) (bool, error) {
+	return isBlocked(s.db, userID, otherID)
+}
+
+// ListBlockRelations returns the users userID has blocked or been blocked by.
+func (s *blockStore) ListBlockRelations(userID int64) ([]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT blocked_id FROM Blocks WHERE blocker_id = ?
+		UNION
+		SELECT blocker_id FROM Blocks WHERE blocked_id = ?`,
+		userID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning block: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
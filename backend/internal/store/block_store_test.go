@@ -0,0 +1,73 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBlockStore(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob'), (3, 'c@example.com', 'x', 'cy');
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES
+			(1, 2, 'accepted'), (2, 1, 'pending'), (1, 3, 'accepted');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewBlockStore(db)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := s.BlockUser(1, 2, now); err != nil {
+		t.Fatal(err)
+	}
+	// blocking again keeps the first block
+	if err := s.BlockUser(1, 2, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlockUser(1, 99, now); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("blocking an unknown user: got %v", err)
+	}
+
+	var follows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Followers").Scan(&follows); err != nil || follows != 1 {
+		t.Errorf("follows between 1 and 2 should be gone both ways: %d left, %v", follows, err)
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 1}} {
+		if blocked, err := s.IsBlocked(pair[0], pair[1]); err != nil || !blocked {
+			t.Errorf("IsBlocked(%d, %d) = %v, %v; want true", pair[0], pair[1], blocked, err)
+		}
+	}
+	if blocked, err := s.IsBlocked(1, 3); err != nil || blocked {
+		t.Errorf("IsBlocked(1, 3) = %v, %v; want false", blocked, err)
+	}
+
+	users, err := s.ListBlockedUsers(1)
+	if err != nil || len(users) != 1 || users[0].ID != 2 || users[0].Nickname != "bob" || !users[0].BlockedAt.Equal(now) {
+		t.Fatalf("ListBlockedUsers(1) = %+v, %v", users, err)
+	}
+	if users, err := s.ListBlockedUsers(2); err != nil || len(users) != 0 {
+		t.Errorf("the blocked user has blocked nobody: got %+v, %v", users, err)
+	}
+	for _, id := range []int64{1, 2} {
+		if ids, err := s.ListBlockRelations(id); err != nil || len(ids) != 1 || ids[0] != 3-id {
+			t.Errorf("ListBlockRelations(%d) = %v, %v; want [%d]", id, ids, err, 3-id)
+		}
+	}
+	if ids, err := s.ListBlockRelations(3); err != nil || len(ids) != 0 {
+		t.Errorf("ListBlockRelations(3) = %v, %v; want none", ids, err)
+	}
+
+	if removed, err := s.UnblockUser(2, 1); err != nil || removed {
+		t.Errorf("only the blocker can lift a block: got %v, %v", removed, err)
+	}
+	if removed, err := s.UnblockUser(1, 2); err != nil || !removed {
+		t.Errorf("UnblockUser(1, 2) = %v, %v; want true", removed, err)
+	}
+	if blocked, err := s.IsBlocked(2, 1); err != nil || blocked {
+		t.Errorf("IsBlocked after unblocking = %v, %v", blocked, err)
+	}
+}
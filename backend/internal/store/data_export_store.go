@@ -0,0 +1,246 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type dataExportStore struct {
+	db *sql.DB
+}
+
+func NewDataExportStore(db *sql.DB) DataExportStore {
+	return &dataExportStore{db: db}
+}
+
+const dataExportColumns = "id, user_id, status, file_name, error, created_at, completed_at, expires_at"
+
+func (s *dataExportStore) CreateExport(userID int64, now time.Time) (*models.DataExport, error) {
+	row := s.db.QueryRow("INSERT INTO Data_Exports (user_id, status, created_at) VALUES (?, ?, ?) RETURNING "+dataExportColumns,
+		userID, models.DataExportPending, now.UTC())
+	export, err := scanDataExport(row)
+	if err != nil {
+		return nil, fmt.Errorf("error creating data export: %w", err)
+	}
+	return export, nil
+}
+
+// GetActiveExport returns the user's pending or running export, or sql.ErrNoRows.
+func (s *dataExportStore) GetActiveExport(userID int64) (*models.DataExport, error) {
+	return scanDataExport(s.db.QueryRow(
+		"SELECT "+dataExportColumns+" FROM Data_Exports WHERE user_id = ? AND status IN (?, ?) ORDER BY id LIMIT 1",
+		userID, models.DataExportPending, models.DataExportRunning,
+	))
+}
+
+func (s *dataExportStore) GetExport(userID, exportID int64) (*models.DataExport, error) {
+	return scanDataExport(s.db.QueryRow("SELECT "+dataExportColumns+" FROM Data_Exports WHERE id = ? AND user_id = ?", exportID, userID))
+}
+
+// ClaimPendingExport marks the oldest pending export as running and returns
+// it, or sql.ErrNoRows when nothing is waiting. It only writes when there is
+// an export to claim, so polling an empty queue never holds the write lock.
+func (s *dataExportStore) ClaimPendingExport() (*models.DataExport, error) {
+	var exportID int64
+	err := s.db.QueryRow("SELECT id FROM Data_Exports WHERE status = ? ORDER BY id LIMIT 1", models.DataExportPending).Scan(&exportID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDataExport(s.db.QueryRow("UPDATE Data_Exports SET status = ? WHERE id = ? AND status = ? RETURNING "+dataExportColumns,
+		models.DataExportRunning, exportID, models.DataExportPending))
+}
+
+// RequeueRunningExports puts exports left running by a stopped server back in the queue.
+func (s *dataExportStore) RequeueRunningExports() error {
+	_, err := s.db.Exec("UPDATE Data_Exports SET status = ? WHERE status = ?", models.DataExportPending, models.DataExportRunning)
+	if err != nil {
+		return fmt.Errorf("error requeueing data exports: %w", err)
+	}
+	return nil
+}
+
+func (s *dataExportStore) MarkExportReady(exportID int64, fileName string, completedAt, expiresAt time.Time) error {
+	return expectOneRow(s.db.Exec("UPDATE Data_Exports SET status = ?, file_name = ?, completed_at = ?, expires_at = ? WHERE id = ?",
+		models.DataExportReady, fileName, completedAt.UTC(), expiresAt.UTC(), exportID))
+}
+
+func (s *dataExportStore) MarkExportFailed(exportID int64, message string, completedAt time.Time) error {
+	return expectOneRow(s.db.Exec("UPDATE Data_Exports SET status = ?, error = ?, completed_at = ? WHERE id = ?",
+		models.DataExportFailed, message, completedAt.UTC(), exportID))
+}
+
+// ListExpiredExports returns ready exports whose download link has expired.
+func (s *dataExportStore) ListExpiredExports(now time.Time) ([]*models.DataExport, error) {
+	rows, err := s.db.Query("SELECT "+dataExportColumns+" FROM Data_Exports WHERE status = ? AND expires_at <= ?", models.DataExportReady, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error listing expired data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*models.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning data export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+// MarkExportExpired records that an export's archive was deleted.
+func (s *dataExportStore) MarkExportExpired(exportID int64) error {
+	_, err := s.db.Exec("UPDATE Data_Exports SET status = ?, file_name = NULL WHERE id = ?", models.DataExportExpired, exportID)
+	return err
+}
+
+// ListExportFiles returns the archive names that exports still point at.
+func (s *dataExportStore) ListExportFiles() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT file_name FROM Data_Exports WHERE file_name IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("error listing data export files: %w", err)
+	}
+	defer rows.Close()
+
+	files := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("error scanning data export file: %w", err)
+		}
+		files[name] = true
+	}
+	return files, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDataExport(row rowScanner) (*models.DataExport, error) {
+	var export models.DataExport
+	var fileName, message sql.NullString
+	var completedAt, expiresAt sql.NullTime
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &fileName, &message,
+		&export.CreatedAt, &completedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	export.FileName = fileName.String
+	export.Error = message.String
+	export.CompletedAt = nullTimePtr(completedAt)
+	export.ExpiresAt = nullTimePtr(expiresAt)
+	return &export, nil
+}
+
+// exportSections select what goes into a user's data export, one JSON file
+// each. Each takes the user id once per placeholder. Credentials, sessions
+// and security records are left out.
+var exportSections = []struct {
+	name  string
+	query string
+}{
+	{"profile", `SELECT id, email, first_name, last_name, date_of_birth, nickname, about_me, avatar,
+		is_profile_public, role, created_at, email_verified_at FROM Users WHERE id = ?`},
+	{"posts", "SELECT id, content, image, privacy, created_at, updated_at FROM Posts WHERE user_id = ? ORDER BY id"},
+	{"post_audiences", "SELECT post_id, viewer_id FROM Post_Visibility WHERE post_id IN (" + userPosts + ") ORDER BY post_id, viewer_id"},
+	{"comments", "SELECT id, post_id, content, image, created_at, updated_at FROM Comments WHERE user_id = ? ORDER BY id"},
+	{"attachments", `SELECT id, post_id, comment_id, path, position, alt_text, width, height, created_at
+		FROM Post_Attachments WHERE user_id = ? ORDER BY id`},
+	{"reactions", `
+		SELECT 'post' AS target, post_id AS target_id, reaction_type, created_at FROM Post_Reactions WHERE user_id = ?
+		UNION ALL SELECT 'comment', comment_id, reaction_type, created_at FROM Comment_Reactions WHERE user_id = ?
+		UNION ALL SELECT 'group_post', group_post_id, reaction_type, created_at FROM Group_Post_Reactions WHERE user_id = ?
+		UNION ALL SELECT 'group_comment', comment_id, reaction_type, created_at FROM Group_Comment_Reactions WHERE user_id = ?
+		ORDER BY created_at`},
+	{"private_messages", `SELECT id, sender_id, receiver_id, content, is_emoji, created_at FROM Messages
+		WHERE group_id IS NULL AND (sender_id = ? OR receiver_id = ?) ORDER BY id`},
+	{"group_messages", "SELECT id, group_id, content, is_emoji, created_at FROM Messages WHERE group_id IS NOT NULL AND sender_id = ? ORDER BY id"},
+	{"notifications", "SELECT id, type, message, is_read, created_at FROM Notifications WHERE user_id = ? ORDER BY id"},
+	{"follows", "SELECT follower_id, followee_id, status, requested_at, accepted_at FROM Followers WHERE follower_id = ? OR followee_id = ? ORDER BY id"},
+	{"groups_created", "SELECT id, title, description, created_at FROM Groups WHERE creator_id = ? ORDER BY id"},
+	{"group_memberships", `SELECT m.group_id, g.title, m.role, m.is_accepted, m.invited_by, m.created_at
+		FROM Group_Members m JOIN Groups g ON g.id = m.group_id WHERE m.user_id = ? ORDER BY m.created_at`},
+	{"group_requests", "SELECT group_id, created_at FROM Group_Requests WHERE user_id = ? ORDER BY id"},
+	{"group_posts", "SELECT id, group_id, content, image, created_at FROM Group_Posts WHERE user_id = ? ORDER BY id"},
+	{"group_comments", "SELECT id, group_post_id, parent_comment_id, content, created_at, updated_at FROM Group_Post_Comments WHERE user_id = ? ORDER BY id"},
+	{"event_responses", "SELECT event_id, response, responded_at FROM Event_Responses WHERE user_id = ? ORDER BY responded_at"},
+	{"blocks", "SELECT blocked_id, created_at FROM Blocks WHERE blocker_id = ? ORDER BY created_at"},
+	{"muted_users", "SELECT muted_id, created_at, expires_at FROM Muted_Users WHERE user_id = ? ORDER BY created_at"},
+	{"muted_keywords", "SELECT keyword, created_at FROM Muted_Keywords WHERE user_id = ? ORDER BY id"},
+}
+
+// exportFiles select the attachment paths of the files a user uploaded.
+var exportFiles = []string{
+	"SELECT avatar FROM Users WHERE id = ?",
+	"SELECT image FROM Posts WHERE user_id = ?",
+	"SELECT image FROM Comments WHERE user_id = ?",
+	"SELECT image FROM Group_Posts WHERE user_id = ?",
+	"SELECT path FROM Post_Attachments WHERE user_id = ?",
+}
+
+// CollectUserData reads everything exported for a user in one snapshot and
+// returns it by section, along with the attachment paths of their uploads.
+func (s *dataExportStore) CollectUserData(userID int64) ([]models.DataExportSection, []string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sections := make([]models.DataExportSection, 0, len(exportSections))
+	for _, section := range exportSections {
+		rows, err := queryRecords(tx, section.query, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error exporting %s: %w", section.name, err)
+		}
+		sections = append(sections, models.DataExportSection{Name: section.name, Rows: rows})
+	}
+
+	var files []string
+	for _, query := range exportFiles {
+		paths, err := queryPaths(tx, query, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error listing attachments: %w", err)
+		}
+		files = append(files, paths...)
+	}
+	return sections, files, nil
+}
+
+// queryRecords returns each row as a map from column name to value.
+func queryRecords(tx *sql.Tx, query string, userID int64) ([]map[string]any, error) {
+	rows, err := tx.Query(query, repeatArg(query, userID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	records := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		record := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			record[column] = values[i]
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func TestDataExportLifecycle(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	s := NewDataExportStore(db)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if _, err := s.GetActiveExport(1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("no export yet: got %v", err)
+	}
+	first, err := s.CreateExport(1, now)
+	if err != nil || first.Status != models.DataExportPending {
+		t.Fatalf("create: got %+v, %v", first, err)
+	}
+	second, _ := s.CreateExport(2, now)
+	if active, err := s.GetActiveExport(1); err != nil || active.ID != first.ID {
+		t.Errorf("active export = %+v, %v", active, err)
+	}
+	if _, err := s.GetExport(2, first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("someone else's export: got %v", err)
+	}
+
+	claimed, err := s.ClaimPendingExport()
+	if err != nil || claimed.ID != first.ID || claimed.UserID != 1 || claimed.Status != models.DataExportRunning {
+		t.Fatalf("claim: got %+v, %v", claimed, err)
+	}
+	if err := s.MarkExportReady(first.ID, "export-1.zip", now, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := s.ClaimPendingExport(); claimed.ID != second.ID {
+		t.Fatalf("second claim: got %+v", claimed)
+	}
+	if err := s.RequeueRunningExports(); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := s.ClaimPendingExport(); err != nil || claimed.ID != second.ID {
+		t.Fatalf("a requeued export should be claimed again: got %+v, %v", claimed, err)
+	}
+	if err := s.MarkExportFailed(second.ID, "boom", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ClaimPendingExport(); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("nothing left to claim: got %v", err)
+	}
+
+	ready, err := s.GetExport(1, first.ID)
+	if err != nil || ready.Status != models.DataExportReady || ready.FileName != "export-1.zip" || !ready.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("ready export = %+v, %v", ready, err)
+	}
+	if files, err := s.ListExportFiles(); err != nil || len(files) != 1 || !files["export-1.zip"] {
+		t.Errorf("ListExportFiles = %v, %v", files, err)
+	}
+	if expired, err := s.ListExpiredExports(now); err != nil || len(expired) != 0 {
+		t.Errorf("expired before expires_at: %+v, %v", expired, err)
+	}
+	expired, err := s.ListExpiredExports(now.Add(time.Hour))
+	if err != nil || len(expired) != 1 || expired[0].ID != first.ID {
+		t.Fatalf("ListExpiredExports = %+v, %v", expired, err)
+	}
+	if err := s.MarkExportExpired(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := s.ListExportFiles(); len(files) != 0 {
+		t.Errorf("an expired export still points at its archive: %v", files)
+	}
+}
+
+func TestCollectUserData(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname, avatar) VALUES
+			(1, 'a@example.com', 'secret-hash', 'ada', 'avatars/ada.png'), (2, 'b@example.com', 'x', 'bob', 'avatars/bob.png');
+		INSERT INTO Posts (id, user_id, content, image, privacy) VALUES (10, 1, 'mine', 'posts/mine.jpg', 'public'), (11, 2, 'theirs', 'posts/theirs.jpg', 'public');
+		INSERT INTO Comments (id, post_id, user_id, content, image) VALUES (20, 11, 1, 'nice', 'comments/nice.gif'), (21, 10, 2, 'thanks', 'comments/thanks.gif');
+		INSERT INTO Post_Reactions (user_id, post_id, reaction_type) VALUES (1, 11, 'like');
+		INSERT INTO Comment_Reactions (user_id, comment_id, reaction_type) VALUES (1, 21, 'dislike');
+		INSERT INTO Messages (sender_id, receiver_id, group_id, content) VALUES (1, 2, NULL, 'hi bob'), (2, 1, NULL, 'hi ada'), (2, NULL, 5, 'bob in a group'), (1, NULL, 5, 'ada in a group');
+		INSERT INTO Notifications (user_id, type, message) VALUES (1, 'follow', 'bob followed you'), (2, 'follow', 'ada followed you');
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (1, 2, 'accepted'), (2, 1, 'accepted');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sections, files, err := NewDataExportStore(db).CollectUserData(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	byName := map[string][]map[string]any{}
+	for _, section := range sections {
+		counts[section.Name] = len(section.Rows)
+		byName[section.Name] = section.Rows
+	}
+	for name, want := range map[string]int{
+		"profile": 1, "posts": 1, "comments": 1, "reactions": 2, "private_messages": 2,
+		"group_messages": 1, "notifications": 1, "follows": 2, "group_memberships": 0,
+	} {
+		if counts[name] != want {
+			t.Errorf("%s: got %d rows, want %d", name, counts[name], want)
+		}
+	}
+	profile := byName["profile"][0]
+	if profile["email"] != "a@example.com" || profile["nickname"] != "ada" {
+		t.Errorf("profile = %v", profile)
+	}
+	if _, ok := profile["password"]; ok {
+		t.Error("the password hash is exported")
+	}
+	if byName["group_messages"][0]["content"] != "ada in a group" {
+		t.Errorf("group messages = %v", byName["group_messages"])
+	}
+
+	sort.Strings(files)
+	want := []string{"avatars/ada.png", "comments/nice.gif", "posts/mine.jpg"}
+	if len(files) != len(want) {
+		t.Fatalf("files = %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("files = %v, want %v", files, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type emailVerificationStore struct {
+	db *sql.DB
+}
+
+func NewEmailVerificationStore(db *sql.DB) EmailVerificationStore {
+	return &emailVerificationStore{db: db}
+}
+
+// CreateVerificationToken stores a token hash confirming email for the user.
+// Earlier unused tokens are discarded so only the most recent email works.
+func (s *emailVerificationStore) CreateVerificationToken(userID int64, email, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM Email_Verification_Tokens WHERE user_id = ? AND used_at IS NULL", userID); err != nil {
+		return fmt.Errorf("error discarding old verification tokens: %w", err)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO Email_Verification_Tokens (user_id, email, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		userID, email, tokenHash, time.Now().UTC(), expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating verification token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// VerifyEmail consumes the token and marks the address it was sent to as
+// verified, returning the user it belonged to. It returns sql.ErrNoRows when
+// the token is unknown, used, expired at now, or the user has since changed
+// their email.
+func (s *emailVerificationStore) VerifyEmail(tokenHash string, now time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	var email string
+	err = tx.QueryRow(
+		"SELECT user_id, email FROM Email_Verification_Tokens WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		tokenHash, now.UTC(),
+	).Scan(&userID, &email)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("UPDATE Users SET email_verified_at = ? WHERE id = ? AND email = ?", now.UTC(), userID, email)
+	if err != nil {
+		return 0, fmt.Errorf("error verifying email: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("error verifying email: %w", err)
+	} else if n == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("UPDATE Email_Verification_Tokens SET used_at = ? WHERE token_hash = ?", now.UTC(), tokenHash); err != nil {
+		return 0, fmt.Errorf("error consuming verification token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing email verification: %w", err)
+	}
+	return userID, nil
+}
+
+// MarkEmailVerified records email as verified without a token, for addresses
+// another party has already confirmed. It returns sql.ErrNoRows when the user's
+// email has changed since.
+func (s *emailVerificationStore) MarkEmailVerified(userID int64, email string, now time.Time) error {
+	result, err := s.db.Exec("UPDATE Users SET email_verified_at = ? WHERE id = ? AND email = ?", now.UTC(), userID, email)
+	if err != nil {
+		return fmt.Errorf("error verifying email: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error verifying email: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetEmailStatus returns the user's current email and whether it is verified.
+func (s *emailVerificationStore) GetEmailStatus(userID int64) (string, bool, error) {
+	var email string
+	var verifiedAt sql.NullTime
+	err := s.db.QueryRow("SELECT email, email_verified_at FROM Users WHERE id = ?", userID).Scan(&email, &verifiedAt)
+	if err != nil {
+		return "", false, err
+	}
+	return email, verifiedAt.Valid, nil
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupEmailVerificationTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "verification.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE Users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT UNIQUE NOT NULL,
+		email_verified_at DATETIME
+	);
+	CREATE TABLE Email_Verification_Tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		email TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME
+	);
+	INSERT INTO Users (id, email) VALUES (1, 'ada@example.com');`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestEmailVerificationStore_VerifyEmail(t *testing.T) {
+	db := setupEmailVerificationTestDB(t)
+	verifications := NewEmailVerificationStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, verified, _ := verifications.GetEmailStatus(1); verified {
+		t.Fatal("new user should be unverified")
+	}
+	if err := verifications.CreateVerificationToken(1, "ada@example.com", "hash", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifications.VerifyEmail("hash", now.Add(time.Hour)); err != sql.ErrNoRows {
+		t.Errorf("expired token: got %v, want sql.ErrNoRows", err)
+	}
+	if userID, err := verifications.VerifyEmail("hash", now); err != nil || userID != 1 {
+		t.Fatalf("VerifyEmail() = %d, %v", userID, err)
+	}
+	if email, verified, err := verifications.GetEmailStatus(1); err != nil || !verified || email != "ada@example.com" {
+		t.Errorf("GetEmailStatus() = %q, %v, %v", email, verified, err)
+	}
+	if _, err := verifications.VerifyEmail("hash", now); err != sql.ErrNoRows {
+		t.Errorf("used token: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestEmailVerificationStore_TokenForOldEmail(t *testing.T) {
+	db := setupEmailVerificationTestDB(t)
+	verifications := NewEmailVerificationStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	_ = verifications.CreateVerificationToken(1, "ada@example.com", "hash", now.Add(time.Hour))
+	if _, err := db.Exec("UPDATE Users SET email = 'lovelace@example.com' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifications.VerifyEmail("hash", now); err != sql.ErrNoRows {
+		t.Errorf("token for a replaced email: got %v, want sql.ErrNoRows", err)
+	}
+	if _, verified, _ := verifications.GetEmailStatus(1); verified {
+		t.Error("the new email must stay unverified")
+	}
+}
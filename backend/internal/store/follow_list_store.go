@@ -0,0 +1,79 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// IsProfilePublic reports whether anyone may see the user's profile.
+// It returns sql.ErrNoRows for an unknown user.
+func (ps *ProfileStore) IsProfilePublic(userid int64) (bool, error) {
+	var public bool
+	err := ps.DB.QueryRow("SELECT COALESCE(is_profile_public, 0) FROM Users WHERE id = ?", userid).Scan(&public)
+	return public, err
+}
+
+// followersQuery and followeesQuery select a page of a user's accepted
+// followers or followees. Each row carries the follow's id, which orders the
+// list and serves as its cursor, and the entry's relationship to the viewer.
+var (
+	followersQuery = followListQuery("f.follower_id", "f.followee_id")
+	followeesQuery = followListQuery("f.followee_id", "f.follower_id")
+)
+
+func followListQuery(entryColumn, userColumn string) string {
+	return `
+		SELECT f.id, u.id, u.first_name, u.last_name, u.nickname, u.avatar,
+			EXISTS (SELECT 1 FROM Followers v WHERE v.follower_id = ? AND v.followee_id = u.id AND v.status = 'accepted'),
+			EXISTS (SELECT 1 FROM Followers v WHERE v.follower_id = ? AND v.followee_id = u.id AND v.status = 'pending'),
+			EXISTS (SELECT 1 FROM Followers v WHERE v.follower_id = u.id AND v.followee_id = ? AND v.status = 'accepted')
+		FROM Followers f
+		JOIN Users u ON u.id = ` + entryColumn + `
+		WHERE ` + userColumn + ` = ? AND f.status = 'accepted' AND (? = 0 OR f.id < ?)
+		ORDER BY f.id DESC
+		LIMIT ?`
+}
+
+// ListFollowConnections returns up to limit followers (or followees) of
+// userid, newest first, starting after the follow with id afterID (0 for the
+// first page). It also returns the afterID of the next page, or 0 when there
+// is none.
+func (ps *ProfileStore) ListFollowConnections(userid, viewerID int64, followers bool, afterID int64, limit int) ([]models.FollowUser, int64, error) {
+	query := followeesQuery
+	if followers {
+		query = followersQuery
+	}
+	rows, err := ps.DB.Query(query, viewerID, viewerID, viewerID, userid, afterID, afterID, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.FollowUser{}
+	var followIDs []int64
+	for rows.Next() {
+		var user models.FollowUser
+		var followID int64
+		var firstName, lastName, nickname, avatar sql.NullString
+		err := rows.Scan(&followID, &user.FollowerID, &firstName, &lastName, &nickname, &avatar,
+			&user.Following, &user.Pending, &user.FollowsYou)
+		if err != nil {
+			return nil, 0, err
+		}
+		user.FirstName = firstName.String
+		user.LastName = lastName.String
+		user.Nickname = nickname.String
+		user.Avatar = avatar.String
+		users = append(users, user)
+		followIDs = append(followIDs, followID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(users) <= limit {
+		return users, 0, nil
+	}
+	return users[:limit], followIDs[limit-1], nil
+}
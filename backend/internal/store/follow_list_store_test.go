@@ -0,0 +1,56 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestListFollowConnections(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname, is_profile_public) VALUES
+			(1, 'a@example.com', 'x', 'ada', 0), (2, 'b@example.com', 'x', 'bob', 1),
+			(3, 'c@example.com', 'x', 'cy', 1), (4, 'd@example.com', 'x', 'dee', 1), (5, 'e@example.com', 'x', 'eve', 1);
+		INSERT INTO Followers (id, follower_id, followee_id, status) VALUES
+			(1, 2, 1, 'accepted'), (2, 3, 1, 'accepted'), (3, 4, 1, 'accepted'), (4, 5, 1, 'pending'),
+			(5, 5, 2, 'accepted'), (6, 5, 3, 'pending'), (7, 4, 5, 'accepted');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewProfileStore(db)
+
+	if public, err := s.IsProfilePublic(1); err != nil || public {
+		t.Errorf("IsProfilePublic(1) = %v, %v; want false", public, err)
+	}
+
+	page, next, err := s.ListFollowConnections(1, 5, true, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].FollowerID != 4 || page[1].FollowerID != 3 || next != 2 {
+		t.Fatalf("first page: got %+v, next %d", page, next)
+	}
+	if !page[0].FollowsYou || page[0].Following || page[0].Pending {
+		t.Errorf("dee follows the viewer only: got %+v", page[0])
+	}
+	if !page[1].Pending || page[1].Following {
+		t.Errorf("the viewer's request to cy is pending: got %+v", page[1])
+	}
+
+	page, next, err = s.ListFollowConnections(1, 5, true, next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].FollowerID != 2 || next != 0 {
+		t.Fatalf("last page: got %+v, next %d", page, next)
+	}
+	if !page[0].Following {
+		t.Errorf("the viewer follows bob: got %+v", page[0])
+	}
+
+	// pending requests are not followees yet
+	page, _, err = s.ListFollowConnections(5, 5, false, 0, 10)
+	if err != nil || len(page) != 1 || page[0].FollowerID != 2 {
+		t.Errorf("followees of eve: got %+v, %v", page, err)
+	}
+}
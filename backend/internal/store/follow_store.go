@@ -25,6 +25,11 @@ func (followstore *FollowStore) IsUserAccountPublic(userid int64) (bool, error)
 	return num == 1, nil
 }
 
+// IsBlocked reports whether either user has blocked the other.
+func (followstore *FollowStore) IsBlocked(userID, otherID int64) (bool, error) {
+	return isBlocked(followstore.DB, userID, otherID)
+}
+
 func (followstore *FollowStore) CreatePublicFollowConnection(followerId, followeeId int64) (int64, error) {
 	currentTime := time.Now()
 
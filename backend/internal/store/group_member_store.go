@@ -24,6 +24,19 @@ func (s *groupMemberStore) IsGroupMember(groupID, userID int64) (bool, error) {
 	return exists, nil
 }
 
+// GetGroupMemberRole returns the role of userID in groupID, or an empty string if they are not a member.
+func (s *groupMemberStore) GetGroupMemberRole(groupID, userID int64) (string, error) {
+	var role sql.NullString
+	err := s.db.QueryRow("SELECT role FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error getting group member role: %w", err)
+	}
+	return role.String, nil
+}
+
 func (s *groupMemberStore) AddGroupMember(groupID, userID int64, role string) (*models.GroupMember, error) {
 	stmt, err := s.db.Prepare("INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)")
 	if err != nil {
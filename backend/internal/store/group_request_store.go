@@ -2,11 +2,15 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
 )
 
+// ErrGroupRequestNotPending is returned when a request was already approved or rejected.
+var ErrGroupRequestNotPending = errors.New("group request is not pending")
+
 type groupRequestStore struct {
 	db *sql.DB
 }
@@ -67,3 +71,75 @@ func (s *groupRequestStore) UpdateGroupRequestStatus(requestID int64, status str
 	}
 	return nil
 }
+
+func (s *groupRequestStore) HasPendingGroupRequest(groupID, userID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM group_requests WHERE group_id = ? AND user_id = ? AND status = 'pending')", groupID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking pending group request: %w", err)
+	}
+	return exists, nil
+}
+
+// ApproveGroupRequest marks a pending request as approved, adds the requester to the group
+// with the given role and records a notification for them, all in one transaction. An
+// empty notification is not recorded.
+func (s *groupRequestStore) ApproveGroupRequest(request *models.GroupRequest, role, notification string) error {
+	return s.resolveGroupRequest(request, "approved", "group_request_approved", notification, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO group_members (group_id, user_id, role, is_accepted, requested)
+			VALUES (?, ?, ?, 1, 1)
+			ON CONFLICT(group_id, user_id) DO UPDATE SET role = excluded.role, is_accepted = 1`,
+			request.GroupID, request.UserID, role)
+		if err != nil {
+			return fmt.Errorf("error adding group member: %w", err)
+		}
+		return nil
+	})
+}
+
+// RejectGroupRequest marks a pending request as rejected and records a notification for the
+// requester, unless notification is empty.
+func (s *groupRequestStore) RejectGroupRequest(request *models.GroupRequest, notification string) error {
+	return s.resolveGroupRequest(request, "rejected", "group_request_rejected", notification, nil)
+}
+
+// resolveGroupRequest moves a pending request to status, runs apply (if any) and stores the
+// requester's notification (if any) in one transaction. Nothing is written unless every step succeeds.
+func (s *groupRequestStore) resolveGroupRequest(request *models.GroupRequest, status, notificationType, notification string, apply func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE group_requests SET status = ? WHERE id = ? AND status = 'pending'", status, request.ID)
+	if err != nil {
+		return fmt.Errorf("error updating group request status: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error reading updated rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrGroupRequestNotPending
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return err
+		}
+	}
+
+	if notification != "" {
+		if _, err := tx.Exec("INSERT INTO Notifications (user_id, type, message) VALUES (?, ?, ?)", request.UserID, notificationType, notification); err != nil {
+			return fmt.Errorf("error adding notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing group request: %w", err)
+	}
+	request.Status = status
+	return nil
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func setupGroupRequestTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := `
+	CREATE TABLE group_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		status TEXT NOT NULL CHECK (status IN ('pending', 'approved', 'rejected')),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE UNIQUE INDEX idx_group_requests_pending ON group_requests(group_id, user_id) WHERE status = 'pending';
+	CREATE TABLE group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT,
+		is_accepted BOOLEAN DEFAULT 0,
+		invited_by INTEGER,
+		requested BOOLEAN DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id)
+	);
+	CREATE TABLE Notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT,
+		message TEXT,
+		is_read BOOLEAN DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApproveGroupRequest(t *testing.T) {
+	db := setupGroupRequestTestDB(t)
+	defer db.Close()
+
+	requests := NewGroupRequestStore(db)
+	members := NewGroupMemberStore(db)
+
+	request, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"})
+	if err != nil {
+		t.Fatalf("CreateGroupRequest failed: %v", err)
+	}
+
+	if err := requests.ApproveGroupRequest(request, "member", "approved"); err != nil {
+		t.Fatalf("ApproveGroupRequest failed: %v", err)
+	}
+
+	isMember, err := members.IsGroupMember(1, 7)
+	if err != nil || !isMember {
+		t.Fatalf("expected user to be a group member, got %v, %v", isMember, err)
+	}
+	role, err := members.GetGroupMemberRole(1, 7)
+	if err != nil || role != "member" {
+		t.Errorf("expected role 'member', got %q, %v", role, err)
+	}
+
+	stored, err := requests.GetGroupRequestByID(request.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != "approved" {
+		t.Errorf("expected status 'approved', got %q", stored.Status)
+	}
+
+	var notificationType string
+	if err := db.QueryRow("SELECT type FROM Notifications WHERE user_id = ?", 7).Scan(&notificationType); err != nil {
+		t.Fatalf("expected a notification for the requester: %v", err)
+	}
+	if notificationType != "group_request_approved" {
+		t.Errorf("expected notification type 'group_request_approved', got %q", notificationType)
+	}
+
+	// A request can only be resolved once
+	if err := requests.ApproveGroupRequest(request, "member", "approved"); !errors.Is(err, ErrGroupRequestNotPending) {
+		t.Errorf("expected ErrGroupRequestNotPending, got %v", err)
+	}
+}
+
+func TestRejectGroupRequest(t *testing.T) {
+	db := setupGroupRequestTestDB(t)
+	defer db.Close()
+
+	requests := NewGroupRequestStore(db)
+	members := NewGroupMemberStore(db)
+
+	request, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"})
+	if err != nil {
+		t.Fatalf("CreateGroupRequest failed: %v", err)
+	}
+
+	if err := requests.RejectGroupRequest(request, "rejected"); err != nil {
+		t.Fatalf("RejectGroupRequest failed: %v", err)
+	}
+
+	isMember, err := members.IsGroupMember(1, 7)
+	if err != nil || isMember {
+		t.Errorf("expected rejected user not to be a member, got %v, %v", isMember, err)
+	}
+
+	pending, err := requests.HasPendingGroupRequest(1, 7)
+	if err != nil || pending {
+		t.Errorf("expected no pending request, got %v, %v", pending, err)
+	}
+
+	if err := requests.ApproveGroupRequest(request, "member", "approved"); !errors.Is(err, ErrGroupRequestNotPending) {
+		t.Errorf("expected ErrGroupRequestNotPending, got %v", err)
+	}
+}
+
+func TestResolveGroupRequest_WithoutNotification(t *testing.T) {
+	db := setupGroupRequestTestDB(t)
+	defer db.Close()
+
+	requests := NewGroupRequestStore(db)
+	request, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"})
+	if err != nil {
+		t.Fatalf("CreateGroupRequest failed: %v", err)
+	}
+
+	if err := requests.ApproveGroupRequest(request, "member", ""); err != nil {
+		t.Fatalf("ApproveGroupRequest failed: %v", err)
+	}
+	var notifications int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Notifications WHERE user_id = ?", 7).Scan(&notifications); err != nil || notifications != 0 {
+		t.Errorf("expected no notification, got %d, %v", notifications, err)
+	}
+}
+
+func TestApproveGroupRequest_RollsBackOnFailure(t *testing.T) {
+	db := setupGroupRequestTestDB(t)
+	defer db.Close()
+
+	requests := NewGroupRequestStore(db)
+
+	request, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"})
+	if err != nil {
+		t.Fatalf("CreateGroupRequest failed: %v", err)
+	}
+
+	// Make the final step of the transaction fail
+	if _, err := db.Exec("DROP TABLE Notifications"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := requests.ApproveGroupRequest(request, "member", "approved"); err == nil {
+		t.Fatal("expected ApproveGroupRequest to fail")
+	}
+
+	pending, err := requests.HasPendingGroupRequest(1, 7)
+	if err != nil || !pending {
+		t.Errorf("expected request to stay pending, got %v, %v", pending, err)
+	}
+	isMember, err := NewGroupMemberStore(db).IsGroupMember(1, 7)
+	if err != nil || isMember {
+		t.Errorf("expected no membership after rollback, got %v, %v", isMember, err)
+	}
+}
+
+func TestCreateGroupRequest_DuplicatePending(t *testing.T) {
+	db := setupGroupRequestTestDB(t)
+	defer db.Close()
+
+	requests := NewGroupRequestStore(db)
+
+	if _, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"}); err != nil {
+		t.Fatalf("CreateGroupRequest failed: %v", err)
+	}
+	if _, err := requests.CreateGroupRequest(&models.GroupRequest{GroupID: 1, UserID: 7, Status: "pending"}); err == nil {
+		t.Error("expected a second pending request for the same group to fail")
+	}
+}
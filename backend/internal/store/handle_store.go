@@ -0,0 +1,74 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type handleStore struct {
+	db *sql.DB
+}
+
+func NewHandleStore(db *sql.DB) HandleStore {
+	return &handleStore{db: db}
+}
+
+// GetHandle returns the user's current handle, or "" when they have none.
+func (s *handleStore) GetHandle(userID int64) (string, error) {
+	var handle sql.NullString
+	if err := s.db.QueryRow("SELECT nickname FROM Users WHERE id = ?", userID).Scan(&handle); err != nil {
+		return "", err
+	}
+	return handle.String, nil
+}
+
+// FindHandleOwner returns the user a handle belongs to, ignoring case, and
+// whether it is their current handle rather than one they renamed away from.
+// It returns sql.ErrNoRows when nobody holds the handle.
+func (s *handleStore) FindHandleOwner(handle string, now time.Time) (int64, bool, error) {
+	var userID int64
+	err := s.db.QueryRow("SELECT id FROM Users WHERE nickname = ? COLLATE NOCASE", handle).Scan(&userID)
+	if err == nil {
+		return userID, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("error finding handle: %w", err)
+	}
+
+	err = s.db.QueryRow("SELECT user_id FROM Handle_Redirects WHERE handle = ? AND expires_at > ?", handle, now.UTC()).Scan(&userID)
+	if err != nil {
+		return 0, false, err
+	}
+	return userID, false, nil
+}
+
+// AddHandleRedirect points an old handle at its user until expiresAt,
+// replacing any earlier redirect of the same handle and dropping expired ones.
+func (s *handleStore) AddHandleRedirect(handle string, userID int64, now, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM Handle_Redirects WHERE expires_at <= ?", now.UTC()); err != nil {
+		return fmt.Errorf("error pruning handle redirects: %w", err)
+	}
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO Handle_Redirects (handle, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		handle, userID, now.UTC(), expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error adding handle redirect: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *handleStore) DeleteHandleRedirect(handle string) error {
+	if _, err := s.db.Exec("DELETE FROM Handle_Redirects WHERE handle = ?", handle); err != nil {
+		return fmt.Errorf("error deleting handle redirect: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleMigrationDeduplicatesNicknames(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "handles.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db, "", "000039")
+	_, err = db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'ADA '), (3, 'c@example.com', 'x', ''),
+			(4, 'd@example.com', 'x', ''), (5, 'e@example.com', 'x', NULL),
+			(6, 'f@example.com', 'x', 'bob'), (7, 'g@example.com', 'x', 'Bob'), (8, 'h@example.com', 'x', 'bob_7'), (9, 'i@example.com', 'x', 'BOB_7_7');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateTestDB(t, db, "000039", "")
+
+	want := map[int64]sql.NullString{1: {String: "ada", Valid: true}, 2: {String: "ADA_2", Valid: true}, 3: {}, 4: {}, 5: {},
+		// the id suffix is appended until the handle is free
+		6: {String: "bob", Valid: true}, 7: {String: "Bob_7_7_7", Valid: true}, 8: {String: "bob_7", Valid: true}, 9: {String: "BOB_7_7", Valid: true}}
+	for id, nickname := range want {
+		var got sql.NullString
+		if err := db.QueryRow("SELECT nickname FROM Users WHERE id = ?", id).Scan(&got); err != nil || got != nickname {
+			t.Errorf("user %d: got %v, %v; want %v", id, got, err, nickname)
+		}
+	}
+	if _, err := db.Exec("UPDATE Users SET nickname = 'Ada' WHERE id = 3"); err == nil {
+		t.Error("nicknames should be unique ignoring case")
+	}
+}
+
+func TestHandleMigrationAppliesHandleRules(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "handles.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db, "", "000039")
+	long := strings.Repeat("c", 30)
+	_, err = db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(10, 'a@example.com', 'x', 'Grace Hopper'), (11, 'b@example.com', 'x', ' @linus'), (12, 'c@example.com', 'x', '42'),
+			(13, 'd@example.com', 'x', '_x'), (14, 'e@example.com', 'x', 'al'), (15, 'f@example.com', 'x', 'Admin'),
+			(16, 'g@example.com', 'x', '!!!'), (17, 'h@example.com', 'x', ?), (18, 'i@example.com', 'x', 'zoë'),
+			(21, 'j@example.com', 'x', ?), (22, 'k@example.com', 'x', ?), (23, 'l@example.com', 'x', ?);
+	`, strings.Repeat("a", 40), long, strings.ToUpper(long), strings.Repeat("c", 27)+"_22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateTestDB(t, db, "000039", "")
+
+	want := map[int64]string{
+		10: "GraceHopper_10", 11: "linus", 12: "user_42_12", 13: "user_x_13", 14: "al_14", 15: "Admin_15",
+		16: "user_16", 17: strings.Repeat("a", 27) + "_17", 18: "zo_18",
+		// duplicates are cut short to fit the id, and again while that is taken
+		21: long, 22: strings.Repeat("C", 24) + "_22_22", 23: strings.Repeat("c", 27) + "_22",
+	}
+	for id, nickname := range want {
+		var got string
+		if err := db.QueryRow("SELECT nickname FROM Users WHERE id = ?", id).Scan(&got); err != nil || got != nickname {
+			t.Errorf("user %d: got %q, %v; want %q", id, got, err, nickname)
+		}
+	}
+}
+
+func TestHandleStore(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`INSERT INTO Users (id, email, password, nickname) VALUES (1, 'a@example.com', 'x', 'Ada'), (2, 'b@example.com', 'x', NULL)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewHandleStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if handle, err := s.GetHandle(2); err != nil || handle != "" {
+		t.Errorf("no handle: got %q, %v", handle, err)
+	}
+	if id, current, err := s.FindHandleOwner("ADA", now); err != nil || id != 1 || !current {
+		t.Errorf("current handle: got %d, %v, %v", id, current, err)
+	}
+
+	if err := s.AddHandleRedirect("lovelace", 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if id, current, err := s.FindHandleOwner("Lovelace", now); err != nil || id != 1 || current {
+		t.Errorf("old handle: got %d, %v, %v", id, current, err)
+	}
+	if _, _, err := s.FindHandleOwner("lovelace", now.Add(time.Hour)); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expired redirect: got %v", err)
+	}
+
+	// adding another redirect prunes the expired one
+	later := now.Add(2 * time.Hour)
+	if err := s.AddHandleRedirect("countess", 1, later, later.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	var redirects int
+	_ = db.QueryRow("SELECT COUNT(*) FROM Handle_Redirects").Scan(&redirects)
+	if redirects != 1 {
+		t.Errorf("got %d redirects, want 1", redirects)
+	}
+	if err := s.DeleteHandleRedirect("COUNTESS"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.FindHandleOwner("countess", later); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted redirect: got %v", err)
+	}
+}
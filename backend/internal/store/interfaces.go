@@ -1,24 +1,39 @@
 package store
 
-import "github.com/tajjjjr/social-network/backend/internal/models"
+import (
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// PostVisibilityStore exposes the lookups needed to decide who may see a post.
+type PostVisibilityStore interface {
+	GetPostByID(id int64) (*models.Post, error)
+	GetCommentByID(commentID int64) (*models.Comment, error)
+	IsAcceptedFollower(followerID, followeeID int64) (bool, error)
+	IsPostViewer(postID, viewerID int64) (bool, error)
+	IsBlocked(userID, otherID int64) (bool, error)
+}
 
 // PostStoreInterface defines the interface for post-related database operations.
 type PostStoreInterface interface {
+	PostVisibilityStore
+
 	CreatePost(post *models.Post) (int64, error)
 	CreateComment(comment *models.Comment) (int64, error)
-	GetPostByID(id int64) (*models.Post, error)
 	GetPosts(userID int64) ([]*models.Post, error)
 	GetPostsPaginated(userID int64, limit, offset int) ([]*models.Post, error)
 	GetPostsCount(userID int64) (int, error)
-	UpdatePost(postID int64, content, imagePath string) (*models.Post, error)
+	GetFeedPage(userID int64, before, after *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error)
+	CountFeedAfter(userID int64, after models.FeedCursor) (int, error)
+	UpdatePost(postID int64, content string, attachments []models.Attachment) (*models.Post, error)
 	GetCommentsByPostID(postID, userID int64) ([]*models.Comment, error)
 	DeletePost(postID int64) error
 	AddPostViewers(postID int64, viewerIDs []int64) error
 	SearchUsers(query string, currentUserID int64) ([]*models.User, error)
 
-	UpdateComment(commentID int64, content, imagePath string) (*models.Comment, error)
+	UpdateComment(commentID int64, content string, attachments []models.Attachment) (*models.Comment, error)
 	DeleteComment(commentID int64) error
-	GetCommentByID(commentID int64) (*models.Comment, error)
 }
 
 type GroupStore interface {
@@ -30,6 +45,9 @@ type GroupRequestStore interface {
 	CreateGroupRequest(request *models.GroupRequest) (*models.GroupRequest, error)
 	GetGroupRequestByID(requestID int64) (*models.GroupRequest, error)
 	UpdateGroupRequestStatus(requestID int64, status string) error
+	HasPendingGroupRequest(groupID, userID int64) (bool, error)
+	ApproveGroupRequest(request *models.GroupRequest, role, notification string) error
+	RejectGroupRequest(request *models.GroupRequest, notification string) error
 }
 
 type GroupChatMessageStore interface {
@@ -39,6 +57,142 @@ type GroupChatMessageStore interface {
 
 type GroupMemberStore interface {
 	IsGroupMember(groupID, userID int64) (bool, error)
+	GetGroupMemberRole(groupID, userID int64) (string, error)
 	AddGroupMember(groupID, userID int64, role string) (*models.GroupMember, error)
 	RemoveGroupMember(groupID, userID int64) error
 }
+
+type SessionStore interface {
+	CreateSession(session *models.Session) error
+	GetSession(sessionID string) (*models.Session, error)
+	ListUserSessions(userID int64, now time.Time) ([]*models.Session, error)
+	TouchSession(sessionID string, lastSeenAt, expiresAt time.Time) error
+	DeleteSession(sessionID string) error
+	DeleteUserSessions(userID int64) ([]string, error)
+	DeleteUserSessionsExcept(userID int64, keepID string) ([]string, error)
+	DeleteExpiredSessions(now time.Time) ([]string, error)
+}
+
+type PasswordResetStore interface {
+	CreateResetToken(userID int64, tokenHash string, expiresAt time.Time) error
+	ResetPassword(tokenHash, passwordHash string, now time.Time) (int64, error)
+}
+
+type EmailVerificationStore interface {
+	CreateVerificationToken(userID int64, email, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(tokenHash string, now time.Time) (int64, error)
+	MarkEmailVerified(userID int64, email string, now time.Time) error
+	GetEmailStatus(userID int64) (string, bool, error)
+}
+
+type TwoFactorStore interface {
+	GetTwoFactor(userID int64) (*models.TwoFactor, error)
+	SetPendingSecret(userID int64, secret string, recoveryCodeHashes []string) error
+	EnableTwoFactor(userID int64, enabledAt time.Time, step int64) error
+	DisableTwoFactor(userID int64) error
+	UseStep(userID, step int64) (bool, error)
+	UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error)
+	CreateChallenge(userID int64, tokenHash string, expiresAt time.Time) error
+	GetChallenge(tokenHash string, now time.Time) (int64, int, error)
+	RecordChallengeFailure(tokenHash string) error
+	DeleteChallenge(tokenHash string) (bool, error)
+	DeleteExpiredChallenges(now time.Time) error
+}
+
+type LoginAttemptStore interface {
+	RecordFailure(failure *models.LoginFailure) error
+	GetThrottle(key string) (*models.LoginThrottle, error)
+	SaveThrottle(throttle *models.LoginThrottle) error
+	ClearThrottle(key string) error
+}
+
+type AccessTokenStore interface {
+	CreateAccessToken(token *models.AccessToken, tokenHash string) error
+	GetAccessTokenByHash(tokenHash string) (*models.AccessToken, error)
+	ListUserAccessTokens(userID int64) ([]*models.AccessToken, error)
+	TouchAccessToken(tokenID int64, lastUsedAt time.Time) error
+	DeleteUserAccessToken(userID, tokenID int64) (bool, error)
+}
+
+type OIDCStore interface {
+	CreateLoginState(stateHash string, state *models.OIDCLoginState) error
+	ConsumeLoginState(stateHash string, now time.Time) (*models.OIDCLoginState, error)
+	DeleteExpiredLoginStates(now time.Time) error
+	FindExternalIdentity(issuer, subject string, now time.Time) (int64, error)
+	LinkExternalIdentity(identity *models.ExternalIdentity, now time.Time) error
+}
+
+type AccountDeletionStore interface {
+	ScheduleDeletion(userID int64, deleteAfter time.Time) error
+	CancelDeletion(userID int64) (bool, error)
+	ListDueDeletions(now time.Time) ([]int64, error)
+	PurgeUser(userID int64, successorRole string) ([]string, error)
+}
+
+type AdminStore interface {
+	GetAccountStatus(userID int64) (string, bool, error)
+	SetUserRole(userID int64, role string) error
+	SetUserRoleByEmail(email, role string) (int64, error)
+	SetSuspended(userID int64, at *time.Time) error
+	CountUsersWithRole(role string) (int, error)
+	ListUsers(query string, limit, offset int) ([]*models.AdminUser, error)
+	GetPlatformStats(now time.Time) (*models.PlatformStats, error)
+}
+
+type HandleStore interface {
+	GetHandle(userID int64) (string, error)
+	FindHandleOwner(handle string, now time.Time) (int64, bool, error)
+	AddHandleRedirect(handle string, userID int64, now, expiresAt time.Time) error
+	DeleteHandleRedirect(handle string) error
+}
+
+type BlockStore interface {
+	BlockUser(blockerID, blockedID int64, now time.Time) error
+	UnblockUser(blockerID, blockedID int64) (bool, error)
+	ListBlockedUsers(blockerID int64) ([]*models.BlockedUser, error)
+	IsBlocked(userID, otherID int64) (bool, error)
+	ListBlockRelations(userID int64) ([]int64, error)
+}
+
+type MuteStore interface {
+	MuteUser(userID, mutedID int64, expiresAt *time.Time, now time.Time) error
+	UnmuteUser(userID, mutedID int64) (bool, error)
+	ListMutedUsers(userID int64, now time.Time) ([]*models.MutedUser, error)
+	AddMutedKeyword(userID int64, keyword string, now time.Time) (*models.MutedKeyword, error)
+	DeleteMutedKeyword(userID, keywordID int64) (bool, error)
+	ListMutedKeywords(userID int64) ([]*models.MutedKeyword, error)
+	IsMuted(userID, actorID int64, text string, now time.Time) (bool, error)
+}
+
+type DataExportStore interface {
+	CreateExport(userID int64, now time.Time) (*models.DataExport, error)
+	GetActiveExport(userID int64) (*models.DataExport, error)
+	GetExport(userID, exportID int64) (*models.DataExport, error)
+	ClaimPendingExport() (*models.DataExport, error)
+	RequeueRunningExports() error
+	MarkExportReady(exportID int64, fileName string, completedAt, expiresAt time.Time) error
+	MarkExportFailed(exportID int64, message string, completedAt time.Time) error
+	ListExpiredExports(now time.Time) ([]*models.DataExport, error)
+	MarkExportExpired(exportID int64) error
+	ListExportFiles() (map[string]bool, error)
+	CollectUserData(userID int64) ([]models.DataExportSection, []string, error)
+}
+
+type TagStore interface {
+	GetTagPage(userID int64, tag string, before *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error)
+	TrendingTags(since time.Time, limit int) ([]models.TrendingTag, error)
+}
+
+type MentionStore interface {
+	SetPostMentions(postID, authorID int64, handles []string, now time.Time) ([]int64, error)
+	SetCommentMentions(commentID, authorID int64, handles []string, now time.Time) ([]int64, error)
+	SetMessageMentions(messageID, authorID int64, handles []string, now time.Time) ([]int64, error)
+	AuthorInfo(userID int64) (string, string, error)
+	AddMentionNotification(userID int64, message string) error
+	MentionCandidates(userID int64, prefix string, limit int) ([]models.MentionCandidate, error)
+}
+
+type SearchStore interface {
+	SearchAvailable() bool
+	SearchPosts(userID int64, match string, offset, limit int) ([]models.SearchResult, error)
+}
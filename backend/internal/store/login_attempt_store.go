@@ -0,0 +1,77 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type loginAttemptStore struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptStore(db *sql.DB) LoginAttemptStore {
+	return &loginAttemptStore{db: db}
+}
+
+// RecordFailure appends a failed login to the audit log.
+func (s *loginAttemptStore) RecordFailure(failure *models.LoginFailure) error {
+	var userID sql.NullInt64
+	if failure.UserID != nil {
+		userID = sql.NullInt64{Int64: *failure.UserID, Valid: true}
+	}
+	result, err := s.db.Exec(
+		`INSERT INTO Login_Failures (email, user_id, ip_address, user_agent, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		failure.Email, userID, failure.IPAddress, failure.UserAgent, failure.Reason, failure.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error recording login failure: %w", err)
+	}
+	failure.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetThrottle returns the failure count for key, or sql.ErrNoRows when there is none.
+func (s *loginAttemptStore) GetThrottle(key string) (*models.LoginThrottle, error) {
+	throttle := models.LoginThrottle{Key: key}
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRow(
+		"SELECT failures, last_failure_at, locked_until FROM Login_Throttles WHERE throttle_key = ?",
+		key,
+	).Scan(&throttle.Failures, &throttle.LastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = &lockedUntil.Time
+	}
+	return &throttle, nil
+}
+
+func (s *loginAttemptStore) SaveThrottle(throttle *models.LoginThrottle) error {
+	var lockedUntil sql.NullTime
+	if throttle.LockedUntil != nil {
+		lockedUntil = sql.NullTime{Time: throttle.LockedUntil.UTC(), Valid: true}
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO Login_Throttles (throttle_key, failures, last_failure_at, locked_until) VALUES (?, ?, ?, ?)
+		ON CONFLICT(throttle_key) DO UPDATE SET
+			failures = excluded.failures,
+			last_failure_at = excluded.last_failure_at,
+			locked_until = excluded.locked_until`,
+		throttle.Key, throttle.Failures, throttle.LastFailureAt.UTC(), lockedUntil,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving login throttle: %w", err)
+	}
+	return nil
+}
+
+func (s *loginAttemptStore) ClearThrottle(key string) error {
+	if _, err := s.db.Exec("DELETE FROM Login_Throttles WHERE throttle_key = ?", key); err != nil {
+		return fmt.Errorf("error clearing login throttle: %w", err)
+	}
+	return nil
+}
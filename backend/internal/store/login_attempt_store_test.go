@@ -0,0 +1,95 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func setupLoginAttemptTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "login_attempts.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE Login_Failures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL,
+		user_id INTEGER,
+		ip_address TEXT NOT NULL,
+		user_agent TEXT,
+		reason TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE Login_Throttles (
+		throttle_key TEXT PRIMARY KEY,
+		failures INTEGER NOT NULL DEFAULT 0,
+		last_failure_at DATETIME NOT NULL,
+		locked_until DATETIME
+	);`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoginAttemptStore_Throttles(t *testing.T) {
+	db := setupLoginAttemptTestDB(t)
+	attempts := NewLoginAttemptStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := attempts.GetThrottle("account:ada@example.com"); err != sql.ErrNoRows {
+		t.Fatalf("missing throttle: got %v, want sql.ErrNoRows", err)
+	}
+
+	throttle := &models.LoginThrottle{Key: "account:ada@example.com", Failures: 1, LastFailureAt: now}
+	if err := attempts.SaveThrottle(throttle); err != nil {
+		t.Fatal(err)
+	}
+	lockedUntil := now.Add(15 * time.Minute)
+	throttle.Failures, throttle.LockedUntil = 2, &lockedUntil
+	if err := attempts.SaveThrottle(throttle); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := attempts.GetThrottle("account:ada@example.com")
+	if err != nil || got.Failures != 2 || !got.LastFailureAt.Equal(now) || got.LockedUntil == nil || !got.LockedUntil.Equal(lockedUntil) {
+		t.Fatalf("GetThrottle() = %+v, %v", got, err)
+	}
+
+	if err := attempts.ClearThrottle("account:ada@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := attempts.GetThrottle("account:ada@example.com"); err != sql.ErrNoRows {
+		t.Errorf("cleared throttle: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestLoginAttemptStore_RecordFailure(t *testing.T) {
+	db := setupLoginAttemptTestDB(t)
+	attempts := NewLoginAttemptStore(db)
+	userID := int64(7)
+
+	for _, failure := range []*models.LoginFailure{
+		{Email: "ada@example.com", UserID: &userID, IPAddress: "10.0.0.1", Reason: "invalid_credentials", CreatedAt: time.Now()},
+		{Email: "nobody@example.com", IPAddress: "10.0.0.1", Reason: "invalid_credentials", CreatedAt: time.Now()},
+	} {
+		if err := attempts.RecordFailure(failure); err != nil || failure.ID == 0 {
+			t.Fatalf("RecordFailure() = %v, id %d", err, failure.ID)
+		}
+	}
+
+	var rows, withUser int
+	db.QueryRow("SELECT COUNT(*), COUNT(user_id) FROM Login_Failures").Scan(&rows, &withUser)
+	if rows != 2 || withUser != 1 {
+		t.Errorf("got %d rows with %d user ids, want 2 with 1", rows, withUser)
+	}
+}
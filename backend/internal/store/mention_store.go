@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// Mentions are made in posts, comments or chat messages; messageOwner names
+// the column of Mentions pointing at a message, next to postOwner and commentOwner.
+const messageOwner = "message_id"
+
+type mentionStore struct {
+	db *sql.DB
+}
+
+func NewMentionStore(db *sql.DB) MentionStore {
+	return &mentionStore{db: db}
+}
+
+// SetPostMentions makes the users named by handles the ones mentioned in a
+// post, and returns those who were not mentioned in it before.
+func (s *mentionStore) SetPostMentions(postID, authorID int64, handles []string, now time.Time) ([]int64, error) {
+	return s.setMentions(postOwner, postID, authorID, handles, now)
+}
+
+// SetCommentMentions is SetPostMentions for a comment.
+func (s *mentionStore) SetCommentMentions(commentID, authorID int64, handles []string, now time.Time) ([]int64, error) {
+	return s.setMentions(commentOwner, commentID, authorID, handles, now)
+}
+
+// SetMessageMentions is SetPostMentions for a chat message.
+func (s *mentionStore) SetMessageMentions(messageID, authorID int64, handles []string, now time.Time) ([]int64, error) {
+	return s.setMentions(messageOwner, messageID, authorID, handles, now)
+}
+
+// setMentions resolves handles, current ones and old ones that still
+// redirect, and keeps one mention per user other than the author. Mentions
+// no longer in the text are dropped.
+func (s *mentionStore) setMentions(owner string, ownerID, authorID int64, handles []string, now time.Time) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userIDs []any
+	if len(handles) > 0 {
+		list := "(?" + strings.Repeat(", ?", len(handles)-1) + ")"
+		args := make([]any, 0, 2*len(handles)+2)
+		for _, handle := range handles {
+			args = append(args, handle)
+		}
+		args = append(args, authorID)
+		for _, handle := range handles {
+			args = append(args, handle)
+		}
+		args = append(args, now.UTC(), authorID)
+		rows, err := tx.Query(`
+			SELECT id FROM Users WHERE nickname COLLATE NOCASE IN `+list+` AND id != ?
+			UNION
+			SELECT user_id FROM Handle_Redirects WHERE handle IN `+list+` AND expires_at > ? AND user_id != ?`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving mentions: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, fmt.Errorf("error scanning mention: %w", err)
+			}
+			userIDs = append(userIDs, id)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt := "DELETE FROM Mentions WHERE " + owner + " = ?"
+	if len(userIDs) > 0 {
+		stmt += " AND user_id NOT IN (?" + strings.Repeat(", ?", len(userIDs)-1) + ")"
+	}
+	if _, err := tx.Exec(stmt, append([]any{ownerID}, userIDs...)...); err != nil {
+		return nil, fmt.Errorf("error removing mentions: %w", err)
+	}
+
+	var added []int64
+	for _, id := range userIDs {
+		res, err := tx.Exec("INSERT OR IGNORE INTO Mentions (user_id, author_id, "+owner+", created_at) VALUES (?, ?, ?, ?)",
+			id, authorID, ownerID, now.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("error adding mention: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 1 {
+			added = append(added, id.(int64))
+		}
+	}
+	return added, tx.Commit()
+}
+
+// AuthorInfo returns the name and avatar to show for the author of a mention.
+func (s *mentionStore) AuthorInfo(userID int64) (string, string, error) {
+	var firstName, lastName, nickname, avatar sql.NullString
+	err := s.db.QueryRow("SELECT first_name, last_name, nickname, avatar FROM Users WHERE id = ?", userID).
+		Scan(&firstName, &lastName, &nickname, &avatar)
+	if err != nil {
+		return "", "", err
+	}
+	name := strings.TrimSpace(firstName.String + " " + lastName.String)
+	if name == "" {
+		name = nickname.String
+	}
+	if name == "" {
+		name = "User"
+	}
+	return name, avatar.String, nil
+}
+
+// AddMentionNotification records a notification that userID was mentioned.
+func (s *mentionStore) AddMentionNotification(userID int64, message string) error {
+	if _, err := s.db.Exec("INSERT INTO Notifications (user_id, type, message) VALUES (?, 'mention', ?)", userID, message); err != nil {
+		return fmt.Errorf("error adding notification: %w", err)
+	}
+	return nil
+}
+
+// MentionCandidates returns up to limit users whose handle starts with
+// prefix, ignoring case, for userID to mention: people they follow first,
+// then people following them, then everyone else, shorter handles first
+// within each. prefix must only hold handle characters; the match runs on the
+// handle index rather than scanning names.
+func (s *mentionStore) MentionCandidates(userID int64, prefix string, limit int) ([]models.MentionCandidate, error) {
+	pattern := strings.ReplaceAll(prefix, "_", `\_`) + "%"
+	rows, err := s.db.Query(`
+		SELECT id, nickname, first_name, last_name, avatar, following, follows_you
+		FROM (
+			SELECT u.id, u.nickname, u.first_name, u.last_name, u.avatar,
+				EXISTS (SELECT 1 FROM Followers f WHERE f.follower_id = ? AND f.followee_id = u.id AND f.status = 'accepted') AS following,
+				EXISTS (SELECT 1 FROM Followers f WHERE f.follower_id = u.id AND f.followee_id = ? AND f.status = 'accepted') AS follows_you
+			FROM Users u
+			WHERE u.nickname LIKE ? ESCAPE '\' AND u.id != ? AND `+notBlockedClause("u.id")+`
+		)
+		ORDER BY following DESC, follows_you DESC, LENGTH(nickname), nickname COLLATE NOCASE
+		LIMIT ?`, userID, userID, pattern, userID, userID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing mention candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []models.MentionCandidate{}
+	for rows.Next() {
+		var c models.MentionCandidate
+		var firstName, lastName, avatar sql.NullString
+		if err := rows.Scan(&c.ID, &c.Nickname, &firstName, &lastName, &avatar, &c.Following, &c.FollowsYou); err != nil {
+			return nil, fmt.Errorf("error scanning mention candidate: %w", err)
+		}
+		c.FirstName, c.LastName, c.Avatar = firstName.String, lastName.String, avatar.String
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetMentions(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'Bob'), (3, 'c@example.com', 'x', 'cy'), (4, 'd@example.com', 'x', 'dee');
+		INSERT INTO Handle_Redirects (handle, user_id, expires_at) VALUES ('oldcy', 3, '2024-06-01 00:00:00'), ('gone', 4, '2024-04-01 00:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMentionStore(db)
+	mentioned := func() string {
+		var users []string
+		rows, err := db.Query("SELECT user_id FROM Mentions WHERE post_id = 7 ORDER BY user_id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			rows.Scan(&id)
+			users = append(users, id)
+		}
+		return strings.Join(users, " ")
+	}
+
+	// handles match ignoring case, old handles redirect until they expire, and authors do not mention themselves
+	added, err := s.SetPostMentions(7, 1, []string{"BOB", "oldcy", "gone", "ada", "nobody"}, now)
+	if err != nil || fmt.Sprint(added) != "[2 3]" || mentioned() != "2 3" {
+		t.Fatalf("first mentions: added %v, %v; stored %q", added, err, mentioned())
+	}
+
+	added, err = s.SetPostMentions(7, 1, []string{"bob", "dee"}, now)
+	if err != nil || fmt.Sprint(added) != "[4]" || mentioned() != "2 4" {
+		t.Errorf("after an edit: added %v, %v; stored %q", added, err, mentioned())
+	}
+	if added, err := s.SetPostMentions(7, 1, nil, now); err != nil || len(added) != 0 || mentioned() != "" {
+		t.Errorf("after removing them: added %v, %v; stored %q", added, err, mentioned())
+	}
+
+	if added, err := s.SetMessageMentions(7, 2, []string{"ada"}, now); err != nil || fmt.Sprint(added) != "[1]" {
+		t.Errorf("a message with the post's id: added %v, %v", added, err)
+	}
+}
+
+func TestMentionCandidates(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname, first_name) VALUES
+			(1, 'me@example.com', 'x', 'me_too', 'Me'),
+			(2, 'a@example.com', 'x', 'sam_long', 'Sam'), (3, 'b@example.com', 'x', 'Sam', 'Sam'),
+			(4, 'c@example.com', 'x', 'samantha', 'Samantha'), (5, 'd@example.com', 'x', 'sammy', 'Sammy'),
+			(6, 'e@example.com', 'x', 'samx', 'Blocked'), (7, 'f@example.com', 'x', 'other', 'Sam');
+		INSERT INTO Followers (follower_id, followee_id, status) VALUES (1, 4, 'accepted'), (5, 1, 'accepted'), (1, 2, 'pending');
+		INSERT INTO Blocks (blocker_id, blocked_id) VALUES (6, 1);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMentionStore(db)
+	handles := func(prefix string) string {
+		candidates, err := s.MentionCandidates(1, prefix, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var handles []string
+		for _, c := range candidates {
+			handles = append(handles, c.Nickname)
+		}
+		return strings.Join(handles, " ")
+	}
+
+	// followees, then followers, then the shortest handles; names are not searched
+	if got := handles("SAM"); got != "samantha sammy Sam sam_long" {
+		t.Errorf("candidates for sam = %q", got)
+	}
+	if got := handles("sam_"); got != "sam_long" {
+		t.Errorf("an underscore is not a wildcard: %q", got)
+	}
+	if got := handles("me"); got != "" {
+		t.Errorf("the caller is not a candidate: %q", got)
+	}
+
+	var plan string
+	rows, err := db.Query("EXPLAIN QUERY PLAN SELECT id FROM Users WHERE nickname LIKE 'sam%' ESCAPE '\\'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		rows.Scan(&id, &parent, &unused, &detail)
+		plan += detail + "; "
+	}
+	if !strings.Contains(plan, "idx_users_nickname") {
+		t.Errorf("the prefix match does not use the handle index: %s", plan)
+	}
+}
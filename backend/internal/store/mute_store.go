@@ -0,0 +1,185 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// ErrMutedKeywordExists is returned when muting a keyword the user already muted, ignoring case.
+var ErrMutedKeywordExists = errors.New("keyword already muted")
+
+// notMutedClause returns a condition that holds unless the viewer muted the
+// user in userColumn, with a mute that has not expired, or muted a keyword
+// found in textColumn. Keywords match case-insensitively anywhere in the text.
+func notMutedClause(userColumn, textColumn string, viewerID int64, now time.Time) (string, []interface{}) {
+	clause := `NOT EXISTS (
+            SELECT 1 FROM Muted_Users mu
+            WHERE mu.user_id = ? AND mu.muted_id = ` + userColumn + `
+                AND (mu.expires_at IS NULL OR mu.expires_at > ?)
+        ) AND NOT EXISTS (
+            SELECT 1 FROM Muted_Keywords mk
+            WHERE mk.user_id = ? AND INSTR(LOWER(` + textColumn + `), LOWER(mk.keyword)) > 0
+        )`
+	return clause, []interface{}{viewerID, now.UTC(), viewerID}
+}
+
+type muteStore struct {
+	db *sql.DB
+}
+
+func NewMuteStore(db *sql.DB) MuteStore {
+	return &muteStore{db: db}
+}
+
+// MuteUser mutes mutedID for userID until expiresAt, or for good when it is
+// nil. Muting a user again replaces the previous mute. It returns
+// sql.ErrNoRows when mutedID is not a user.
+func (s *muteStore) MuteUser(userID, mutedID int64, expiresAt *time.Time, now time.Time) error {
+	var expires any
+	if expiresAt != nil {
+		expires = expiresAt.UTC()
+	}
+	result, err := s.db.Exec(`
+		INSERT INTO Muted_Users (user_id, muted_id, created_at, expires_at)
+		SELECT ?, id, ?, ? FROM Users WHERE id = ?
+		ON CONFLICT (user_id, muted_id) DO UPDATE SET created_at = excluded.created_at, expires_at = excluded.expires_at`,
+		userID, now.UTC(), expires, mutedID,
+	)
+	if err := expectOneRow(result, err); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("error muting user: %w", err)
+	}
+
+	// expired mutes no longer hide anything
+	if _, err := s.db.Exec("DELETE FROM Muted_Users WHERE user_id = ? AND expires_at <= ?", userID, now.UTC()); err != nil {
+		return fmt.Errorf("error pruning expired mutes: %w", err)
+	}
+	return nil
+}
+
+// UnmuteUser lifts a mute and reports whether there was one.
+func (s *muteStore) UnmuteUser(userID, mutedID int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM Muted_Users WHERE user_id = ? AND muted_id = ?", userID, mutedID)
+	if err != nil {
+		return false, fmt.Errorf("error unmuting user: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unmuting user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListMutedUsers returns the users userID has muted and whose mute has not
+// expired, most recent first.
+func (s *muteStore) ListMutedUsers(userID int64, now time.Time) ([]*models.MutedUser, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.first_name, u.last_name, u.nickname, u.avatar, m.created_at, m.expires_at
+		FROM Muted_Users m
+		JOIN Users u ON u.id = m.muted_id
+		WHERE m.user_id = ? AND (m.expires_at IS NULL OR m.expires_at > ?)
+		ORDER BY m.created_at DESC, u.id DESC`,
+		userID, now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing muted users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.MutedUser{}
+	for rows.Next() {
+		var user models.MutedUser
+		var firstName, lastName, nickname, avatar sql.NullString
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&user.ID, &firstName, &lastName, &nickname, &avatar, &user.MutedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("error scanning muted user: %w", err)
+		}
+		user.FirstName = firstName.String
+		user.LastName = lastName.String
+		user.Nickname = nickname.String
+		user.Avatar = avatar.String
+		user.ExpiresAt = nullTimePtr(expiresAt)
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+func (s *muteStore) AddMutedKeyword(userID int64, keyword string, now time.Time) (*models.MutedKeyword, error) {
+	result, err := s.db.Exec(
+		"INSERT INTO Muted_Keywords (user_id, keyword, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		userID, keyword, now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error muting keyword: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error muting keyword: %w", err)
+	} else if n == 0 {
+		return nil, ErrMutedKeywordExists
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error muting keyword: %w", err)
+	}
+	return &models.MutedKeyword{ID: id, Keyword: keyword, CreatedAt: now.UTC()}, nil
+}
+
+// DeleteMutedKeyword removes one of userID's muted keywords and reports whether it existed.
+func (s *muteStore) DeleteMutedKeyword(userID, keywordID int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM Muted_Keywords WHERE id = ? AND user_id = ?", keywordID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error unmuting keyword: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unmuting keyword: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *muteStore) ListMutedKeywords(userID int64) ([]*models.MutedKeyword, error) {
+	rows, err := s.db.Query(
+		"SELECT id, keyword, created_at FROM Muted_Keywords WHERE user_id = ? ORDER BY created_at DESC, id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing muted keywords: %w", err)
+	}
+	defer rows.Close()
+
+	keywords := []*models.MutedKeyword{}
+	for rows.Next() {
+		var keyword models.MutedKeyword
+		if err := rows.Scan(&keyword.ID, &keyword.Keyword, &keyword.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning muted keyword: %w", err)
+		}
+		keywords = append(keywords, &keyword)
+	}
+	return keywords, rows.Err()
+}
+
+// IsMuted reports whether userID muted actorID or a keyword found in text.
+// It applies the same rules as notMutedClause.
+func (s *muteStore) IsMuted(userID, actorID int64, text string, now time.Time) (bool, error) {
+	var muted bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM Muted_Users
+			WHERE user_id = ? AND muted_id = ? AND (expires_at IS NULL OR expires_at > ?)
+		) OR EXISTS (
+			SELECT 1 FROM Muted_Keywords
+			WHERE user_id = ? AND INSTR(LOWER(?), LOWER(keyword)) > 0
+		)`,
+		userID, actorID, now.UTC(), userID, text,
+	).Scan(&muted)
+	if err != nil {
+		return false, fmt.Errorf("error checking mutes: %w", err)
+	}
+	return muted, nil
+}
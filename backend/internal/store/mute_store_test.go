@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMuteStore(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob'), (3, 'c@example.com', 'x', 'cy');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMuteStore(db)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	if err := s.MuteUser(1, 2, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MuteUser(1, 3, &later, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MuteUser(1, 99, nil, now); err == nil {
+		t.Error("muting an unknown user should fail")
+	}
+
+	if muted, err := s.IsMuted(1, 3, "hello", now); err != nil || !muted {
+		t.Errorf("IsMuted before expiry = %v, %v", muted, err)
+	}
+	if muted, err := s.IsMuted(1, 3, "hello", later); err != nil || muted {
+		t.Errorf("IsMuted after expiry = %v, %v", muted, err)
+	}
+	if muted, err := s.IsMuted(2, 1, "hello", now); err != nil || muted {
+		t.Errorf("mutes are one-way: got %v, %v", muted, err)
+	}
+	users, err := s.ListMutedUsers(1, later)
+	if err != nil || len(users) != 1 || users[0].ID != 2 || users[0].ExpiresAt != nil {
+		t.Fatalf("ListMutedUsers after cy's mute expired = %+v, %v", users, err)
+	}
+
+	keyword, err := s.AddMutedKeyword(3, "World Cup", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddMutedKeyword(3, "world cup", now); !errors.Is(err, ErrMutedKeywordExists) {
+		t.Errorf("muting a keyword twice: got %v", err)
+	}
+	if muted, err := s.IsMuted(3, 1, "Who watched the WORLD CUP final?", now); err != nil || !muted {
+		t.Errorf("keywords should match ignoring case: got %v, %v", muted, err)
+	}
+	if keywords, err := s.ListMutedKeywords(3); err != nil || len(keywords) != 1 || keywords[0].Keyword != "World Cup" {
+		t.Errorf("ListMutedKeywords = %+v, %v", keywords, err)
+	}
+	if removed, err := s.DeleteMutedKeyword(1, keyword.ID); err != nil || removed {
+		t.Errorf("deleting someone else's keyword: got %v, %v", removed, err)
+	}
+	if removed, err := s.DeleteMutedKeyword(3, keyword.ID); err != nil || !removed {
+		t.Errorf("DeleteMutedKeyword = %v, %v", removed, err)
+	}
+	if removed, err := s.UnmuteUser(1, 2); err != nil || !removed {
+		t.Errorf("UnmuteUser = %v, %v", removed, err)
+	}
+}
+
+func TestFeedLeavesOutMutes(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob'), (3, 'c@example.com', 'x', 'cy');
+		INSERT INTO Posts (id, user_id, content, image, privacy) VALUES
+			(10, 2, 'noise', '', 'public'), (11, 3, 'Spoilers ahead', '', 'public'), (12, 3, 'hello', '', 'public');
+		INSERT INTO Muted_Users (user_id, muted_id, created_at) VALUES (1, 2, '2026-01-01 00:00:00');
+		INSERT INTO Muted_Keywords (user_id, keyword, created_at) VALUES (1, 'spoiler', '2026-01-01 00:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPostStore(db)
+
+	posts, err := s.GetPostsPaginated(1, 10, 0)
+	if err != nil || len(posts) != 1 || posts[0].ID != 12 {
+		t.Fatalf("feed of the muting user = %v, %v; want post 12 only", posts, err)
+	}
+	if count, err := s.GetPostsCount(1); err != nil || count != 1 {
+		t.Errorf("GetPostsCount = %d, %v; want 1", count, err)
+	}
+	if posts, err := s.GetPostsPaginated(2, 10, 0); err != nil || len(posts) != 3 {
+		t.Errorf("the muted user's feed is unaffected: got %d posts, %v", len(posts), err)
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type oidcStore struct {
+	db *sql.DB
+}
+
+func NewOIDCStore(db *sql.DB) OIDCStore {
+	return &oidcStore{db: db}
+}
+
+func (s *oidcStore) CreateLoginState(stateHash string, state *models.OIDCLoginState) error {
+	_, err := s.db.Exec(
+		"INSERT INTO OIDC_Login_States (state_hash, provider, nonce, code_verifier, expires_at) VALUES (?, ?, ?, ?, ?)",
+		stateHash, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState deletes and returns a live login state, so each can finish
+// one login. It returns sql.ErrNoRows when the state is unknown or expired at now.
+func (s *oidcStore) ConsumeLoginState(stateHash string, now time.Time) (*models.OIDCLoginState, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var state models.OIDCLoginState
+	err = tx.QueryRow(
+		"SELECT provider, nonce, code_verifier, expires_at FROM OIDC_Login_States WHERE state_hash = ?",
+		stateHash,
+	).Scan(&state.Provider, &state.Nonce, &state.CodeVerifier, &state.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec("DELETE FROM OIDC_Login_States WHERE state_hash = ?", stateHash)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting login state: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		return nil, sql.ErrNoRows
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if !now.Before(state.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	return &state, nil
+}
+
+func (s *oidcStore) DeleteExpiredLoginStates(now time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM OIDC_Login_States WHERE expires_at <= ?", now.UTC()); err != nil {
+		return fmt.Errorf("error pruning login states: %w", err)
+	}
+	return nil
+}
+
+// FindExternalIdentity returns the user linked to the provider account and
+// records the login, or sql.ErrNoRows when the account is not linked.
+func (s *oidcStore) FindExternalIdentity(issuer, subject string, now time.Time) (int64, error) {
+	var userID int64
+	err := s.db.QueryRow(
+		"SELECT user_id FROM External_Identities WHERE issuer = ? AND subject = ?",
+		issuer, subject,
+	).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.db.Exec(
+		"UPDATE External_Identities SET last_login_at = ? WHERE issuer = ? AND subject = ?",
+		now.UTC(), issuer, subject,
+	); err != nil {
+		return 0, fmt.Errorf("error recording external login: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *oidcStore) LinkExternalIdentity(identity *models.ExternalIdentity, now time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO External_Identities (user_id, issuer, subject, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		identity.UserID, identity.Issuer, identity.Subject, identity.Email, now.UTC(), now.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error linking external identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func setupOIDCTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "oidc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE OIDC_Login_States (
+		state_hash TEXT PRIMARY KEY,
+		provider TEXT NOT NULL,
+		nonce TEXT NOT NULL,
+		code_verifier TEXT NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE TABLE External_Identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		issuer TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT,
+		created_at DATETIME NOT NULL,
+		last_login_at DATETIME,
+		UNIQUE (issuer, subject)
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestConsumeLoginState(t *testing.T) {
+	s := NewOIDCStore(setupOIDCTestDB(t))
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	state := &models.OIDCLoginState{Provider: "test", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(10 * time.Minute)}
+	if err := s.CreateLoginState("live", state); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateLoginState("stale", state); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.ConsumeLoginState("live", now)
+	if err != nil || got.Provider != "test" || got.Nonce != "n" || got.CodeVerifier != "v" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if _, err := s.ConsumeLoginState("live", now); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("second use: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.ConsumeLoginState("stale", now.Add(10*time.Minute)); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expired state: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestExternalIdentities(t *testing.T) {
+	db := setupOIDCTestDB(t)
+	s := NewOIDCStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := s.FindExternalIdentity("https://issuer", "sub", now); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unlinked identity: got %v", err)
+	}
+	identity := &models.ExternalIdentity{UserID: 7, Issuer: "https://issuer", Subject: "sub", Email: "ada@example.com"}
+	if err := s.LinkExternalIdentity(identity, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LinkExternalIdentity(identity, now); err == nil {
+		t.Error("linking the same provider account twice should fail")
+	}
+
+	later := now.Add(time.Hour)
+	userID, err := s.FindExternalIdentity("https://issuer", "sub", later)
+	if err != nil || userID != 7 {
+		t.Fatalf("got user %d, %v", userID, err)
+	}
+	var lastLogin time.Time
+	db.QueryRow("SELECT last_login_at FROM External_Identities WHERE subject = 'sub'").Scan(&lastLogin)
+	if !lastLogin.Equal(later) {
+		t.Errorf("last_login_at = %v, want %v", lastLogin, later)
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type passwordResetStore struct {
+	db *sql.DB
+}
+
+func NewPasswordResetStore(db *sql.DB) PasswordResetStore {
+	return &passwordResetStore{db: db}
+}
+
+// CreateResetToken stores a new token hash for the user. Any earlier unused
+// tokens are discarded so only the most recent email works.
+func (s *passwordResetStore) CreateResetToken(userID int64, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM Password_Reset_Tokens WHERE user_id = ? AND used_at IS NULL", userID); err != nil {
+		return fmt.Errorf("error discarding old reset tokens: %w", err)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO Password_Reset_Tokens (user_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		userID, tokenHash, time.Now().UTC(), expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating reset token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ResetPassword consumes the token and sets the new password hash in one
+// transaction, returning the user it belonged to. It returns sql.ErrNoRows
+// when the token is unknown, already used or expired at now.
+func (s *passwordResetStore) ResetPassword(tokenHash, passwordHash string, now time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRow(
+		"SELECT user_id FROM Password_Reset_Tokens WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		tokenHash, now.UTC(),
+	).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("UPDATE Password_Reset_Tokens SET used_at = ? WHERE token_hash = ?", now.UTC(), tokenHash); err != nil {
+		return 0, fmt.Errorf("error consuming reset token: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE Users SET password = ? WHERE id = ?", passwordHash, userID); err != nil {
+		return 0, fmt.Errorf("error updating password: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing password reset: %w", err)
+	}
+	return userID, nil
+}
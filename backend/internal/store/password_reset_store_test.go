@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupPasswordResetTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "resets.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE Users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL
+	);
+	CREATE TABLE Password_Reset_Tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME
+	);
+	INSERT INTO Users (id, email, password) VALUES (1, 'ada@example.com', 'old-hash');`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPasswordResetStore_ResetPassword(t *testing.T) {
+	db := setupPasswordResetTestDB(t)
+	resets := NewPasswordResetStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := resets.CreateResetToken(1, "hash", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := resets.ResetPassword("hash", "new-hash", now.Add(time.Hour)); err != sql.ErrNoRows {
+		t.Errorf("expired token: got %v, want sql.ErrNoRows", err)
+	}
+
+	userID, err := resets.ResetPassword("hash", "new-hash", now)
+	if err != nil || userID != 1 {
+		t.Fatalf("ResetPassword() = %d, %v", userID, err)
+	}
+	var password string
+	_ = db.QueryRow("SELECT password FROM Users WHERE id = 1").Scan(&password)
+	if password != "new-hash" {
+		t.Errorf("password = %q, want new-hash", password)
+	}
+
+	if _, err := resets.ResetPassword("hash", "other-hash", now); err != sql.ErrNoRows {
+		t.Errorf("used token: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestPasswordResetStore_NewTokenReplacesOld(t *testing.T) {
+	db := setupPasswordResetTestDB(t)
+	resets := NewPasswordResetStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	_ = resets.CreateResetToken(1, "first", now.Add(time.Hour))
+	if err := resets.CreateResetToken(1, "second", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := resets.ResetPassword("first", "new-hash", now); err != sql.ErrNoRows {
+		t.Errorf("superseded token: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := resets.ResetPassword("second", "new-hash", now); err != nil {
+		t.Errorf("latest token should work: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// Galleries belong either to a post or to a comment; owner names the column
+// of Post_Attachments that points at it.
+const (
+	postOwner    = "post_id"
+	commentOwner = "comment_id"
+)
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// attachmentsOf returns the galleries of the given posts or comments in
+// display order, keyed by their id.
+func attachmentsOf(q queryer, owner string, ids []int64) (map[int64][]models.Attachment, error) {
+	galleries := map[int64][]models.Attachment{}
+	if len(ids) == 0 {
+		return galleries, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := q.Query(`
+		SELECT `+owner+`, id, path, position, alt_text, width, height
+		FROM Post_Attachments
+		WHERE `+owner+` IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		ORDER BY `+owner+`, position, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ownerID int64
+		var a models.Attachment
+		if err := rows.Scan(&ownerID, &a.ID, &a.Path, &a.Position, &a.AltText, &a.Width, &a.Height); err != nil {
+			return nil, fmt.Errorf("error scanning attachment: %w", err)
+		}
+		galleries[ownerID] = append(galleries[ownerID], a)
+	}
+	return galleries, rows.Err()
+}
+
+func loadPostAttachments(q queryer, posts ...*models.Post) error {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	galleries, err := attachmentsOf(q, postOwner, ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.Attachments = galleries[post.ID]
+	}
+	return nil
+}
+
+func loadCommentAttachments(q queryer, comments ...*models.Comment) error {
+	ids := make([]int64, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	galleries, err := attachmentsOf(q, commentOwner, ids)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Attachments = galleries[comment.ID]
+	}
+	return nil
+}
+
+// replaceAttachments makes attachments the whole gallery of a post or
+// comment, positioned in the order given. Those with an id are existing
+// attachments to keep, the rest are inserted, and any not listed are deleted.
+func replaceAttachments(tx *sql.Tx, owner string, ownerID, userID int64, attachments []models.Attachment) error {
+	keep := []any{ownerID}
+	for _, a := range attachments {
+		if a.ID != 0 {
+			keep = append(keep, a.ID)
+		}
+	}
+	stmt := "DELETE FROM Post_Attachments WHERE " + owner + " = ?"
+	if len(keep) > 1 {
+		stmt += " AND id NOT IN (?" + strings.Repeat(", ?", len(keep)-2) + ")"
+	}
+	if _, err := tx.Exec(stmt, keep...); err != nil {
+		return fmt.Errorf("error removing attachments: %w", err)
+	}
+
+	for i := range attachments {
+		a := &attachments[i]
+		a.Position = i
+		if a.ID != 0 {
+			err := expectOneRow(tx.Exec("UPDATE Post_Attachments SET position = ?, alt_text = ? WHERE id = ? AND "+owner+" = ?",
+				a.Position, a.AltText, a.ID, ownerID))
+			if err != nil {
+				return fmt.Errorf("error updating attachment %d: %w", a.ID, err)
+			}
+			continue
+		}
+		res, err := tx.Exec("INSERT INTO Post_Attachments ("+owner+", user_id, path, position, alt_text, width, height) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			ownerID, userID, a.Path, a.Position, a.AltText, a.Width, a.Height)
+		if err != nil {
+			return fmt.Errorf("error adding attachment: %w", err)
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstImage is what Posts.image and Comments.image hold for clients that
+// only show one image.
+func firstImage(attachments []models.Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	return attachments[0].Path
+}
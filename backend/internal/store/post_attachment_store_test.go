@@ -0,0 +1,117 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func TestAttachmentMigrationMovesSingleImages(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "attachments.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrateTestDB(t, db, "", "000043")
+	_, err = db.Exec(`
+		INSERT INTO Users (id, email, password) VALUES (1, 'a@example.com', 'x'), (2, 'b@example.com', 'x');
+		INSERT INTO Posts (id, user_id, content, image) VALUES (1, 1, 'with', 'posts/a.png'), (2, 1, 'without', ''), (3, 1, 'null', NULL);
+		INSERT INTO Comments (id, post_id, user_id, content, image) VALUES (1, 1, 2, 'with', 'comments/b.png'), (2, 1, 2, 'without', NULL);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateTestDB(t, db, "000043", "")
+
+	s := NewPostStore(db)
+	post, err := s.GetPostByID(1)
+	if err != nil || len(post.Attachments) != 1 || post.Attachments[0].Path != "posts/a.png" {
+		t.Fatalf("post 1 attachments = %+v, %v", post, err)
+	}
+	comment, err := s.GetCommentByID(1)
+	if err != nil || len(comment.Attachments) != 1 || comment.Attachments[0].Path != "comments/b.png" {
+		t.Fatalf("comment 1 attachments = %+v, %v", comment, err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Post_Attachments").Scan(&count); err != nil || count != 2 {
+		t.Errorf("attachments = %d, %v; want 2", count, err)
+	}
+}
+
+func TestPostGalleries(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	if _, err := db.Exec(`INSERT INTO Users (id, email, password, nickname) VALUES (1, 'a@example.com', 'x', 'ada')`); err != nil {
+		t.Fatal(err)
+	}
+	s := NewPostStore(db)
+	paths := func(attachments []models.Attachment) string {
+		var paths []string
+		for _, a := range attachments {
+			paths = append(paths, fmt.Sprintf("%s@%d", a.Path, a.Position))
+		}
+		return fmt.Sprint(paths)
+	}
+
+	post := &models.Post{UserID: 1, Content: "gallery", Privacy: "public", Attachments: []models.Attachment{
+		{Path: "posts/a.png", AltText: "first", Width: 4, Height: 3},
+		{Path: "posts/b.png"},
+		{Path: "posts/c.png"},
+	}}
+	postID, err := s.CreatePost(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetPostByID(postID)
+	if err != nil || paths(got.Attachments) != "[posts/a.png@0 posts/b.png@1 posts/c.png@2]" || got.Image != "posts/a.png" {
+		t.Fatalf("created gallery = %s, image %q, %v", paths(got.Attachments), got.Image, err)
+	}
+	if a := got.Attachments[0]; a.AltText != "first" || a.Width != 4 || a.Height != 3 {
+		t.Errorf("first attachment = %+v", a)
+	}
+
+	// drop b, put c first with new alt text, and add d
+	c, a := got.Attachments[2], got.Attachments[0]
+	c.AltText = "now first"
+	updated, err := s.UpdatePost(postID, "edited", []models.Attachment{c, a, {Path: "posts/d.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths(updated.Attachments) != "[posts/c.png@0 posts/a.png@1 posts/d.png@2]" || updated.Image != "posts/c.png" || updated.Attachments[0].AltText != "now first" {
+		t.Errorf("edited gallery = %s, image %q", paths(updated.Attachments), updated.Image)
+	}
+
+	// attachments of another post cannot be pulled in
+	other, err := s.CreatePost(&models.Post{UserID: 1, Content: "other", Privacy: "public", Attachments: []models.Attachment{{Path: "posts/e.png"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stolen, _ := s.GetPostByID(other)
+	if _, err := s.UpdatePost(postID, "edited", append(updated.Attachments, stolen.Attachments...)); err == nil {
+		t.Error("kept an attachment of another post")
+	}
+
+	comment := &models.Comment{PostID: postID, UserID: 1, Content: "reply", Attachments: []models.Attachment{{Path: "comments/f.png"}}}
+	commentID, err := s.CreateComment(comment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptied, err := s.UpdateComment(commentID, "reply", nil)
+	if err != nil || len(emptied.Attachments) != 0 || emptied.Image != "" {
+		t.Errorf("emptied comment = %+v, %v", emptied, err)
+	}
+
+	if err := s.DeletePost(postID); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM Post_Attachments").Scan(&count); err != nil || count != 1 {
+		t.Errorf("attachments left = %d, %v; want the other post's", count, err)
+	}
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func TestFeedPages(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES (1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob');
+		INSERT INTO Posts (id, user_id, content, image, privacy, created_at) VALUES
+			(1, 2, 'one', '', 'public', '2020-01-01 10:00:00'),
+			(2, 2, 'two', '', 'public', '2020-01-01 11:00:00'),
+			(3, 2, 'three', '', 'public', '2020-01-01 11:00:00'),
+			(4, 2, 'hidden', '', 'private', '2020-01-01 11:30:00'),
+			(5, 2, 'five', '', 'public', '2020-01-01 12:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPostStore(db)
+	ids := func(posts []*models.Post) string {
+		var ids []int64
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	// posts 2 and 3 share a timestamp, so the id breaks the tie
+	first, cursors, err := s.GetFeedPage(1, nil, nil, 2)
+	if err != nil || ids(first) != "[5 3]" {
+		t.Fatalf("first page = %s, %v", ids(first), err)
+	}
+	second, _, err := s.GetFeedPage(1, &cursors[1], nil, 2)
+	if err != nil || ids(second) != "[2 1]" {
+		t.Fatalf("page before post 3 = %s, %v", ids(second), err)
+	}
+
+	// a post arriving later shows up above the first page, without shifting the ones below it
+	if _, err := s.CreatePost(&models.Post{UserID: 2, Content: "six", Privacy: "public"}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _, _ := s.GetFeedPage(1, &cursors[1], nil, 2); ids(again) != "[2 1]" {
+		t.Errorf("page before post 3 after a new post = %s", ids(again))
+	}
+	if n, err := s.CountFeedAfter(1, cursors[0]); err != nil || n != 1 {
+		t.Errorf("CountFeedAfter = %d, %v; want 1", n, err)
+	}
+	newer, newerCursors, err := s.GetFeedPage(1, nil, &cursors[1], 5)
+	if err != nil || ids(newer) != "[6 5]" || newerCursors[1].ID != 5 {
+		t.Errorf("page after post 3 = %s, %v", ids(newer), err)
+	}
+	if closest, _, _ := s.GetFeedPage(1, nil, &models.FeedCursor{CreatedAt: "2020-01-01 10:00:00", ID: 1}, 2); ids(closest) != "[3 2]" {
+		t.Errorf("after= pages start next to the cursor: got %s", ids(closest))
+	}
+
+	if n, err := s.CountFeedAfter(2, models.FeedCursor{CreatedAt: "2020-01-01 11:00:00", ID: 3}); err != nil || n != 3 {
+		t.Errorf("the author sees their private post: CountFeedAfter = %d, %v", n, err)
+	}
+}
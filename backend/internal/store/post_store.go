@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"slices"
 	"time"
 
 	"github.com/tajjjjr/social-network/backend/internal/models"
@@ -15,34 +16,60 @@ func NewPostStore(db *sql.DB) *PostStore {
 	return &PostStore{DB: db}
 }
 
+// CreatePost adds a post along with its gallery, and sets the ids of the
+// post's attachments.
 func (s *PostStore) CreatePost(post *models.Post) (int64, error) {
-	stmt, err := s.DB.Prepare("INSERT INTO Posts (user_id, content, image, privacy, created_at) VALUES (?, ?, ?, ?, ?)")
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(post.UserID, post.Content, post.Image, post.Privacy, time.Now())
+	now := time.Now()
+	res, err := tx.Exec("INSERT INTO Posts (user_id, content, image, privacy, created_at) VALUES (?, ?, ?, ?, ?)",
+		post.UserID, post.Content, firstImage(post.Attachments), post.Privacy, now)
 	if err != nil {
 		return 0, err
 	}
-
-	return res.LastInsertId()
+	postID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceAttachments(tx, postOwner, postID, post.UserID, post.Attachments); err != nil {
+		return 0, err
+	}
+	if err := indexTags(tx, postOwner, postID, post.UserID, post.Content, now); err != nil {
+		return 0, err
+	}
+	return postID, tx.Commit()
 }
 
+// CreateComment adds a comment along with its gallery, and sets the ids of
+// the comment's attachments.
 func (s *PostStore) CreateComment(comment *models.Comment) (int64, error) {
-	stmt, err := s.DB.Prepare("INSERT INTO Comments (post_id, user_id, content, image, created_at) VALUES (?, ?, ?, ?, ?)")
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(comment.PostID, comment.UserID, comment.Content, comment.Image, time.Now())
+	now := time.Now()
+	res, err := tx.Exec("INSERT INTO Comments (post_id, user_id, content, image, created_at) VALUES (?, ?, ?, ?, ?)",
+		comment.PostID, comment.UserID, comment.Content, firstImage(comment.Attachments), now)
 	if err != nil {
 		return 0, err
 	}
-
-	return res.LastInsertId()
+	commentID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceAttachments(tx, commentOwner, commentID, comment.UserID, comment.Attachments); err != nil {
+		return 0, err
+	}
+	if err := indexTags(tx, commentOwner, commentID, comment.UserID, comment.Content, now); err != nil {
+		return 0, err
+	}
+	return commentID, tx.Commit()
 }
 
 func (s *PostStore) GetPostByID(id int64) (*models.Post, error) {
@@ -61,22 +88,38 @@ func (s *PostStore) GetPostByID(id int64) (*models.Post, error) {
 		post.IsEdited = true
 	}
 
+	if err := loadPostAttachments(s.DB, &post); err != nil {
+		return nil, err
+	}
 	return &post, nil
 }
 
-func (s *PostStore) UpdatePost(postID int64, content, imagePath string) (*models.Post, error) {
-	// Update the post with new content, image, and set updated_at timestamp
-	stmt, err := s.DB.Prepare("UPDATE Posts SET content = ?, image = ?, updated_at = ? WHERE id = ?")
+// UpdatePost sets a post's content and replaces its gallery with
+// attachments, in that order; see replaceAttachments.
+func (s *PostStore) UpdatePost(postID int64, content string, attachments []models.Attachment) (*models.Post, error) {
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
+	// Update the post with new content, image, and set updated_at timestamp
+	var userID int64
 	now := time.Now()
-	_, err = stmt.Exec(content, imagePath, now, postID)
+	err = tx.QueryRow("UPDATE Posts SET content = ?, image = ?, updated_at = ? WHERE id = ? RETURNING user_id",
+		content, firstImage(attachments), now, postID).Scan(&userID)
 	if err != nil {
 		return nil, err
 	}
+	if err := replaceAttachments(tx, postOwner, postID, userID, attachments); err != nil {
+		return nil, err
+	}
+	if err := indexTags(tx, postOwner, postID, userID, content, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	// Fetch and return the updated post with author information
 	row := s.DB.QueryRow(`
@@ -102,6 +145,9 @@ func (s *PostStore) UpdatePost(postID int64, content, imagePath string) (*models
 		post.IsEdited = true
 	}
 
+	if err := loadPostAttachments(s.DB, &post); err != nil {
+		return nil, err
+	}
 	return &post, nil
 }
 
@@ -109,55 +155,119 @@ func (s *PostStore) GetPosts(userID int64) ([]*models.Post, error) {
 	return s.GetPostsPaginated(userID, 0, 0)
 }
 
-func (s *PostStore) GetPostsPaginated(userID int64, limit, offset int) ([]*models.Post, error) {
+// feedQuery selects userID's home feed: the posts they may see, less those
+// of users or with keywords they muted. Callers append conditions with AND,
+// then the order and limit. Rows are read by scanFeed.
+func feedQuery(userID int64) (string, []any) {
+	visibility, visibilityArgs := visiblePostsClause(userID)
+	muted, mutedArgs := notMutedClause("p.user_id", "p.content", userID, time.Now())
 	query := `
         SELECT p.id, p.user_id, p.content, p.image, p.privacy, p.created_at, p.updated_at,
                u.first_name, u.last_name, u.nickname, u.avatar,
                COALESCE(likes.count, 0) as likes_count,
                COALESCE(dislikes.count, 0) as dislikes_count,
-               ur.reaction_type as user_reaction
+               ur.reaction_type as user_reaction,
+               CAST(p.created_at AS TEXT) as cursor_created_at
         FROM Posts p
         JOIN Users u ON p.user_id = u.id
         LEFT JOIN (SELECT post_id, COUNT(*) as count FROM Post_Reactions WHERE reaction_type = 'like' GROUP BY post_id) likes ON p.id = likes.post_id
         LEFT JOIN (SELECT post_id, COUNT(*) as count FROM Post_Reactions WHERE reaction_type = 'dislike' GROUP BY post_id) dislikes ON p.id = dislikes.post_id
         LEFT JOIN Post_Reactions ur ON p.id = ur.post_id AND ur.user_id = ?
-        WHERE p.privacy = 'public'
-        OR (p.privacy = 'almost_private' AND p.user_id = ? OR p.user_id IN (
-            SELECT follower_id FROM Followers WHERE followee_id = ?
-        ))
-        OR (p.privacy = 'private' AND EXISTS (
-            SELECT 1 FROM Post_visibility pv WHERE pv.post_id = p.id AND pv.viewer_id = ?
-        ))
-        ORDER BY p.created_at DESC`
+        WHERE ` + visibility + ` AND ` + muted
+
+	args := append([]interface{}{userID}, visibilityArgs...)
+	return query, append(args, mutedArgs...)
+}
 
+// GetPostsPaginated returns a page of userID's home feed by offset. It only
+// backs the deprecated page mode of GET /posts; GetFeedPage pages by cursor.
+func (s *PostStore) GetPostsPaginated(userID int64, limit, offset int) ([]*models.Post, error) {
+	query, args := feedQuery(userID)
+	query += ` ORDER BY p.created_at DESC`
 	if limit > 0 {
 		query += ` LIMIT ? OFFSET ?`
-		rows, err := s.DB.Query(query, userID, userID, userID, userID, limit, offset)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-		return s.scanPosts(rows)
+		args = append(args, limit, offset)
 	}
 
-	rows, err := s.DB.Query(query, userID, userID, userID, userID)
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	posts, _, err := scanFeed(rows)
 	if err != nil {
 		return nil, err
 	}
+	return posts, loadPostAttachments(s.DB, posts...)
+}
+
+// GetFeedPage returns up to limit posts of userID's home feed, newest first,
+// with the cursor of each. With before, the page holds the posts right below
+// it; with after, the posts right above it, so the newest may be left for a
+// later page. Without either it starts at the newest post.
+func (s *PostStore) GetFeedPage(userID int64, before, after *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error) {
+	query, args := feedQuery(userID)
+	switch {
+	case after != nil:
+		query += ` AND (p.created_at, p.id) > (?, ?) ORDER BY p.created_at, p.id LIMIT ?`
+		args = append(args, after.CreatedAt, after.ID, limit)
+	case before != nil:
+		query += ` AND (p.created_at, p.id) < (?, ?) ORDER BY p.created_at DESC, p.id DESC LIMIT ?`
+		args = append(args, before.CreatedAt, before.ID, limit)
+	default:
+		query += ` ORDER BY p.created_at DESC, p.id DESC LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer rows.Close()
-	return s.scanPosts(rows)
+	posts, cursors, err := scanFeed(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	if after != nil {
+		slices.Reverse(posts)
+		slices.Reverse(cursors)
+	}
+	if err := loadPostAttachments(s.DB, posts...); err != nil {
+		return nil, nil, err
+	}
+	return posts, cursors, nil
 }
 
-func (s *PostStore) scanPosts(rows *sql.Rows) ([]*models.Post, error) {
+// CountFeedAfter returns how many posts of userID's home feed are newer than after.
+func (s *PostStore) CountFeedAfter(userID int64, after models.FeedCursor) (int, error) {
+	visibility, args := visiblePostsClause(userID)
+	muted, mutedArgs := notMutedClause("p.user_id", "p.content", userID, time.Now())
+	args = append(args, mutedArgs...)
+	row := s.DB.QueryRow(`
+        SELECT COUNT(*)
+        FROM Posts p
+        WHERE `+visibility+` AND `+muted+` AND (p.created_at, p.id) > (?, ?)`,
+		append(args, after.CreatedAt, after.ID)...)
+
+	var count int
+	err := row.Scan(&count)
+	return count, err
+}
+
+// scanFeed reads the rows of feedQuery, returning each post with its cursor.
+func scanFeed(rows *sql.Rows) ([]*models.Post, []models.FeedCursor, error) {
 	var posts []*models.Post
+	var cursors []models.FeedCursor
 	for rows.Next() {
 		var post models.Post
+		var cursor models.FeedCursor
 		var updatedAt sql.NullTime
 		var userReaction sql.NullString
 		if err := rows.Scan(&post.ID, &post.UserID, &post.Content, &post.Image, &post.Privacy,
 			&post.CreatedAt, &updatedAt, &post.Author.FirstName, &post.Author.LastName,
-			&post.Author.Nickname, &post.Author.Avatar, &post.LikesCount, &post.DislikesCount, &userReaction); err != nil {
-			return nil, err
+			&post.Author.Nickname, &post.Author.Avatar, &post.LikesCount, &post.DislikesCount, &userReaction,
+			&cursor.CreatedAt); err != nil {
+			return nil, nil, err
 		}
 
 		// Set the updated_at field and is_edited flag
@@ -171,24 +281,21 @@ func (s *PostStore) scanPosts(rows *sql.Rows) ([]*models.Post, error) {
 			post.UserReaction = &userReaction.String
 		}
 
+		cursor.ID = post.ID
 		posts = append(posts, &post)
+		cursors = append(cursors, cursor)
 	}
-	return posts, nil
+	return posts, cursors, rows.Err()
 }
 
-// GetPostsCount returns the total count of posts visible to a user
+// GetPostsCount returns the number of posts in a user's feed
 func (s *PostStore) GetPostsCount(userID int64) (int, error) {
+	visibility, args := visiblePostsClause(userID)
+	muted, mutedArgs := notMutedClause("p.user_id", "p.content", userID, time.Now())
 	row := s.DB.QueryRow(`
         SELECT COUNT(*)
         FROM Posts p
-        WHERE p.privacy = 'public'
-        OR (p.privacy = 'almost_private' AND p.user_id = ? OR p.user_id IN (
-            SELECT follower_id FROM Followers WHERE followee_id = ?
-        ))
-        OR (p.privacy = 'private' AND EXISTS (
-            SELECT 1 FROM Post_visibility pv WHERE pv.post_id = p.id AND pv.viewer_id = ?
-        ))
-    `, userID, userID, userID)
+        WHERE `+visibility+` AND `+muted, append(args, mutedArgs...)...)
 
 	var count int
 	err := row.Scan(&count)
@@ -207,9 +314,9 @@ func (s *PostStore) GetCommentsByPostID(postID, userID int64) ([]*models.Comment
         LEFT JOIN (SELECT comment_id, COUNT(*) as count FROM Comment_Reactions WHERE reaction_type = 'like' GROUP BY comment_id) likes ON c.id = likes.comment_id
         LEFT JOIN (SELECT comment_id, COUNT(*) as count FROM Comment_Reactions WHERE reaction_type = 'dislike' GROUP BY comment_id) dislikes ON c.id = dislikes.comment_id
         LEFT JOIN Comment_Reactions ur ON c.id = ur.comment_id AND ur.user_id = ?
-        WHERE c.post_id = ?
+        WHERE c.post_id = ? AND `+notBlockedClause("c.user_id")+`
         ORDER BY c.created_at DESC
-    `, userID, postID)
+    `, userID, postID, userID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -239,13 +346,40 @@ func (s *PostStore) GetCommentsByPostID(postID, userID int64) ([]*models.Comment
 
 		comments = append(comments, &comment)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := loadCommentAttachments(s.DB, comments...); err != nil {
+		return nil, err
+	}
 	return comments, nil
 }
 
+// DeletePost removes a post with its gallery, and takes it and the comments
+// on it out of the tag index and the mentions.
 func (s *PostStore) DeletePost(postID int64) error {
-	_, err := s.DB.Exec("DELETE FROM Posts WHERE id = ?", postID)
-	return err
+	return s.deleteWith(postID,
+		"DELETE FROM Post_Attachments WHERE post_id = ?",
+		"DELETE FROM Post_Tags WHERE post_id = ? OR comment_id IN (SELECT id FROM Comments WHERE post_id = ?)",
+		"DELETE FROM Mentions WHERE post_id = ? OR comment_id IN (SELECT id FROM Comments WHERE post_id = ?)",
+		"DELETE FROM Posts WHERE id = ?",
+	)
+}
+
+// deleteWith runs statements that each take id once per placeholder in one transaction.
+func (s *PostStore) deleteWith(id int64, statements ...string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, repeatArg(stmt, id)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // AddPostViewers adds viewers to a private post
@@ -279,7 +413,7 @@ func (s *PostStore) SearchUsers(query string, currentUserID int64) ([]*models.Us
 	rows, err := s.DB.Query(`
 		SELECT id, first_name, last_name, nickname, avatar
 		FROM Users
-		WHERE id != ? AND (
+		WHERE id != ? AND `+notBlockedClause("id")+` AND (
 			first_name LIKE ? OR
 			last_name LIKE ? OR
 			nickname LIKE ? OR
@@ -294,7 +428,7 @@ func (s *PostStore) SearchUsers(query string, currentUserID int64) ([]*models.Us
 			END,
 			first_name, last_name
 		LIMIT 10
-	`, currentUserID, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery)
+	`, currentUserID, currentUserID, currentUserID, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery, searchQuery)
 
 	if err != nil {
 		return nil, err
@@ -314,20 +448,32 @@ func (s *PostStore) SearchUsers(query string, currentUserID int64) ([]*models.Us
 	return users, nil
 }
 
-// UpdateComment updates a comment's content and image, setting the updated_at timestamp
-func (s *PostStore) UpdateComment(commentID int64, content, imagePath string) (*models.Comment, error) {
-	// Update the comment with new content, image, and set updated_at timestamp
-	stmt, err := s.DB.Prepare("UPDATE Comments SET content = ?, image = ?, updated_at = ? WHERE id = ?")
+// UpdateComment sets a comment's content and replaces its gallery with
+// attachments, like UpdatePost.
+func (s *PostStore) UpdateComment(commentID int64, content string, attachments []models.Attachment) (*models.Comment, error) {
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
+	// Update the comment with new content, image, and set updated_at timestamp
+	var userID int64
 	now := time.Now()
-	_, err = stmt.Exec(content, imagePath, now, commentID)
+	err = tx.QueryRow("UPDATE Comments SET content = ?, image = ?, updated_at = ? WHERE id = ? RETURNING user_id",
+		content, firstImage(attachments), now, commentID).Scan(&userID)
 	if err != nil {
 		return nil, err
 	}
+	if err := replaceAttachments(tx, commentOwner, commentID, userID, attachments); err != nil {
+		return nil, err
+	}
+	if err := indexTags(tx, commentOwner, commentID, userID, content, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	// Fetch and return the updated comment with author information
 	row := s.DB.QueryRow(`
@@ -353,13 +499,20 @@ func (s *PostStore) UpdateComment(commentID int64, content, imagePath string) (*
 		comment.IsEdited = true
 	}
 
+	if err := loadCommentAttachments(s.DB, &comment); err != nil {
+		return nil, err
+	}
 	return &comment, nil
 }
 
-// DeleteComment removes a comment from the database
+// DeleteComment removes a comment with its gallery, its uses of tags and its mentions
 func (s *PostStore) DeleteComment(commentID int64) error {
-	_, err := s.DB.Exec("DELETE FROM Comments WHERE id = ?", commentID)
-	return err
+	return s.deleteWith(commentID,
+		"DELETE FROM Post_Attachments WHERE comment_id = ?",
+		"DELETE FROM Post_Tags WHERE comment_id = ?",
+		"DELETE FROM Mentions WHERE comment_id = ?",
+		"DELETE FROM Comments WHERE id = ?",
+	)
 }
 
 // GetCommentByID retrieves a specific comment by its ID with author information
@@ -387,5 +540,8 @@ func (s *PostStore) GetCommentByID(commentID int64) (*models.Comment, error) {
 		comment.IsEdited = true
 	}
 
+	if err := loadCommentAttachments(s.DB, &comment); err != nil {
+		return nil, err
+	}
 	return &comment, nil
 }
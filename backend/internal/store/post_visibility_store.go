@@ -0,0 +1,53 @@
+package store
+
+// visiblePostsClause returns a WHERE fragment restricting the Posts alias "p" to
+// rows the viewer may see, together with its arguments.
+// It is the SQL form of service.PostVisibilityPolicy and must follow the same rules:
+// authors see their own posts, everyone sees public posts, accepted followers see
+// almost_private posts and only listed viewers see private posts. Nobody sees the
+// posts of a user they blocked or who blocked them.
+func visiblePostsClause(viewerID int64) (string, []interface{}) {
+	clause := `((
+            p.user_id = ?
+            OR p.privacy = 'public'
+            OR (p.privacy = 'almost_private' AND EXISTS (
+                SELECT 1 FROM Followers f
+                WHERE f.follower_id = ? AND f.followee_id = p.user_id AND f.status = 'accepted'
+            ))
+            OR (p.privacy = 'private' AND EXISTS (
+                SELECT 1 FROM Post_Visibility pv WHERE pv.post_id = p.id AND pv.viewer_id = ?
+            ))
+        ) AND ` + notBlockedClause("p.user_id") + `)`
+	return clause, []interface{}{viewerID, viewerID, viewerID, viewerID, viewerID}
+}
+
+// IsAcceptedFollower reports whether followerID follows followeeID with an accepted request.
+func (s *PostStore) IsAcceptedFollower(followerID, followeeID int64) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM Followers WHERE follower_id = ? AND followee_id = ? AND status = 'accepted')",
+		followerID, followeeID,
+	).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// IsPostViewer reports whether viewerID was listed as a viewer of a private post.
+func (s *PostStore) IsPostViewer(postID, viewerID int64) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM Post_Visibility WHERE post_id = ? AND viewer_id = ?)",
+		postID, viewerID,
+	).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (s *PostStore) IsBlocked(userID, otherID int64) (bool, error) {
+	return isBlocked(s.DB, userID, otherID)
+}
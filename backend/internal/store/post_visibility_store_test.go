@@ -0,0 +1,109 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupVisibilityTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := `
+	CREATE TABLE Posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL, privacy TEXT NOT NULL);
+	CREATE TABLE Followers (follower_id INTEGER NOT NULL, followee_id INTEGER NOT NULL, status TEXT NOT NULL);
+	CREATE TABLE Post_Visibility (post_id INTEGER NOT NULL, viewer_id INTEGER NOT NULL);
+	CREATE TABLE Blocks (blocker_id INTEGER NOT NULL, blocked_id INTEGER NOT NULL);
+
+	-- user 1 is the author, 2 an accepted follower, 3 a pending follower, 4 a listed viewer, 5 a stranger
+	INSERT INTO Posts (id, user_id, privacy) VALUES (10, 1, 'public'), (11, 1, 'almost_private'), (12, 1, 'private');
+	INSERT INTO Followers (follower_id, followee_id, status) VALUES (2, 1, 'accepted'), (3, 1, 'pending');
+	-- the author following someone must not expose almost_private posts to them
+	INSERT INTO Followers (follower_id, followee_id, status) VALUES (1, 5, 'accepted');
+	INSERT INTO Post_Visibility (post_id, viewer_id) VALUES (12, 4);
+	-- user 6 was listed on the private post and followed the author, who then blocked them
+	INSERT INTO Followers (follower_id, followee_id, status) VALUES (6, 1, 'accepted');
+	INSERT INTO Post_Visibility (post_id, viewer_id) VALUES (12, 6);
+	INSERT INTO Blocks (blocker_id, blocked_id) VALUES (1, 6);`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestVisiblePostsClause(t *testing.T) {
+	db := setupVisibilityTestDB(t)
+	defer db.Close()
+
+	tests := []struct {
+		name   string
+		viewer int64
+		want   []int64
+	}{
+		{"author", 1, []int64{10, 11, 12}},
+		{"accepted follower", 2, []int64{10, 11}},
+		{"pending follower", 3, []int64{10}},
+		{"listed viewer", 4, []int64{10, 12}},
+		{"stranger", 5, []int64{10}},
+		{"blocked user", 6, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args := visiblePostsClause(tt.viewer)
+			rows, err := db.Query("SELECT p.id FROM Posts p WHERE "+clause+" ORDER BY p.id", args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+
+			var got []int64
+			for rows.Next() {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, id)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got posts %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got posts %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPostStoreVisibilityLookups(t *testing.T) {
+	db := setupVisibilityTestDB(t)
+	defer db.Close()
+	store := NewPostStore(db)
+
+	if ok, err := store.IsAcceptedFollower(2, 1); err != nil || !ok {
+		t.Errorf("IsAcceptedFollower(2, 1) = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.IsAcceptedFollower(3, 1); err != nil || ok {
+		t.Errorf("IsAcceptedFollower(3, 1) = %v, %v; want false", ok, err)
+	}
+	if ok, err := store.IsPostViewer(12, 4); err != nil || !ok {
+		t.Errorf("IsPostViewer(12, 4) = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.IsPostViewer(12, 5); err != nil || ok {
+		t.Errorf("IsPostViewer(12, 5) = %v, %v; want false", ok, err)
+	}
+	if ok, err := store.IsBlocked(6, 1); err != nil || !ok {
+		t.Errorf("IsBlocked(6, 1) = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.IsBlocked(5, 1); err != nil || ok {
+		t.Errorf("IsBlocked(5, 1) = %v, %v; want false", ok, err)
+	}
+}
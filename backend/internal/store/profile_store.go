@@ -140,96 +140,32 @@ func (s *ProfileStore) GetPostsOfUser(id int64) ([]models.Post, error) {
 
 		posts = append(posts, post)
 	}
-
-	return posts, nil
-}
-
-func (followstore *ProfileStore) GetUserFollowers(userid int64) (models.FollowListResponse, error) {
-	var followersList models.FollowListResponse
-	rows, err := followstore.DB.Query(`
-		SELECT u.id, u.first_name, u.last_name, u.avatar 
-		FROM Users u 
-		INNER JOIN Followers f ON u.id = f.follower_id 
-		WHERE f.followee_id = ? AND f.status = 'accepted'`, userid)
-	if err != nil {
-		return followersList, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var follower models.FollowUser
-		var firstName, lastName, avatar sql.NullString
-		err := rows.Scan(&follower.FollowerID, &firstName, &lastName, &avatar)
-		if err != nil {
-			return followersList, err
-		}
-
-		if firstName.Valid {
-			follower.FirstName = firstName.String
-		}
-		if lastName.Valid {
-			follower.LastName = lastName.String
-		}
-		if avatar.Valid {
-			follower.Avatar = avatar.String
-		}
-
-		followersList.Followers = append(followersList.Followers, follower)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return followersList, err
+	ids := make([]int64, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
 	}
-
-	return followersList, nil
-}
-
-func (followstore *ProfileStore) GetUserFollowees(userid int64) (models.FollowListResponse, error) {
-	var followersList models.FollowListResponse
-	rows, err := followstore.DB.Query(`
-		SELECT u.id, u.first_name, u.last_name, u.avatar 
-		FROM Users u 
-		INNER JOIN Followers f ON u.id = f.followee_id 
-		WHERE f.follower_id = ? AND f.status = 'accepted'`, userid)
+	galleries, err := attachmentsOf(s.DB, postOwner, ids)
 	if err != nil {
-		return followersList, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var follower models.FollowUser
-		var firstName, lastName, avatar sql.NullString
-		err := rows.Scan(&follower.FollowerID, &firstName, &lastName, &avatar)
-		if err != nil {
-			return followersList, err
-		}
-
-		if firstName.Valid {
-			follower.FirstName = firstName.String
-		}
-		if lastName.Valid {
-			follower.LastName = lastName.String
-		}
-		if avatar.Valid {
-			follower.Avatar = avatar.String
-		}
-
-		followersList.Followers = append(followersList.Followers, follower)
+		return nil, err
 	}
-
-	if err = rows.Err(); err != nil {
-		return followersList, err
+	for i := range posts {
+		posts[i].Attachments = galleries[posts[i].ID]
 	}
-
-	return followersList, nil
+	return posts, nil
 }
 
 func (pr *ProfileStore)GetUserPostPhotos(userId int64) ([]models.Photo, error) {
 	var photos []models.Photo
 	rows, err := pr.DB.Query(`
-		SELECT p.image
-		FROM Posts p
-		WHERE p.user_id = ?`, userId)
+		SELECT a.post_id, a.path
+		FROM Post_Attachments a
+		JOIN Posts p ON p.id = a.post_id
+		WHERE p.user_id = ?
+		ORDER BY p.id, a.position`, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +174,7 @@ func (pr *ProfileStore)GetUserPostPhotos(userId int64) ([]models.Photo, error) {
 	for rows.Next() {
 		var photo models.Photo
 		var image sql.NullString
-		err := rows.Scan(&image)
+		err := rows.Scan(&photo.PostID, &image)
 		if err != nil {
 			return nil, err
 		}
@@ -259,9 +195,11 @@ func (pr *ProfileStore)GetUserPostPhotos(userId int64) ([]models.Photo, error) {
 func (pr *ProfileStore)GetUserCommentPhotos(userId int64) ([]models.Photo, error) {
 	var photos []models.Photo
 	rows, err := pr.DB.Query(`
-		SELECT c.image
-		FROM Comments c
-		WHERE c.user_id = ?`, userId)
+		SELECT c.post_id, a.path
+		FROM Post_Attachments a
+		JOIN Comments c ON c.id = a.comment_id
+		WHERE c.user_id = ?
+		ORDER BY c.id, a.position`, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -270,7 +208,7 @@ func (pr *ProfileStore)GetUserCommentPhotos(userId int64) ([]models.Photo, error
 	for rows.Next() {
 		var photo models.Photo
 		var image sql.NullString
-		err := rows.Scan(&image)
+		err := rows.Scan(&photo.PostID, &image)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+// Snippets come back from SQLite with matches between these control
+// characters, which cannot be typed into a post, so that the rest of the
+// text can be escaped before the matches are marked up.
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+type searchStore struct {
+	db        *sql.DB
+	available bool
+}
+
+// NewSearchStore returns a SearchStore over the full-text indexes, which
+// only exist when SQLite was built with FTS5.
+func NewSearchStore(db *sql.DB) SearchStore {
+	var available bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'Posts_FTS')").Scan(&available)
+	return &searchStore{db: db, available: err == nil && available}
+}
+
+// SearchAvailable reports whether the full-text indexes exist.
+func (s *searchStore) SearchAvailable() bool {
+	return s.available
+}
+
+// SearchPosts returns up to limit posts and comments matching the FTS5 query
+// match, best first by bm25 and newest first among equals, skipping offset
+// results. Only content userID may see counts, less any they muted; a
+// comment also needs its author not to be blocked from userID.
+func (s *searchStore) SearchPosts(userID int64, match string, offset, limit int) ([]models.SearchResult, error) {
+	now := time.Now()
+	postVisible, postArgs := visiblePostsClause(userID)
+	postMuted, postMutedArgs := notMutedClause("p.user_id", "p.content", userID, now)
+	commentMuted, commentMutedArgs := notMutedClause("c.user_id", "c.content", userID, now)
+
+	query := `
+		SELECT kind, post_id, comment_id, snippet, results.created_at, u.id, u.first_name, u.last_name, u.nickname, u.avatar
+		FROM (
+			SELECT 'post' AS kind, p.id AS post_id, 0 AS comment_id,
+				snippet(Posts_FTS, 0, '` + matchStart + `', '` + matchEnd + `', '…', 16) AS snippet,
+				bm25(Posts_FTS) AS rank, p.created_at, p.user_id
+			FROM Posts_FTS
+			JOIN Posts p ON p.id = Posts_FTS.rowid
+			WHERE Posts_FTS MATCH ? AND ` + postVisible + ` AND ` + postMuted + `
+			UNION ALL
+			SELECT 'comment', c.post_id, c.id,
+				snippet(Comments_FTS, 0, '` + matchStart + `', '` + matchEnd + `', '…', 16),
+				bm25(Comments_FTS), c.created_at, c.user_id
+			FROM Comments_FTS
+			JOIN Comments c ON c.id = Comments_FTS.rowid
+			JOIN Posts p ON p.id = c.post_id
+			WHERE Comments_FTS MATCH ? AND ` + postVisible + ` AND ` + notBlockedClause("c.user_id") + ` AND ` + commentMuted + `
+		) results
+		JOIN Users u ON u.id = results.user_id
+		ORDER BY rank, results.created_at DESC, post_id DESC, comment_id DESC
+		LIMIT ? OFFSET ?`
+
+	args := []any{match}
+	args = append(args, postArgs...)
+	args = append(args, postMutedArgs...)
+	args = append(args, match)
+	args = append(args, postArgs...)
+	args = append(args, userID, userID)
+	args = append(args, commentMutedArgs...)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching posts: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Kind, &r.PostID, &r.CommentID, &r.Snippet, &r.CreatedAt,
+			&r.Author.ID, &r.Author.FirstName, &r.Author.LastName, &r.Author.Nickname, &r.Author.Avatar); err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		r.Snippet = markMatches(r.Snippet)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// markMatches escapes a snippet for HTML and wraps its matches in <mark>.
+func markMatches(snippet string) string {
+	return strings.NewReplacer(matchStart, "<mark>", matchEnd, "</mark>").Replace(html.EscapeString(snippet))
+}
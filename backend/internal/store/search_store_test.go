@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tajjjjr/social-network/backend/pkg/db/sqlite"
+)
+
+func TestSearchPosts(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if !sqlite.HasFTS5(db) {
+		t.Skip("SQLite was built without FTS5; run with -tags sqlite_fts5")
+	}
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// content written before the index exists is backfilled by its migration
+	migrateTestDB(t, db, "", "000046")
+	_, err = db.Exec(`
+		INSERT INTO Users (id, email, password, nickname) VALUES
+			(1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob'), (3, 'c@example.com', 'x', 'cy'), (4, 'd@example.com', 'x', 'dee');
+		INSERT INTO Blocks (blocker_id, blocked_id) VALUES (1, 4);
+		INSERT INTO Posts (id, user_id, content, privacy, created_at) VALUES
+			(1, 2, 'Tomatoes, tomatoes & <more> tomatoes', 'public', '2024-05-01 10:00:00'),
+			(2, 3, 'secret tomatoes', 'almost_private', '2024-05-01 11:00:00'),
+			(3, 4, 'tomatoes from someone blocked', 'public', '2024-05-01 12:00:00');
+		INSERT INTO Comments (id, post_id, user_id, content, created_at) VALUES
+			(1, 1, 2, 'a comment that is not about the garden at all', '2024-05-01 13:00:00'),
+			(2, 1, 4, 'tomatoes, says someone blocked', '2024-05-01 14:00:00');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrateTestDB(t, db, "000046", "")
+
+	// later writes reach the index through its triggers
+	_, err = db.Exec(`
+		INSERT INTO Posts (id, user_id, content, privacy, created_at) VALUES
+			(4, 2, 'a much longer post that mentions tomatoes only once among many other words about the garden', 'public', '2024-05-02 10:00:00'),
+			(5, 2, 'tomatoes soon to be deleted', 'public', '2024-05-02 11:00:00');
+		UPDATE Comments SET content = 'now it is about tomatoes after all' WHERE id = 1;
+		DELETE FROM Posts WHERE id = 5;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSearchStore(db)
+	if !s.SearchAvailable() {
+		t.Fatal("the search index is missing")
+	}
+	found := func(offset, limit int) string {
+		results, err := s.SearchPosts(1, `"tomato"*`, offset, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range results {
+			id := fmt.Sprintf("%s:%d", r.Kind, r.PostID)
+			if r.Kind == "comment" {
+				id += fmt.Sprintf(":%d", r.CommentID)
+			}
+			ids = append(ids, id)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	// the post saying it three times ranks first; hidden posts and blocked authors are left out
+	if got := found(0, 10); got != "post:1 comment:1:1 post:4" {
+		t.Errorf("results = %q", got)
+	}
+	if got := found(1, 1); got != "comment:1:1" {
+		t.Errorf("second page = %q", got)
+	}
+
+	results, err := s.SearchPosts(1, `"more"`, 0, 1)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("search for markup: %v, %v", results, err)
+	}
+	// the content is escaped, only the marks are markup
+	if want := "Tomatoes, tomatoes &amp; &lt;<mark>more</mark>&gt; tomatoes"; results[0].Snippet != want {
+		t.Errorf("snippet = %q", results[0].Snippet)
+	}
+	if results[0].Author.Nickname == nil || *results[0].Author.Nickname != "bob" {
+		t.Errorf("author = %+v", results[0].Author)
+	}
+}
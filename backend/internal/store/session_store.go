@@ -0,0 +1,163 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type sessionStore struct {
+	db *sql.DB
+}
+
+func NewSessionStore(db *sql.DB) SessionStore {
+	return &sessionStore{db: db}
+}
+
+// sessionPublicID derives the identifier shown to users from the session
+// secret, so listing sessions never exposes a usable cookie value.
+func sessionPublicID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+const sessionColumns = "id, user_id, created_at, last_seen_at, expires_at, user_agent, ip_address"
+
+func scanSession(row interface{ Scan(...any) error }) (*models.Session, error) {
+	var session models.Session
+	var lastSeen, expires sql.NullTime
+	var userAgent, ip sql.NullString
+	err := row.Scan(&session.ID, &session.UserID, &session.CreatedAt, &lastSeen, &expires, &userAgent, &ip)
+	if err != nil {
+		return nil, err
+	}
+
+	session.PublicID = sessionPublicID(session.ID)
+	session.UserAgent = userAgent.String
+	session.IPAddress = ip.String
+	// Sessions created before activity tracking have no last_seen_at
+	session.LastSeenAt = session.CreatedAt
+	if lastSeen.Valid {
+		session.LastSeenAt = lastSeen.Time
+	}
+	// A session without an expiry is treated as already expired
+	if expires.Valid {
+		session.ExpiresAt = expires.Time
+	}
+	return &session, nil
+}
+
+// Times are stored in UTC so the pruner can compare expires_at in SQL.
+func (s *sessionStore) CreateSession(session *models.Session) error {
+	_, err := s.db.Exec(
+		"INSERT INTO Sessions (id, user_id, created_at, last_seen_at, expires_at, user_agent, ip_address) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		session.ID, session.UserID, session.CreatedAt.UTC(), session.LastSeenAt.UTC(), session.ExpiresAt.UTC(), session.UserAgent, session.IPAddress,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+	session.PublicID = sessionPublicID(session.ID)
+	return nil
+}
+
+// GetSession returns sql.ErrNoRows when the session does not exist.
+func (s *sessionStore) GetSession(sessionID string) (*models.Session, error) {
+	return scanSession(s.db.QueryRow("SELECT "+sessionColumns+" FROM Sessions WHERE id = ?", sessionID))
+}
+
+// ListUserSessions returns the user's unexpired sessions, most recently used first.
+func (s *sessionStore) ListUserSessions(userID int64, now time.Time) ([]*models.Session, error) {
+	rows, err := s.db.Query(
+		"SELECT "+sessionColumns+" FROM Sessions WHERE user_id = ? AND expires_at > ? ORDER BY last_seen_at DESC",
+		userID, now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteUserSessions removes every session of the user and returns their IDs.
+func (s *sessionStore) DeleteUserSessions(userID int64) ([]string, error) {
+	return s.deleteSessions("user_id = ?", userID)
+}
+
+// DeleteUserSessionsExcept removes every session of the user other than keepID
+// and returns the IDs it removed.
+func (s *sessionStore) DeleteUserSessionsExcept(userID int64, keepID string) ([]string, error) {
+	return s.deleteSessions("user_id = ? AND id != ?", userID, keepID)
+}
+
+func (s *sessionStore) TouchSession(sessionID string, lastSeenAt, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"UPDATE Sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?",
+		lastSeenAt.UTC(), expiresAt.UTC(), sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("error renewing session: %w", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) DeleteSession(sessionID string) error {
+	_, err := s.db.Exec("DELETE FROM Sessions WHERE id = ?", sessionID)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions removes every session that expired at or before now
+// and returns their IDs.
+func (s *sessionStore) DeleteExpiredSessions(now time.Time) ([]string, error) {
+	return s.deleteSessions("expires_at IS NULL OR expires_at <= ?", now.UTC())
+}
+
+// deleteSessions deletes the sessions matching where and returns their IDs.
+func (s *sessionStore) deleteSessions(where string, args ...any) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM Sessions WHERE "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding sessions: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error finding sessions: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM Sessions WHERE "+where, args...); err != nil {
+		return nil, fmt.Errorf("error deleting sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing session delete: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func setupSessionTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := `
+	CREATE TABLE Sessions (
+		id TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME,
+		last_seen_at DATETIME,
+		user_agent TEXT,
+		ip_address TEXT
+	);`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSessionStore_RoundTrip(t *testing.T) {
+	db := setupSessionTestDB(t)
+	defer db.Close()
+	sessions := NewSessionStore(db)
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := sessions.CreateSession(&models.Session{
+		ID: "abc", UserID: 7, CreatedAt: created, LastSeenAt: created, ExpiresAt: created.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sessions.TouchSession("abc", created.Add(30*time.Minute), created.Add(90*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := sessions.GetSession("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.UserID != 7 || !session.CreatedAt.Equal(created) {
+		t.Errorf("unexpected session %+v", session)
+	}
+	if !session.LastSeenAt.Equal(created.Add(30*time.Minute)) || !session.ExpiresAt.Equal(created.Add(90*time.Minute)) {
+		t.Errorf("touch not persisted: %+v", session)
+	}
+
+	if _, err := sessions.GetSession("missing"); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for a missing session, got %v", err)
+	}
+}
+
+func TestSessionStore_LegacyRowWithoutLastSeen(t *testing.T) {
+	db := setupSessionTestDB(t)
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO Sessions (id, user_id, expires_at) VALUES ('old', 1, datetime('now', '+1 hour'))`); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := NewSessionStore(db).GetSession("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.LastSeenAt.Equal(session.CreatedAt) {
+		t.Errorf("LastSeenAt should fall back to CreatedAt, got %v vs %v", session.LastSeenAt, session.CreatedAt)
+	}
+}
+
+func TestSessionStore_DeleteExpiredSessions(t *testing.T) {
+	db := setupSessionTestDB(t)
+	defer db.Close()
+	sessions := NewSessionStore(db)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for id, expires := range map[string]time.Time{
+		"expired": now.Add(-time.Second),
+		"exact":   now,
+		"live":    now.Add(time.Second),
+	} {
+		if err := sessions.CreateSession(&models.Session{ID: id, UserID: 1, CreatedAt: now, LastSeenAt: now, ExpiresAt: expires}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO Sessions (id, user_id) VALUES ('no-expiry', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := sessions.DeleteExpiredSessions(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 {
+		t.Errorf("expected 3 pruned sessions, got %v", ids)
+	}
+
+	var remaining []string
+	rows, err := db.Query(`SELECT id FROM Sessions`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		_ = rows.Scan(&id)
+		remaining = append(remaining, id)
+	}
+	if len(remaining) != 1 || remaining[0] != "live" {
+		t.Errorf("expected only the live session to remain, got %v", remaining)
+	}
+}
+
+func TestSessionStore_ListAndDeleteUserSessions(t *testing.T) {
+	db := setupSessionTestDB(t)
+	defer db.Close()
+	sessions := NewSessionStore(db)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, s := range []*models.Session{
+		{ID: "laptop", UserID: 1, UserAgent: "Firefox", IPAddress: "10.0.0.1", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "phone", UserID: 1, UserAgent: "Safari", IPAddress: "10.0.0.2", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "stale", UserID: 1, LastSeenAt: now, ExpiresAt: now.Add(-time.Second)},
+		{ID: "other-user", UserID: 2, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		s.CreatedAt = now.Add(-2 * time.Hour)
+		if err := sessions.CreateSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listed, err := sessions.ListUserSessions(1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || listed[0].ID != "phone" || listed[1].ID != "laptop" {
+		t.Fatalf("expected live sessions most recent first, got %+v", listed)
+	}
+	if listed[1].UserAgent != "Firefox" || listed[1].IPAddress != "10.0.0.1" {
+		t.Errorf("client details not persisted: %+v", listed[1])
+	}
+	if listed[0].PublicID == "" || listed[0].PublicID == listed[0].ID || listed[0].PublicID == listed[1].PublicID {
+		t.Errorf("unexpected public IDs %q and %q", listed[0].PublicID, listed[1].PublicID)
+	}
+
+	ids, err := sessions.DeleteUserSessionsExcept(1, "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("expected laptop and stale to be deleted, got %v", ids)
+	}
+	for id, want := range map[string]bool{"phone": true, "other-user": true, "laptop": false} {
+		_, err := sessions.GetSession(id)
+		if exists := err == nil; exists != want {
+			t.Errorf("session %s exists = %v, want %v", id, exists, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+	"github.com/tajjjjr/social-network/backend/pkg/utils"
+)
+
+// indexTags makes the tag index match the hashtags in the content of a post
+// or comment: tags no longer used are dropped, new ones are added as used at
+// now, and those still there keep their first use.
+func indexTags(tx *sql.Tx, owner string, ownerID, userID int64, content string, now time.Time) error {
+	tags := utils.Hashtags(content)
+	args := []any{ownerID}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	stmt := "DELETE FROM Post_Tags WHERE " + owner + " = ?"
+	if len(tags) > 0 {
+		stmt += " AND tag_id NOT IN (SELECT id FROM Tags WHERE name IN (?" + strings.Repeat(", ?", len(tags)-1) + "))"
+	}
+	if _, err := tx.Exec(stmt, args...); err != nil {
+		return fmt.Errorf("error removing tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO Tags (name) VALUES (?)", tag); err != nil {
+			return fmt.Errorf("error adding tag: %w", err)
+		}
+		_, err := tx.Exec("INSERT OR IGNORE INTO Post_Tags (tag_id, "+owner+", user_id, used_at) SELECT id, ?, ?, ? FROM Tags WHERE name = ?",
+			ownerID, userID, now.UTC(), tag)
+		if err != nil {
+			return fmt.Errorf("error tagging: %w", err)
+		}
+	}
+	return nil
+}
+
+type tagStore struct {
+	db *sql.DB
+}
+
+func NewTagStore(db *sql.DB) TagStore {
+	return &tagStore{db: db}
+}
+
+// GetTagPage returns up to limit posts tagged with tag from those userID may
+// see, less any they muted, newest first and each with its cursor. With
+// before, the page holds the posts right below it. Tags in comments do not
+// put the post on the tag's timeline.
+func (s *tagStore) GetTagPage(userID int64, tag string, before *models.FeedCursor, limit int) ([]*models.Post, []models.FeedCursor, error) {
+	query, args := feedQuery(userID)
+	query += ` AND p.id IN (
+            SELECT pt.post_id FROM Post_Tags pt JOIN Tags t ON t.id = pt.tag_id WHERE t.name = ?
+        )`
+	args = append(args, tag)
+	if before != nil {
+		query += ` AND (p.created_at, p.id) < (?, ?)`
+		args = append(args, before.CreatedAt, before.ID)
+	}
+	query += ` ORDER BY p.created_at DESC, p.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing tagged posts: %w", err)
+	}
+	defer rows.Close()
+	posts, cursors, err := scanFeed(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := loadPostAttachments(s.db, posts...); err != nil {
+		return nil, nil, err
+	}
+	return posts, cursors, nil
+}
+
+// TrendingTags ranks the tags used since then by how many people used them,
+// then by how often. Only public posts and the comments on them count, so
+// that trending never reveals what was said to a restricted audience.
+func (s *tagStore) TrendingTags(since time.Time, limit int) ([]models.TrendingTag, error) {
+	rows, err := s.db.Query(`
+        SELECT t.name, COUNT(*) AS uses, COUNT(DISTINCT pt.user_id) AS users
+        FROM Post_Tags pt
+        JOIN Tags t ON t.id = pt.tag_id
+        LEFT JOIN Comments c ON c.id = pt.comment_id
+        JOIN Posts p ON p.id = COALESCE(pt.post_id, c.post_id)
+        WHERE pt.used_at >= ? AND p.privacy = 'public'
+        GROUP BY t.id
+        ORDER BY users DESC, uses DESC, MAX(pt.used_at) DESC, t.name
+        LIMIT ?`, since.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error ranking tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []models.TrendingTag{}
+	for rows.Next() {
+		var tag models.TrendingTag
+		if err := rows.Scan(&tag.Tag, &tag.Uses, &tag.Users); err != nil {
+			return nil, fmt.Errorf("error scanning tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+func TestTagIndex(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	if _, err := db.Exec(`INSERT INTO Users (id, email, password) VALUES (1, 'a@example.com', 'x'), (2, 'b@example.com', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	posts := NewPostStore(db)
+	uses := func() string {
+		rows, err := db.Query(`
+			SELECT t.name, COALESCE(pt.post_id, 0), COALESCE(pt.comment_id, 0)
+			FROM Post_Tags pt JOIN Tags t ON t.id = pt.tag_id ORDER BY t.name, pt.post_id, pt.comment_id`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var uses []string
+		for rows.Next() {
+			var name string
+			var postID, commentID int64
+			if err := rows.Scan(&name, &postID, &commentID); err != nil {
+				t.Fatal(err)
+			}
+			uses = append(uses, fmt.Sprintf("%s:p%d:c%d", name, postID, commentID))
+		}
+		return fmt.Sprint(uses)
+	}
+
+	postID, err := posts.CreatePost(&models.Post{UserID: 1, Content: "#Go is fun #go #sqlite", Privacy: "public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commentID, err := posts.CreateComment(&models.Comment{PostID: postID, UserID: 2, Content: "agreed #go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := uses(); got != "[go:p0:c1 go:p1:c0 sqlite:p1:c0]" {
+		t.Fatalf("after creating: %s", got)
+	}
+
+	var usedAt string
+	db.QueryRow("SELECT used_at FROM Post_Tags WHERE post_id = 1 AND tag_id = (SELECT id FROM Tags WHERE name = 'go')").Scan(&usedAt)
+	if _, err := posts.UpdatePost(postID, "#go with #postgres instead", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := uses(); got != "[go:p0:c1 go:p1:c0 postgres:p1:c0]" {
+		t.Errorf("after editing the post: %s", got)
+	}
+	var stillUsedAt string
+	db.QueryRow("SELECT used_at FROM Post_Tags WHERE post_id = 1 AND tag_id = (SELECT id FROM Tags WHERE name = 'go')").Scan(&stillUsedAt)
+	if stillUsedAt != usedAt {
+		t.Errorf("a kept tag was re-dated from %s to %s", usedAt, stillUsedAt)
+	}
+
+	if _, err := posts.UpdateComment(commentID, "no tags now", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := uses(); got != "[go:p1:c0 postgres:p1:c0]" {
+		t.Errorf("after editing the comment: %s", got)
+	}
+
+	if _, err := posts.CreateComment(&models.Comment{PostID: postID, UserID: 2, Content: "#again"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.DeletePost(postID); err != nil {
+		t.Fatal(err)
+	}
+	if got := uses(); got != "[]" {
+		t.Errorf("after deleting the post: %s", got)
+	}
+}
+
+func TestTagTimelineAndTrending(t *testing.T) {
+	db := setupMigratedTestDB(t)
+	if _, err := db.Exec(`INSERT INTO Users (id, email, password, nickname) VALUES (1, 'a@example.com', 'x', 'ada'), (2, 'b@example.com', 'x', 'bob'), (3, 'c@example.com', 'x', 'cy')`); err != nil {
+		t.Fatal(err)
+	}
+	posts := NewPostStore(db)
+	tags := NewTagStore(db)
+	create := func(userID int64, content, privacy string) int64 {
+		id, err := posts.CreatePost(&models.Post{UserID: userID, Content: content, Privacy: privacy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	ids := func(posts []*models.Post) string {
+		var ids []int64
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	create(2, "#go one", "public")
+	create(2, "#go two, #secret", "private")
+	create(3, "#Go three #rust", "public")
+	create(3, "#rust again", "public")
+	create(2, "no tag", "public")
+
+	page, cursors, err := tags.GetTagPage(1, "go", nil, 1)
+	if err != nil || ids(page) != "[3]" {
+		t.Fatalf("first page = %s, %v", ids(page), err)
+	}
+	page, _, err = tags.GetTagPage(1, "go", &cursors[0], 5)
+	if err != nil || ids(page) != "[1]" {
+		t.Errorf("the private post should be left out: %s, %v", ids(page), err)
+	}
+	if page, _, err := tags.GetTagPage(2, "go", nil, 5); err != nil || ids(page) != "[3 2 1]" {
+		t.Errorf("the author sees their private post: %s, %v", ids(page), err)
+	}
+
+	// a comment by someone else on a public post counts as another person using #rust
+	if _, err := posts.CreateComment(&models.Comment{PostID: 1, UserID: 1, Content: "#rust"}); err != nil {
+		t.Fatal(err)
+	}
+	trending, err := tags.TrendingTags(time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(trending); got != "[{rust 3 2} {go 2 2}]" {
+		t.Errorf("trending = %s", got)
+	}
+	if trending, err := tags.TrendingTags(time.Now().Add(time.Hour), 10); err != nil || len(trending) != 0 {
+		t.Errorf("nothing was used after the window: %v, %v", trending, err)
+	}
+}
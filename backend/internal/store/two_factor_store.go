@@ -0,0 +1,182 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/models"
+)
+
+type twoFactorStore struct {
+	db *sql.DB
+}
+
+func NewTwoFactorStore(db *sql.DB) TwoFactorStore {
+	return &twoFactorStore{db: db}
+}
+
+// GetTwoFactor returns the user's TOTP state; Secret is empty when the user never enrolled.
+func (s *twoFactorStore) GetTwoFactor(userID int64) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	var secret sql.NullString
+	var enabledAt sql.NullTime
+	var lastStep sql.NullInt64
+	err := s.db.QueryRow(
+		"SELECT id, email, totp_secret, totp_enabled_at, totp_last_step FROM Users WHERE id = ?",
+		userID,
+	).Scan(&tf.UserID, &tf.Email, &secret, &enabledAt, &lastStep)
+	if err != nil {
+		return nil, err
+	}
+
+	tf.Secret = secret.String
+	if enabledAt.Valid {
+		tf.EnabledAt = &enabledAt.Time
+	}
+	tf.LastStep = lastStep.Int64
+	return &tf, nil
+}
+
+// SetPendingSecret starts an enrollment: it stores the encrypted secret,
+// leaves two-factor disabled until confirmed, and replaces any recovery codes.
+func (s *twoFactorStore) SetPendingSecret(userID int64, secret string, recoveryCodeHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE Users SET totp_secret = ?, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?",
+		secret, userID,
+	); err != nil {
+		return fmt.Errorf("error storing two-factor secret: %w", err)
+	}
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int64, hashes []string) error {
+	if _, err := tx.Exec("DELETE FROM Recovery_Codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec("INSERT INTO Recovery_Codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return fmt.Errorf("error storing recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// EnableTwoFactor turns on a pending enrollment, recording step as used.
+func (s *twoFactorStore) EnableTwoFactor(userID int64, enabledAt time.Time, step int64) error {
+	_, err := s.db.Exec(
+		"UPDATE Users SET totp_enabled_at = ?, totp_last_step = ? WHERE id = ? AND totp_secret IS NOT NULL",
+		enabledAt.UTC(), step, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error enabling two-factor: %w", err)
+	}
+	return nil
+}
+
+// DisableTwoFactor removes the secret and every recovery code of the user.
+func (s *twoFactorStore) DisableTwoFactor(userID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE Users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?",
+		userID,
+	); err != nil {
+		return fmt.Errorf("error disabling two-factor: %w", err)
+	}
+	if err := replaceRecoveryCodes(tx, userID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep records step as the last accepted code. It reports false when that
+// step, or a later one, was already used.
+func (s *twoFactorStore) UseStep(userID, step int64) (bool, error) {
+	result, err := s.db.Exec(
+		"UPDATE Users SET totp_last_step = ? WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error recording code use: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode marks an unused recovery code as used, reporting whether one matched.
+func (s *twoFactorStore) UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error) {
+	result, err := s.db.Exec(
+		"UPDATE Recovery_Codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		now.UTC(), userID, codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (s *twoFactorStore) CreateChallenge(userID int64, tokenHash string, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO Two_Factor_Challenges (token_hash, user_id, expires_at) VALUES (?, ?, ?)",
+		tokenHash, userID, expiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// GetChallenge returns the user and failed attempts of a live challenge, or
+// sql.ErrNoRows when it is unknown or expired at now.
+func (s *twoFactorStore) GetChallenge(tokenHash string, now time.Time) (int64, int, error) {
+	var userID int64
+	var attempts int
+	err := s.db.QueryRow(
+		"SELECT user_id, attempts FROM Two_Factor_Challenges WHERE token_hash = ? AND expires_at > ?",
+		tokenHash, now.UTC(),
+	).Scan(&userID, &attempts)
+	return userID, attempts, err
+}
+
+func (s *twoFactorStore) RecordChallengeFailure(tokenHash string) error {
+	_, err := s.db.Exec("UPDATE Two_Factor_Challenges SET attempts = attempts + 1 WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return fmt.Errorf("error recording failed attempt: %w", err)
+	}
+	return nil
+}
+
+// DeleteChallenge removes a challenge, reporting whether it still existed so
+// that only one request can complete it.
+func (s *twoFactorStore) DeleteChallenge(tokenHash string) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM Two_Factor_Challenges WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("error deleting two-factor challenge: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// DeleteExpiredChallenges removes challenges that expired at or before now.
+func (s *twoFactorStore) DeleteExpiredChallenges(now time.Time) error {
+	_, err := s.db.Exec("DELETE FROM Two_Factor_Challenges WHERE expires_at <= ?", now.UTC())
+	if err != nil {
+		return fmt.Errorf("error pruning two-factor challenges: %w", err)
+	}
+	return nil
+}
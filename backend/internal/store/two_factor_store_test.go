@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTwoFactorTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "two_factor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE Users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT UNIQUE NOT NULL,
+		totp_secret TEXT,
+		totp_enabled_at DATETIME,
+		totp_last_step INTEGER
+	);
+	CREATE TABLE Recovery_Codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		used_at DATETIME
+	);
+	CREATE TABLE Two_Factor_Challenges (
+		token_hash TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME NOT NULL
+	);
+	INSERT INTO Users (id, email) VALUES (1, 'ada@example.com');`
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTwoFactorStore_EnrollmentLifecycle(t *testing.T) {
+	db := setupTwoFactorTestDB(t)
+	twoFactor := NewTwoFactorStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := twoFactor.SetPendingSecret(1, "sealed", []string{"r1", "r2"}); err != nil {
+		t.Fatal(err)
+	}
+	tf, err := twoFactor.GetTwoFactor(1)
+	if err != nil || tf.Secret != "sealed" || tf.Enabled() {
+		t.Fatalf("pending state = %+v, %v", tf, err)
+	}
+
+	if err := twoFactor.EnableTwoFactor(1, now, 100); err != nil {
+		t.Fatal(err)
+	}
+	if tf, _ := twoFactor.GetTwoFactor(1); !tf.Enabled() || tf.LastStep != 100 {
+		t.Fatalf("enabled state = %+v", tf)
+	}
+
+	if fresh, _ := twoFactor.UseStep(1, 100); fresh {
+		t.Error("step 100 was already used")
+	}
+	if fresh, _ := twoFactor.UseStep(1, 101); !fresh {
+		t.Error("step 101 should be accepted")
+	}
+
+	if used, _ := twoFactor.UseRecoveryCode(1, "r1", now); !used {
+		t.Error("r1 should be accepted")
+	}
+	if used, _ := twoFactor.UseRecoveryCode(1, "r1", now); used {
+		t.Error("r1 is single use")
+	}
+
+	if err := twoFactor.DisableTwoFactor(1); err != nil {
+		t.Fatal(err)
+	}
+	if tf, _ := twoFactor.GetTwoFactor(1); tf.Enabled() || tf.Secret != "" {
+		t.Fatalf("disabled state = %+v", tf)
+	}
+	if used, _ := twoFactor.UseRecoveryCode(1, "r2", now); used {
+		t.Error("recovery codes should be removed on disable")
+	}
+}
+
+func TestTwoFactorStore_Challenges(t *testing.T) {
+	db := setupTwoFactorTestDB(t)
+	twoFactor := NewTwoFactorStore(db)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := twoFactor.CreateChallenge(1, "hash", now.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactor.RecordChallengeFailure("hash"); err != nil {
+		t.Fatal(err)
+	}
+	if userID, attempts, err := twoFactor.GetChallenge("hash", now); err != nil || userID != 1 || attempts != 1 {
+		t.Fatalf("GetChallenge() = %d, %d, %v", userID, attempts, err)
+	}
+	if _, _, err := twoFactor.GetChallenge("hash", now.Add(5*time.Minute)); err != sql.ErrNoRows {
+		t.Errorf("expired challenge: got %v, want sql.ErrNoRows", err)
+	}
+
+	if deleted, _ := twoFactor.DeleteChallenge("hash"); !deleted {
+		t.Error("expected challenge to be deleted")
+	}
+	if deleted, _ := twoFactor.DeleteChallenge("hash"); deleted {
+		t.Error("challenge can only be completed once")
+	}
+}
@@ -7,21 +7,21 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/tajjjjr/social-network/backend/internal/auth"
 )
 
 type ChatHandler struct {
 	DB                *sql.DB
-	Resolver          *DBSessionResolver
 	Persister         *DBMessagePersister
 	Notifier          *NotificationSender
 	WSManager         *Manager
 	PermissionChecker PermissionChecker
 }
 
-func NewChatHandler(db *sql.DB, resolver *DBSessionResolver, persister *DBMessagePersister, notifier *NotificationSender, wsManager *Manager, permissionChecker PermissionChecker) *ChatHandler {
+func NewChatHandler(db *sql.DB, persister *DBMessagePersister, notifier *NotificationSender, wsManager *Manager, permissionChecker PermissionChecker) *ChatHandler {
 	return &ChatHandler{
 		DB:                db,
-		Resolver:          resolver,
 		Persister:         persister,
 		Notifier:          notifier,
 		WSManager:         wsManager,
@@ -31,8 +31,8 @@ func NewChatHandler(db *sql.DB, resolver *DBSessionResolver, persister *DBMessag
 
 // GET /api/messages/private?user=123&limit=50&offset=0
 func (h *ChatHandler) GetPrivateMessages(w http.ResponseWriter, r *http.Request) {
-	userID, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -77,8 +77,7 @@ func (h *ChatHandler) GetPrivateMessages(w http.ResponseWriter, r *http.Request)
 
 // GET /api/messages/group?group=123&limit=50&offset=0
 func (h *ChatHandler) GetGroupMessages(w http.ResponseWriter, r *http.Request) {
-	_, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	if _, ok := auth.UserID(r.Context()); !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -110,8 +109,8 @@ func (h *ChatHandler) GetGroupMessages(w http.ResponseWriter, r *http.Request) {
 
 // POST /api/groups/invite
 func (h *ChatHandler) SendGroupInvite(w http.ResponseWriter, r *http.Request) {
-	inviterID, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	inviterID, ok := auth.UserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -126,6 +125,17 @@ func (h *ChatHandler) SendGroupInvite(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+
+	blocked, err := h.PermissionChecker.BlockedUsers(inviterID)
+	if err != nil {
+		http.Error(w, "Could not verify relationship", http.StatusInternalServerError)
+		return
+	}
+	if blocked[req.UserID] {
+		http.Error(w, "You cannot invite this user", http.StatusForbidden)
+		return
+	}
+
 	_, err = h.DB.Exec(`INSERT INTO Group_Members (group_id, user_id, invited_by, is_accepted) VALUES (?, ?, ?, 0)`, req.GroupID, req.UserID, inviterID)
 	if err != nil {
 		http.Error(w, "Could not invite user", http.StatusInternalServerError)
@@ -133,6 +143,11 @@ func (h *ChatHandler) SendGroupInvite(w http.ResponseWriter, r *http.Request) {
 	}
 
 	msg := "You were invited to join group '" + req.GroupName + "'"
+	// the invite stands, but users who muted the inviter are not told about it
+	if !h.PermissionChecker.ShouldNotify(req.UserID, inviterID, msg) {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
 	_, err = h.DB.Exec(`INSERT INTO Notifications (user_id, type, message) VALUES (?, 'group_invite', ?)`, req.UserID, msg)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -154,8 +169,8 @@ func (h *ChatHandler) SendGroupInvite(w http.ResponseWriter, r *http.Request) {
 
 // GET /api/notifications?limit=20&offset=0
 func (h *ChatHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
-	userID, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -205,12 +220,12 @@ func (h *ChatHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 
 // POST /api/notifications/read
 func (h *ChatHandler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
-	userID, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	_, err = h.DB.Exec(`UPDATE Notifications SET is_read = 1 WHERE user_id = ?`, userID)
+	_, err := h.DB.Exec(`UPDATE Notifications SET is_read = 1 WHERE user_id = ?`, userID)
 	if err != nil {
 		http.Error(w, "Failed to mark as read", http.StatusInternalServerError)
 		return
@@ -241,8 +256,8 @@ func (h *ChatHandler) GetOnlineUsers(w http.ResponseWriter, r *http.Request) {
 /* The UNION operator automatically handles duplicates.
 */
 func (h *ChatHandler) GetMessageableUsers(w http.ResponseWriter, r *http.Request) {
-	userID, _, _, err := h.Resolver.GetUserFromRequest(r)
-	if err != nil {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -266,6 +281,11 @@ func (h *ChatHandler) GetMessageableUsers(w http.ResponseWriter, r *http.Request
 	// }
 
 	// [ PERMISSIBLE PERMISSIONS ]
+	blocked, err := h.PermissionChecker.BlockedUsers(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch messageable users", http.StatusInternalServerError)
+		return
+	}
 	rows, err := h.DB.Query(`
 		SELECT u.id, u.nickname, u.avatar
 		FROM Users u
@@ -287,7 +307,7 @@ func (h *ChatHandler) GetMessageableUsers(w http.ResponseWriter, r *http.Request
 	for rows.Next() {
 		fmt.Println("Scanning row")
 		var u MessageableUser
-		if err := rows.Scan(&u.ID, &u.Nickname, &u.Avatar); err == nil {
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Avatar); err == nil && !blocked[u.ID] {
 			users = append(users, u)
 			fmt.Printf("Found messageable user: %+v\n", u)
 		}
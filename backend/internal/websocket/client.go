@@ -8,6 +8,9 @@ import (
 
 type Client struct {
 	ID        int64
+	SessionID string // session the connection was opened with; closing it ends the connection
+	TokenID   int64  // access token the connection was opened with, if any; revoking it ends the connection
+	ReadOnly  bool   // opened with a read-only access token, so the client may not send messages
 	Nickname  string
 	Avatar    string
 	Conn      *websocket.Conn
@@ -2,7 +2,8 @@ package websocket
 
 import "net/http"
 
-// SessionResolver resolves the authenticated user ID, nickname, and avatar from the HTTP request.
+// SessionResolver resolves the authenticated user ID, nickname, and avatar of an HTTP request
+// that has already passed the auth middleware.
 type SessionResolver interface {
 	GetUserFromRequest(r *http.Request) (int64, string, string, error)
 }
@@ -15,11 +16,29 @@ type GroupMemberFetcher interface {
 
 // MessagePersister stores chat messages for retrieval and persistence.
 type MessagePersister interface {
-	SaveMessage(senderID int64, msg *Message) error
+	// SaveMessage stores msg and returns its id.
+	SaveMessage(senderID int64, msg *Message) (int64, error)
+}
+
+// MentionRecorder records the @mentions in a saved chat message and notifies
+// those mentioned among readers, the users it was delivered to. groupID is 0
+// for a private message.
+type MentionRecorder interface {
+	MentionsInMessage(messageID, senderID, groupID int64, content string, readers []int64)
 }
 
 // PermissionChecker allows checking if a user has a certain permission.
 // some of these permissions might be ones that allow a user to send a message to someone else
 type PermissionChecker interface {
 	CanUsersChat(userID, targetID int64) (bool, error)
+	// CanSendMessages reports whether the user may send messages at all,
+	// which requires a verified email address.
+	CanSendMessages(userID int64) (bool, error)
+	// BlockedUsers returns the users who blocked userID or whom userID
+	// blocked; they never receive each other's messages.
+	BlockedUsers(userID int64) (map[int64]bool, error)
+	// ShouldNotify reports whether userID wants a notification about
+	// actorID's activity that reads message, i.e. has muted neither actorID
+	// nor a keyword in message.
+	ShouldNotify(userID, actorID int64, message string) bool
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,16 +13,20 @@ import (
 
 type Manager struct {
 	clients           map[int64]*Client
+	conns             map[*Client]struct{} // every open connection, including ones replaced in clients by a newer login
 	mu                sync.RWMutex
 	Resolver          SessionResolver
 	groupQuery        GroupMemberFetcher
 	persister         MessagePersister
 	PermissionChecker PermissionChecker
+	// Mentions, when set, records the @mentions in saved messages.
+	Mentions MentionRecorder
 }
 
 func NewManager(resolver SessionResolver, groupFetcher GroupMemberFetcher, persister MessagePersister, permissionChecker PermissionChecker) *Manager {
 	return &Manager{
 		clients:           make(map[int64]*Client),
+		conns:             make(map[*Client]struct{}),
 		Resolver:          resolver,
 		groupQuery:        groupFetcher,
 		persister:         persister,
@@ -33,6 +38,7 @@ func (m *Manager) Register(c *Client) {
 	// Add client to map first
 	m.mu.Lock()
 	m.clients[c.ID] = c
+	m.conns[c] = struct{}{}
 	fmt.Printf("User %d connected as %s\n", c.ID, c.Nickname)
 	m.mu.Unlock() // Release lock before broadcasting
 
@@ -51,30 +57,75 @@ func (m *Manager) Register(c *Client) {
 }
 
 func (m *Manager) Unregister(id int64) {
+	m.mu.RLock()
+	client := m.clients[id]
+	m.mu.RUnlock()
+	if client != nil {
+		m.UnregisterClient(client)
+	}
+}
+
+// UnregisterClient removes a single connection. The user only goes offline
+// if it is their current connection, not one replaced by a newer login.
+func (m *Manager) UnregisterClient(c *Client) {
 	var disconnectionNotification map[string]interface{}
 
 	// Remove client and prepare notification
 	m.mu.Lock()
-	if client, ok := m.clients[id]; ok {
+	delete(m.conns, c)
+	if m.clients[c.ID] == c {
 		// Prepare notification before removing client
 		disconnectionNotification = map[string]interface{}{
 			"type":      "notification",
 			"subtype":   "user_disconnected",
-			"user_id":   id,
-			"nickname":  client.Nickname,
-			"avatar":    client.Avatar,
-			"message":   client.Nickname + " went offline",
+			"user_id":   c.ID,
+			"nickname":  c.Nickname,
+			"avatar":    c.Avatar,
+			"message":   c.Nickname + " went offline",
 			"timestamp": time.Now().Unix(),
 		}
 
-		delete(m.clients, id)
-		fmt.Printf("User %d disconnected", id)
+		delete(m.clients, c.ID)
+		fmt.Printf("User %d disconnected", c.ID)
 	}
 	m.mu.Unlock() // Release lock before broadcasting
 
 	// Broadcast after releasing the lock
 	if disconnectionNotification != nil {
-		m.broadcastNotificationToAll(disconnectionNotification, id)
+		m.broadcastNotificationToAll(disconnectionNotification, c.ID)
+	}
+}
+
+// CloseSessions closes every connection opened with one of the given sessions.
+// The connection's read loop then fails and the handler unregisters the client.
+func (m *Manager) CloseSessions(sessionIDs ...string) {
+	ended := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		ended[id] = true
+	}
+	m.closeClients("session ended", func(c *Client) bool { return ended[c.SessionID] })
+}
+
+// CloseAccessTokens closes every connection opened with one of the given access tokens.
+func (m *Manager) CloseAccessTokens(tokenIDs ...int64) {
+	revoked := make(map[int64]bool, len(tokenIDs))
+	for _, id := range tokenIDs {
+		revoked[id] = true
+	}
+	m.closeClients("access token revoked", func(c *Client) bool { return c.TokenID != 0 && revoked[c.TokenID] })
+}
+
+func (m *Manager) closeClients(reason string, match func(*Client) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for client := range m.conns {
+		if client.Conn == nil || !match(client) {
+			continue
+		}
+		msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+		_ = client.Conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		_ = client.Conn.Close()
+		log.Printf("Closed websocket for user %d: %s", client.ID, reason)
 	}
 }
 
@@ -99,6 +150,38 @@ func (m *Manager) ReadPump(c *Client) {
 			continue
 		}
 
+		switch msg.Type {
+		case "private", "group", "broadcast":
+			if c.ReadOnly {
+				errorMsg, _ := json.Marshal(Message{
+					Type:      "error",
+					Content:   "This connection uses a read-only access token.",
+					Code:      "insufficient_scope",
+					Timestamp: time.Now().Unix(),
+				})
+				select {
+				case c.Send <- errorMsg:
+				default:
+				}
+				continue
+			}
+			allowed, err := m.PermissionChecker.CanSendMessages(c.ID)
+			if err != nil {
+				log.Printf("MSG: Error checking whether user %d can send messages: %v", c.ID, err)
+				continue
+			}
+			if !allowed {
+				errorMsg, _ := json.Marshal(Message{
+					Type:      "error",
+					Content:   "Verify your email address to send messages.",
+					Code:      "email_unverified",
+					Timestamp: time.Now().Unix(),
+				})
+				m.SendToUser(c.ID, errorMsg)
+				continue
+			}
+		}
+
 		switch msg.Type {
 		case "private":
 			log.Printf("MSG: Received private message from user %d to user %d: %s", c.ID, msg.To, msg.Content)
@@ -115,8 +198,12 @@ func (m *Manager) ReadPump(c *Client) {
 
 				// Requirement #3: Save to DB if persister is configured
 				if m.persister != nil {
-					_ = m.persister.SaveMessage(c.ID, msg)
-					log.Printf("MSG: Message saved to database")
+					if messageID, err := m.persister.SaveMessage(c.ID, msg); err != nil {
+						log.Printf("MSG: Error saving message from user %d: %v", c.ID, err)
+					} else {
+						log.Printf("MSG: Message saved to database")
+						m.recordMentions(messageID, c.ID, msg, []int64{msg.To})
+					}
 				}
 
 				// Requirement #3: Forward to recipient and sender
@@ -135,9 +222,9 @@ func (m *Manager) ReadPump(c *Client) {
 				m.SendToUser(c.ID, errorMsg)
 			}
 		case "group":
-			m.BroadcastToGroup(c.ID, msg.GroupID, encoded)
+			m.sendGroupMessage(c.ID, msg, encoded)
 		case "broadcast":
-			m.BroadcastToAll(encoded)
+			m.BroadcastFrom(c.ID, encoded)
 		}
 	}
 }
@@ -161,16 +248,93 @@ func (m *Manager) SendToUser(id int64, msg []byte) {
 	}
 }
 
+// BroadcastToGroup sends msg to the group's members, except the sender and
+// members who blocked the sender or whom the sender blocked.
 func (m *Manager) BroadcastToGroup(sender int64, groupID string, msg []byte) {
-	ids, err := m.groupQuery.GetGroupMemberIDs(groupID)
+	readers, _, err := m.groupReaders(sender, groupID)
 	if err != nil {
+		log.Printf("MSG: Error loading readers of group %s: %v", groupID, err)
 		return
 	}
+	for _, id := range readers {
+		m.SendToUser(id, msg)
+	}
+}
+
+// groupReaders returns the members of the group who receive sender's
+// messages, and whether sender is a member.
+func (m *Manager) groupReaders(sender int64, groupID string) ([]int64, bool, error) {
+	ids, err := m.groupQuery.GetGroupMemberIDs(groupID)
+	if err != nil {
+		return nil, false, err
+	}
+	blocked, err := m.PermissionChecker.BlockedUsers(sender)
+	if err != nil {
+		return nil, false, err
+	}
+	var readers []int64
+	member := false
 	for _, id := range ids {
 		if id == sender {
+			member = true
 			continue
 		}
-		m.SendToUser(id, msg)
+		if !blocked[id] {
+			readers = append(readers, id)
+		}
+	}
+	return readers, member, nil
+}
+
+// sendGroupMessage delivers a group message like BroadcastToGroup and, when
+// the sender is a member, saves it and records its mentions.
+func (m *Manager) sendGroupMessage(sender int64, msg *Message, encoded []byte) {
+	readers, member, err := m.groupReaders(sender, msg.GroupID)
+	if err != nil {
+		log.Printf("MSG: Error loading readers of group %s: %v", msg.GroupID, err)
+		return
+	}
+	for _, id := range readers {
+		m.SendToUser(id, encoded)
+	}
+	if !member || m.persister == nil {
+		return
+	}
+	messageID, err := m.persister.SaveMessage(sender, msg)
+	if err != nil {
+		log.Printf("MSG: Error saving group message from user %d: %v", sender, err)
+		return
+	}
+	m.recordMentions(messageID, sender, msg, readers)
+}
+
+// recordMentions hands a saved message to Mentions, if set. readers are the
+// users the message was delivered to.
+func (m *Manager) recordMentions(messageID, sender int64, msg *Message, readers []int64) {
+	if m.Mentions == nil {
+		return
+	}
+	var groupID int64
+	if msg.Type == "group" {
+		groupID, _ = strconv.ParseInt(msg.GroupID, 10, 64)
+	}
+	m.Mentions.MentionsInMessage(messageID, sender, groupID, msg.Content, readers)
+}
+
+// BroadcastFrom sends sender's msg to everyone online, except users who
+// blocked the sender or whom the sender blocked.
+func (m *Manager) BroadcastFrom(sender int64, msg []byte) {
+	blocked, err := m.PermissionChecker.BlockedUsers(sender)
+	if err != nil {
+		log.Printf("MSG: Error loading blocks of user %d: %v", sender, err)
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for id, client := range m.clients {
+		if !blocked[id] {
+			client.Send <- msg
+		}
 	}
 }
 
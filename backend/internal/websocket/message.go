@@ -8,6 +8,7 @@ type Message struct {
 	To        int64  `json:"to,omitempty"`
 	GroupID   string `json:"group_id,omitempty"`
 	Content   string `json:"content"`
+	Code      string `json:"code,omitempty"` // set on error messages clients must react to
 	Timestamp int64  `json:"timestamp,omitempty"`
 }
 
@@ -15,27 +15,31 @@ func NewDBMessagePersister(db *sql.DB) *DBMessagePersister {
 	return &DBMessagePersister{DB: db}
 }
 
-func (p *DBMessagePersister) SaveMessage(senderID int64, msg *Message) error {
+func (p *DBMessagePersister) SaveMessage(senderID int64, msg *Message) (int64, error) {
+	var res sql.Result
+	var err error
 	switch msg.Type {
 	case "private":
-		_, err := p.DB.Exec(`
+		res, err = p.DB.Exec(`
 			INSERT INTO Messages (sender_id, receiver_id, content, created_at)
 			VALUES (?, ?, ?, ?)
 		`, senderID, msg.To, msg.Content, time.Now().UTC())
-		return err
 	case "group":
-		groupID, err := strconv.Atoi(msg.GroupID)
-		if err != nil {
-			return err
+		groupID, convErr := strconv.Atoi(msg.GroupID)
+		if convErr != nil {
+			return 0, convErr
 		}
-		_, err = p.DB.Exec(`
+		res, err = p.DB.Exec(`
 			INSERT INTO Messages (sender_id, group_id, content, created_at)
 			VALUES (?, ?, ?, ?)
 		`, senderID, groupID, msg.Content, time.Now().UTC())
-		return err
 	default:
-		return nil // Ignore broadcast messages for now
+		return 0, nil // Ignore broadcast messages for now
+	}
+	if err != nil {
+		return 0, err
 	}
+	return res.LastInsertId()
 }
 
 func (p *DBMessagePersister) FetchPrivateMessages(userA, userB int64, limit int) ([]Message, error) {
@@ -1,25 +1,40 @@
 package websocket
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"github.com/tajjjjr/social-network/backend/internal/service"
+)
 
 type DBPermissionChecker struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Blocks service.BlockService
+	Mutes  service.MuteService
 }
 
-func NewDBPermissionChecker(db *sql.DB) *DBPermissionChecker {
-	return &DBPermissionChecker{DB: db}
+func NewDBPermissionChecker(db *sql.DB, blocks service.BlockService, mutes service.MuteService) *DBPermissionChecker {
+	return &DBPermissionChecker{DB: db, Blocks: blocks, Mutes: mutes}
 }
 
 // CanUsersChat checks if two users are allowed to chat.
-// This means they must be followers of each other OR the target has a public profile.
+// This means they must be followers of each other OR the target has a public profile,
+// and neither may have blocked the other.
 func (p *DBPermissionChecker) CanUsersChat(userID, targetID int64) (bool, error) {
 	if userID == targetID {
 		return true, nil // Users can always chat with themselves (e.g., for notes)
 	}
 
+	blocked, err := p.Blocks.IsBlocked(userID, targetID)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, nil
+	}
+
 	// Check if they are mutual followers
 	var mutualFollowCount int
-	err := p.DB.QueryRow(`
+	err = p.DB.QueryRow(`
 		SELECT COUNT(*) FROM Followers
 		WHERE (follower_id = ? AND followee_id = ? AND status = 'accepted')
 		   OR (follower_id = ? AND followee_id = ? AND status = 'accepted')
@@ -43,3 +58,26 @@ func (p *DBPermissionChecker) CanUsersChat(userID, targetID int64) (bool, error)
 
 	return isPublic, nil // Allow chat if target profile is public
 }
+
+// CanSendMessages checks that the user has verified their email address.
+func (p *DBPermissionChecker) CanSendMessages(userID int64) (bool, error) {
+	var verified bool
+	err := p.DB.QueryRow(`
+		SELECT email_verified_at IS NOT NULL FROM Users WHERE id = ?
+	`, userID).Scan(&verified)
+	if err != nil {
+		return false, err
+	}
+	return verified, nil
+}
+
+// BlockedUsers returns the users who blocked userID or whom userID blocked.
+func (p *DBPermissionChecker) BlockedUsers(userID int64) (map[int64]bool, error) {
+	return p.Blocks.BlockedUsers(userID)
+}
+
+// ShouldNotify reports whether userID wants a notification about actorID's
+// activity that reads message.
+func (p *DBPermissionChecker) ShouldNotify(userID, actorID int64, message string) bool {
+	return p.Mutes.ShouldNotify(userID, actorID, message)
+}
@@ -0,0 +1,2 @@
+-- Remove the pending group request uniqueness constraint
+DROP INDEX IF EXISTS idx_group_requests_pending;
\ No newline at end of file
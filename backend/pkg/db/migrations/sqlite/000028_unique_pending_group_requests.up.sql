@@ -0,0 +1,10 @@
+-- Keep only the oldest pending request per user and group before enforcing uniqueness
+UPDATE Group_Requests
+SET status = 'rejected'
+WHERE status = 'pending'
+  AND id NOT IN (
+    SELECT MIN(id) FROM Group_Requests WHERE status = 'pending' GROUP BY group_id, user_id
+  );
+
+-- A user may only have one pending join request per group
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_requests_pending ON Group_Requests(group_id, user_id) WHERE status = 'pending';
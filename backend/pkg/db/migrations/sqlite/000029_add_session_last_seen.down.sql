@@ -0,0 +1,3 @@
+-- Remove session activity tracking
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+ALTER TABLE Sessions DROP COLUMN last_seen_at;
@@ -0,0 +1,6 @@
+-- Track session activity so idle sessions can expire and active ones can be renewed
+ALTER TABLE Sessions ADD COLUMN last_seen_at DATETIME;
+UPDATE Sessions SET last_seen_at = created_at WHERE last_seen_at IS NULL;
+
+-- The pruner deletes dead sessions by expiry
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON Sessions(expires_at);
@@ -0,0 +1,4 @@
+-- Remove session client details
+DROP INDEX IF EXISTS idx_sessions_user_id;
+ALTER TABLE Sessions DROP COLUMN ip_address;
+ALTER TABLE Sessions DROP COLUMN user_agent;
@@ -0,0 +1,5 @@
+-- Record where each session was opened from so users can recognise their devices
+ALTER TABLE Sessions ADD COLUMN user_agent TEXT;
+ALTER TABLE Sessions ADD COLUMN ip_address TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON Sessions(user_id);
@@ -0,0 +1,2 @@
+-- Drop Password_Reset_Tokens table
+DROP TABLE IF EXISTS Password_Reset_Tokens;
@@ -0,0 +1,3 @@
+-- Remove email verification
+DROP TABLE IF EXISTS Email_Verification_Tokens;
+ALTER TABLE Users DROP COLUMN email_verified_at;
@@ -0,0 +1,19 @@
+-- Track when a user's current email address was confirmed
+ALTER TABLE Users ADD COLUMN email_verified_at DATETIME;
+
+-- Accounts created before verification existed keep working
+UPDATE Users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+
+-- Create Email_Verification_Tokens table; a token only confirms the address it was sent to
+CREATE TABLE IF NOT EXISTS Email_Verification_Tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON Email_Verification_Tokens(user_id);
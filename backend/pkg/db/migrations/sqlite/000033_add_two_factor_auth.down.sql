@@ -0,0 +1,6 @@
+-- Remove two-factor authentication
+DROP TABLE IF EXISTS Two_Factor_Challenges;
+DROP TABLE IF EXISTS Recovery_Codes;
+ALTER TABLE Users DROP COLUMN totp_last_step;
+ALTER TABLE Users DROP COLUMN totp_enabled_at;
+ALTER TABLE Users DROP COLUMN totp_secret;
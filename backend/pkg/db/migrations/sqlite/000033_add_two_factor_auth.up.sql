@@ -0,0 +1,26 @@
+-- TOTP two-factor authentication. The secret is stored encrypted; it is
+-- pending until totp_enabled_at is set by confirming a first code.
+ALTER TABLE Users ADD COLUMN totp_secret TEXT;
+ALTER TABLE Users ADD COLUMN totp_enabled_at DATETIME;
+-- Last accepted time step, so a code cannot be replayed
+ALTER TABLE Users ADD COLUMN totp_last_step INTEGER;
+
+-- Create Recovery_Codes table; only hashes of the one-time codes are stored
+CREATE TABLE IF NOT EXISTS Recovery_Codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON Recovery_Codes(user_id);
+
+-- Create Two_Factor_Challenges table for logins waiting on a second factor
+CREATE TABLE IF NOT EXISTS Two_Factor_Challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
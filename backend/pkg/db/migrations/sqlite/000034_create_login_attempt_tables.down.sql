@@ -0,0 +1,3 @@
+-- Drop login attempt tracking tables
+DROP TABLE IF EXISTS Login_Throttles;
+DROP TABLE IF EXISTS Login_Failures;
@@ -0,0 +1,24 @@
+-- Create Login_Failures table, an audit log of every failed login attempt
+CREATE TABLE IF NOT EXISTS Login_Failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    user_id INTEGER,            -- NULL when no account has the email
+    ip_address TEXT NOT NULL,
+    user_agent TEXT,
+    reason TEXT NOT NULL,       -- invalid_credentials or throttled
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_created_at ON Login_Failures(created_at);
+CREATE INDEX IF NOT EXISTS idx_login_failures_ip_address ON Login_Failures(ip_address);
+CREATE INDEX IF NOT EXISTS idx_login_failures_email ON Login_Failures(email);
+
+-- Create Login_Throttles table with the running failure count of each
+-- account ("account:<email>") and client address ("ip:<address>")
+CREATE TABLE IF NOT EXISTS Login_Throttles (
+    throttle_key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
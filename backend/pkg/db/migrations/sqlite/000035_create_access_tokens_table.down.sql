@@ -0,0 +1,2 @@
+-- Drop Access_Tokens table
+DROP TABLE IF EXISTS Access_Tokens;
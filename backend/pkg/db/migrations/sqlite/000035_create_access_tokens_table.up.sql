@@ -0,0 +1,14 @@
+-- Create Access_Tokens table for personal access tokens; only a hash of each token is stored
+CREATE TABLE IF NOT EXISTS Access_Tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scope TEXT NOT NULL CHECK (scope IN ('read', 'write')),
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME,        -- NULL for tokens that never expire
+    last_used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON Access_Tokens(user_id);
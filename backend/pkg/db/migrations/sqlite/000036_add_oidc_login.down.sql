@@ -0,0 +1,3 @@
+-- Drop OpenID Connect login tables
+DROP TABLE IF EXISTS External_Identities;
+DROP TABLE IF EXISTS OIDC_Login_States;
@@ -0,0 +1,24 @@
+-- Create OIDC_Login_States table for logins waiting on the provider's redirect back.
+-- The PKCE verifier and nonce are needed in clear to finish the login; the state is hashed.
+CREATE TABLE IF NOT EXISTS OIDC_Login_States (
+    state_hash TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+-- Create External_Identities table linking OpenID provider accounts to users
+CREATE TABLE IF NOT EXISTS External_Identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at DATETIME NOT NULL,
+    last_login_at DATETIME,
+    UNIQUE (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_identities_user_id ON External_Identities(user_id);
@@ -0,0 +1,4 @@
+-- Remove scheduled account deletion
+DROP INDEX IF EXISTS idx_users_delete_after;
+
+ALTER TABLE Users DROP COLUMN delete_after;
@@ -0,0 +1,4 @@
+-- Accounts scheduled for deletion are purged once delete_after has passed; logging in clears it
+ALTER TABLE Users ADD COLUMN delete_after DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_delete_after ON Users(delete_after);
@@ -0,0 +1,4 @@
+-- Remove site roles and suspension
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE Users DROP COLUMN suspended_at;
+ALTER TABLE Users DROP COLUMN role;
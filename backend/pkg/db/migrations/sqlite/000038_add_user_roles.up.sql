@@ -0,0 +1,7 @@
+-- Site-wide roles, separate from the per-group Group_Members.role
+ALTER TABLE Users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Suspended users cannot log in
+ALTER TABLE Users ADD COLUMN suspended_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON Users(role);
@@ -0,0 +1,4 @@
+-- Remove handle redirects and uniqueness; renamed duplicate nicknames are kept
+DROP INDEX IF EXISTS idx_handle_redirects_user_id;
+DROP TABLE IF EXISTS Handle_Redirects;
+DROP INDEX IF EXISTS idx_users_nickname;
@@ -0,0 +1,80 @@
+-- Nicknames become handles: unique ignoring case, and optional
+UPDATE Users SET nickname = TRIM(nickname) WHERE nickname IS NOT NULL;
+UPDATE Users SET nickname = TRIM(SUBSTR(nickname, 2)) WHERE nickname LIKE '@%';
+UPDATE Users SET nickname = NULL WHERE nickname = '';
+
+-- Handles are 3 to 30 letters, digits or underscores, start with a letter and
+-- are not reserved; the names below are reservedHandles in
+-- internal/service/handle_service.go. A nickname breaking these rules keeps
+-- its letters, digits and underscores, behind "user" when it does not start
+-- with a letter, and gets its id appended, which also makes it long enough.
+CREATE TEMP TABLE handle_fixes AS
+WITH RECURSIVE kept(id, rest, handle) AS (
+    SELECT id, nickname, '' FROM Users WHERE nickname IS NOT NULL
+    UNION ALL
+    SELECT id, SUBSTR(rest, 2),
+        handle || CASE WHEN SUBSTR(rest, 1, 1) GLOB '[A-Za-z0-9_]' THEN SUBSTR(rest, 1, 1) ELSE '' END
+    FROM kept WHERE rest != ''
+),
+cleaned(id, nickname, handle) AS (
+    SELECT Users.id, Users.nickname, CASE
+        WHEN kept.handle GLOB '[A-Za-z]*' THEN kept.handle
+        WHEN kept.handle = '' THEN 'user'
+        WHEN kept.handle GLOB '_*' THEN 'user' || kept.handle
+        ELSE 'user_' || kept.handle
+    END
+    FROM kept JOIN Users ON Users.id = kept.id
+    WHERE kept.rest = ''
+)
+SELECT id, SUBSTR(handle, 1, 30 - LENGTH('_' || id)) || '_' || id AS handle FROM cleaned
+WHERE handle != nickname OR LENGTH(nickname) NOT BETWEEN 3 AND 30 OR LOWER(nickname) IN (
+    'admin', 'administrator', 'moderator', 'mod', 'staff',
+    'support', 'help', 'official', 'root', 'system',
+    'api', 'auth', 'login', 'logout', 'register', 'signup',
+    'settings', 'me', 'profile', 'users', 'posts', 'groups',
+    'search', 'everyone', 'here', 'anonymous', 'null', 'undefined'
+);
+
+UPDATE Users SET nickname = (SELECT handle FROM handle_fixes WHERE handle_fixes.id = Users.id)
+WHERE id IN (SELECT id FROM handle_fixes);
+
+DROP TABLE handle_fixes;
+
+-- The oldest account keeps a shared nickname; later ones get their id
+-- appended, more times over while that still names another user, cutting the
+-- nickname short to stay within 30 characters. Names ending in '_' || id
+-- cannot clash with each other, so the renamed handles are unique.
+CREATE TEMP TABLE handle_renames AS
+WITH RECURSIVE candidates(id, nickname, n, handle) AS (
+    SELECT id, nickname, 1, SUBSTR(nickname, 1, 30 - LENGTH('_' || id)) || '_' || id FROM Users
+    WHERE nickname IS NOT NULL AND EXISTS (
+        SELECT 1 FROM Users older
+        WHERE older.nickname = Users.nickname COLLATE NOCASE AND older.id < Users.id
+    )
+    UNION ALL
+    SELECT id, nickname, n + 1,
+        SUBSTR(nickname, 1, 30 - (n + 1) * LENGTH('_' || id)) || REPLACE(HEX(ZEROBLOB(n + 1)), '00', '_' || id)
+    FROM candidates
+    WHERE EXISTS (SELECT 1 FROM Users other WHERE other.nickname = candidates.handle COLLATE NOCASE AND other.id != candidates.id)
+        AND 30 - (n + 1) * LENGTH('_' || id) >= 1
+)
+SELECT id, handle FROM candidates
+WHERE NOT EXISTS (SELECT 1 FROM Users other WHERE other.nickname = candidates.handle COLLATE NOCASE AND other.id != candidates.id);
+
+UPDATE Users SET nickname = (SELECT handle FROM handle_renames WHERE handle_renames.id = Users.id)
+WHERE id IN (SELECT id FROM handle_renames);
+
+DROP TABLE handle_renames;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_nickname ON Users(nickname COLLATE NOCASE);
+
+-- Old handles keep resolving to the renamed user, and stay reserved, until they expire
+CREATE TABLE IF NOT EXISTS Handle_Redirects (
+    handle TEXT PRIMARY KEY COLLATE NOCASE,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_handle_redirects_user_id ON Handle_Redirects(user_id);
@@ -0,0 +1,2 @@
+-- Drop Blocks table
+DROP TABLE IF EXISTS Blocks;
@@ -0,0 +1,11 @@
+-- Create Blocks table; a block hides the two users from each other in both directions
+CREATE TABLE IF NOT EXISTS Blocks (
+    blocker_id INTEGER NOT NULL,
+    blocked_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES Users(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocks_blocked_id ON Blocks(blocked_id);
@@ -0,0 +1,3 @@
+-- Drop mute tables
+DROP TABLE IF EXISTS Muted_Keywords;
+DROP TABLE IF EXISTS Muted_Users;
@@ -0,0 +1,20 @@
+-- Create Muted_Users table; muted users' posts and notifications are hidden from the muter only
+CREATE TABLE IF NOT EXISTS Muted_Users (
+    user_id INTEGER NOT NULL,
+    muted_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME,        -- NULL for mutes that never expire
+    PRIMARY KEY (user_id, muted_id),
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE,
+    FOREIGN KEY (muted_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+-- Create Muted_Keywords table; posts and notifications containing a keyword are hidden
+CREATE TABLE IF NOT EXISTS Muted_Keywords (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    keyword TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, keyword COLLATE NOCASE)
+);
@@ -0,0 +1,4 @@
+-- Drop Data_Exports table and its indexes
+DROP INDEX IF EXISTS idx_data_exports_status;
+DROP INDEX IF EXISTS idx_data_exports_user;
+DROP TABLE IF EXISTS Data_Exports;
@@ -0,0 +1,15 @@
+-- Create Data_Exports table; each row is one requested archive of a user's data
+CREATE TABLE IF NOT EXISTS Data_Exports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'ready', 'failed', 'expired')),
+    file_name TEXT,             -- archive inside the export directory while ready
+    error TEXT,
+    created_at DATETIME NOT NULL,
+    completed_at DATETIME,
+    expires_at DATETIME,        -- the archive is deleted and the export expired after this
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_user ON Data_Exports(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON Data_Exports(status);
@@ -0,0 +1,5 @@
+-- Drop Post_Attachments table and its indexes; Posts.image and Comments.image still hold the first image
+DROP INDEX IF EXISTS idx_post_attachments_user;
+DROP INDEX IF EXISTS idx_post_attachments_comment;
+DROP INDEX IF EXISTS idx_post_attachments_post;
+DROP TABLE IF EXISTS Post_Attachments;
@@ -0,0 +1,28 @@
+-- Create Post_Attachments table; each row is one image in the gallery of a post or a comment
+CREATE TABLE IF NOT EXISTS Post_Attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER,
+    comment_id INTEGER,
+    user_id INTEGER NOT NULL,
+    path TEXT NOT NULL,             -- relative to the attachments directory
+    position INTEGER NOT NULL DEFAULT 0,
+    alt_text TEXT NOT NULL DEFAULT '',
+    width INTEGER NOT NULL DEFAULT 0,   -- 0 when the size could not be read
+    height INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((post_id IS NULL) != (comment_id IS NULL)),
+    FOREIGN KEY (post_id) REFERENCES Posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_attachments_post ON Post_Attachments(post_id, position);
+CREATE INDEX IF NOT EXISTS idx_post_attachments_comment ON Post_Attachments(comment_id, position);
+CREATE INDEX IF NOT EXISTS idx_post_attachments_user ON Post_Attachments(user_id);
+
+-- Existing single images become the first attachment of their post or comment
+INSERT INTO Post_Attachments (post_id, user_id, path, position, created_at)
+SELECT id, user_id, image, 0, created_at FROM Posts WHERE image IS NOT NULL AND image != '';
+
+INSERT INTO Post_Attachments (comment_id, user_id, path, position, created_at)
+SELECT id, user_id, image, 0, created_at FROM Comments WHERE image IS NOT NULL AND image != '';
@@ -0,0 +1,7 @@
+-- Drop Post_Tags and Tags tables and their indexes
+DROP INDEX IF EXISTS idx_post_tags_used_at;
+DROP INDEX IF EXISTS idx_post_tags_tag;
+DROP INDEX IF EXISTS idx_post_tags_comment;
+DROP INDEX IF EXISTS idx_post_tags_post;
+DROP TABLE IF EXISTS Post_Tags;
+DROP TABLE IF EXISTS Tags;
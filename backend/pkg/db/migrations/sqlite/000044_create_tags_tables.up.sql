@@ -0,0 +1,25 @@
+-- Create Tags table; one row per normalized hashtag, lower case and without the #
+CREATE TABLE IF NOT EXISTS Tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+-- Create Post_Tags table; each row is one use of a tag in a post or a comment
+CREATE TABLE IF NOT EXISTS Post_Tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tag_id INTEGER NOT NULL,
+    post_id INTEGER,
+    comment_id INTEGER,
+    user_id INTEGER NOT NULL,
+    used_at DATETIME NOT NULL,      -- when the tag was added, for trending
+    CHECK ((post_id IS NULL) != (comment_id IS NULL)),
+    FOREIGN KEY (tag_id) REFERENCES Tags(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES Posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_tags_post ON Post_Tags(post_id, tag_id) WHERE post_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_tags_comment ON Post_Tags(comment_id, tag_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON Post_Tags(tag_id, used_at);
+CREATE INDEX IF NOT EXISTS idx_post_tags_used_at ON Post_Tags(used_at);
@@ -0,0 +1,7 @@
+-- Drop Mentions table and its indexes
+DROP INDEX IF EXISTS idx_mentions_author_id;
+DROP INDEX IF EXISTS idx_mentions_user_id;
+DROP INDEX IF EXISTS idx_mentions_message;
+DROP INDEX IF EXISTS idx_mentions_comment;
+DROP INDEX IF EXISTS idx_mentions_post;
+DROP TABLE IF EXISTS Mentions;
@@ -0,0 +1,22 @@
+-- Create Mentions table; each row is one user @mentioned in a post, a comment or a chat message
+CREATE TABLE IF NOT EXISTS Mentions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,       -- who was mentioned
+    author_id INTEGER NOT NULL,     -- who wrote the mention
+    post_id INTEGER,
+    comment_id INTEGER,
+    message_id INTEGER,
+    created_at DATETIME NOT NULL,
+    CHECK ((post_id IS NOT NULL) + (comment_id IS NOT NULL) + (message_id IS NOT NULL) = 1),
+    FOREIGN KEY (user_id) REFERENCES Users(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES Users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES Posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES Messages(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_post ON Mentions(post_id, user_id) WHERE post_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_comment ON Mentions(comment_id, user_id) WHERE comment_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_message ON Mentions(message_id, user_id) WHERE message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_mentions_user_id ON Mentions(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_mentions_author_id ON Mentions(author_id);
@@ -0,0 +1,46 @@
+-- Full-text indexes over the content of posts and comments. They are
+-- external content tables: the text stays in Posts and Comments, and the
+-- triggers below keep the indexes in step with it.
+CREATE VIRTUAL TABLE IF NOT EXISTS Posts_FTS USING fts5(
+    content,
+    content = 'Posts',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS Comments_FTS USING fts5(
+    content,
+    content = 'Comments',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_insert AFTER INSERT ON Posts BEGIN
+    INSERT INTO Posts_FTS (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_delete AFTER DELETE ON Posts BEGIN
+    INSERT INTO Posts_FTS (Posts_FTS, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_fts_update AFTER UPDATE OF content ON Posts BEGIN
+    INSERT INTO Posts_FTS (Posts_FTS, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO Posts_FTS (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_insert AFTER INSERT ON Comments BEGIN
+    INSERT INTO Comments_FTS (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_delete AFTER DELETE ON Comments BEGIN
+    INSERT INTO Comments_FTS (Comments_FTS, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_update AFTER UPDATE OF content ON Comments BEGIN
+    INSERT INTO Comments_FTS (Comments_FTS, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO Comments_FTS (rowid, content) VALUES (new.id, new.content);
+END;
+
+-- Index the posts and comments written before the search index existed
+INSERT INTO Posts_FTS (Posts_FTS) VALUES ('rebuild');
+INSERT INTO Comments_FTS (Comments_FTS) VALUES ('rebuild');
@@ -6,8 +6,20 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// fts5Suffix marks migrations that need SQLite's FTS5 module, which
+// go-sqlite3 only compiles in with the sqlite_fts5 build tag.
+const fts5Suffix = ".fts5.up.sql"
+
+// HasFTS5 reports whether SQLite was built with the FTS5 full-text search module.
+func HasFTS5(db *sql.DB) bool {
+	var enabled bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled)
+	return err == nil && enabled
+}
+
 func ensureSchemaVersionTable(db *sql.DB) error {
 	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY);`)
 	return err
@@ -36,7 +48,15 @@ func GetAppliedMigrations(db *sql.DB) (map[string]bool, error) {
 	return applied, nil
 }
 
+// ApplyMigrationInTx runs one migration file and records it. A migration
+// that needs FTS5 is skipped, and left unrecorded so that it runs once SQLite
+// has FTS5, when SQLite was built without it.
 func ApplyMigrationInTx(db *sql.DB, migrationDir, fileName string) (err error) {
+	if strings.HasSuffix(fileName, fts5Suffix) && !HasFTS5(db) {
+		log.Printf("skipping %s: SQLite was built without FTS5; build with -tags sqlite_fts5 to apply it", fileName)
+		return nil
+	}
+
 	path := filepath.Join(migrationDir, fileName)
 	content, err := os.ReadFile(path)
 	if err != nil {
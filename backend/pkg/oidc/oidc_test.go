@@ -0,0 +1,131 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajjjjr/social-network/backend/pkg/oidc"
+	"github.com/tajjjjr/social-network/backend/pkg/oidc/oidctest"
+)
+
+var noRedirects = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+func newProvider(t *testing.T) (*oidc.Provider, *oidctest.Issuer) {
+	t.Helper()
+	iss := oidctest.NewIssuer("client", "secret")
+	t.Cleanup(iss.Close)
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "test",
+		Issuer:       iss.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.example/auth/oidc/test/callback",
+	}, nil)
+	return provider, iss
+}
+
+// authorize follows the authorization URL and returns the code and state sent back.
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	resp, err := noRedirects.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorize: got %s, location %q", resp.Status, resp.Header.Get("Location"))
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider, iss := newProvider(t)
+	iss.SetUser(oidctest.User{Subject: "u-1", Email: "ada@example.com", EmailVerified: true, GivenName: "Ada"})
+	ctx := context.Background()
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(authURL, "code_challenge_method=S256") || !strings.Contains(authURL, "scope=openid+email+profile") {
+		t.Errorf("unexpected authorization URL %s", authURL)
+	}
+
+	code, state := authorize(t, authURL)
+	if state != "state-1" {
+		t.Errorf("state = %q", state)
+	}
+
+	if _, err := provider.Exchange(ctx, code, "wrong-verifier"); err == nil {
+		t.Fatal("exchange with the wrong PKCE verifier should fail")
+	}
+	code, _ = authorize(t, authURL)
+	rawIDToken, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "u-1" || claims.Email != "ada@example.com" || !claims.EmailVerified || claims.GivenName != "Ada" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if _, err := provider.VerifyIDToken(ctx, rawIDToken, "other-nonce", time.Now()); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("nonce mismatch: got %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce-1", time.Now().Add(time.Hour)); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expired token: got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsForgeries(t *testing.T) {
+	provider, iss := newProvider(t)
+	now := time.Now()
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": iss.Issuer(), "sub": "u-1", "aud": "client", "nonce": "n",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+	}
+	if _, err := provider.VerifyIDToken(context.Background(), iss.Sign(valid()), "n", now); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	signed := iss.Sign(valid())
+	parts := strings.Split(signed, ".")
+	tests := map[string]string{
+		"unsigned":     "eyJhbGciOiJub25lIn0." + parts[1] + ".",
+		"tampered":     parts[0] + "." + strings.Split(iss.Sign(map[string]any{"iss": iss.Issuer(), "sub": "admin", "aud": "client", "nonce": "n", "exp": now.Add(time.Minute).Unix()}), ".")[1] + "." + parts[2],
+		"wrong issuer": iss.Sign(func() map[string]any { c := valid(); c["iss"] = "https://evil.example"; return c }()),
+		"wrong client": iss.Sign(func() map[string]any { c := valid(); c["aud"] = "other"; return c }()),
+		"other azp": iss.Sign(func() map[string]any {
+			c := valid()
+			c["aud"] = []string{"client", "other"}
+			c["azp"] = "other"
+			return c
+		}()),
+		"no subject":    iss.Sign(func() map[string]any { c := valid(); delete(c, "sub"); return c }()),
+		"issued later":  iss.Sign(func() map[string]any { c := valid(); c["iat"] = now.Add(time.Hour).Unix(); return c }()),
+		"not a jwt":     "abc",
+		"missing parts": parts[0] + "." + parts[1],
+	}
+	for name, token := range tests {
+		if _, err := provider.VerifyIDToken(context.Background(), token, "n", now); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Errorf("%s: got %v, want ErrInvalidIDToken", name, err)
+		}
+	}
+}
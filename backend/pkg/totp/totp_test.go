@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 test vectors from RFC 6238, truncated to 6 digits.
+func TestCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		got, err := Code(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Code at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestVerify_Skew(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	code, _ := Code(secret, now)
+
+	if step, ok := Verify(secret, code, now.Add(Period), 1); !ok || step != Step(now) {
+		t.Errorf("code from the previous step should verify with skew 1, got %d %v", step, ok)
+	}
+	if _, ok := Verify(secret, code, now.Add(2*Period), 1); ok {
+		t.Error("code two steps old should not verify with skew 1")
+	}
+	if _, ok := Verify(secret, "12345", now, 1); ok {
+		t.Error("short code should not verify")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Social Network", "ada@example.com", "JBSWY3DPEHPK3PXP")
+	for _, want := range []string{"otpauth://totp/Social%20Network:ada@example.com?", "secret=JBSWY3DPEHPK3PXP", "issuer=Social+Network"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("%s missing %q", uri, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxHashtagLength is the longest hashtag indexed, in characters, without the #.
+	MaxHashtagLength = 64
+	// MaxHashtags is how many distinct hashtags are taken from one text.
+	MaxHashtags = 30
+)
+
+// Hashtags returns the distinct hashtags in text, normalized by
+// NormalizeHashtag, in order of first use. A # only starts a hashtag at the
+// start of the text or after a character that cannot be part of one, so
+// "a#b" and URL fragments are not tags.
+func Hashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i, r := range text {
+		if r == '#' && !isHashtagRune(prev) && prev != '#' {
+			end := i + 1
+			for end < len(text) {
+				next, size := utf8.DecodeRuneInString(text[end:])
+				if !isHashtagRune(next) {
+					break
+				}
+				end += size
+			}
+			if tag, ok := NormalizeHashtag(text[i+1 : end]); ok && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+				if len(tags) == MaxHashtags {
+					break
+				}
+			}
+		}
+		prev = r
+	}
+	return tags
+}
+
+// NormalizeHashtag returns the indexed form of a hashtag given with or
+// without its #: lower case, with at least one letter, and no longer than
+// MaxHashtagLength.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return "", false
+	}
+	return tag, true
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
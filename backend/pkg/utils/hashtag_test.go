@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	cases := map[string]string{
+		"#Go and #go again":                           "[go]",
+		"(#first), #second! end#not ##double":         "[first second]",
+		"see https://example.com/page#section":        "[]",
+		"#2024 is a number, #2024goals is not":        "[2024goals]",
+		"#café #日本 #snake_case":                       "[café 日本 snake_case]",
+		"#" + strings.Repeat("a", MaxHashtagLength+1): "[]",
+	}
+	for text, want := range cases {
+		if got := fmt.Sprint(Hashtags(text)); got != want {
+			t.Errorf("Hashtags(%q) = %s, want %s", text, got, want)
+		}
+	}
+
+	many := ""
+	for i := 0; i < MaxHashtags+5; i++ {
+		many += fmt.Sprintf(" #tag%d", i)
+	}
+	if got := len(Hashtags(many)); got != MaxHashtags {
+		t.Errorf("got %d tags, want at most %d", got, MaxHashtags)
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	for tag, want := range map[string]string{"#GoLang": "golang", "golang": "golang", "#": "", "go-lang": "", "123": ""} {
+		got, ok := NormalizeHashtag(tag)
+		if got != want || ok != (want != "") {
+			t.Errorf("NormalizeHashtag(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import "strings"
+
+const (
+	// MaxMentionLength is the longest handle that can be mentioned, without the @.
+	MaxMentionLength = 30
+	// MaxMentions is how many distinct handles are taken from one text.
+	MaxMentions = 20
+)
+
+// broadcastMentions address everyone reading rather than one person. They
+// are reserved as handles and never resolve to a user.
+var broadcastMentions = map[string]bool{"everyone": true, "here": true}
+
+// Mentions returns the distinct handles mentioned in text as @handle, in
+// order of first use and as first written; handles are matched ignoring case.
+// An @ only starts a mention at the start of the text or after a character
+// that cannot be part of a handle, so email addresses are not mentions.
+func Mentions(text string) []string {
+	var handles []string
+	seen := map[string]bool{}
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' || i > 0 && (isHandleByte(text[i-1]) || text[i-1] == '@') {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && isHandleByte(text[end]) {
+			end++
+		}
+		handle := text[i+1 : end]
+		key := strings.ToLower(handle)
+		if isMentionable(handle) && !broadcastMentions[key] && !seen[key] {
+			seen[key] = true
+			handles = append(handles, handle)
+			if len(handles) == MaxMentions {
+				break
+			}
+		}
+	}
+	return handles
+}
+
+// MentionPrefix returns the start of a handle being typed, given with or
+// without its @, and whether it could begin a handle at all.
+func MentionPrefix(text string) (string, bool) {
+	prefix := strings.TrimPrefix(text, "@")
+	if !isMentionable(prefix) {
+		return "", false
+	}
+	for i := 1; i < len(prefix); i++ {
+		if !isHandleByte(prefix[i]) {
+			return "", false
+		}
+	}
+	return prefix, true
+}
+
+// isMentionable follows the handle rules: a letter, then letters, digits or
+// underscores, MaxMentionLength at most.
+func isMentionable(handle string) bool {
+	if handle == "" || len(handle) > MaxMentionLength {
+		return false
+	}
+	first := handle[0]
+	return first >= 'a' && first <= 'z' || first >= 'A' && first <= 'Z'
+}
+
+func isHandleByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
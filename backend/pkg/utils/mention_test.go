@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	cases := map[string]string{
+		"@Ada and @ada, hi @bob_2!":                   "[Ada bob_2]",
+		"mail ada@example.com or @@ada":               "[]",
+		"(@ada) @9lives @everyone @HERE":              "[ada]",
+		"@" + strings.Repeat("a", MaxMentionLength+1): "[]",
+	}
+	for text, want := range cases {
+		if got := fmt.Sprint(Mentions(text)); got != want {
+			t.Errorf("Mentions(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
-	"errors"
+	"fmt"
+	"log"
+	"os"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,9 +24,76 @@ type PasswordManager struct {
 	Config PasswordConfig
 }
 
+var (
+	upperPattern   = regexp.MustCompile(`[A-Z]`)
+	lowerPattern   = regexp.MustCompile(`[a-z]`)
+	numberPattern  = regexp.MustCompile(`[0-9]`)
+	specialPattern = regexp.MustCompile(`[!@#~$%^&*()+|_.,<>?/\\-]`)
+)
+
+/*
+* DefaultPasswordConfig
+* returns the policy used when none is configured: at least 8 characters
+* with an uppercase and lowercase letter, a number and a special character.
+ */
+func DefaultPasswordConfig() PasswordConfig {
+	return PasswordConfig{
+		MinLength:      8,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireNumber:  true,
+		RequireSpecial: true,
+		BcryptCost:     bcrypt.DefaultCost,
+	}
+}
+
+/*
+* PasswordConfigFromEnv
+* overrides the default policy with PASSWORD_MIN_LENGTH, PASSWORD_BCRYPT_COST
+* and PASSWORD_REQUIRE_UPPER, _LOWER, _NUMBER and _SPECIAL ("true"/"false").
+ */
+func PasswordConfigFromEnv() PasswordConfig {
+	config := DefaultPasswordConfig()
+
+	for name, target := range map[string]*int{
+		"PASSWORD_MIN_LENGTH":  &config.MinLength,
+		"PASSWORD_BCRYPT_COST": &config.BcryptCost,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				*target = n
+			} else {
+				log.Printf("ignoring invalid %s=%q", name, value)
+			}
+		}
+	}
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		log.Printf("ignoring out of range PASSWORD_BCRYPT_COST=%d", config.BcryptCost)
+		config.BcryptCost = bcrypt.DefaultCost
+	}
+
+	for name, target := range map[string]*bool{
+		"PASSWORD_REQUIRE_UPPER":   &config.RequireUpper,
+		"PASSWORD_REQUIRE_LOWER":   &config.RequireLower,
+		"PASSWORD_REQUIRE_NUMBER":  &config.RequireNumber,
+		"PASSWORD_REQUIRE_SPECIAL": &config.RequireSpecial,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if b, err := strconv.ParseBool(value); err == nil {
+				*target = b
+			} else {
+				log.Printf("ignoring invalid %s=%q", name, value)
+			}
+		}
+	}
+	return config
+}
+
 /*
 * NewPasswordManager
 * creates a new PasswordManager with the given config.
+* The character rules are applied as configured; use DefaultPasswordConfig
+* or PasswordConfigFromEnv for the standard policy.
  */
 func NewPasswordManager(config PasswordConfig) *PasswordManager {
 	// Set sensible defaults if not provided
@@ -32,14 +103,22 @@ func NewPasswordManager(config PasswordConfig) *PasswordManager {
 	if config.BcryptCost == 0 {
 		config.BcryptCost = bcrypt.DefaultCost
 	}
-	config.RequireLower = true
-	config.RequireSpecial = true
-	config.RequireUpper = true
-	config.RequireNumber = true
 
 	return &PasswordManager{Config: config}
 }
 
+/*
+* PasswordPolicyError
+* lists every rule a password failed.
+ */
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
 /*
 * HashPassword
 * hashes the plain-text password after validating strength.
@@ -62,23 +141,28 @@ func (pm *PasswordManager) ComparePassword(hashed, plain string) error {
 
 /*
 * ValidatePasswordStrength
-* checks if the password meets configured rules.
+* checks if the password meets configured rules, returning a
+* *PasswordPolicyError naming each rule it fails.
  */
 func (pm *PasswordManager) ValidatePasswordStrength(password string) error {
+	var violations []string
 	if len(password) < pm.Config.MinLength {
-		return errors.New("password must be at least 8 characters")
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters", pm.Config.MinLength))
+	}
+	if pm.Config.RequireUpper && !upperPattern.MatchString(password) {
+		violations = append(violations, "password must contain an uppercase letter")
 	}
-	if pm.Config.RequireUpper && !regexp.MustCompile(`[A-Z]`).MatchString(password) {
-		return errors.New("password must contain an uppercase letter")
+	if pm.Config.RequireLower && !lowerPattern.MatchString(password) {
+		violations = append(violations, "password must contain a lowercase letter")
 	}
-	if pm.Config.RequireLower && !regexp.MustCompile(`[a-z]`).MatchString(password) {
-		return errors.New("password must contain a lowercase letter")
+	if pm.Config.RequireNumber && !numberPattern.MatchString(password) {
+		violations = append(violations, "password must contain a number")
 	}
-	if pm.Config.RequireNumber && !regexp.MustCompile(`[0-9]`).MatchString(password) {
-		return errors.New("password must contain a number")
+	if pm.Config.RequireSpecial && !specialPattern.MatchString(password) {
+		violations = append(violations, "password must contain a special character")
 	}
-	if pm.Config.RequireSpecial && !regexp.MustCompile(`[!@#~$%^&*()+|_.,<>?/\\-]`).MatchString(password) {
-		return errors.New("password must contain a special character")
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
 	}
 	return nil
 }
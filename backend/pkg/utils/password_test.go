@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePasswordStrength_NamesEveryRule(t *testing.T) {
+	pm := NewPasswordManager(DefaultPasswordConfig())
+
+	var policyErr *PasswordPolicyError
+	if !errors.As(pm.ValidatePasswordStrength("abc"), &policyErr) {
+		t.Fatal("expected a *PasswordPolicyError")
+	}
+	if len(policyErr.Violations) != 4 {
+		t.Errorf("expected length, uppercase, number and special violations, got %q", policyErr.Violations)
+	}
+
+	if err := pm.ValidatePasswordStrength("Str0ng-Password"); err != nil {
+		t.Errorf("strong password rejected: %v", err)
+	}
+}
+
+func TestPasswordConfigFromEnv(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_BCRYPT_COST", "5")
+	t.Setenv("PASSWORD_REQUIRE_SPECIAL", "false")
+
+	pm := NewPasswordManager(PasswordConfigFromEnv())
+	if err := pm.ValidatePasswordStrength("Abcdefgh1234"); err != nil {
+		t.Errorf("special characters should be optional: %v", err)
+	}
+	if err := pm.ValidatePasswordStrength("Abcdefgh123"); err == nil {
+		t.Error("minimum length of 12 should be enforced")
+	}
+
+	hash, err := pm.HashPassword("Abcdefgh1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != 5 {
+		t.Errorf("expected bcrypt cost 5, got %d", cost)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+/*
+* SecretBox
+* encrypts small secrets, such as TOTP seeds, before they are stored.
+* It uses AES-256-GCM and encodes the nonce and ciphertext as base64.
+ */
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != 32 {
+		return nil, errors.New("secret box key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+/*
+* SecretBoxFromEnv
+* reads a base64 encoded 32 byte key from SECRET_ENCRYPTION_KEY.
+* Without a valid key it returns nil, leaving features that store secrets
+* switched off, unless ALLOW_INSECURE_SECRET_KEY=true asks for the fixed
+* development key, which is public and only fit for local development.
+ */
+func SecretBoxFromEnv() *SecretBox {
+	var key []byte
+	if value := os.Getenv("SECRET_ENCRYPTION_KEY"); value != "" {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && len(decoded) == 32 {
+			key = decoded
+		} else {
+			log.Printf("ignoring invalid SECRET_ENCRYPTION_KEY: expected 32 bytes of base64")
+		}
+	}
+	if key == nil {
+		if os.Getenv("ALLOW_INSECURE_SECRET_KEY") != "true" {
+			log.Printf("WARNING: SECRET_ENCRYPTION_KEY is not set; two-factor authentication is unavailable until it is")
+			return nil
+		}
+		log.Printf("WARNING: SECRET_ENCRYPTION_KEY is not set; ALLOW_INSECURE_SECRET_KEY is on, so secrets are encrypted with a public development key. Never use this in production")
+		sum := sha256.Sum256([]byte("social-network development key"))
+		key = sum[:]
+	}
+
+	box, err := NewSecretBox(key)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create secret box: %v", err))
+	}
+	return box
+}
+
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *SecretBox) Open(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+	if len(data) < b.aead.NonceSize() {
+		return "", errors.New("sealed secret is too short")
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
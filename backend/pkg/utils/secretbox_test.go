@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSecretBox(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Error("sealed value must not contain the plaintext")
+	}
+	if opened, err := box.Open(sealed); err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Open() = %q, %v", opened, err)
+	}
+
+	other, _ := NewSecretBox(bytes.Repeat([]byte{8}, 32))
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("a different key must not open the secret")
+	}
+}
+
+func TestSecretBoxFromEnv(t *testing.T) {
+	t.Setenv("SECRET_ENCRYPTION_KEY", "")
+	t.Setenv("ALLOW_INSECURE_SECRET_KEY", "")
+	if box := SecretBoxFromEnv(); box != nil {
+		t.Error("without a key there must be no box")
+	}
+
+	t.Setenv("SECRET_ENCRYPTION_KEY", "too short")
+	if box := SecretBoxFromEnv(); box != nil {
+		t.Error("an invalid key must not fall back to the development key")
+	}
+
+	t.Setenv("ALLOW_INSECURE_SECRET_KEY", "true")
+	if box := SecretBoxFromEnv(); box == nil {
+		t.Error("the development key must be used when allowed")
+	}
+
+	t.Setenv("SECRET_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	t.Setenv("ALLOW_INSECURE_SECRET_KEY", "")
+	box := SecretBoxFromEnv()
+	keyed, _ := NewSecretBox(bytes.Repeat([]byte{7}, 32))
+	sealed, _ := keyed.Seal("JBSWY3DPEHPK3PXP")
+	if box == nil {
+		t.Fatal("a valid key must give a box")
+	}
+	if opened, err := box.Open(sealed); err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("the box does not use the configured key: %q, %v", opened, err)
+	}
+}
@@ -1,11 +1,9 @@
 package utils
 
-import "context"
-
-type UserID string
-
 type Response struct {
-	Message string `json:"message,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Code    string   `json:"code,omitempty"`   // machine-readable reason, set where clients must react to a specific error
+	Errors  []string `json:"errors,omitempty"` // every failed rule, for validation errors
 }
 
 type PaginationMeta struct {
@@ -20,10 +18,3 @@ type PostsResponse struct {
 	Posts      interface{}    `json:"posts"`
 	Pagination PaginationMeta `json:"pagination"`
 }
-
-var User_id UserID = "userID"
-
-// SetUserContext is a helper function for tests to set user context
-func SetUserContext(ctx context.Context, userID int64) context.Context {
-	return context.WithValue(ctx, User_id, userID)
-}
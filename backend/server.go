@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/tajjjjr/social-network/backend/internal/api"
-	"github.com/tajjjjr/social-network/backend/internal/api/middleware"
 	"github.com/tajjjjr/social-network/backend/pkg/db/sqlite"
 	"github.com/tajjjjr/social-network/backend/pkg/utils"
 )
@@ -26,14 +31,40 @@ func main() {
 	}
 	defer db.Close()
 
+	if len(os.Args) > 1 {
+		if err := runCommand(db, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	Port := utils.Port(Port)
 	srvAddr := fmt.Sprintf("%s:%d", Host, Port)
 
-	// Create a new router
+	// Create a new router; it applies CORS and CSRF protection itself
 	router := api.NewRouter(db)
+	server := &http.Server{Addr: srvAddr, Handler: router}
+
+	// Interrupting the server stops the background jobs and lets requests in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workersDone := make(chan struct{})
+	go func() {
+		router.RunWorkers(ctx)
+		close(workersDone)
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Println("error shutting down:", err)
+		}
+	}()
 
 	fmt.Printf("\n\n\n\t-----------[ server running on http://%s]-------------\n\n", srvAddr)
-	if err := http.ListenAndServe(srvAddr, middleware.CORSMiddleware(router)); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	<-workersDone
 }
@@ -11,13 +11,18 @@ import { generateInitialSkeletons, generatePaginationSkeletons } from '../../lib
 import { profileAPI } from '../../lib/api';
 import { postAPI } from '../../lib/api';
 
+// How often the feed checks for posts newer than the ones shown
+const NEW_POSTS_POLL_INTERVAL = 30000;
+
 const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = false }) => {
   const router = useRouter();
   const [posts, setPosts] = useState([]);
   const [loading, setLoading] = useState(true);
   const [loadingMore, setLoadingMore] = useState(false);
   const [error, setError] = useState('');
-  const [page, setPage] = useState(1);
+  const [nextCursor, setNextCursor] = useState('');
+  const [prevCursor, setPrevCursor] = useState('');
+  const [newPostsCount, setNewPostsCount] = useState(0);
   const [hasMore, setHasMore] = useState(true);
   const [expandedComments, setExpandedComments] = useState(new Set());
   const [newComments, setNewComments] = useState({});
@@ -29,29 +34,34 @@ const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = fal
   const [editLoading, setEditLoading] = useState(false);
   const loadingRef = useRef(false);
 
-  const loadPosts = async (pageNum = 1, append = false) => {
+  // loadPosts loads the newest page of the feed, or with a cursor the page
+  // of older posts after it, appended to the ones shown.
+  const loadPosts = async (cursor = '') => {
+    const append = cursor !== '';
     if (append) {
       setLoadingMore(true);
     } else {
       setLoading(true);
       setPosts([]);
-      setPage(1);
+      setNextCursor('');
       setHasMore(true);
     }
     setError('');
 
     try {
-      const result = await postAPI.fetchPostsPaginated(pageNum, 10);
+      const result = await postAPI.fetchFeed(cursor, 10);
 
       if (result.success) {
-        const { posts: newPosts, pagination } = result.data;
+        const { posts: newPosts = [], next_cursor = '', prev_cursor = '' } = result.data;
         if (append) {
           setPosts(prev => [...prev, ...newPosts]);
         } else {
           setPosts(newPosts);
+          setPrevCursor(prev_cursor);
+          setNewPostsCount(0);
         }
-        setHasMore(pagination.hasMore);
-        setPage(pageNum + 1);
+        setNextCursor(next_cursor);
+        setHasMore(next_cursor !== '');
       } else {
         setError(result.error);
       }
@@ -76,7 +86,28 @@ const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = fal
   const loadMorePosts = () => {
     if (loadingRef.current || loadingMore || !hasMore) return;
     loadingRef.current = true;
-    loadPosts(page, true);
+    loadPosts(nextCursor);
+  };
+
+  // Poll for posts newer than the first page so the reader can choose to
+  // refresh, rather than having the feed shift under them.
+  useEffect(() => {
+    if (profileView || initialPosts || !prevCursor) return;
+
+    const checkForNewPosts = async () => {
+      const result = await postAPI.countNewPosts(prevCursor);
+      if (result.success) {
+        setNewPostsCount(result.data);
+      }
+    };
+
+    const interval = setInterval(checkForNewPosts, NEW_POSTS_POLL_INTERVAL);
+    return () => clearInterval(interval);
+  }, [prevCursor, profileView, initialPosts]);
+
+  const showNewPosts = () => {
+    window.scrollTo({ top: 0, behavior: 'smooth' });
+    loadPosts();
   };
 
   useEffect(() => {
@@ -93,7 +124,7 @@ const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = fal
     const debouncedScroll = debounce(handleScroll, 200);
     window.addEventListener('scroll', debouncedScroll);
     return () => window.removeEventListener('scroll', debouncedScroll);
-  }, [page, loadingMore, hasMore, profileView]);
+  }, [nextCursor, loadingMore, hasMore, profileView]);
 
   const debounce = (func, wait) => {
     let timeout;
@@ -266,7 +297,7 @@ const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = fal
       <div className="rounded-xl p-4 mb-4" style={{ backgroundColor: 'rgba(var(--danger-color-rgb), 0.2)', border: '1px solid var(--warning-color)' }}>
         <div style={{ color: 'var(--warning-color)' }} className="text-center">{error}</div>
         <button
-          onClick={loadPosts}
+          onClick={() => loadPosts()}
           className="mt-2 w-full py-2 px-4 rounded-lg transition-colors"
           style={{ backgroundColor: 'var(--warning-color)', color: 'var(--primary-text)' }}
           onMouseOver={(e) => e.currentTarget.style.opacity = '0.8'}
@@ -289,6 +320,19 @@ const PostList = ({ refreshTrigger, user, posts: initialPosts, profileView = fal
 
   return (
     <div className="space-y-4">
+      {/* New posts banner - Only show in non-profile view */}
+      {!profileView && newPostsCount > 0 && (
+        <button
+          onClick={showNewPosts}
+          className="w-full py-2 px-4 rounded-lg text-sm font-medium transition-colors"
+          style={{ backgroundColor: 'var(--primary-accent)', color: 'var(--quinary-text)' }}
+          onMouseEnter={(e) => e.currentTarget.style.opacity = '0.8'}
+          onMouseLeave={(e) => e.currentTarget.style.opacity = '1'}
+        >
+          {newPostsCount === 1 ? '1 new post' : `${newPostsCount} new posts`}
+        </button>
+      )}
+
       {posts.map((post) => (
         <div key={post.id} className="rounded-xl p-4 post-content" style={{ backgroundColor: 'var(--primary-background)' }}>
           {/* Post Header */}
@@ -4,25 +4,40 @@ import { useRouter } from 'next/navigation';
 
 const ProfileFollowers = ({ user, currentUser, isOwnProfile }) => {
   const [followers, setFollowers] = useState([]);
+  const [count, setCount] = useState(0);
+  const [nextCursor, setNextCursor] = useState('');
+  const [loadingMore, setLoadingMore] = useState(false);
 
   const router = useRouter();
 
-  useEffect(() => {
-    const fetchFollowers = async () => {
-      if (user && user.profile_details && user.profile_details.id) {
-        try {
-          const data = await profileAPI.getFollowers(user.profile_details.id);
-          setFollowers(data.user || []);
-        } catch (error) {
-          console.error('Error fetching followers:', error);
-        }
+  const fetchFollowers = async (cursor = '') => {
+    try {
+      const result = await profileAPI.getFollowers(user.profile_details.id, cursor);
+      if (result.success) {
+        setFollowers(prev => cursor ? [...prev, ...result.data.user] : result.data.user);
+        setNextCursor(result.data.nextCursor);
       }
-    };
+    } catch (error) {
+      console.error('Error fetching followers:', error);
+    }
+  };
 
-    
-    fetchFollowers();
+  useEffect(() => {
+    if (user && user.profile_details && user.profile_details.id) {
+      fetchFollowers();
+      profileAPI.getFollowersCount(user.profile_details.id).then(result => {
+        if (result.success) setCount(result.data);
+      });
+    }
   }, [user]);
 
+  const loadMore = async () => {
+    if (loadingMore || !nextCursor) return;
+    setLoadingMore(true);
+    await fetchFollowers(nextCursor);
+    setLoadingMore(false);
+  };
+
   // Handle view profile
   const handleViewProfile = (userId) => {
     router.push(`/profile/${userId}`);
@@ -35,7 +50,7 @@ const ProfileFollowers = ({ user, currentUser, isOwnProfile }) => {
         className="rounded-xl p-6"
         style={{ backgroundColor: 'var(--primary-background)' }}
       >
-        <h3 className="text-xl font-bold mb-4 text-white">Followers ({followers.length})</h3>
+        <h3 className="text-xl font-bold mb-4 text-white">Followers ({count})</h3>
         <div className="grid grid-cols-2 md:grid-cols-3 gap-4">
           {followers && followers.length > 0 ? (
             followers.map((follower) => (
@@ -62,6 +77,18 @@ const ProfileFollowers = ({ user, currentUser, isOwnProfile }) => {
             <p className="text-white">No followers yet. Start sharing your content to attract followers!</p>
           )}
         </div>
+        {nextCursor && (
+          <button
+            onClick={loadMore}
+            disabled={loadingMore}
+            className="mt-4 w-full py-2 px-4 rounded-lg transition-colors"
+            style={{ backgroundColor: 'var(--secondary-background)', color: 'var(--primary-text)' }}
+            onMouseOver={(e) => e.currentTarget.style.backgroundColor = 'var(--hover-background)'}
+            onMouseOut={(e) => e.currentTarget.style.backgroundColor = 'var(--secondary-background)'}
+          >
+            {loadingMore ? 'Loading...' : 'Load more'}
+          </button>
+        )}
       </div>
     </div>
   );
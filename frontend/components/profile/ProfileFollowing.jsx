@@ -4,24 +4,40 @@ import { useRouter } from 'next/navigation';
 
 const ProfileFollowing = ({ user, currentUser, isOwnProfile }) => {
   const [following, setFollowing] = useState([]);
+  const [count, setCount] = useState(0);
+  const [nextCursor, setNextCursor] = useState('');
+  const [loadingMore, setLoadingMore] = useState(false);
 
   const router = useRouter();
 
-  useEffect(() => {
-    const fetchFollowing = async () => {
-      if (user && user.profile_details && user.profile_details.id) {
-        try {
-          const data = await profileAPI.getFollowing(user.profile_details.id);
-          setFollowing(data.user || []);
-        } catch (error) {
-          console.error('Error fetching following:', error);
-        }
+  const fetchFollowing = async (cursor = '') => {
+    try {
+      const result = await profileAPI.getFollowing(user.profile_details.id, cursor);
+      if (result.success) {
+        setFollowing(prev => cursor ? [...prev, ...result.data.user] : result.data.user);
+        setNextCursor(result.data.nextCursor);
       }
-    };
+    } catch (error) {
+      console.error('Error fetching following:', error);
+    }
+  };
 
-    fetchFollowing();
+  useEffect(() => {
+    if (user && user.profile_details && user.profile_details.id) {
+      fetchFollowing();
+      profileAPI.getFollowingCount(user.profile_details.id).then(result => {
+        if (result.success) setCount(result.data);
+      });
+    }
   }, [user]);
 
+  const loadMore = async () => {
+    if (loadingMore || !nextCursor) return;
+    setLoadingMore(true);
+    await fetchFollowing(nextCursor);
+    setLoadingMore(false);
+  };
+
   // Handle view profile
   const handleViewProfile = (userId) => {
     router.push(`/profile/${userId}`);
@@ -34,7 +50,7 @@ const ProfileFollowing = ({ user, currentUser, isOwnProfile }) => {
         className="rounded-xl p-6"
         style={{ backgroundColor: 'var(--primary-background)' }}
       >
-        <h3 className="text-xl font-bold mb-4 text-white">Following ({following.length})</h3>
+        <h3 className="text-xl font-bold mb-4 text-white">Following ({count})</h3>
         <div className="grid grid-cols-2 md:grid-cols-3 gap-4">
           {following && following.length > 0 ? (
             following.map((followedUser) => (
@@ -58,6 +74,18 @@ const ProfileFollowing = ({ user, currentUser, isOwnProfile }) => {
             <p className="text-white">Not following anyone yet. Explore and connect with other users!</p>
           )}
         </div>
+        {nextCursor && (
+          <button
+            onClick={loadMore}
+            disabled={loadingMore}
+            className="mt-4 w-full py-2 px-4 rounded-lg transition-colors"
+            style={{ backgroundColor: 'var(--secondary-background)', color: 'var(--primary-text)' }}
+            onMouseOver={(e) => e.currentTarget.style.backgroundColor = 'var(--hover-background)'}
+            onMouseOut={(e) => e.currentTarget.style.backgroundColor = 'var(--secondary-background)'}
+          >
+            {loadingMore ? 'Loading...' : 'Load more'}
+          </button>
+        )}
       </div>
     </div>
   );
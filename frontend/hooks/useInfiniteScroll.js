@@ -1,8 +1,9 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
 
+// fetchFunction(cursor, limit) loads a page of posts: the first page with an
+// empty cursor, then the page after the previous page's next_cursor.
 const useInfiniteScroll = (fetchFunction, options = {}) => {
   const {
-    initialPage = 1,
     limit = 15,
     threshold = 200
   } = options;
@@ -12,12 +13,12 @@ const useInfiniteScroll = (fetchFunction, options = {}) => {
   const [initialLoading, setInitialLoading] = useState(true);
   const [hasMore, setHasMore] = useState(true);
   const [error, setError] = useState(null);
-  const [page, setPage] = useState(initialPage);
+  const [cursor, setCursor] = useState('');
   
   const observerRef = useRef();
   const loadingRef = useRef(false);
 
-  const loadMore = useCallback(async (pageNum = page, isInitial = false) => {
+  const loadMore = useCallback(async (pageCursor = cursor, isInitial = false) => {
     if (loadingRef.current || (!hasMore && !isInitial)) return;
     
     loadingRef.current = true;
@@ -25,14 +26,14 @@ const useInfiniteScroll = (fetchFunction, options = {}) => {
     setError(null);
 
     try {
-      const result = await fetchFunction(pageNum, limit);
+      const result = await fetchFunction(pageCursor, limit);
       
       if (result.success) {
-        const { posts, pagination } = result.data;
+        const { posts = [], next_cursor = '' } = result.data;
         
-        setData(prevData => pageNum === 1 ? posts : [...prevData, ...posts]);
-        setHasMore(pagination.hasMore);
-        setPage(pageNum + 1);
+        setData(prevData => pageCursor === '' ? posts : [...prevData, ...posts]);
+        setHasMore(next_cursor !== '');
+        setCursor(next_cursor);
       } else {
         setError(result.error);
       }
@@ -43,7 +44,7 @@ const useInfiniteScroll = (fetchFunction, options = {}) => {
       setInitialLoading(false);
       loadingRef.current = false;
     }
-  }, [fetchFunction, limit, page, hasMore]);
+  }, [fetchFunction, limit, cursor, hasMore]);
 
   const observerCallback = useCallback((entries) => {
     const [entry] = entries;
@@ -64,7 +65,7 @@ const useInfiniteScroll = (fetchFunction, options = {}) => {
   }, [observerCallback, threshold]);
 
   useEffect(() => {
-    loadMore(initialPage, true);
+    loadMore('', true);
   }, []);
 
   useEffect(() => {
@@ -77,11 +78,11 @@ const useInfiniteScroll = (fetchFunction, options = {}) => {
 
   const refresh = useCallback(() => {
     setData([]);
-    setPage(initialPage);
+    setCursor('');
     setHasMore(true);
     setError(null);
-    loadMore(initialPage, true);
-  }, [loadMore, initialPage]);
+    loadMore('', true);
+  }, [loadMore]);
 
   return {
     data,
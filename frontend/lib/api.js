@@ -1,9 +1,10 @@
 import { fetchComments, fetchPostsPaginated } from "./auth";
+import { csrfFetch } from "./csrf";
 
 const API_BASE = process.env.NEXT_PUBLIC_API_URL;
 
 const apiCall = async (endpoint, options = {}) => {
-  const response = await fetch(`${API_BASE}${endpoint}`, {
+  const response = await csrfFetch(`${API_BASE}${endpoint}`, {
     credentials: "include",
     headers: {
       "Content-Type": "application/json",
@@ -22,7 +23,7 @@ const apiCall = async (endpoint, options = {}) => {
 export const postAPI = {
   createPost: async (formData) => {
     try {
-      const response = await fetch(`${API_BASE}/posts`, {
+      const response = await csrfFetch(`${API_BASE}/posts`, {
         method: "POST",
         credentials: "include",
         body: formData,
@@ -43,7 +44,7 @@ export const postAPI = {
   },
   createComment: async (postId, formData) => {
     try {
-      const response = await fetch(`${API_BASE}/posts/${postId}/comments`, {
+      const response = await csrfFetch(`${API_BASE}/posts/${postId}/comments`, {
         method: "POST",
         credentials: "include",
         body: formData,
@@ -62,9 +63,13 @@ export const postAPI = {
       };
     }
   },
-  fetchPostsPaginated: async (page, limit) => {
+  // fetchFeed gets a page of the home feed, newest first: the first page
+  // without a cursor, then older posts with before set to the page's
+  // next_cursor.
+  fetchFeed: async (before, limit) => {
     try {
-      const data = await apiCall(`/posts?page=${page}&limit=${limit}`);
+      const query = `?limit=${limit}` + (before ? `&before=${encodeURIComponent(before)}` : "");
+      const data = await apiCall(`/posts${query}`);
       return { success: true, data };
     } catch (error) {
       return {
@@ -73,6 +78,19 @@ export const postAPI = {
       };
     }
   },
+  // countNewPosts counts the posts newer than since, the prev_cursor of the
+  // first feed page.
+  countNewPosts: async (since) => {
+    try {
+      const { count } = await apiCall(`/posts/new?since=${encodeURIComponent(since)}`);
+      return { success: true, data: count };
+    } catch (error) {
+      return {
+        success: false,
+        error: error.message || "Failed to check for new posts",
+      };
+    }
+  },
   updatePost: async (postId, content, image) => {
     try {
       const formData = new FormData();
@@ -81,7 +99,7 @@ export const postAPI = {
       if (image) {
         formData.append("image", image);
       }
-      const response = await fetch(`${API_BASE}/posts/${postId}`, {
+      const response = await csrfFetch(`${API_BASE}/posts/${postId}`, {
         method: "PUT",
         credentials: "include",
         body: formData,
@@ -130,7 +148,7 @@ export const postAPI = {
         formData.append("image", image);
       }
 
-      const response = await fetch(`${API_BASE}/posts/${postId}/comments/${commentId}`, {
+      const response = await csrfFetch(`${API_BASE}/posts/${postId}/comments/${commentId}`, {
         method: "PUT",
         credentials: "include",
         body: formData,
@@ -308,7 +326,7 @@ const cancelFollowRequest = (requestId) =>
   apiCall(`/follow-request/${requestId}/cancel`, { method: "DELETE" });
 
 export async function updateProfile(profileData) {
-  var response = await fetch(`${API_BASE}/EditProfile`, {
+  var response = await csrfFetch(`${API_BASE}/EditProfile`, {
     method: "PUT",
     credentials: "include",
     body: profileData,
@@ -325,24 +343,56 @@ export async function updateProfile(profileData) {
   };
 }
 
+// Follow lists come a page at a time: pass the nextCursor of a page to get
+// the next one. Counts are fetched on their own with count_only, so they do
+// not depend on how many entries have been loaded.
+const FOLLOW_PAGE_SIZE = 30;
+
+const fetchFollowPage = async (path, cursor) => {
+  const query = `?limit=${FOLLOW_PAGE_SIZE}` + (cursor ? `&cursor=${encodeURIComponent(cursor)}` : "");
+  const page = await apiCall(`${path}${query}`);
+  return { user: page.user || [], nextCursor: page.next_cursor || "" };
+};
+
+const fetchFollowCount = async (path) => {
+  const { count } = await apiCall(`${path}?count_only=true`);
+  return count;
+};
+
 export const profileAPI = {
   getProfile: (userId) => apiCall(`/profile/${userId}`),
-  getFollowers: async (userId) => {
+  getFollowers: async (userId, cursor = "") => {
     try {
-      const data = await apiCall(`/profile/${userId}/followers`);
+      const data = await fetchFollowPage(`/profile/${userId}/followers`, cursor);
       return { success: true, data };
     } catch (error) {
       return { success: false, error: error.message || 'Failed to fetch followers' };
     }
   },
-  getFollowing: async (userId) => {
+  getFollowing: async (userId, cursor = "") => {
     try {
-      const data = await apiCall(`/profile/${userId}/followees`);
+      const data = await fetchFollowPage(`/profile/${userId}/followees`, cursor);
       return { success: true, data };
     } catch (error) {
       return { success: false, error: error.message || 'Failed to fetch following' };
     }
   },
+  getFollowersCount: async (userId) => {
+    try {
+      const count = await fetchFollowCount(`/profile/${userId}/followers`);
+      return { success: true, data: count };
+    } catch (error) {
+      return { success: false, error: error.message || 'Failed to fetch followers count' };
+    }
+  },
+  getFollowingCount: async (userId) => {
+    try {
+      const count = await fetchFollowCount(`/profile/${userId}/followees`);
+      return { success: true, data: count };
+    } catch (error) {
+      return { success: false, error: error.message || 'Failed to fetch following count' };
+    }
+  },
   follow: (followeeid) =>
     apiCall("/follow", {
       method: "POST",
@@ -1,4 +1,5 @@
 import { useState } from 'react';
+import { csrfFetch } from './csrf';
 
 // Function to handle form input changes
 export const handleRegistrationFormChange = (e) => {
@@ -55,7 +56,7 @@ export const handleRegistrationFormSubmit = async (e, formData, setFormError) =>
   
     try {
       // Send to your Go backend
-      const response = await fetch("http://localhost:9000/register", {
+      const response = await csrfFetch("http://localhost:9000/register", {
         method: "POST",
         body: form,
       });
@@ -96,7 +97,7 @@ export const handleLoginFormSubmit = async (e, formData, setFormError) => {
 
   try {
     // Send to your Go backend - include credentials for cookies
-    const response = await fetch("http://localhost:9000/login", {
+    const response = await csrfFetch("http://localhost:9000/login", {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
@@ -128,7 +129,7 @@ export const handleLoginFormSubmit = async (e, formData, setFormError) => {
 
 export const handleLogout = async () => {
   try {
-    const response = await fetch("http://localhost:9000/logout", {
+    const response = await csrfFetch("http://localhost:9000/logout", {
       method: "POST",
       credentials: "include", 
     });
@@ -146,7 +147,7 @@ export const handleLogout = async () => {
 
 export const validateStepOne = async (userEmail, userPassword, confirmPassword) => {
   try {
-    const response = await fetch("http://localhost:9000/validate/step1", {
+    const response = await csrfFetch("http://localhost:9000/validate/step1", {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
@@ -205,7 +206,7 @@ export const validateStepTwo=(userFirstName,UserLastName,UserDateOfBirth)=>{
 // React to a post
 export const reactToPost = async (postId, reaction) => {
   try {
-    const response = await fetch(`http://localhost:9000/posts/${postId}/reaction`, {
+    const response = await csrfFetch(`http://localhost:9000/posts/${postId}/reaction`, {
       method: 'POST',
       credentials: 'include',
       headers: {
@@ -230,7 +231,7 @@ export const reactToPost = async (postId, reaction) => {
 // Unreact to a post
 export const unreactToPost = async (postId) => {
   try {
-    const response = await fetch(`http://localhost:9000/posts/${postId}/reaction`, {
+    const response = await csrfFetch(`http://localhost:9000/posts/${postId}/reaction`, {
       method: 'DELETE',
       credentials: 'include',
     });
@@ -250,7 +251,7 @@ export const unreactToPost = async (postId) => {
 // React to a comment
 export const reactToComment = async (commentId, reaction) => {
   try {
-    const response = await fetch(`http://localhost:9000/comments/${commentId}/reaction`, {
+    const response = await csrfFetch(`http://localhost:9000/comments/${commentId}/reaction`, {
       method: 'POST',
       credentials: 'include',
       headers: {
@@ -275,7 +276,7 @@ export const reactToComment = async (commentId, reaction) => {
 // Unreact to a comment
 export const unreactToComment = async (commentId) => {
   try {
-    const response = await fetch(`http://localhost:9000/comments/${commentId}/reaction`, {
+    const response = await csrfFetch(`http://localhost:9000/comments/${commentId}/reaction`, {
       method: 'DELETE',
       credentials: 'include',
     });
@@ -0,0 +1,61 @@
+// The API rejects POST, PUT and DELETE requests unless the X-CSRF-Token header
+// repeats its csrf_token cookie. csrfFetch is fetch with that header filled in
+// and credentials included, so that the cookie is sent along.
+
+const API_BASE = process.env.NEXT_PUBLIC_API_URL || "http://localhost:9000";
+
+const CSRF_COOKIE = "csrf_token";
+const CSRF_HEADER = "X-CSRF-Token";
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS"];
+
+let cachedToken = null;
+
+const readCookie = (name) => {
+  if (typeof document === "undefined") return null;
+  const entry = document.cookie
+    .split("; ")
+    .find((part) => part.startsWith(`${name}=`));
+  return entry ? decodeURIComponent(entry.slice(name.length + 1)) : null;
+};
+
+// The cookie can be read when the frontend and the API share a host; otherwise
+// GET /csrf sets it on the API's domain and returns the token.
+export const getCSRFToken = async (refresh = false) => {
+  if (!refresh) {
+    const fromCookie = readCookie(CSRF_COOKIE);
+    if (fromCookie) return fromCookie;
+    if (cachedToken) return cachedToken;
+  }
+  const response = await fetch(`${API_BASE}/csrf`, { credentials: "include" });
+  if (!response.ok) {
+    throw new Error(`Failed to get CSRF token: ${response.status}`);
+  }
+  const data = await response.json();
+  cachedToken = data.csrf_token;
+  return cachedToken;
+};
+
+const withToken = (options, token) => {
+  const headers = new Headers(options.headers || {});
+  headers.set(CSRF_HEADER, token);
+  return { credentials: "include", ...options, headers };
+};
+
+export const csrfFetch = async (url, options = {}) => {
+  const method = (options.method || "GET").toUpperCase();
+  if (SAFE_METHODS.includes(method)) {
+    return fetch(url, options);
+  }
+
+  const response = await fetch(url, withToken(options, await getCSRFToken()));
+  if (response.status !== 403) {
+    return response;
+  }
+
+  // a token gone stale, say after the cookie was cleared, is fetched again once
+  const error = await response.clone().json().catch(() => ({}));
+  if (error.code !== "csrf_token_invalid") {
+    return response;
+  }
+  return fetch(url, withToken(options, await getCSRFToken(true)));
+};